@@ -42,6 +42,7 @@ type CreateOptions struct {
 	Confidence      int                      // Minimum number of nodes that must agree on a verification result
 	RunTimeSettings RunTimeSettings          // Run time settings for execution (e.g. wait, get, etc after submission)
 	DownloadFlags   model.DownloaderSettings // Settings for running Download
+	Encryption      EncryptionSettings       // Settings for encrypting inputs on the client
 	DryRun          bool
 }
 
@@ -72,6 +73,7 @@ func newCreateCmd() *cobra.Command {
 
 	createCmd.Flags().AddFlagSet(NewIPFSDownloadFlags(&OC.DownloadFlags))
 	createCmd.Flags().AddFlagSet(NewRunTimeSettingsFlags(&OC.RunTimeSettings))
+	createCmd.Flags().AddFlagSet(NewEncryptionFlags(&OC.Encryption))
 	createCmd.PersistentFlags().BoolVar(
 		&OC.DryRun, "dry-run", OC.DryRun,
 		`Do not submit the job, but instead print out what will be submitted`,
@@ -232,6 +234,16 @@ func create(cmd *cobra.Command, cmdArgs []string, OC *CreateOptions) error { //n
 			return err
 		}
 	}
+	if OC.Encryption.needsNodeKeys() && OC.RunTimeSettings.IsLocal {
+		err = fmt.Errorf("encrypted inputs and secrets are not supported when running locally")
+		Fatal(cmd, fmt.Sprintf("Error encrypting job: %s", err), 1)
+		return err
+	}
+	if err = encryptJob(ctx, GetAPIClient(), j, OC.Encryption); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error encrypting job: %s", err), 1)
+		return err
+	}
+
 	if OC.DryRun {
 		// Converting job to yaml
 		var yamlBytes []byte
@@ -4,6 +4,7 @@ package bacalhau
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"testing"
 
@@ -236,6 +237,32 @@ func (s *DescribeSuite) TestDescribeJobEdgeCases() {
 
 }
 
+func (s *DescribeSuite) TestDescribeJobRedactsSecrets() {
+	ctx := context.Background()
+	j := testutils.MakeNoopJob()
+	s.Require().NoError(encryptJob(ctx, s.client, j, EncryptionSettings{Secrets: []string{"API_TOKEN=top-secret"}}))
+	encryptedValue := j.Spec.Secrets.Values["API_TOKEN"]
+
+	submittedJob, err := s.client.Submit(ctx, j)
+	s.Require().NoError(err)
+	s.Require().NotNil(submittedJob.Spec.Secrets)
+	s.Nil(submittedJob.Spec.Secrets.Values["API_TOKEN"])
+
+	_, out, err := ExecuteTestCobraCommand("describe",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		submittedJob.Metadata.ID,
+	)
+	s.Require().NoError(err)
+	s.NotContains(out, "top-secret")
+	s.NotContains(out, base64.StdEncoding.EncodeToString(encryptedValue))
+
+	returnedJobDescription := &model.JobWithInfo{}
+	s.Require().NoError(model.YAMLUnmarshalWithMax([]byte(out), returnedJobDescription))
+	s.Equal([]string{"API_TOKEN"}, returnedJobDescription.Job.Spec.Secrets.Names())
+	s.Nil(returnedJobDescription.Job.Spec.Secrets.Encryption)
+}
+
 // In order for 'go test' to run this suite, we need to create
 // a normal test function and pass our suite to suite.Run
 func TestDescribeSuite(t *testing.T) {
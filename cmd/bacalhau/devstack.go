@@ -126,6 +126,8 @@ func newDevStackCmd() *cobra.Command {
 	)
 
 	devstackCmd.Flags().AddFlagSet(JobSelectionCLIFlags(&OS.JobSelectionPolicy))
+	devstackCmd.Flags().AddFlagSet(OutputPolicyCLIFlags(&OS.OutputPolicy))
+	devstackCmd.Flags().AddFlagSet(SandboxCLIFlags(&OS.Sandbox))
 	devstackCmd.Flags().AddFlagSet(DisabledFeatureCLIFlags(&ODs.DisabledFeatures))
 	setupCapacityManagerCLIFlags(devstackCmd, OS)
 
@@ -175,7 +177,10 @@ func runDevstack(cmd *cobra.Command, ODs *devstack.DevStackOptions, OS *ServeOpt
 	}
 
 	computeConfig := getComputeConfig(OS)
-	requestorConfig := getRequesterConfig(OS)
+	requestorConfig, err := getRequesterConfig(OS)
+	if err != nil {
+		return err
+	}
 	if ODs.LocalNetworkLotus {
 		cmd.Println("Note that starting up the Lotus node can take many minutes!")
 	}
@@ -58,6 +58,7 @@ type DockerRunOptions struct {
 	Confidence       int               // Minimum number of nodes that must agree on a verification result
 	MinBids          int               // Minimum number of bids before they will be accepted (at random)
 	Timeout          float64           // Job execution timeout in seconds
+	Priority         int               // Priority of the job over other jobs waiting to be scheduled
 	CPU              string
 	Memory           string
 	GPU              string
@@ -78,6 +79,8 @@ type DockerRunOptions struct {
 
 	DownloadFlags model.DownloaderSettings // Settings for running Download
 
+	EncryptionSettings EncryptionSettings // Settings for encrypting inputs on the client
+
 	FilPlus bool // add a "filplus" label to the job to grab the attention of fil+ moderators
 }
 
@@ -173,6 +176,10 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 		&ODR.Timeout, "timeout", ODR.Timeout,
 		`Job execution timeout in seconds (e.g. 300 for 5 minutes and 0.1 for 100ms)`,
 	)
+	dockerRunCmd.PersistentFlags().IntVar(
+		&ODR.Priority, "priority", ODR.Priority,
+		`Priority of the job over other jobs waiting to be scheduled. Jobs with a higher priority are scheduled first.`,
+	)
 	dockerRunCmd.PersistentFlags().StringVar(
 		&ODR.CPU, "cpu", ODR.CPU,
 		`Job CPU cores (e.g. 500m, 2, 8).`,
@@ -225,6 +232,7 @@ func newDockerRunCmd() *cobra.Command { //nolint:funlen
 
 	dockerRunCmd.PersistentFlags().AddFlagSet(NewRunTimeSettingsFlags(&ODR.RunTimeSettings))
 	dockerRunCmd.PersistentFlags().AddFlagSet(NewIPFSDownloadFlags(&ODR.DownloadFlags))
+	dockerRunCmd.PersistentFlags().AddFlagSet(NewEncryptionFlags(&ODR.EncryptionSettings))
 
 	return dockerRunCmd
 }
@@ -250,6 +258,15 @@ func dockerRun(cmd *cobra.Command, cmdArgs []string, ODR *DockerRunOptions) erro
 		}
 	}
 
+	if ODR.EncryptionSettings.needsNodeKeys() && ODR.RunTimeSettings.IsLocal {
+		Fatal(cmd, "Encrypted inputs and secrets are not supported when running locally.", 1)
+		return nil
+	}
+	if err = encryptJob(ctx, GetAPIClient(), j, ODR.EncryptionSettings); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error encrypting job: %s", err), 1)
+		return nil
+	}
+
 	quiet := ODR.RunTimeSettings.PrintJobIDOnly
 	if !quiet {
 		containsTag := DockerImageContainsTag(j.Spec.Docker.Image)
@@ -347,6 +364,7 @@ func CreateJob(ctx context.Context, cmdArgs []string, odr *DockerRunOptions) (*m
 	if err != nil {
 		return &model.Job{}, errors.Wrap(err, "CreateJobSpecAndDeal")
 	}
+	j.Spec.Priority = odr.Priority
 
 	return j, nil
 }
@@ -112,6 +112,23 @@ func (s *DockerRunSuite) TestRun_DryRun() {
 	}
 }
 
+func (s *DockerRunSuite) TestRun_EncryptResults() {
+	_, out, err := ExecuteTestCobraCommand("docker", "run",
+		"--api-host", s.host,
+		"--api-port", fmt.Sprint(s.port),
+		"--encrypt-results",
+		"--dry-run",
+		"ubuntu",
+		"echo",
+	)
+	s.Require().NoError(err)
+
+	var j *model.Job
+	s.Require().NoError(model.YAMLUnmarshalWithMax([]byte(out), &j))
+	s.Require().NotNil(j, "Failed to unmarshal job from dry run output")
+	s.Require().True(j.Spec.PublisherSpec.Encrypt, "Results should be encrypted")
+}
+
 func (s *DockerRunSuite) TestRun_GPURequests() {
 	if !s.node.ComputeNode.Capacity.IsWithinLimits(context.Background(), model.ResourceUsageData{GPU: 1}) {
 		s.T().Skip("Skipping test as no GPU is available in current host")
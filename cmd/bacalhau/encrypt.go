@@ -0,0 +1,70 @@
+package bacalhau
+
+import (
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	encryptLong = templates.LongDesc(i18n.T(`
+		Encrypt a local file or directory with a data key, so that it can be uploaded to IPFS, a URL or S3 and used as
+		an encrypted input of jobs. The data key is read from the data key file, which is created with a new key if it
+		doesn't exist. Pass the same data key file with --data-key-file when submitting the job, so that the key is
+		wrapped for the compute nodes allowed to decrypt the input.
+`))
+
+	//nolint:lll // Documentation
+	encryptExample = templates.Examples(i18n.T(`
+		# Encrypt a directory, upload it to IPFS and use it as an encrypted input
+		bacalhau encrypt ./data ./data.encrypted --data-key-file ./data.key
+		ipfs add -r ./data.encrypted
+		bacalhau docker run --encrypted-input ipfs://QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA:/inputs/data --data-key-file ./data.key ubuntu ls /inputs/data
+`))
+)
+
+type EncryptOptions struct {
+	DataKeyFile string // File holding the data key, created if it doesn't exist
+}
+
+func newEncryptCmd() *cobra.Command {
+	options := &EncryptOptions{}
+
+	encryptCmd := &cobra.Command{
+		Use:     "encrypt [source] [destination]",
+		Short:   "Encrypt data ahead of time to use it as an encrypted job input",
+		Long:    encryptLong,
+		Example: encryptExample,
+		Args:    cobra.ExactArgs(2),
+		PreRun:  applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return encrypt(cmd, cmdArgs[0], cmdArgs[1], options)
+		},
+	}
+	encryptCmd.PersistentFlags().StringVar(
+		&options.DataKeyFile, "data-key-file", options.DataKeyFile,
+		`File holding the data key to encrypt with, created with a new key if it doesn't exist`,
+	)
+	return encryptCmd
+}
+
+func encrypt(cmd *cobra.Command, source, destination string, options *EncryptOptions) error {
+	if options.DataKeyFile == "" {
+		Fatal(cmd, "A data key file is required, set it with --data-key-file.", 1)
+		return nil
+	}
+	key, err := getOrCreateDataKey(options.DataKeyFile)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error getting the data key: %s", err), 1)
+		return nil
+	}
+	if err = encrypted.EncryptPath(source, destination, key); err != nil {
+		Fatal(cmd, fmt.Sprintf("Error encrypting %s: %s", source, err), 1)
+		return nil
+	}
+	cmd.Printf("Encrypted %s to %s with the data key in %s\n", source, destination, options.DataKeyFile)
+	return nil
+}
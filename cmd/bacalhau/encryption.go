@@ -0,0 +1,268 @@
+package bacalhau
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jobutils "github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/c2h5oh/datasize"
+	"github.com/spf13/pflag"
+)
+
+// maximumInlineEncryptedInputSize is the largest local input that is encrypted and sent inline in the job spec, which
+// is stored by the requester and broadcast to every compute node. Larger inputs should be encrypted ahead of time with
+// `bacalhau encrypt`, uploaded to IPFS, a URL or S3, and referenced by their URI instead.
+const maximumInlineEncryptedInputSize = 1 * datasize.MB
+
+// EncryptionSettings are the settings to encrypt job inputs and secrets on the
+// client, so that they can only be read by the compute nodes running the job,
+// and to have the results encrypted so that they can only be read by the client.
+type EncryptionSettings struct {
+	EncryptedInputs []string // Local files or directories to encrypt, or URIs of encrypted data, in 'source:target' form
+	Secrets         []string // Environment variables to encrypt, in 'NAME=VALUE' or 'NAME' form
+	EncryptFor      []string // IDs of the compute nodes allowed to decrypt the inputs and secrets
+	EncryptResults  bool     // Whether to encrypt the results with the client key
+	DataKeyFile     string   // File holding the data key the remote encrypted inputs were encrypted with
+}
+
+// needsNodeKeys returns true if the settings require wrapping a data key for the compute nodes.
+func (s EncryptionSettings) needsNodeKeys() bool {
+	return len(s.EncryptedInputs) > 0 || len(s.Secrets) > 0
+}
+
+func NewEncryptionFlags(settings *EncryptionSettings) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Encryption settings", pflag.ContinueOnError)
+	flags.StringArrayVar(
+		&settings.EncryptedInputs, "encrypted-input", settings.EncryptedInputs,
+		`source:target of a local file or directory to encrypt and send inline with the job, or the URI of data that `+
+			`was encrypted with 'bacalhau encrypt' and uploaded to IPFS, a URL or S3. `+
+			`It is decrypted by the compute node and mounted at target (e.g. --encrypted-input ./data:/inputs/data, `+
+			`--encrypted-input ipfs://QmXJ3wT1C27W8Vvc21NjLEb7VdNk9oM8zJYtDkG1yH2fnA:/inputs/data). `+
+			`Local inputs are limited to `+maximumInlineEncryptedInputSize.HR()+` once compressed.`,
+	)
+	flags.BoolVar(
+		&settings.EncryptResults, "encrypt-results", settings.EncryptResults,
+		`Encrypt the results with the client key, so that only this client can read them once published`,
+	)
+	flags.StringVar(
+		&settings.DataKeyFile, "data-key-file", settings.DataKeyFile,
+		`File holding the data key used with 'bacalhau encrypt'. Required for encrypted inputs given by URI, `+
+			`and used instead of a new data key to encrypt the local inputs and secrets.`,
+	)
+	flags.StringArrayVar(
+		&settings.Secrets, "secret", settings.Secrets,
+		`NAME=VALUE of an environment variable to encrypt and pass to the job, or NAME to read the value from the local `+
+			`environment. The value is only decrypted by the compute node running the job, and is never shown by the API `+
+			`(e.g. --secret API_TOKEN)`,
+	)
+	flags.StringSliceVar(
+		&settings.EncryptFor, "encrypt-for", settings.EncryptFor,
+		`IDs of the compute nodes allowed to decrypt the encrypted inputs and secrets. `+
+			`Defaults to all compute nodes known to the requester.`,
+	)
+	return flags
+}
+
+// encryptJob encrypts the local inputs and secrets with a new data key, or the
+// one from the data key file, wraps that key for each of the compute nodes
+// allowed to run the job, and adds them to the job spec, the local inputs being
+// sent inline. It also asks for the results to be encrypted if required.
+func encryptJob(
+	ctx context.Context,
+	client *publicapi.RequesterAPIClient,
+	j *model.Job,
+	settings EncryptionSettings,
+) error {
+	if settings.EncryptResults {
+		j.Spec.PublisherSpec.Encrypt = true
+	}
+	if !settings.needsNodeKeys() {
+		return nil
+	}
+	secrets, err := parseSecrets(settings.Secrets)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := getEncryptionNodes(ctx, client, settings.EncryptFor)
+	if err != nil {
+		return err
+	}
+
+	key, err := getDataKey(settings.DataKeyFile)
+	if err != nil {
+		return err
+	}
+	encryptionSpec, err := encrypted.WrapDataKey(ctx, verifier.NewEncrypter(nil).Encrypt, key, nodes)
+	if err != nil {
+		return err
+	}
+
+	for _, input := range settings.EncryptedInputs {
+		spec, err := encryptInput(ctx, input, key, settings.DataKeyFile != "")
+		if err != nil {
+			return err
+		}
+		spec.Encryption = encryptionSpec
+		j.Spec.Inputs = append(j.Spec.Inputs, spec)
+	}
+
+	if len(secrets) > 0 {
+		j.Spec.Secrets, err = encrypted.EncryptSecrets(secrets, key, encryptionSpec)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSecrets returns the values of the secrets keyed by name, reading them
+// from the local environment if they are not set explicitly.
+func parseSecrets(secrets []string) (map[string]string, error) {
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		name, value, ok := strings.Cut(secret, "=")
+		if !ok {
+			value, ok = os.LookupEnv(name)
+			if !ok {
+				return nil, fmt.Errorf("secret %s has no value and is not set in the environment", name)
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid secret %q: name is empty", secret)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// getEncryptionNodes returns the compute nodes the data key will be wrapped for
+func getEncryptionNodes(ctx context.Context, client *publicapi.RequesterAPIClient, nodeIDs []string) ([]model.NodeInfo, error) {
+	allNodes, err := client.Nodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list compute nodes to encrypt the job for: %w", err)
+	}
+
+	nodesByID := make(map[string]model.NodeInfo)
+	for _, node := range allNodes {
+		if node.IsComputeNode() && len(node.PublicKey) > 0 {
+			nodesByID[node.PeerInfo.ID.String()] = node
+		}
+	}
+
+	var nodes []model.NodeInfo
+	if len(nodeIDs) == 0 {
+		for _, node := range nodesByID {
+			nodes = append(nodes, node)
+		}
+	} else {
+		for _, nodeID := range nodeIDs {
+			node, ok := nodesByID[nodeID]
+			if !ok {
+				return nil, fmt.Errorf("compute node %s not found or did not publish a public key", nodeID)
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no compute nodes found to encrypt the job for")
+	}
+	return nodes, nil
+}
+
+// encryptInput returns the storage spec of an encrypted input. Local inputs are encrypted with the data key and sent
+// inline, while inputs given by URI must have been encrypted ahead of time with the data key from the key file.
+func encryptInput(ctx context.Context, input string, key []byte, fromKeyFile bool) (model.StorageSpec, error) {
+	source, target := splitEncryptedInput(input)
+	if strings.Contains(source, "://") {
+		if !fromKeyFile {
+			return model.StorageSpec{}, fmt.Errorf(
+				"encrypted input %s must be encrypted with 'bacalhau encrypt' and its key passed with --data-key-file", source)
+		}
+		return jobutils.ParseStorageString(source, target, nil)
+	}
+	if target == "" {
+		target = filepath.Join("/inputs", filepath.Base(source))
+	}
+
+	tempDir, err := os.MkdirTemp("", "bacalhau-encrypted-input")
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	encryptedPath := filepath.Join(tempDir, filepath.Base(source))
+	if err = encrypted.EncryptPath(source, encryptedPath, key); err != nil {
+		return model.StorageSpec{}, fmt.Errorf("failed to encrypt input %s: %w", source, err)
+	}
+
+	spec, err := inline.NewStorage().Upload(ctx, encryptedPath)
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	if size := datasize.ByteSize(len(spec.URL)); size > maximumInlineEncryptedInputSize {
+		return model.StorageSpec{}, fmt.Errorf(
+			"encrypted input %s is %s, more than the %s that can be sent with the job: encrypt it with "+
+				"'bacalhau encrypt', upload it to IPFS, a URL or S3 and pass its URI instead",
+			source, size.HR(), maximumInlineEncryptedInputSize.HR())
+	}
+	spec.Name = filepath.Base(source)
+	spec.Path = target
+	return spec, nil
+}
+
+// splitEncryptedInput splits an encrypted input in 'source:target' form, where the source can be a URI and the target
+// is an absolute path. The target is empty if the input doesn't have one.
+func splitEncryptedInput(input string) (source, target string) {
+	i := strings.LastIndex(input, ":")
+	if i < 0 || !strings.HasPrefix(input[i+1:], "/") || strings.HasPrefix(input[i+1:], "//") {
+		return input, ""
+	}
+	return input[:i], input[i+1:]
+}
+
+// getDataKey returns the data key read from the key file, or a new data key if no key file is given
+func getDataKey(keyFile string) ([]byte, error) {
+	if keyFile == "" {
+		return encrypted.NewDataKey()
+	}
+	encoded, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data key: %w", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(key) != encrypted.DataKeySize {
+		return nil, fmt.Errorf("data key file %s does not hold a valid data key", keyFile)
+	}
+	return key, nil
+}
+
+// getOrCreateDataKey returns the data key read from the key file, creating the file with a new data key if it
+// doesn't exist yet.
+func getOrCreateDataKey(keyFile string) ([]byte, error) {
+	key, err := getDataKey(keyFile)
+	if !errors.Is(err, os.ErrNotExist) {
+		return key, err
+	}
+	key, err = encrypted.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err = os.WriteFile(keyFile, []byte(encoded), util.OS_USER_RW); err != nil {
+		return nil, fmt.Errorf("failed to write data key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,94 @@
+//go:build unit || !integration
+
+package bacalhau
+
+import (
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitEncryptedInput(t *testing.T) {
+	for input, expected := range map[string][2]string{
+		"./data":                     {"./data", ""},
+		"./data:/inputs/data":        {"./data", "/inputs/data"},
+		"ipfs://QmCID":               {"ipfs://QmCID", ""},
+		"ipfs://QmCID:/inputs/data":  {"ipfs://QmCID", "/inputs/data"},
+		"s3://bucket/key:/inputs":    {"s3://bucket/key", "/inputs"},
+		"https://example.com/a.enc":  {"https://example.com/a.enc", ""},
+		"https://example.com:8080/a": {"https://example.com:8080/a", ""},
+	} {
+		source, target := splitEncryptedInput(input)
+		require.Equal(t, expected, [2]string{source, target}, input)
+	}
+}
+
+func TestEncryptRemoteInput(t *testing.T) {
+	ctx := context.Background()
+	key, err := encrypted.NewDataKey()
+	require.NoError(t, err)
+
+	_, err = encryptInput(ctx, "ipfs://QmCID:/inputs/data", key, false)
+	require.Error(t, err, "remote inputs must have been encrypted with the key file")
+
+	spec, err := encryptInput(ctx, "ipfs://QmCID:/inputs/data", key, true)
+	require.NoError(t, err)
+	require.Equal(t, model.StorageSourceIPFS, spec.StorageSource)
+	require.Equal(t, "QmCID", spec.CID)
+	require.Equal(t, "/inputs/data", spec.Path)
+}
+
+func TestEncryptLocalInputSizeLimit(t *testing.T) {
+	ctx := context.Background()
+	key, err := encrypted.NewDataKey()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "data")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0600))
+	spec, err := encryptInput(ctx, path, key, false)
+	require.NoError(t, err)
+	require.Equal(t, model.StorageSourceInline, spec.StorageSource)
+	require.Equal(t, "/inputs/data", spec.Path)
+
+	// random data doesn't compress, so it is sent as is
+	data := make([]byte, maximumInlineEncryptedInputSize.Bytes()+1)
+	_, err = rand.Read(data)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	_, err = encryptInput(ctx, path, key, false)
+	require.ErrorContains(t, err, "bacalhau encrypt")
+}
+
+func TestDataKeyFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "data.key")
+	_, err := getDataKey(keyFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	key, err := getOrCreateDataKey(keyFile)
+	require.NoError(t, err)
+	require.Len(t, key, encrypted.DataKeySize)
+
+	readKey, err := getDataKey(keyFile)
+	require.NoError(t, err)
+	require.Equal(t, key, readKey)
+
+	require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0600))
+	_, err = getOrCreateDataKey(keyFile)
+	require.Error(t, err)
+}
+
+func TestEncryptResultsFlag(t *testing.T) {
+	settings := EncryptionSettings{}
+	require.NoError(t, NewEncryptionFlags(&settings).Parse([]string{"--encrypt-results"}))
+	require.True(t, settings.EncryptResults)
+
+	j := &model.Job{}
+	require.NoError(t, encryptJob(context.Background(), nil, j, settings))
+	require.True(t, j.Spec.PublisherSpec.Encrypt)
+}
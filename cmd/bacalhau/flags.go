@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/url/urldownload"
+	"github.com/c2h5oh/datasize"
 	"github.com/spf13/pflag"
 )
 
@@ -276,6 +278,32 @@ func DataLocalityFlag(value *model.JobSelectionDataLocality) *ValueFlag[model.Jo
 	}
 }
 
+func BufferOrderingFlag(value *compute.BufferOrdering) *ValueFlag[compute.BufferOrdering] {
+	return &ValueFlag[compute.BufferOrdering]{
+		value:    value,
+		parser:   compute.ParseBufferOrdering,
+		stringer: func(o *compute.BufferOrdering) string { return string(*o) },
+		typeStr:  "fifo|priority|sjf",
+	}
+}
+
+func ByteSizeFlag(value *uint64) *ValueFlag[uint64] {
+	return &ValueFlag[uint64]{
+		value: value,
+		parser: func(s string) (uint64, error) {
+			size, err := datasize.ParseString(s)
+			return size.Bytes(), err
+		},
+		stringer: func(n *uint64) string {
+			if *n == 0 {
+				return ""
+			}
+			return datasize.ByteSize(*n).String()
+		},
+		typeStr: "bytes",
+	}
+}
+
 func LoggingFlag(value *logger.LogMode) *ValueFlag[logger.LogMode] {
 	return &ValueFlag[logger.LogMode]{
 		value:    value,
@@ -326,6 +354,15 @@ func ExcludedTagFlag(value *[]model.ExcludedTag) *ArrayValueFlag[model.ExcludedT
 	}
 }
 
+func UlimitFlag(value *[]model.Ulimit) *ArrayValueFlag[model.Ulimit] {
+	return &ArrayValueFlag[model.Ulimit]{
+		value:    value,
+		parser:   model.ParseUlimit,
+		stringer: func(u *model.Ulimit) string { return u.String() },
+		typeStr:  "ulimit",
+	}
+}
+
 func JobSelectionCLIFlags(policy *model.JobSelectionPolicy) *pflag.FlagSet {
 	flags := pflag.NewFlagSet("Job Selection Policy", pflag.ContinueOnError)
 
@@ -349,6 +386,86 @@ func JobSelectionCLIFlags(policy *model.JobSelectionPolicy) *pflag.FlagSet {
 		&policy.ProbeExec, "job-selection-probe-exec", policy.ProbeExec,
 		`Use the result of a exec an external program to decide if we should take on the job.`,
 	)
+	flags.StringVar(
+		&policy.AccessPolicyFile, "job-selection-access-policy", policy.AccessPolicyFile,
+		`Only accept jobs allowed to use their inputs by the access policy in this YAML or JSON file. `+
+			`The file is reloaded when it changes.`,
+	)
+
+	return flags
+}
+
+func OutputPolicyCLIFlags(policy *model.OutputPolicy) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Output Policy", pflag.ContinueOnError)
+
+	flags.Var(
+		ByteSizeFlag(&policy.MaxVolumeSize), "output-max-volume-size",
+		`Fail executions that write more than this to any of their output volumes (e.g. 500MB, 2GB).`,
+	)
+	flags.StringSliceVar(
+		&policy.AllowedFileTypes, "output-allowed-file-types", policy.AllowedFileTypes,
+		`Fail executions that output files of other types. Types are file extensions (e.g. ".csv") `+
+			`or media types (e.g. "application/json", or "text/" for any text).`,
+	)
+	flags.StringVar(
+		&policy.InspectHTTP, "output-inspect-http", policy.InspectHTTP,
+		`Use the result of a HTTP POST to decide if the outputs of an execution can be published.`,
+	)
+	flags.StringVar(
+		&policy.InspectExec, "output-inspect-exec", policy.InspectExec,
+		`Use the result of a exec an external program to decide if the outputs of an execution can be published.`,
+	)
+
+	return flags
+}
+
+func SandboxCLIFlags(profile *model.SandboxProfile) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("Sandbox", pflag.ContinueOnError)
+
+	flags.BoolVar(
+		&profile.ReadOnlyRootfs, "sandbox-read-only-rootfs", profile.ReadOnlyRootfs,
+		`Mount the root filesystem of docker job containers as read-only.`,
+	)
+	flags.StringSliceVar(
+		&profile.Tmpfs, "sandbox-tmpfs", profile.Tmpfs,
+		`Paths mounted as in-memory scratch space in docker job containers. Defaults to /tmp with a read-only root filesystem.`,
+	)
+	flags.StringSliceVar(
+		&profile.CapDrop, "sandbox-cap-drop", profile.CapDrop,
+		`Kernel capabilities to drop from docker job containers (e.g. ALL).`,
+	)
+	flags.StringSliceVar(
+		&profile.CapAdd, "sandbox-cap-add", profile.CapAdd,
+		`Kernel capabilities to add back to docker job containers (e.g. CHOWN).`,
+	)
+	flags.BoolVar(
+		&profile.NoNewPrivileges, "sandbox-no-new-privileges", profile.NoNewPrivileges,
+		`Prevent processes in docker job containers from gaining privileges.`,
+	)
+	flags.StringVar(
+		&profile.SeccompProfile, "sandbox-seccomp-profile", profile.SeccompProfile,
+		`Path to a seccomp profile in JSON to apply to docker job containers.`,
+	)
+	flags.StringVar(
+		&profile.AppArmorProfile, "sandbox-apparmor-profile", profile.AppArmorProfile,
+		`Name of an AppArmor profile loaded on the host to apply to docker job containers.`,
+	)
+	flags.Int64Var(
+		&profile.PidsLimit, "sandbox-pids-limit", profile.PidsLimit,
+		`Maximum number of processes in docker job containers. Zero means no limit.`,
+	)
+	flags.Var(
+		UlimitFlag(&profile.Ulimits), "sandbox-ulimit",
+		`Resource limit of the processes in docker job containers, in name=soft[:hard] form (e.g. nofile=1024:2048).`,
+	)
+	flags.StringVar(
+		&profile.User, "sandbox-user", profile.User,
+		`User and optionally group to run docker job containers as (e.g. 65534:65534).`,
+	)
+	flags.StringVar(
+		&profile.Runtime, "sandbox-runtime", profile.Runtime,
+		`Name of the OCI runtime registered with docker to run docker job containers with (e.g. runsc).`,
+	)
 
 	return flags
 }
@@ -0,0 +1,180 @@
+package bacalhau
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+var (
+	jobLong = templates.LongDesc(i18n.T(`
+		Work the moderation queue of the requester: list the jobs waiting for approval, and approve or reject them.
+		Only the approvers configured on the requester can approve or reject jobs.
+`))
+
+	//nolint:lll // Documentation
+	jobExample = templates.Examples(i18n.T(`
+		# List the jobs waiting for approval
+		bacalhau job pending
+
+		# Approve a job, with a short ID
+		bacalhau job approve ebd9bf2f
+
+		# Reject a job, giving the reason
+		bacalhau job reject 51225160-807e-48b8-88c9-28311c7899e1 --reason "requires too much network access"
+`))
+)
+
+type ModerateOptions struct {
+	Reason string // The reason given for the approval or rejection
+}
+
+type PendingOptions struct {
+	HideHeader   bool   // Hide the column headers
+	OutputFormat string // The output format for the list of jobs (json or text)
+	OutputWide   bool   // Print full values in the table results
+}
+
+func newJobCmd() *cobra.Command {
+	jobCmd := &cobra.Command{
+		Use:     "job",
+		Short:   "Approve, reject or list the jobs waiting for moderation",
+		Long:    jobLong,
+		Example: jobExample,
+	}
+	jobCmd.AddCommand(newJobModerateCmd(true))
+	jobCmd.AddCommand(newJobModerateCmd(false))
+	jobCmd.AddCommand(newJobPendingCmd())
+	return jobCmd
+}
+
+func newJobModerateCmd(approve bool) *cobra.Command {
+	options := &ModerateOptions{}
+	use, short := "approve [id]", "Approve a job waiting for moderation"
+	if !approve {
+		use, short = "reject [id]", "Reject a job waiting for moderation"
+	}
+
+	moderateCmd := &cobra.Command{
+		Use:    use,
+		Short:  short,
+		Args:   cobra.ExactArgs(1),
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, cmdArgs []string) error {
+			return moderate(cmd, cmdArgs[0], approve, options)
+		},
+	}
+	moderateCmd.PersistentFlags().StringVar(
+		&options.Reason, "reason", options.Reason,
+		`The reason for the decision, recorded in the moderation audit trail of the job`,
+	)
+	return moderateCmd
+}
+
+func moderate(cmd *cobra.Command, requestedJobID string, approve bool, options *ModerateOptions) error {
+	ctx := cmd.Context()
+	apiClient := GetAPIClient()
+
+	// resolve short job IDs to the full ID the approval is signed for
+	job, _, err := apiClient.Get(ctx, requestedJobID)
+	if err != nil {
+		Fatal(cmd, err.Error(), 1)
+		return nil
+	}
+	jobID := job.Job.Metadata.ID
+
+	err = apiClient.Approve(ctx, jobID, bidstrategy.BidStrategyResponse{
+		ShouldBid: approve,
+		Reason:    options.Reason,
+	})
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error moderating job %s: %s", jobID, err), 1)
+		return nil
+	}
+
+	info, err := apiClient.GetModeration(ctx, jobID)
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error getting the moderation of job %s: %s", jobID, err), 1)
+		return nil
+	}
+	cmd.Printf("Job %s is %s: %s\n", jobID, info.Decision.Status, info.Decision.Reason)
+	return nil
+}
+
+func newJobPendingCmd() *cobra.Command {
+	options := &PendingOptions{OutputFormat: "text"}
+
+	pendingCmd := &cobra.Command{
+		Use:    "pending",
+		Short:  "List the jobs waiting for moderation, oldest first",
+		Args:   cobra.NoArgs,
+		PreRun: applyPorcelainLogLevel,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return pending(cmd, options)
+		},
+	}
+	pendingCmd.PersistentFlags().BoolVar(&options.HideHeader, "hide-header", options.HideHeader,
+		`do not print the column headers.`)
+	pendingCmd.PersistentFlags().StringVar(
+		&options.OutputFormat, "output", options.OutputFormat,
+		`The output format for the list of jobs (json or text)`,
+	)
+	pendingCmd.PersistentFlags().BoolVar(
+		&options.OutputWide, "wide", options.OutputWide,
+		`Print full values in the table results`,
+	)
+	return pendingCmd
+}
+
+func pending(cmd *cobra.Command, options *PendingOptions) error {
+	jobs, err := GetAPIClient().PendingModeration(cmd.Context())
+	if err != nil {
+		Fatal(cmd, fmt.Sprintf("Error listing jobs waiting for moderation: %s", err), 1)
+		return nil
+	}
+
+	if options.OutputFormat == JSONFormat {
+		msgBytes, err := model.JSONMarshalWithMax(jobs)
+		if err != nil {
+			Fatal(cmd, fmt.Sprintf("Error marshaling jobs to JSON: %s", err), 1)
+			return nil
+		}
+		cmd.Printf("%s\n", msgBytes)
+		return nil
+	}
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(cmd.OutOrStdout())
+	if !options.HideHeader {
+		tw.AppendHeader(table.Row{"created", "id", "client", "votes", "decision"})
+	}
+	for _, info := range jobs {
+		tw.AppendRow(table.Row{
+			shortenTime(options.OutputWide, info.Job.Metadata.CreatedAt),
+			shortID(options.OutputWide, info.Job.Metadata.ID),
+			shortID(options.OutputWide, info.Job.Metadata.ClientID),
+			summarizeVotes(info.Moderations),
+			info.Decision.Reason,
+		})
+	}
+	tw.SetStyle(table.StyleColoredGreenWhiteOnBlack)
+	tw.Render()
+	return nil
+}
+
+// summarizeVotes lists the approvers who voted on a job
+func summarizeVotes(moderations []model.JobModeration) string {
+	var votes []string
+	for _, m := range moderations {
+		if m.Action == model.JobModerationApproved || m.Action == model.JobModerationRejected {
+			votes = append(votes, fmt.Sprintf("%s %s", shortID(false, m.ClientID), m.Action))
+		}
+	}
+	return strings.Join(votes, ", ")
+}
@@ -94,6 +94,9 @@ func NewRootCmd() *cobra.Command {
 
 	RootCmd.AddCommand(newValidateCmd())
 
+	// Encrypt job inputs ahead of time
+	RootCmd.AddCommand(newEncryptCmd())
+
 	RootCmd.AddCommand(newVersionCmd())
 
 	// ====== Get information or results about a job
@@ -112,6 +115,9 @@ func NewRootCmd() *cobra.Command {
 	// List jobs
 	RootCmd.AddCommand(newListCmd())
 
+	// Approve, reject or list the jobs waiting for moderation
+	RootCmd.AddCommand(newJobCmd())
+
 	// ====== Run a server
 
 	// Serve commands
@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	computenodeapi "github.com/bacalhau-project/bacalhau/pkg/compute/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/boltdb"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
 	"github.com/bacalhau-project/bacalhau/pkg/libp2p"
 	"github.com/bacalhau-project/bacalhau/pkg/libp2p/rcmgr"
@@ -20,7 +24,10 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
 	filecoinlotus "github.com/bacalhau-project/bacalhau/pkg/publisher/filecoin_lotus"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/transport/bprotocol"
 	"github.com/bacalhau-project/bacalhau/pkg/util/templates"
 	"github.com/multiformats/go-multiaddr"
 
@@ -34,6 +41,12 @@ var DefaultSwarmPort = 1235
 const NvidiaCLI = "nvidia-container-cli"
 const DefaultPeerConnect = "none"
 
+// Job store implementations that can be selected with --requester-job-store-type
+const (
+	JobStoreTypeInMemory = "inmemory"
+	JobStoreTypeBoltDB   = "boltdb"
+)
+
 var (
 	serveLong = templates.LongDesc(i18n.T(`
 		Start a bacalhau node.
@@ -68,6 +81,7 @@ type ServeOptions struct {
 	HostAddress                           string                   // The host address to listen on.
 	SwarmPort                             int                      // The host port for libp2p network.
 	JobSelectionPolicy                    model.JobSelectionPolicy // How the node decides what jobs to run.
+	OutputPolicy                          model.OutputPolicy       // What the node allows jobs to output.
 	LimitTotalCPU                         string                   // The total amount of CPU the system can be using at one time.
 	LimitTotalMemory                      string                   // The total amount of memory the system can be using at one time.
 	LimitTotalGPU                         string                   // The total amount of GPU the system can be using at one time.
@@ -83,6 +97,23 @@ type ServeOptions struct {
 	Labels                                map[string]string        // Labels to apply to the node that can be used for node selection and filtering
 	IPFSSwarmAddresses                    []string                 // IPFS multiaddresses that the in-process IPFS should connect to
 	PrivateInternalIPFS                   bool                     // Whether the in-process IPFS should automatically discover other IPFS nodes
+	JobStoreType                          string                   // The type of job store used by the requester node
+	JobStorePath                          string                   // The path of the job store database when using a persistent job store
+	TrustedMeasurements                   []string                 // Measurements of the runtimes trusted to run jobs verified by attestation
+	PrivacyBudget                         float64                  // Maximum epsilon a client can spend on a dataset with differentially private jobs
+	MaxJobQueueTime                       time.Duration            // How long a job can wait for enough nodes to run it
+	ClientWeights                         map[string]int64         // Fair share weights of clients by client ID
+	ClientMaxExecutions                   int                      // Maximum number of executions a client can have running at once
+	ClientMaxCPU                          string                   // Maximum CPU a client's running executions can request
+	ClientMaxMemory                       string                   // Maximum memory a client's running executions can request
+	ClientMaxGPU                          string                   // Maximum GPU a client's running executions can request
+	ModerationPolicyPath                  string                   // File with the approvers and quorum rules of moderated jobs
+	WasmModuleCacheSize                   uint64                   // Maximum size of the compiled WASM modules cached on disk
+	BufferOrdering                        compute.BufferOrdering   // How executions waiting for capacity on the compute node are ordered
+	BufferMaxEnqueuedAge                  time.Duration            // How long an execution can wait before it reserves capacity
+	BufferPreemption                      bool                     // Whether lower priority executions are preempted to run higher priority ones
+	Sandbox                               model.SandboxProfile     // How the containers of docker jobs are isolated from the host
+	RequireSignedMessages                 bool                     // Whether messages from other nodes must be signed by their sender
 }
 
 func NewServeOptions() *ServeOptions {
@@ -104,6 +135,11 @@ func NewServeOptions() *ServeOptions {
 		LotusFilecoinPathDirectory: os.Getenv("LOTUS_PATH"),
 		LotusFilecoinMaximumPing:   2 * time.Second,
 		PrivateInternalIPFS:        true,
+		JobStoreType:               JobStoreTypeInMemory,
+		JobStorePath:               "",
+		PrivacyBudget:              node.DefaultRequesterConfig.PrivacyBudget,
+		WasmModuleCacheSize:        node.DefaultComputeConfig.WasmModuleCacheSize,
+		BufferOrdering:             node.DefaultComputeConfig.ExecutorBufferOrdering,
 	}
 }
 
@@ -136,6 +172,26 @@ func setupCapacityManagerCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
 		&OS.JobExecutionTimeoutClientIDBypassList, "job-execution-timeout-bypass-client-id", OS.JobExecutionTimeoutClientIDBypassList,
 		`List of IDs of clients that are allowed to bypass the job execution timeout check`,
 	)
+	cmd.PersistentFlags().Var(
+		ByteSizeFlag(&OS.WasmModuleCacheSize), "wasm-module-cache-size",
+		`Maximum size of the compiled WASM modules cached on disk (e.g. 500MB, 2GB). `+
+			`Least recently used modules are evicted first.`,
+	)
+	cmd.PersistentFlags().Var(
+		BufferOrderingFlag(&OS.BufferOrdering), "buffer-ordering",
+		`How executions waiting for capacity to run are ordered: by arrival (fifo), by job priority (priority), `+
+			`or by job timeout with the oldest executions aged to the front (sjf).`,
+	)
+	cmd.PersistentFlags().DurationVar(
+		&OS.BufferMaxEnqueuedAge, "buffer-max-enqueued-age", OS.BufferMaxEnqueuedAge,
+		`How long an execution can wait for capacity to run before it reserves the capacity being freed, `+
+			`holding back the executions after it (e.g. 30m). Executions never reserve capacity if zero.`,
+	)
+	cmd.PersistentFlags().BoolVar(
+		&OS.BufferPreemption, "buffer-preemption", OS.BufferPreemption,
+		`Stop running executions of lower priority jobs to make room for higher priority ones. `+
+			`The stopped executions wait to run again from the start.`,
+	)
 }
 
 func setupLibp2pCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
@@ -153,6 +209,11 @@ func setupLibp2pCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
 		&OS.SwarmPort, "swarm-port", OS.SwarmPort,
 		`The port to listen on for swarm connections.`,
 	)
+	cmd.PersistentFlags().BoolVar(
+		&OS.RequireSignedMessages, "require-signed-messages", OS.RequireSignedMessages,
+		`Only speak the versions of the node protocol that sign messages, rejecting unsigned messages `+
+			`from nodes running older versions of bacalhau.`,
+	)
 }
 
 func getPeers(OS *ServeOptions) ([]multiaddr.Multiaddr, error) {
@@ -179,6 +240,7 @@ func getPeers(OS *ServeOptions) ([]multiaddr.Multiaddr, error) {
 func getComputeConfig(OS *ServeOptions) node.ComputeConfig {
 	return node.NewComputeConfigWith(node.ComputeConfigParams{
 		JobSelectionPolicy: OS.JobSelectionPolicy,
+		OutputPolicy:       OS.OutputPolicy,
 		TotalResourceLimits: capacity.ParseResourceUsageConfig(model.ResourceUsageConfig{
 			CPU:    OS.LimitTotalCPU,
 			Memory: OS.LimitTotalMemory,
@@ -191,13 +253,133 @@ func getComputeConfig(OS *ServeOptions) node.ComputeConfig {
 		}),
 		IgnorePhysicalResourceLimits:          os.Getenv("BACALHAU_CAPACITY_MANAGER_OVER_COMMIT") != "",
 		JobExecutionTimeoutClientIDBypassList: OS.JobExecutionTimeoutClientIDBypassList,
+		WasmModuleCacheSize:                   OS.WasmModuleCacheSize,
+		ExecutorBufferOrdering:                OS.BufferOrdering,
+		ExecutorBufferMaxEnqueuedAge:          OS.BufferMaxEnqueuedAge,
+		ExecutorBufferPreemption:              OS.BufferPreemption,
+		Sandbox:                               OS.Sandbox,
 	})
 }
 
-func getRequesterConfig(OS *ServeOptions) node.RequesterConfig {
+func setupRequesterCLIFlags(cmd *cobra.Command, OS *ServeOptions) {
+	cmd.PersistentFlags().StringVar(
+		&OS.JobStoreType, "requester-job-store-type", OS.JobStoreType,
+		fmt.Sprintf(`The type of job store used by the requester node. One of %q (lost on restart) or %q (persisted to disk).`,
+			JobStoreTypeInMemory, JobStoreTypeBoltDB),
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.JobStorePath, "requester-job-store-path", OS.JobStorePath,
+		`The path of the requester job store database. Defaults to a file in the bacalhau config directory.`,
+	)
+	cmd.PersistentFlags().StringSliceVar(
+		&OS.TrustedMeasurements, "requester-trusted-measurements", OS.TrustedMeasurements,
+		`Measurements of the runtimes trusted to run jobs using the attestation verifier. `+
+			`Executions attested by any other runtime are rejected.`,
+	)
+	cmd.PersistentFlags().Float64Var(
+		&OS.PrivacyBudget, "requester-privacy-budget", OS.PrivacyBudget,
+		`Maximum cumulative epsilon a client can spend on a dataset with differentially private jobs. `+
+			`Use 0 for the default budget, or a negative value to disable the limit.`,
+	)
+	cmd.PersistentFlags().DurationVar(
+		&OS.MaxJobQueueTime, "requester-max-job-queue-time", OS.MaxJobQueueTime,
+		`How long a job can wait in the queue for enough compute nodes to run it before it fails (e.g. 10m). `+
+			`Jobs fail straight away when there are not enough nodes to run them if zero.`,
+	)
+	cmd.PersistentFlags().StringToInt64Var(
+		&OS.ClientWeights, "requester-client-weights", OS.ClientWeights,
+		`Fair share weights of clients by client ID (e.g. clientA=2,clientB=1). Jobs of the same priority start first `+
+			`for the clients with the fewest running executions relative to their weight. Clients weigh 1 by default.`,
+	)
+	cmd.PersistentFlags().IntVar(
+		&OS.ClientMaxExecutions, "requester-client-max-executions", OS.ClientMaxExecutions,
+		`Maximum number of executions each client can have running at once. Unlimited if zero.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.ClientMaxCPU, "requester-client-max-cpu", OS.ClientMaxCPU,
+		`Maximum CPU the running executions of each client can request (e.g. 500m, 2, 8). Unlimited if empty.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.ClientMaxMemory, "requester-client-max-memory", OS.ClientMaxMemory,
+		`Maximum memory the running executions of each client can request (e.g. 500Mb, 2Gb, 8Gb). Unlimited if empty.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.ClientMaxGPU, "requester-client-max-gpu", OS.ClientMaxGPU,
+		`Maximum GPU the running executions of each client can request (e.g. 1, 2, or 8). Unlimited if empty.`,
+	)
+	cmd.PersistentFlags().StringVar(
+		&OS.ModerationPolicyPath, "requester-moderation-policy", OS.ModerationPolicyPath,
+		`Path to a YAML or JSON file with the approvers of the jobs held for moderation, their roles, and the quorum `+
+			`of approvals jobs need. The client named by BACALHAU_JOB_APPROVER is the only approver if empty.`,
+	)
+}
+
+func getJobStore(OS *ServeOptions, nodeID string, cm *system.CleanupManager) (jobstore.Store, error) {
+	switch OS.JobStoreType {
+	case JobStoreTypeInMemory:
+		return inmemory.NewJobStore(), nil
+	case JobStoreTypeBoltDB:
+		dbPath := OS.JobStorePath
+		if dbPath == "" {
+			// include the node id in the file name to avoid conflicts when running multiple nodes on the same machine
+			configDir, err := system.EnsureConfigDir()
+			if err != nil {
+				return nil, err
+			}
+			dbPath = filepath.Join(configDir, "requester-jobs-"+nodeID+".db")
+		}
+		store, err := boltdb.NewJobStore(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		cm.RegisterCallbackWithContext(store.Close)
+		return store, nil
+	default:
+		return nil, fmt.Errorf("invalid job store type %s. Only %s and %s values are supported",
+			OS.JobStoreType, JobStoreTypeInMemory, JobStoreTypeBoltDB)
+	}
+}
+
+func getRequesterConfig(OS *ServeOptions) (node.RequesterConfig, error) {
+	moderationPolicy, err := getModerationPolicy(OS)
+	if err != nil {
+		return node.RequesterConfig{}, err
+	}
 	return node.NewRequesterConfigWith(node.RequesterConfigParams{
-		JobSelectionPolicy: OS.JobSelectionPolicy,
-	})
+		JobSelectionPolicy:  OS.JobSelectionPolicy,
+		TrustedMeasurements: OS.TrustedMeasurements,
+		PrivacyBudget:       OS.PrivacyBudget,
+		MaxJobQueueTime:     OS.MaxJobQueueTime,
+		ClientWeights:       getClientWeights(OS),
+		ClientQuota: requester.ClientQuota{
+			MaxConcurrentExecutions: OS.ClientMaxExecutions,
+			MaxResources: capacity.ParseResourceUsageConfig(model.ResourceUsageConfig{
+				CPU:    OS.ClientMaxCPU,
+				Memory: OS.ClientMaxMemory,
+				GPU:    OS.ClientMaxGPU,
+			}),
+		},
+		ModerationPolicy: moderationPolicy,
+	}), nil
+}
+
+func getModerationPolicy(OS *ServeOptions) (*moderation.Policy, error) {
+	if OS.ModerationPolicyPath == "" {
+		return nil, nil
+	}
+	policy, err := moderation.LoadPolicy(OS.ModerationPolicyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func getClientWeights(OS *ServeOptions) map[string]float64 {
+	weights := make(map[string]float64, len(OS.ClientWeights))
+	for clientID, weight := range OS.ClientWeights {
+		weights[clientID] = float64(weight)
+	}
+	return weights
 }
 
 func newServeCmd() *cobra.Command {
@@ -266,7 +448,10 @@ func newServeCmd() *cobra.Command {
 	setupLibp2pCLIFlags(serveCmd, OS)
 	serveCmd.Flags().AddFlagSet(DisabledFeatureCLIFlags(&OS.DisabledFeatures))
 	serveCmd.Flags().AddFlagSet(JobSelectionCLIFlags(&OS.JobSelectionPolicy))
+	serveCmd.Flags().AddFlagSet(OutputPolicyCLIFlags(&OS.OutputPolicy))
+	serveCmd.Flags().AddFlagSet(SandboxCLIFlags(&OS.Sandbox))
 	setupCapacityManagerCLIFlags(serveCmd, OS)
+	setupRequesterCLIFlags(serveCmd, OS)
 
 	return serveCmd
 }
@@ -317,9 +502,13 @@ func serve(cmd *cobra.Command, OS *ServeOptions) error {
 		return err
 	}
 
-	datastore := inmemory.NewJobStore()
+	datastore, err := getJobStore(OS, libp2pHost.ID().String(), cm)
+	if err != nil {
+		return fmt.Errorf("error creating job store: %s", err)
+	}
+	requesterConfig, err := getRequesterConfig(OS)
 	if err != nil {
-		return fmt.Errorf("error creating in memory datastore: %s", err)
+		return err
 	}
 	AutoLabels := AutoOutputLabels()
 	combinedMap := make(map[string]string)
@@ -342,11 +531,14 @@ func serve(cmd *cobra.Command, OS *ServeOptions) error {
 		HostAddress:          OS.HostAddress,
 		APIPort:              apiPort,
 		ComputeConfig:        getComputeConfig(OS),
-		RequesterNodeConfig:  getRequesterConfig(OS),
+		RequesterNodeConfig:  requesterConfig,
 		IsComputeNode:        isComputeNode,
 		IsRequesterNode:      isRequesterNode,
 		Labels:               combinedMap,
 	}
+	if OS.RequireSignedMessages {
+		nodeConfig.ProtocolVersions = bprotocol.SignedVersions
+	}
 
 	if OS.LotusFilecoinStorageDuration != time.Duration(0) &&
 		OS.LotusFilecoinPathDirectory != "" &&
@@ -49,6 +49,7 @@ type WasmRunOptions struct {
 	NodeSelector    string // Selector (label query) to filter nodes on which this job can be executed
 	Publisher       opts.PublisherOpt
 	Inputs          opts.StorageOpt
+	Encryption      EncryptionSettings // Settings for encrypting secrets on the client
 }
 
 func NewRunWasmOptions() *WasmRunOptions {
@@ -111,6 +112,7 @@ func newRunWasmCmd() *cobra.Command {
 
 	wasmRunCmd.PersistentFlags().AddFlagSet(NewRunTimeSettingsFlags(&ODR.RunTimeSettings))
 	wasmRunCmd.PersistentFlags().AddFlagSet(NewIPFSDownloadFlags(&ODR.DownloadFlags))
+	wasmRunCmd.PersistentFlags().AddFlagSet(NewEncryptionFlags(&ODR.Encryption))
 
 	wasmRunCmd.PersistentFlags().StringVarP(
 		&ODR.NodeSelector, "selector", "s", ODR.NodeSelector,
@@ -140,11 +142,20 @@ func newRunWasmCmd() *cobra.Command {
 		&ODR.Job.Spec.Timeout, "timeout", ODR.Job.Spec.Timeout,
 		`Job execution timeout in seconds (e.g. 300 for 5 minutes and 0.1 for 100ms)`,
 	)
+	wasmRunCmd.PersistentFlags().IntVar(
+		&ODR.Job.Spec.Priority, "priority", ODR.Job.Spec.Priority,
+		`Priority of the job over other jobs waiting to be scheduled. Jobs with a higher priority are scheduled first.`,
+	)
 	wasmRunCmd.PersistentFlags().StringVar(
 		&ODR.Job.Spec.Wasm.EntryPoint, "entry-point", ODR.Job.Spec.Wasm.EntryPoint,
 		`The name of the WASM function in the entry module to call. This should be a zero-parameter zero-result function that
 		will execute the job.`,
 	)
+	wasmRunCmd.PersistentFlags().BoolVar(
+		&ODR.Job.Spec.Wasm.Deterministic, "deterministic", ODR.Job.Spec.Wasm.Deterministic,
+		`Run the job with a fake clock and seeded random source so that every node produces the same outputs. `+
+			`Always enabled with the deterministic verifier.`,
+	)
 	wasmRunCmd.PersistentFlags().VarP(&ODR.Inputs, "input", "i", inputUsageMsg)
 	wasmRunCmd.PersistentFlags().VarP(
 		EnvVarMapFlag(&ODR.Job.Spec.Wasm.EnvironmentVariables), "env", "e",
@@ -227,6 +238,9 @@ func runWasm(
 	if ODR.Job.Spec.Deal.Concurrency <= 1 && ODR.Job.Spec.Verifier == model.VerifierDeterministic {
 		ODR.Job.Spec.Verifier = model.VerifierNoop
 	}
+	if ODR.Job.Spec.Verifier == model.VerifierDeterministic {
+		ODR.Job.Spec.Wasm.Deterministic = true
+	}
 
 	// See wazero.ModuleConfig.WithEnv
 	for key, value := range ODR.Job.Spec.Wasm.EnvironmentVariables {
@@ -237,6 +251,13 @@ func runWasm(
 		}
 	}
 
+	if ODR.Encryption.needsNodeKeys() && ODR.RunTimeSettings.IsLocal {
+		return fmt.Errorf("encrypted inputs and secrets are not supported when running locally")
+	}
+	if err := encryptJob(ctx, GetAPIClient(), ODR.Job, ODR.Encryption); err != nil {
+		return fmt.Errorf("error encrypting job: %w", err)
+	}
+
 	return ExecuteJob(ctx, cm, cmd, ODR.Job, ODR.RunTimeSettings, ODR.DownloadFlags)
 }
 
@@ -272,7 +293,11 @@ func validateWasm(cmd *cobra.Command, args []string, wasmJob *model.Job) error {
 
 	config := wazero.NewModuleConfig()
 	storage := model.NewNoopProvider[model.StorageSourceType, storage.Storage](noop.NewNoopStorage())
-	loader := wasm.NewModuleLoader(engine, config, storage)
+	loader := wasm.NewModuleLoader(wasm.ModuleLoaderParams{
+		Runtime:         engine,
+		Config:          config,
+		StorageProvider: storage,
+	})
 	module, err := loader.Load(ctx, programPath)
 	if err != nil {
 		Fatal(cmd, err.Error(), 1)
@@ -0,0 +1,213 @@
+package bidstrategy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/c2h5oh/datasize"
+	"golang.org/x/exp/slices"
+	"sigs.k8s.io/yaml"
+)
+
+// AccessPolicy describes who may compute over which datasets held by this node, and what their jobs may do with them.
+// Inputs that are not covered by any rule are not restricted by the policy. An input is covered by a rule if it
+// overlaps with any of its sources, such as a whole bucket or a wildcard including a protected prefix.
+//
+// An example policy, in YAML:
+//
+//	datasets:
+//	  - name: patient-records
+//	    sources:
+//	      - QmPatientRecordsCID
+//	      - s3://hospital-data/records/
+//	    clients:
+//	      - 0a1b2c...
+//	    images:
+//	      - ghcr.io/hospital/analytics:*
+//	    network: none
+//	    max_output_size: 10MB
+type AccessPolicy struct {
+	Datasets []DatasetPolicy `json:"datasets"`
+}
+
+// DatasetPolicy grants access to a dataset. A dataset can be covered by several rules, in which case a job may use it
+// if any of the rules allow it.
+type DatasetPolicy struct {
+	// Name of the rule, used in the reasons given to clients
+	Name string `json:"name,omitempty"`
+	// Sources identifying the dataset: IPFS CIDs, or prefixes of S3 (s3://bucket/prefix) or URL inputs. Inputs that
+	// include the data under a prefix, such as its parent prefix or a wildcard matching it, are covered as well.
+	Sources []string `json:"sources"`
+	// Clients allowed to use the dataset. Any client is allowed if empty.
+	Clients []string `json:"clients,omitempty"`
+	// Images allowed to run over the dataset, ending with * to allow any image with that prefix
+	Images []string `json:"images,omitempty"`
+	// WasmModules are the CIDs or URLs of the WASM modules allowed to run over the dataset
+	WasmModules []string `json:"wasm_modules,omitempty"`
+	// Network is the most permissive network access allowed to jobs using the dataset. Defaults to none.
+	Network model.Network `json:"network,omitempty"`
+	// MaxOutputSize is the maximum total size of the results of jobs using the dataset, including their stdout and
+	// stderr, enforced on the results once the job ran. Unlimited if empty.
+	MaxOutputSize datasize.ByteSize `json:"max_output_size,omitempty"`
+}
+
+// ParseAccessPolicy parses a policy in YAML or JSON
+func ParseAccessPolicy(data []byte) (*AccessPolicy, error) {
+	var policy AccessPolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, err
+	}
+	for i, dataset := range policy.Datasets {
+		if len(dataset.Sources) == 0 {
+			return nil, fmt.Errorf("dataset rule %s has no sources", dataset.name(i))
+		}
+		if err := (model.NetworkConfig{Type: dataset.Network}).IsValid(); err != nil {
+			return nil, fmt.Errorf("dataset rule %s: %w", dataset.name(i), err)
+		}
+	}
+	return &policy, nil
+}
+
+func (p DatasetPolicy) name(index int) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+func (p DatasetPolicy) covers(source string) bool {
+	// a wildcard covers everything under the prefix before it
+	if i := strings.IndexAny(source, "*?["); i >= 0 {
+		source = source[:i]
+	}
+	for _, prefix := range p.Sources {
+		if source == prefix {
+			return true
+		}
+		// the input is covered if it is under the prefix, or if the prefix is under the input
+		if strings.Contains(prefix, "://") && strings.Contains(source, "://") &&
+			(strings.HasPrefix(source, prefix) || strings.HasPrefix(prefix, source)) {
+			return true
+		}
+	}
+	return false
+}
+
+// allows returns why the job can't use the dataset under this rule, or an empty string if it can
+func (p DatasetPolicy) allows(job model.Job) string {
+	if len(p.Clients) > 0 && !slices.Contains(p.Clients, job.Metadata.ClientID) {
+		return fmt.Sprintf("client %s is not allowed", job.Metadata.ClientID)
+	}
+
+	if len(p.Images) > 0 || len(p.WasmModules) > 0 {
+		switch job.Spec.Engine {
+		case model.EngineDocker:
+			if !slices.ContainsFunc(p.Images, imageMatcher(job.Spec.Docker.Image)) {
+				return fmt.Sprintf("image %s is not allowed", job.Spec.Docker.Image)
+			}
+		case model.EngineWasm:
+			// imported modules run with the same access as the entry module, as they can supply its imports
+			modules := append([]model.StorageSpec{job.Spec.Wasm.EntryModule}, job.Spec.Wasm.ImportModules...)
+			for _, spec := range modules {
+				module := sourceOf(spec)
+				if !slices.Contains(p.WasmModules, module) {
+					return fmt.Sprintf("wasm module %s is not allowed", module)
+				}
+			}
+		default:
+			return fmt.Sprintf("engine %s is not allowed", job.Spec.Engine)
+		}
+	}
+
+	if networkPermissiveness(job.Spec.Network.Type) > networkPermissiveness(p.Network) {
+		return fmt.Sprintf("network %s is not allowed", job.Spec.Network.Type)
+	}
+	return ""
+}
+
+// evaluate returns why the job is not allowed by the policy, or an empty string if it is
+func (p *AccessPolicy) evaluate(job model.Job) string {
+	var reasons []string
+	for _, input := range job.Spec.Inputs {
+		source := sourceOf(input)
+		covered := false
+		var denials []string
+		for i, dataset := range p.Datasets {
+			if !dataset.covers(source) {
+				continue
+			}
+			covered = true
+			denial := dataset.allows(job)
+			if denial == "" {
+				denials = nil
+				break
+			}
+			denials = append(denials, fmt.Sprintf("%s (rule %s)", denial, dataset.name(i)))
+		}
+		if covered && len(denials) > 0 {
+			reasons = append(reasons, fmt.Sprintf("access to %s denied: %s", source, strings.Join(denials, ", ")))
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// maxOutputSize returns the maximum total size of the results of the job, and the name of the rule setting it, or zero
+// if the results are not limited. The most permissive of the rules allowing the job to use an input applies to it,
+// and the most restrictive of the inputs applies to the job.
+func (p *AccessPolicy) maxOutputSize(job model.Job) (limit datasize.ByteSize, rule string) {
+	for _, input := range job.Spec.Inputs {
+		source := sourceOf(input)
+		var inputLimit datasize.ByteSize
+		var inputRule string
+		for i, dataset := range p.Datasets {
+			if !dataset.covers(source) || dataset.allows(job) != "" {
+				continue
+			}
+			if dataset.MaxOutputSize == 0 {
+				inputLimit, inputRule = 0, ""
+				break
+			}
+			if dataset.MaxOutputSize > inputLimit {
+				inputLimit, inputRule = dataset.MaxOutputSize, dataset.name(i)
+			}
+		}
+		if inputLimit > 0 && (limit == 0 || inputLimit < limit) {
+			limit, rule = inputLimit, inputRule
+		}
+	}
+	return limit, rule
+}
+
+func imageMatcher(image string) func(string) bool {
+	return func(allowed string) bool {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			return strings.HasPrefix(image, prefix)
+		}
+		return image == allowed
+	}
+}
+
+// sourceOf identifies where the data of a storage spec comes from, in the form used by the sources of a policy
+func sourceOf(spec model.StorageSpec) string {
+	switch {
+	case spec.CID != "":
+		return spec.CID
+	case spec.S3 != nil:
+		return fmt.Sprintf("s3://%s/%s", spec.S3.Bucket, spec.S3.Key)
+	default:
+		return spec.URL
+	}
+}
+
+// networkPermissiveness orders network types from the most restrictive to the most permissive
+func networkPermissiveness(network model.Network) int {
+	switch network {
+	case model.NetworkNone:
+		return 0
+	case model.NetworkHTTP:
+		return 1
+	default:
+		return 2
+	}
+}
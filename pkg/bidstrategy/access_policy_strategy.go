@@ -0,0 +1,146 @@
+package bidstrategy
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/outputs"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog/log"
+)
+
+type AccessPolicyStrategyParams struct {
+	// Path of the file holding the AccessPolicy. Every job is allowed if empty.
+	Path string
+}
+
+// AccessPolicyStrategy rejects jobs that are not allowed to use their inputs by the access policy of the node. It is
+// also an output inspector rejecting results larger than the policy allows. The policy file is reloaded when it
+// changes, so that data owners can update it without restarting the node. If a changed policy can't be loaded, the
+// last valid policy keeps being enforced.
+type AccessPolicyStrategy struct {
+	path string
+
+	mu      sync.Mutex
+	policy  *AccessPolicy
+	modTime time.Time
+	size    int64
+}
+
+func NewAccessPolicyStrategy(params AccessPolicyStrategyParams) *AccessPolicyStrategy {
+	return &AccessPolicyStrategy{
+		path: params.Path,
+	}
+}
+
+func (s *AccessPolicyStrategy) ShouldBid(ctx context.Context, request BidStrategyRequest) (BidStrategyResponse, error) {
+	if s.path == "" {
+		return NewShouldBidResponse(), nil
+	}
+
+	policy, err := s.load(ctx)
+	if err != nil {
+		return BidStrategyResponse{}, fmt.Errorf("AccessPolicyStrategy: %w", err)
+	}
+	if reason := policy.evaluate(request.Job); reason != "" {
+		return BidStrategyResponse{ShouldBid: false, Reason: reason}, nil
+	}
+	return NewShouldBidResponse(), nil
+}
+
+func (s *AccessPolicyStrategy) ShouldBidBasedOnUsage(
+	ctx context.Context, request BidStrategyRequest, _ model.ResourceUsageData) (BidStrategyResponse, error) {
+	return s.ShouldBid(ctx, request)
+}
+
+// Inspect rejects results that are larger than the maximum output size of the datasets used by the job. The size of
+// the whole result folder is checked, as everything in it is published.
+func (s *AccessPolicyStrategy) Inspect(ctx context.Context, request outputs.InspectionRequest) (outputs.InspectionResponse, error) {
+	if s.path == "" {
+		return outputs.NewApprovedResponse(), nil
+	}
+
+	policy, err := s.load(ctx)
+	if err != nil {
+		return outputs.InspectionResponse{}, fmt.Errorf("AccessPolicyStrategy: %w", err)
+	}
+	limit, rule := policy.maxOutputSize(request.Job)
+	if limit == 0 {
+		return outputs.NewApprovedResponse(), nil
+	}
+
+	size, err := resultsSize(request.ResultPath)
+	if err != nil {
+		return outputs.InspectionResponse{}, fmt.Errorf("AccessPolicyStrategy: failed to measure results: %w", err)
+	}
+	if size > limit {
+		return outputs.InspectionResponse{
+			Approved: false,
+			Reason:   fmt.Sprintf("results of %s exceed the maximum of %s (rule %s)", size, limit, rule),
+		}, nil
+	}
+	return outputs.NewApprovedResponse(), nil
+}
+
+// resultsSize returns the total size of the files in the result folder
+func resultsSize(resultPath string) (datasize.ByteSize, error) {
+	var size datasize.ByteSize
+	err := filepath.WalkDir(resultPath, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += datasize.ByteSize(info.Size())
+		return nil
+	})
+	return size, err
+}
+
+// load returns the current policy, reloading it if the file changed since it was last loaded
+func (s *AccessPolicyStrategy) load(ctx context.Context) (*AccessPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return s.fallback(ctx, fmt.Errorf("error reading access policy %s: %w", s.path, err))
+	}
+	if s.policy != nil && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.policy, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return s.fallback(ctx, fmt.Errorf("error reading access policy %s: %w", s.path, err))
+	}
+	policy, err := ParseAccessPolicy(data)
+	if err != nil {
+		return s.fallback(ctx, fmt.Errorf("error parsing access policy %s: %w", s.path, err))
+	}
+
+	if s.policy != nil {
+		log.Ctx(ctx).Info().Msgf("reloaded access policy %s", s.path)
+	}
+	s.policy, s.modTime, s.size = policy, info.ModTime(), info.Size()
+	return s.policy, nil
+}
+
+func (s *AccessPolicyStrategy) fallback(ctx context.Context, err error) (*AccessPolicy, error) {
+	if s.policy == nil {
+		return nil, err
+	}
+	log.Ctx(ctx).Warn().Err(err).Msg("keeping the last valid access policy")
+	return s.policy, nil
+}
+
+var _ BidStrategy = (*AccessPolicyStrategy)(nil)
+var _ outputs.Inspector = (*AccessPolicyStrategy)(nil)
@@ -0,0 +1,253 @@
+//go:build unit || !integration
+
+package bidstrategy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/outputs"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+const testAccessPolicy = `
+datasets:
+  - name: records
+    sources:
+      - QmRecords
+      - s3://hospital/records/
+    clients:
+      - trusted-client
+    images:
+      - ghcr.io/hospital/*
+    wasm_modules:
+      - QmModule
+    network: http
+    max_output_size: 1MB
+`
+
+func accessPolicyJob(clientID string, input model.StorageSpec) model.Job {
+	return model.Job{
+		Metadata: model.Metadata{ClientID: clientID},
+		Spec: model.Spec{
+			Engine: model.EngineDocker,
+			Docker: model.JobSpecDocker{Image: "ghcr.io/hospital/analytics:1.0"},
+			Inputs: []model.StorageSpec{input},
+		},
+	}
+}
+
+func s3Input(bucket, key string) model.StorageSpec {
+	return model.StorageSpec{StorageSource: model.StorageSourceS3, S3: &model.S3StorageSpec{Bucket: bucket, Key: key}}
+}
+
+func writeAccessPolicy(t *testing.T, path, policy string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(policy), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestAccessPolicyStrategy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeAccessPolicy(t, path, testAccessPolicy, time.Now())
+	strategy := NewAccessPolicyStrategy(AccessPolicyStrategyParams{Path: path})
+
+	records := model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmRecords"}
+	s3Records := model.StorageSpec{StorageSource: model.StorageSourceS3, S3: &model.S3StorageSpec{Bucket: "hospital", Key: "records/2023.csv"}}
+	public := model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmPublic"}
+
+	for _, test := range []struct {
+		name      string
+		job       func() model.Job
+		shouldBid bool
+		reason    string
+	}{
+		{
+			name:      "allowed",
+			job:       func() model.Job { return accessPolicyJob("trusted-client", records) },
+			shouldBid: true,
+		},
+		{
+			name:      "unprotected dataset",
+			job:       func() model.Job { return accessPolicyJob("other-client", public) },
+			shouldBid: true,
+		},
+		{
+			name:   "client not allowed",
+			job:    func() model.Job { return accessPolicyJob("other-client", records) },
+			reason: "access to QmRecords denied: client other-client is not allowed (rule records)",
+		},
+		{
+			name:   "client not allowed on s3 prefix",
+			job:    func() model.Job { return accessPolicyJob("other-client", s3Records) },
+			reason: "access to s3://hospital/records/2023.csv denied: client other-client is not allowed (rule records)",
+		},
+		{
+			name: "image not allowed",
+			job: func() model.Job {
+				job := accessPolicyJob("trusted-client", records)
+				job.Spec.Docker.Image = "ubuntu"
+				return job
+			},
+			reason: "image ubuntu is not allowed",
+		},
+		{
+			name: "wasm module allowed",
+			job: func() model.Job {
+				job := accessPolicyJob("trusted-client", records)
+				job.Spec.Engine = model.EngineWasm
+				job.Spec.Wasm.EntryModule = model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmModule"}
+				return job
+			},
+			shouldBid: true,
+		},
+		{
+			name: "wasm import module not allowed",
+			job: func() model.Job {
+				job := accessPolicyJob("trusted-client", records)
+				job.Spec.Engine = model.EngineWasm
+				job.Spec.Wasm.EntryModule = model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmModule"}
+				job.Spec.Wasm.ImportModules = []model.StorageSpec{{StorageSource: model.StorageSourceIPFS, CID: "QmOther"}}
+				return job
+			},
+			reason: "wasm module QmOther is not allowed",
+		},
+		{
+			name: "network not allowed",
+			job: func() model.Job {
+				job := accessPolicyJob("trusted-client", records)
+				job.Spec.Network.Type = model.NetworkFull
+				return job
+			},
+			reason: "network Full is not allowed",
+		},
+		{
+			name:   "bucket root includes the protected prefix",
+			job:    func() model.Job { return accessPolicyJob("other-client", s3Input("hospital", "")) },
+			reason: "access to s3://hospital/ denied: client other-client is not allowed (rule records)",
+		},
+		{
+			name:   "partial prefix includes the protected prefix",
+			job:    func() model.Job { return accessPolicyJob("other-client", s3Input("hospital", "rec")) },
+			reason: "access to s3://hospital/rec denied",
+		},
+		{
+			name:   "wildcard includes the protected prefix",
+			job:    func() model.Job { return accessPolicyJob("other-client", s3Input("hospital", "*")) },
+			reason: "access to s3://hospital/* denied",
+		},
+		{
+			name:      "sibling prefix is not protected",
+			job:       func() model.Job { return accessPolicyJob("other-client", s3Input("hospital", "public/")) },
+			shouldBid: true,
+		},
+		{
+			name: "url that doesn't overlap the protected sources",
+			job: func() model.Job {
+				return accessPolicyJob("other-client", model.StorageSpec{StorageSource: model.StorageSourceURLDownload, URL: "https://hospital/"})
+			},
+			shouldBid: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			request := BidStrategyRequest{Job: test.job()}
+			response, err := strategy.ShouldBid(context.Background(), request)
+			require.NoError(t, err)
+			if response.ShouldBid {
+				response, err = strategy.ShouldBidBasedOnUsage(context.Background(), request, model.ResourceUsageData{})
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.shouldBid, response.ShouldBid)
+			require.Contains(t, response.Reason, test.reason)
+		})
+	}
+}
+
+func TestAccessPolicyStrategyInspectsResultsSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeAccessPolicy(t, path, testAccessPolicy+`
+  - name: records-for-auditors
+    sources:
+      - QmRecords
+    clients:
+      - auditor
+    max_output_size: 2MB
+`, time.Now())
+	strategy := NewAccessPolicyStrategy(AccessPolicyStrategyParams{Path: path})
+	records := model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmRecords"}
+
+	inspect := func(job model.Job, files map[string]int) outputs.InspectionResponse {
+		resultPath := t.TempDir()
+		for name, size := range files {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(resultPath, name)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(resultPath, name), make([]byte, size), 0600))
+		}
+		response, err := strategy.Inspect(context.Background(), outputs.InspectionRequest{Job: job, ResultPath: resultPath})
+		require.NoError(t, err)
+		return response
+	}
+
+	job := accessPolicyJob("trusted-client", records)
+	require.True(t, inspect(job, map[string]int{"outputs/a.csv": 1000, "stdout": 1000}).Approved)
+
+	// stdout and stderr count towards the limit, as they are published with the outputs
+	response := inspect(job, map[string]int{"outputs/a.csv": 1000, "stdout": 1024 * 1024})
+	require.False(t, response.Approved)
+	require.Contains(t, response.Reason, "exceed the maximum of 1MB (rule records)")
+
+	// the limit of the rule allowing the job applies
+	auditorJob := accessPolicyJob("auditor", records)
+	require.True(t, inspect(auditorJob, map[string]int{"stdout": 1024 * 1024}).Approved)
+
+	// jobs not using protected datasets are not limited
+	publicJob := accessPolicyJob("other-client", model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmPublic"})
+	require.True(t, inspect(publicJob, map[string]int{"stdout": 4 * 1024 * 1024}).Approved)
+}
+
+func TestAccessPolicyStrategyReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	modTime := time.Now().Add(-time.Minute)
+	writeAccessPolicy(t, path, testAccessPolicy, modTime)
+	strategy := NewAccessPolicyStrategy(AccessPolicyStrategyParams{Path: path})
+
+	request := BidStrategyRequest{
+		Job: accessPolicyJob("new-client", model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmRecords"}),
+	}
+	response, err := strategy.ShouldBid(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, response.ShouldBid)
+
+	// grant access to the new client
+	modTime = modTime.Add(time.Second)
+	writeAccessPolicy(t, path, strings.Replace(testAccessPolicy, "- trusted-client", "- trusted-client\n      - new-client", 1), modTime)
+	response, err = strategy.ShouldBid(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, response.ShouldBid)
+
+	// an invalid policy keeps the last valid one in place
+	modTime = modTime.Add(time.Second)
+	writeAccessPolicy(t, path, "datasets: [{unknown: field}]", modTime)
+	response, err = strategy.ShouldBid(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, response.ShouldBid)
+}
+
+func TestAccessPolicyStrategyInvalidPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeAccessPolicy(t, path, "datasets: [{clients: [someone]}]", time.Now())
+	strategy := NewAccessPolicyStrategy(AccessPolicyStrategyParams{Path: path})
+
+	_, err := strategy.ShouldBid(context.Background(), BidStrategyRequest{})
+	require.Error(t, err)
+}
+
+func TestAccessPolicyStrategyDisabled(t *testing.T) {
+	strategy := NewAccessPolicyStrategy(AccessPolicyStrategyParams{})
+	response, err := strategy.ShouldBid(context.Background(), BidStrategyRequest{})
+	require.NoError(t, err)
+	require.True(t, response.ShouldBid)
+}
@@ -15,5 +15,8 @@ func FromJobSelectionPolicy(jsp model.JobSelectionPolicy) BidStrategy {
 		NewStatelessJobStrategy(StatelessJobStrategyParams{
 			RejectStatelessJobs: jsp.RejectStatelessJobs,
 		}),
+		NewAccessPolicyStrategy(AccessPolicyStrategyParams{
+			Path: jsp.AccessPolicyFile,
+		}),
 	)
 }
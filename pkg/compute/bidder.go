@@ -75,18 +75,21 @@ func (b Bidder) ReturnBidResult(ctx context.Context, execution store.Execution,
 		return
 	}
 
+	// the bid is recorded so that a requester that missed it can recover it from the status of the execution
+	newState := store.ExecutionStateCreated
 	if !response.ShouldBid {
-		err := b.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
-			ExecutionID:   execution.ID,
-			NewState:      store.ExecutionStateCancelled,
-			ExpectedState: store.ExecutionStateCreated,
-			Comment:       response.Reason,
-		})
-
-		if err != nil {
-			log.Ctx(ctx).Error().Err(err).Msg("Unable to update execution state")
-			return
-		}
+		newState = store.ExecutionStateCancelled
+	}
+	err := b.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID:   execution.ID,
+		NewState:      newState,
+		ExpectedState: store.ExecutionStateCreated,
+		Comment:       response.Reason,
+		Bid:           &store.Bid{Accepted: response.ShouldBid, Reason: response.Reason},
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("Unable to update execution state")
+		return
 	}
 
 	result := BidResult{
@@ -0,0 +1,143 @@
+package compute
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+)
+
+// BufferOrdering names a policy ordering the executions enqueued in the ExecutorBuffer
+type BufferOrdering string
+
+const (
+	// BufferOrderingFIFO considers executions in the order they were enqueued
+	BufferOrderingFIFO BufferOrdering = "fifo"
+	// BufferOrderingPriority considers executions of jobs with a higher priority first
+	BufferOrderingPriority BufferOrdering = "priority"
+	// BufferOrderingShortestJobFirst considers executions of jobs with a shorter timeout first, ageing the ones waiting
+	BufferOrderingShortestJobFirst BufferOrdering = "sjf"
+)
+
+// DefaultAgingFactor is how much shorter the jobs waiting in the buffer are considered by the shortest job first
+// ordering for each second they waited.
+const DefaultAgingFactor = 1.0
+
+func BufferOrderings() []BufferOrdering {
+	return []BufferOrdering{BufferOrderingFIFO, BufferOrderingPriority, BufferOrderingShortestJobFirst}
+}
+
+func ParseBufferOrdering(s string) (BufferOrdering, error) {
+	for _, ordering := range BufferOrderings() {
+		if strings.EqualFold(string(ordering), strings.TrimSpace(s)) {
+			return ordering, nil
+		}
+	}
+	return BufferOrderingFIFO, fmt.Errorf("unknown buffer ordering '%s', expected one of %v", s, BufferOrderings())
+}
+
+// EnqueuedExecution is an execution waiting in the ExecutorBuffer for capacity to run it
+type EnqueuedExecution struct {
+	Execution  store.Execution
+	EnqueuedAt time.Time
+}
+
+// OrderingPolicy decides the order in which the ExecutorBuffer considers the enqueued executions to run. Executions
+// that don't fit in the available capacity are skipped in favour of the next ones in the order.
+type OrderingPolicy interface {
+	fmt.Stringer
+	// Less returns true if execution a should be considered before execution b at the given time
+	Less(a, b EnqueuedExecution, now time.Time) bool
+}
+
+type OrderingPolicyParams struct {
+	Ordering BufferOrdering
+	// DefaultJobExecutionTimeout is the length of the jobs that don't have a timeout, for shortest job first
+	DefaultJobExecutionTimeout time.Duration
+	// AgingFactor is how much shorter waiting jobs are considered for each second they waited, for shortest job first.
+	// Defaults to DefaultAgingFactor.
+	AgingFactor float64
+}
+
+// NewOrderingPolicy returns the named ordering policy. It defaults to FIFO.
+func NewOrderingPolicy(params OrderingPolicyParams) (OrderingPolicy, error) {
+	switch params.Ordering {
+	case BufferOrderingFIFO, "":
+		return FIFOOrdering{}, nil
+	case BufferOrderingPriority:
+		return PriorityOrdering{}, nil
+	case BufferOrderingShortestJobFirst:
+		agingFactor := params.AgingFactor
+		if agingFactor == 0 {
+			agingFactor = DefaultAgingFactor
+		}
+		return ShortestJobFirstOrdering{
+			defaultJobExecutionTimeout: params.DefaultJobExecutionTimeout,
+			agingFactor:                agingFactor,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown buffer ordering '%s', expected one of %v", params.Ordering, BufferOrderings())
+	}
+}
+
+// FIFOOrdering considers executions in the order they were enqueued
+type FIFOOrdering struct{}
+
+func (FIFOOrdering) String() string {
+	return string(BufferOrderingFIFO)
+}
+
+func (FIFOOrdering) Less(a, b EnqueuedExecution, _ time.Time) bool {
+	return a.EnqueuedAt.Before(b.EnqueuedAt)
+}
+
+// PriorityOrdering considers executions of jobs with a higher priority first, and executions of the same priority in
+// the order they were enqueued
+type PriorityOrdering struct{}
+
+func (PriorityOrdering) String() string {
+	return string(BufferOrderingPriority)
+}
+
+func (PriorityOrdering) Less(a, b EnqueuedExecution, now time.Time) bool {
+	priorityA, priorityB := a.Execution.Job.Spec.Priority, b.Execution.Job.Spec.Priority
+	if priorityA != priorityB {
+		return priorityA > priorityB
+	}
+	return FIFOOrdering{}.Less(a, b, now)
+}
+
+// ShortestJobFirstOrdering considers executions of jobs with a shorter timeout first. Jobs are considered shorter the
+// longer they wait, so that a long job is only overtaken by shorter jobs enqueued shortly after it, within the
+// difference in timeouts divided by the aging factor, and is not starved by the short jobs enqueued after that.
+type ShortestJobFirstOrdering struct {
+	defaultJobExecutionTimeout time.Duration
+	agingFactor                float64
+}
+
+func (o ShortestJobFirstOrdering) String() string {
+	return string(BufferOrderingShortestJobFirst)
+}
+
+func (o ShortestJobFirstOrdering) Less(a, b EnqueuedExecution, now time.Time) bool {
+	lengthA, lengthB := o.agedLength(a, now), o.agedLength(b, now)
+	if lengthA != lengthB {
+		return lengthA < lengthB
+	}
+	return FIFOOrdering{}.Less(a, b, now)
+}
+
+// agedLength is the timeout of the job, less the time it waited scaled by the aging factor
+func (o ShortestJobFirstOrdering) agedLength(execution EnqueuedExecution, now time.Time) float64 {
+	timeout := execution.Execution.Job.Spec.GetTimeout()
+	if timeout == 0 {
+		timeout = o.defaultJobExecutionTimeout
+	}
+	return timeout.Seconds() - o.agingFactor*now.Sub(execution.EnqueuedAt).Seconds()
+}
+
+// compile-time interface check
+var _ OrderingPolicy = FIFOOrdering{}
+var _ OrderingPolicy = PriorityOrdering{}
+var _ OrderingPolicy = ShortestJobFirstOrdering{}
@@ -0,0 +1,72 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func newEnqueuedExecution(priority int, timeout float64, enqueuedAt time.Time) EnqueuedExecution {
+	job := model.Job{Spec: model.Spec{Priority: priority, Timeout: timeout}}
+	return EnqueuedExecution{Execution: *store.NewExecution("", job, "", model.ResourceUsageData{}), EnqueuedAt: enqueuedAt}
+}
+
+func TestParseBufferOrdering(t *testing.T) {
+	for _, ordering := range BufferOrderings() {
+		parsed, err := ParseBufferOrdering(string(ordering))
+		require.NoError(t, err)
+		require.Equal(t, ordering, parsed)
+	}
+	parsed, err := ParseBufferOrdering(" SJF ")
+	require.NoError(t, err)
+	require.Equal(t, BufferOrderingShortestJobFirst, parsed)
+
+	_, err = ParseBufferOrdering("lifo")
+	require.Error(t, err)
+}
+
+func TestOrderingPolicies(t *testing.T) {
+	now := time.Now()
+	older := newEnqueuedExecution(0, 600, now.Add(-time.Minute))
+	newer := newEnqueuedExecution(0, 60, now)
+	important := newEnqueuedExecution(10, 600, now)
+
+	fifo, err := NewOrderingPolicy(OrderingPolicyParams{})
+	require.NoError(t, err)
+	require.Equal(t, "fifo", fifo.String())
+	require.True(t, fifo.Less(older, newer, now))
+	require.False(t, fifo.Less(newer, older, now))
+
+	priority, err := NewOrderingPolicy(OrderingPolicyParams{Ordering: BufferOrderingPriority})
+	require.NoError(t, err)
+	require.True(t, priority.Less(important, older, now))
+	require.True(t, priority.Less(older, newer, now))
+
+	sjf, err := NewOrderingPolicy(OrderingPolicyParams{Ordering: BufferOrderingShortestJobFirst})
+	require.NoError(t, err)
+	require.True(t, sjf.Less(newer, older, now))
+	// shorter jobs only overtake the longer job if they were enqueued within the difference in timeouts after it
+	later := now.Add(10 * time.Minute)
+	muchNewer := newEnqueuedExecution(0, 60, later)
+	require.True(t, sjf.Less(older, muchNewer, later))
+
+	_, err = NewOrderingPolicy(OrderingPolicyParams{Ordering: "lifo"})
+	require.Error(t, err)
+}
+
+func TestShortestJobFirstUsesDefaultTimeout(t *testing.T) {
+	now := time.Now()
+	sjf, err := NewOrderingPolicy(OrderingPolicyParams{
+		Ordering:                   BufferOrderingShortestJobFirst,
+		DefaultJobExecutionTimeout: time.Hour,
+	})
+	require.NoError(t, err)
+	noTimeout := newEnqueuedExecution(0, 0, now)
+	withTimeout := newEnqueuedExecution(0, 600, now)
+	require.True(t, sjf.Less(withTimeout, noTimeout, now))
+}
@@ -0,0 +1,108 @@
+package capacity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+)
+
+// StaticGPUProvider returns a fixed list of GPU devices. Useful for tests and nodes with a known set of devices.
+type StaticGPUProvider struct {
+	gpus []model.GPU
+}
+
+func NewStaticGPUProvider(gpus ...model.GPU) *StaticGPUProvider {
+	return &StaticGPUProvider{
+		gpus: gpus,
+	}
+}
+
+func (p *StaticGPUProvider) GetGPUs(ctx context.Context) ([]model.GPU, error) {
+	return p.gpus, nil
+}
+
+type LocalGPUAllocatorParams struct {
+	GPUs []model.GPU
+}
+
+// LocalGPUAllocator keeps track of the GPU devices of the local node in-memory.
+type LocalGPUAllocator struct {
+	gpus []model.GPU
+	// executions that reserved each device, by device position in gpus
+	owners      []string
+	allocations map[string][]model.GPU
+	mu          sync.Mutex
+}
+
+func NewLocalGPUAllocator(params LocalGPUAllocatorParams) *LocalGPUAllocator {
+	return &LocalGPUAllocator{
+		gpus:        params.GPUs,
+		owners:      make([]string, len(params.GPUs)),
+		allocations: make(map[string][]model.GPU),
+	}
+}
+
+func (a *LocalGPUAllocator) Allocate(ctx context.Context, executionID string, count uint64) ([]model.GPU, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if allocated, ok := a.allocations[executionID]; ok {
+		if uint64(len(allocated)) != count {
+			return nil, fmt.Errorf("execution %s already has %d GPUs allocated, cannot allocate %d",
+				executionID, len(allocated), count)
+		}
+		return allocated, nil
+	}
+
+	var free []int
+	for i, owner := range a.owners {
+		if owner == "" {
+			free = append(free, i)
+		}
+	}
+	if uint64(len(free)) < count {
+		return nil, fmt.Errorf("not enough GPUs to allocate %d for execution %s: %d of %d available",
+			count, executionID, len(free), len(a.gpus))
+	}
+
+	allocated := make([]model.GPU, 0, count)
+	for _, i := range free[:count] {
+		a.owners[i] = executionID
+		allocated = append(allocated, a.gpus[i])
+	}
+	a.allocations[executionID] = allocated
+	return allocated, nil
+}
+
+func (a *LocalGPUAllocator) Release(ctx context.Context, executionID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, owner := range a.owners {
+		if owner == executionID {
+			a.owners[i] = ""
+		}
+	}
+	delete(a.allocations, executionID)
+}
+
+func (a *LocalGPUAllocator) GetGPUs(ctx context.Context) []model.GPU {
+	return a.gpus
+}
+
+func (a *LocalGPUAllocator) GetAvailableGPUs(ctx context.Context) []model.GPU {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var available []model.GPU
+	for i, owner := range a.owners {
+		if owner == "" {
+			available = append(available, a.gpus[i])
+		}
+	}
+	return available
+}
+
+// compile-time checks that the types implement the interfaces
+var _ GPUProvider = (*StaticGPUProvider)(nil)
+var _ GPUAllocator = (*LocalGPUAllocator)(nil)
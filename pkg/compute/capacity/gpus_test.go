@@ -0,0 +1,65 @@
+//go:build unit || !integration
+
+package capacity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalGPUAllocator(t *testing.T) {
+	ctx := context.Background()
+	gpus, err := NewStaticGPUProvider(
+		model.GPU{Index: 0, Name: "Tesla T4"},
+		model.GPU{Index: 1, Name: "Tesla T4"},
+		model.GPU{Index: 2, Name: "Tesla T4"},
+	).GetGPUs(ctx)
+	require.NoError(t, err)
+	allocator := NewLocalGPUAllocator(LocalGPUAllocatorParams{GPUs: gpus})
+
+	first, err := allocator.Allocate(ctx, "first", 2)
+	require.NoError(t, err)
+	require.Equal(t, gpus[:2], first)
+	require.Equal(t, gpus[2:], allocator.GetAvailableGPUs(ctx))
+
+	// allocating again for the same execution returns the same devices
+	again, err := allocator.Allocate(ctx, "first", 2)
+	require.NoError(t, err)
+	require.Equal(t, first, again)
+	_, err = allocator.Allocate(ctx, "first", 1)
+	require.Error(t, err)
+
+	_, err = allocator.Allocate(ctx, "second", 2)
+	require.Error(t, err)
+
+	second, err := allocator.Allocate(ctx, "second", 1)
+	require.NoError(t, err)
+	require.Equal(t, gpus[2:], second)
+	require.Empty(t, allocator.GetAvailableGPUs(ctx))
+
+	// released devices are allocated to other executions
+	allocator.Release(ctx, "first")
+	require.Equal(t, gpus[:2], allocator.GetAvailableGPUs(ctx))
+	third, err := allocator.Allocate(ctx, "third", 1)
+	require.NoError(t, err)
+	require.Equal(t, gpus[:1], third)
+
+	allocator.Release(ctx, "second")
+	allocator.Release(ctx, "third")
+	allocator.Release(ctx, "unknown")
+	require.Equal(t, gpus, allocator.GetAvailableGPUs(ctx))
+	require.Equal(t, gpus, allocator.GetGPUs(ctx))
+}
+
+func TestLocalGPUAllocatorNoGPUs(t *testing.T) {
+	allocator := NewLocalGPUAllocator(LocalGPUAllocatorParams{})
+	_, err := allocator.Allocate(context.Background(), "execution", 1)
+	require.Error(t, err)
+
+	gpus, err := allocator.Allocate(context.Background(), "execution", 0)
+	require.NoError(t, err)
+	require.Empty(t, gpus)
+}
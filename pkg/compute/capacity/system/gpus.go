@@ -0,0 +1,97 @@
+package system
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// NvidiaCLI is the path to the Nvidia helper binary
+const NvidiaCLI = "nvidia-container-cli"
+
+const (
+	nvidiaDeviceIndexColumn = "Device Index"
+	nvidiaModelColumn       = "Model"
+)
+
+// NvidiaGPUProvider lists the Nvidia GPUs of the host using nvidia-container-cli
+type NvidiaGPUProvider struct {
+}
+
+func NewNvidiaGPUProvider() *NvidiaGPUProvider {
+	return &NvidiaGPUProvider{}
+}
+
+func (p *NvidiaGPUProvider) GetGPUs(ctx context.Context) ([]model.GPU, error) {
+	nvidiaPath, err := exec.LookPath(NvidiaCLI)
+	if err != nil {
+		// If the NVIDIA CLI is not installed, we can't know the GPUs, assume there are none
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	resp, err := exec.CommandContext(ctx, nvidiaPath, "info", "--csv").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNvidiaCLIOutput(string(resp))
+}
+
+// parseNvidiaCLIOutput parses the output of `nvidia-container-cli info --csv`, which lists the devices after a
+// header line starting with "Device Index", e.g.:
+//
+//	Device Index,Device Minor,Model,Brand,GPU UUID,Bus Location,Architecture
+//	0,0,Tesla T4,Tesla,GPU-2f9c1d3e-...,00000000:00:1e.0,7.5
+func parseNvidiaCLIOutput(output string) ([]model.GPU, error) {
+	_, devices, found := strings.Cut(output, nvidiaDeviceIndexColumn)
+	if !found {
+		return nil, nil
+	}
+	reader := csv.NewReader(strings.NewReader(nvidiaDeviceIndexColumn + devices))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s output: %w", NvidiaCLI, err)
+	}
+	modelColumn := -1
+	for i, column := range header {
+		if column == nvidiaModelColumn {
+			modelColumn = i
+		}
+	}
+
+	var gpus []model.GPU
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s output: %w", NvidiaCLI, err)
+		}
+		index, err := strconv.ParseUint(record[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s device index %q: %w", NvidiaCLI, record[0], err)
+		}
+		gpu := model.GPU{Index: index}
+		if modelColumn >= 0 && modelColumn < len(record) {
+			gpu.Name = record[modelColumn]
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// compile-time check that the provider implements the interface
+var _ capacity.GPUProvider = (*NvidiaGPUProvider)(nil)
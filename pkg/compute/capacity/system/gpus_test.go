@@ -0,0 +1,34 @@
+//go:build unit || !integration
+
+package system
+
+import (
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+const nvidiaCLIOutput = `NVRM version,CUDA version
+525.85.12,12.0
+
+Device Index,Device Minor,Model,Brand,GPU UUID,Bus Location,Architecture
+0,0,Tesla T4,Nvidia,GPU-2f9c1d3e-6c8a-4b1e-9d1f-0a1b2c3d4e5f,00000000:00:1e.0,7.5
+1,1,NVIDIA A100-SXM4-40GB,Nvidia,GPU-5a6b7c8d-1e2f-4a3b-8c9d-0e1f2a3b4c5d,00000000:00:1f.0,8.0
+`
+
+func TestParseNvidiaCLIOutput(t *testing.T) {
+	gpus, err := parseNvidiaCLIOutput(nvidiaCLIOutput)
+	require.NoError(t, err)
+	require.Equal(t, []model.GPU{
+		{Index: 0, Name: "Tesla T4"},
+		{Index: 1, Name: "NVIDIA A100-SXM4-40GB"},
+	}, gpus)
+
+	gpus, err = parseNvidiaCLIOutput("NVRM version,CUDA version\n525.85.12,12.0\n")
+	require.NoError(t, err)
+	require.Empty(t, gpus)
+
+	_, err = parseNvidiaCLIOutput("Device Index,Model\nfirst,Tesla T4\n")
+	require.Error(t, err)
+}
@@ -3,9 +3,7 @@ package system
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"runtime"
-	"strings"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/config"
@@ -14,9 +12,6 @@ import (
 	"github.com/ricochet2200/go-disk-usage/du"
 )
 
-// NvidiaCLI is the path to the Nvidia helper binary
-const NvidiaCLI = "nvidia-container-cli"
-
 type PhysicalCapacityProvider struct {
 }
 
@@ -29,7 +24,7 @@ func (p *PhysicalCapacityProvider) GetAvailableCapacity(ctx context.Context) (mo
 	if err != nil {
 		return model.ResourceUsageData{}, err
 	}
-	gpus, err := numSystemGPUs()
+	gpus, err := NewNvidiaGPUProvider().GetGPUs(ctx)
 	if err != nil {
 		return model.ResourceUsageData{}, err
 	}
@@ -39,7 +34,7 @@ func (p *PhysicalCapacityProvider) GetAvailableCapacity(ctx context.Context) (mo
 		CPU:    float64(runtime.NumCPU()) * 0.8,
 		Memory: memory.TotalMemory() * 80 / 100,
 		Disk:   diskSpace * 80 / 100,
-		GPU:    gpus,
+		GPU:    uint64(len(gpus)),
 	}, nil
 }
 
@@ -53,46 +48,5 @@ func getFreeDiskSpace(path string) (uint64, error) {
 	return usage.Free(), nil
 }
 
-// numSystemGPUs wraps nvidia-container-cli to get the number of GPUs
-func numSystemGPUs() (uint64, error) {
-	nvidiaPath, err := exec.LookPath(NvidiaCLI)
-	if err != nil {
-		// If the NVIDIA CLI is not installed, we can't know the number of GPUs, assume zero
-		if (err.(*exec.Error)).Unwrap() == exec.ErrNotFound {
-			return 0, nil
-		}
-		return 0, err
-	}
-	args := []string{
-		"info",
-		"--csv",
-	}
-	cmd := exec.Command(nvidiaPath, args...)
-	resp, err := cmd.Output()
-	if err != nil {
-		return 0, err
-	}
-
-	// Parse output of nvidia-container-cli command
-	lines := strings.Split(string(resp), "\n")
-	deviceInfoFlag := false
-	numDevices := uint64(0)
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		if strings.HasPrefix(line, "Device Index") {
-			deviceInfoFlag = true
-			continue
-		}
-		if deviceInfoFlag {
-			numDevices += 1
-		}
-	}
-
-	fmt.Println(numDevices)
-	return numDevices, nil
-}
-
 // compile-time check that the provider implements the interface
 var _ capacity.Provider = (*PhysicalCapacityProvider)(nil)
@@ -35,3 +35,23 @@ type UsageCalculator interface {
 type Provider interface {
 	GetAvailableCapacity(ctx context.Context) (model.ResourceUsageData, error)
 }
+
+// GPUProvider returns the GPU devices of a compute node.
+type GPUProvider interface {
+	GetGPUs(ctx context.Context) ([]model.GPU, error)
+}
+
+// GPUAllocator keeps track of which GPU devices of the compute node are in use by executions.
+// The regular flow is to call Allocate before starting an execution that requires GPUs, and Release after the
+// execution is done to free the devices.
+type GPUAllocator interface {
+	// Allocate reserves count distinct devices for the execution and returns them. Allocating again for the same
+	// execution returns the devices already reserved for it.
+	Allocate(ctx context.Context, executionID string, count uint64) ([]model.GPU, error)
+	// Release frees the devices reserved for the execution.
+	Release(ctx context.Context, executionID string)
+	// GetGPUs returns all the devices managed by the allocator.
+	GetGPUs(ctx context.Context) []model.GPU
+	// GetAvailableGPUs returns the devices not reserved by any execution.
+	GetAvailableGPUs(ctx context.Context) []model.GPU
+}
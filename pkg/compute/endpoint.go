@@ -2,6 +2,7 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
@@ -79,6 +80,9 @@ func (s BaseEndpoint) AskForBid(ctx context.Context, request AskForBidRequest) (
 
 func (s BaseEndpoint) BidAccepted(ctx context.Context, request BidAcceptedRequest) (BidAcceptedResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("bid accepted: %s", request.ExecutionID)
+	if _, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata); err != nil {
+		return BidAcceptedResponse{}, err
+	}
 	err := s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   request.ExecutionID,
 		ExpectedState: store.ExecutionStateCreated,
@@ -107,6 +111,9 @@ func (s BaseEndpoint) BidAccepted(ctx context.Context, request BidAcceptedReques
 
 func (s BaseEndpoint) BidRejected(ctx context.Context, request BidRejectedRequest) (BidRejectedResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("bid rejected: %s", request.ExecutionID)
+	if _, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata); err != nil {
+		return BidRejectedResponse{}, err
+	}
 	err := s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   request.ExecutionID,
 		ExpectedState: store.ExecutionStateCreated,
@@ -127,6 +134,9 @@ func (s BaseEndpoint) BidRejected(ctx context.Context, request BidRejectedReques
 
 func (s BaseEndpoint) ResultAccepted(ctx context.Context, request ResultAcceptedRequest) (ResultAcceptedResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("results accepted: %s", request.ExecutionID)
+	if _, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata); err != nil {
+		return ResultAcceptedResponse{}, err
+	}
 	err := s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   request.ExecutionID,
 		ExpectedState: store.ExecutionStateWaitingVerification,
@@ -151,6 +161,9 @@ func (s BaseEndpoint) ResultAccepted(ctx context.Context, request ResultAccepted
 
 func (s BaseEndpoint) ResultRejected(ctx context.Context, request ResultRejectedRequest) (ResultRejectedResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("results rejected: %s", request.ExecutionID)
+	if _, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata); err != nil {
+		return ResultRejectedResponse{}, err
+	}
 	err := s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   request.ExecutionID,
 		ExpectedState: store.ExecutionStateWaitingVerification,
@@ -171,7 +184,7 @@ func (s BaseEndpoint) ResultRejected(ctx context.Context, request ResultRejected
 
 func (s BaseEndpoint) CancelExecution(ctx context.Context, request CancelExecutionRequest) (CancelExecutionResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("canceling execution %s due to %s", request.ExecutionID, request.Justification)
-	execution, err := s.executionStore.GetExecution(ctx, request.ExecutionID)
+	execution, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata)
 	if err != nil {
 		return CancelExecutionResponse{}, err
 	}
@@ -201,7 +214,7 @@ func (s BaseEndpoint) CancelExecution(ctx context.Context, request CancelExecuti
 
 func (s BaseEndpoint) ExecutionLogs(ctx context.Context, request ExecutionLogsRequest) (ExecutionLogsResponse, error) {
 	log.Ctx(ctx).Debug().Msgf("processing log request for %s", request.ExecutionID)
-	execution, err := s.executionStore.GetExecution(ctx, request.ExecutionID)
+	execution, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata)
 	if err != nil {
 		return ExecutionLogsResponse{}, err
 	}
@@ -212,5 +225,44 @@ func (s BaseEndpoint) ExecutionLogs(ctx context.Context, request ExecutionLogsRe
 	}, nil
 }
 
+func (s BaseEndpoint) ExecutionStatus(ctx context.Context, request ExecutionStatusRequest) (ExecutionStatusResponse, error) {
+	log.Ctx(ctx).Debug().Msgf("processing status request for %s", request.ExecutionID)
+	execution, err := s.getExecutionFor(ctx, request.ExecutionID, request.RoutingMetadata)
+	if err != nil {
+		if errors.As(err, &store.ErrExecutionNotFound{}) {
+			return ExecutionStatusResponse{
+				ExecutionMetadata: ExecutionMetadata{ExecutionID: request.ExecutionID},
+				State:             store.ExecutionStateUndefined,
+			}, nil
+		}
+		return ExecutionStatusResponse{}, err
+	}
+
+	return ExecutionStatusResponse{
+		ExecutionMetadata: NewExecutionMetadata(execution),
+		State:             execution.State,
+		Bid:               execution.Bid,
+		ResultProposal:    execution.ResultProposal,
+		RunCommandResult:  execution.RunCommandResult,
+		PublishResult:     execution.PublishedResult,
+	}, nil
+}
+
+// getExecutionFor returns the execution if the request was sent by the requester node that created it,
+// as other nodes must not be able to drive or inspect executions they do not own.
+func (s BaseEndpoint) getExecutionFor(ctx context.Context, executionID string, routing RoutingMetadata) (store.Execution, error) {
+	execution, err := s.executionStore.GetExecution(ctx, executionID)
+	if err != nil {
+		return store.Execution{}, err
+	}
+	if execution.RequesterNodeID != routing.SourcePeerID {
+		requestsRejected.Add(ctx, 1)
+		err = NewErrExecutionRequesterMismatch(executionID, execution.RequesterNodeID, routing.SourcePeerID)
+		log.Ctx(ctx).Warn().Err(err).Msg("rejecting request")
+		return store.Execution{}, err
+	}
+	return execution, nil
+}
+
 // Compile-time interface check:
 var _ Endpoint = (*BaseEndpoint)(nil)
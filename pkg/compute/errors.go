@@ -0,0 +1,41 @@
+package compute
+
+import "fmt"
+
+// ErrExecutionRequesterMismatch is returned when a request about an execution is not sent by the requester node
+// that created the execution
+type ErrExecutionRequesterMismatch struct {
+	ExecutionID     string
+	RequesterNodeID string
+	SourcePeerID    string
+}
+
+func NewErrExecutionRequesterMismatch(executionID, requesterNodeID, sourcePeerID string) ErrExecutionRequesterMismatch {
+	return ErrExecutionRequesterMismatch{
+		ExecutionID:     executionID,
+		RequesterNodeID: requesterNodeID,
+		SourcePeerID:    sourcePeerID,
+	}
+}
+
+func (e ErrExecutionRequesterMismatch) Error() string {
+	return fmt.Sprintf("execution %s was requested by %s, not %s", e.ExecutionID, e.RequesterNodeID, e.SourcePeerID)
+}
+
+// ErrExecutionPreempted is returned when a running execution was stopped to make room for an execution of a higher
+// priority, and was enqueued again to run later
+type ErrExecutionPreempted struct {
+	ExecutionID string
+	PreemptedBy string
+}
+
+func NewErrExecutionPreempted(executionID, preemptedBy string) ErrExecutionPreempted {
+	return ErrExecutionPreempted{
+		ExecutionID: executionID,
+		PreemptedBy: preemptedBy,
+	}
+}
+
+func (e ErrExecutionPreempted) Error() string {
+	return fmt.Sprintf("execution %s was preempted by execution %s", e.ExecutionID, e.PreemptedBy)
+}
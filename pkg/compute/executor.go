@@ -2,13 +2,17 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute/outputs"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/privacy"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"github.com/bacalhau-project/bacalhau/pkg/telemetry"
 	"github.com/bacalhau-project/bacalhau/pkg/util/generic"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
 	"github.com/rs/zerolog/log"
@@ -21,6 +25,7 @@ type BaseExecutorParams struct {
 	Executors       executor.ExecutorProvider
 	Verifiers       verifier.VerifierProvider
 	Publishers      publisher.PublisherProvider
+	OutputInspector outputs.Inspector
 	SimulatorConfig model.SimulatorConfigCompute
 }
 
@@ -31,9 +36,11 @@ type BaseExecutor struct {
 	callback        Callback
 	store           store.ExecutionStore
 	cancellers      generic.SyncMap[string, context.CancelFunc]
+	resultsUsage    generic.SyncMap[string, model.ResourceUsageData]
 	executors       executor.ExecutorProvider
 	verifiers       verifier.VerifierProvider
 	publishers      publisher.PublisherProvider
+	outputInspector outputs.Inspector
 	simulatorConfig model.SimulatorConfigCompute
 }
 
@@ -45,6 +52,7 @@ func NewBaseExecutor(params BaseExecutorParams) *BaseExecutor {
 		executors:       params.Executors,
 		verifiers:       params.Verifiers,
 		publishers:      params.Publishers,
+		outputInspector: params.OutputInspector,
 		simulatorConfig: params.SimulatorConfig,
 	}
 }
@@ -66,11 +74,20 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 	}()
 
 	defer func() {
-		if err != nil {
+		var preempted ErrExecutionPreempted
+		if err != nil && !errors.As(err, &preempted) {
 			e.handleFailure(ctx, execution, err, "Running")
 		}
 	}()
 
+	var resultFolder string
+	defer func() {
+		var preempted ErrExecutionPreempted
+		if err != nil && errors.As(context.Cause(ctx), &preempted) {
+			err = e.requeue(ctx, execution, resultFolder, preempted)
+		}
+	}()
+
 	log.Ctx(ctx).Debug().Msg("Running execution")
 	err = e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID:   execution.ID,
@@ -87,7 +104,7 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 		return
 	}
 
-	resultFolder, err := jobVerifier.GetResultPath(ctx, execution.ID, execution.Job)
+	resultFolder, err = jobVerifier.GetResultPath(ctx, execution.ID, execution.Job)
 	if err != nil {
 		err = fmt.Errorf("failed to get result path: %w", err)
 		return
@@ -103,6 +120,13 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 
 	if !e.simulatorConfig.IsBadActor {
 		runCommandResult, err = jobExecutor.Run(ctx, execution.ID, execution.Job, resultFolder)
+		// executors may stop an execution when its context is cancelled and still return its partial results without
+		// an error, such as WASM modules that are closed, so a preempted execution is requeued whatever it returned
+		var preempted ErrExecutionPreempted
+		if errors.As(context.Cause(ctx), &preempted) {
+			err = preempted
+			return
+		}
 		if err != nil {
 			jobsFailed.Add(ctx, 1)
 		} else {
@@ -113,6 +137,34 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 			log.Ctx(ctx).Error().Err(err).Msg("failed to run execution")
 			return
 		}
+
+		// the usage is measured before the run output is redacted, as the outputs stay in the result folder until
+		// they are published. Executors may not return a result when they succeed.
+		var resultsUsage model.ResourceUsageData
+		if runCommandResult != nil && runCommandResult.ResourceUsage != nil {
+			usage := runCommandResult.ResourceUsage
+			telemetry.RecordResourceUsage(ctx, execution.Job.Spec.Engine, *usage)
+			resultsUsage.Disk = usage.DiskWritten
+		}
+
+		if privacySpec := execution.Job.Spec.DifferentialPrivacy; privacySpec != nil {
+			// only the noisy declared outputs are verified, published and reported to the requester
+			err = privacy.Release(resultFolder, privacySpec)
+			if err != nil {
+				err = fmt.Errorf("failed to release differentially private results: %w", err)
+				return
+			}
+			runCommandResult = privacy.RedactRunOutput(runCommandResult)
+		}
+
+		if e.outputInspector != nil {
+			err = e.inspectOutputs(ctx, execution, resultFolder)
+			if err != nil {
+				return
+			}
+		}
+
+		e.resultsUsage.Put(execution.ID, resultsUsage)
 	}
 
 	proposal, err := jobVerifier.GetProposal(ctx, execution.Job, resultFolder)
@@ -122,9 +174,11 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 	}
 
 	err = e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
-		ExecutionID:   execution.ID,
-		ExpectedState: store.ExecutionStateRunning,
-		NewState:      store.ExecutionStateWaitingVerification,
+		ExecutionID:      execution.ID,
+		ExpectedState:    store.ExecutionStateRunning,
+		NewState:         store.ExecutionStateWaitingVerification,
+		ResultProposal:   proposal,
+		RunCommandResult: runCommandResult,
 	})
 	if err != nil {
 		return
@@ -142,6 +196,47 @@ func (e *BaseExecutor) Run(ctx context.Context, execution store.Execution) (err
 	return err
 }
 
+// requeue moves an execution that was preempted while running back to the accepted state, so that it runs again from
+// scratch, and returns the preemption error. It returns another error if the execution can't run again.
+func (e *BaseExecutor) requeue(ctx context.Context, execution store.Execution, resultFolder string, preempted ErrExecutionPreempted) error {
+	log.Ctx(ctx).Info().Msg(preempted.Error())
+	e.resultsUsage.Delete(execution.ID)
+	if resultFolder != "" {
+		if err := os.RemoveAll(resultFolder); err != nil {
+			return fmt.Errorf("failed to remove partial results of preempted execution: %w", err)
+		}
+	}
+	// use a fresh context as the execution context was cancelled
+	err := e.store.UpdateExecutionState(context.Background(), store.UpdateExecutionStateRequest{
+		ExecutionID:   execution.ID,
+		ExpectedState: store.ExecutionStateRunning,
+		NewState:      store.ExecutionStateBidAccepted,
+		Comment:       preempted.Error(),
+	})
+	if err != nil {
+		return err
+	}
+	return preempted
+}
+
+// inspectOutputs checks that the output policy of the node allows the results of the execution to be proposed and
+// published.
+func (e *BaseExecutor) inspectOutputs(ctx context.Context, execution store.Execution, resultFolder string) error {
+	response, err := e.outputInspector.Inspect(ctx, outputs.InspectionRequest{
+		NodeID:      e.ID,
+		ExecutionID: execution.ID,
+		Job:         execution.Job,
+		ResultPath:  resultFolder,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to inspect outputs: %w", err)
+	}
+	if !response.Approved {
+		return outputs.NewErrOutputsRejected(execution.ID, response.Reason)
+	}
+	return nil
+}
+
 // Publish the result of an execution after it has been verified.
 func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (err error) {
 	defer func() {
@@ -185,9 +280,10 @@ func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (
 		Msg("Execution published")
 
 	err = e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
-		ExecutionID:   execution.ID,
-		ExpectedState: store.ExecutionStatePublishing,
-		NewState:      store.ExecutionStateCompleted,
+		ExecutionID:     execution.ID,
+		ExpectedState:   store.ExecutionStatePublishing,
+		NewState:        store.ExecutionStateCompleted,
+		PublishedResult: publishedResult,
 	})
 	if err != nil {
 		return
@@ -198,6 +294,7 @@ func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msgf("failed to remove results folder at %s", resultFolder)
 	}
+	e.resultsUsage.Delete(execution.ID)
 
 	e.callback.OnPublishComplete(ctx, PublishResult{
 		ExecutionMetadata: NewExecutionMetadata(execution),
@@ -210,6 +307,13 @@ func (e *BaseExecutor) Publish(ctx context.Context, execution store.Execution) (
 	return err
 }
 
+// GetResultsUsage returns the disk used by the outputs of the execution, from the time it ran until its results are
+// published or it fails.
+func (e *BaseExecutor) GetResultsUsage(ctx context.Context, executionID string) model.ResourceUsageData {
+	usage, _ := e.resultsUsage.Get(executionID)
+	return usage
+}
+
 // Cancel the execution.
 func (e *BaseExecutor) Cancel(ctx context.Context, execution store.Execution) (err error) {
 	defer func() {
@@ -236,6 +340,7 @@ func (e *BaseExecutor) Cancel(ctx context.Context, execution store.Execution) (e
 
 func (e *BaseExecutor) handleFailure(ctx context.Context, execution store.Execution, err error, operation string) {
 	log.Ctx(ctx).Error().Err(err).Msgf("%s execution %s failed", operation, execution.ID)
+	e.resultsUsage.Delete(execution.ID)
 	updateError := e.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
 		ExecutionID: execution.ID,
 		NewState:    store.ExecutionStateFailed,
@@ -258,3 +363,4 @@ func (e *BaseExecutor) handleFailure(ctx context.Context, execution store.Execut
 
 // compile-time interface check
 var _ Executor = (*BaseExecutor)(nil)
+var _ ResultsUsageProvider = (*BaseExecutor)(nil)
@@ -2,19 +2,31 @@ package compute
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	sync "github.com/bacalhau-project/golang-mutex-tracer"
+	"github.com/rs/zerolog/log"
 )
 
 type bufferTask struct {
 	execution  store.Execution
 	enqueuedAt time.Time
+	startedAt  time.Time
+	// reserving is set when the task waited for longer than the max enqueued age, or preempted running executions,
+	// and executions ordered after it wait until it runs
+	reserving bool
+	// preemptedBy is the ID of the execution the running task is being stopped for
+	preemptedBy string
+	preemptions int
+	cancel      context.CancelCauseFunc
 }
 
 func newBufferTask(execution store.Execution) *bufferTask {
@@ -24,34 +36,87 @@ func newBufferTask(execution store.Execution) *bufferTask {
 	}
 }
 
+func (t *bufferTask) enqueuedExecution() EnqueuedExecution {
+	return EnqueuedExecution{Execution: t.execution, EnqueuedAt: t.enqueuedAt}
+}
+
 type ExecutorBufferParams struct {
 	ID                         string
 	DelegateExecutor           Executor
 	Callback                   Callback
 	RunningCapacityTracker     capacity.Tracker
 	EnqueuedCapacityTracker    capacity.Tracker
+	ResultsUsage               ResultsUsageProvider
 	DefaultJobExecutionTimeout time.Duration
 	BackoffDuration            time.Duration
+	// OrderingPolicy orders the enqueued executions. Defaults to FIFO.
+	OrderingPolicy OrderingPolicy
+	// MaxEnqueuedAge is how long an execution can wait before it reserves the capacity being freed, and executions
+	// ordered after it wait until it runs. Executions never reserve capacity if zero.
+	MaxEnqueuedAge time.Duration
+	// Preemption enables stopping running executions of jobs with a lower priority to run an execution, in which
+	// case they are enqueued again.
+	Preemption bool
+}
+
+// EnqueuedExecutionInfo describes an execution waiting in the buffer, as reported by the /debug endpoint
+type EnqueuedExecutionInfo struct {
+	store.ExecutionSummary
+	Priority    int       `json:"Priority"`
+	Position    int       `json:"Position"`
+	EnqueuedAt  time.Time `json:"EnqueuedAt"`
+	Reserving   bool      `json:"Reserving"`
+	Preemptions int       `json:"Preemptions,omitempty"`
+}
+
+// RunningExecutionInfo describes an execution running in the buffer, as reported by the /debug endpoint
+type RunningExecutionInfo struct {
+	store.ExecutionSummary
+	Priority    int       `json:"Priority"`
+	EnqueuedAt  time.Time `json:"EnqueuedAt"`
+	StartedAt   time.Time `json:"StartedAt"`
+	PreemptedBy string    `json:"PreemptedBy,omitempty"`
+	Preemptions int       `json:"Preemptions,omitempty"`
+}
+
+// ExecutorBufferInfo describes the state of the buffer, as reported by the /debug endpoint
+type ExecutorBufferInfo struct {
+	OrderingPolicy string                  `json:"OrderingPolicy"`
+	MaxEnqueuedAge time.Duration           `json:"MaxEnqueuedAge"`
+	Preemption     bool                    `json:"Preemption"`
+	Running        []RunningExecutionInfo  `json:"Running"`
+	Enqueued       []EnqueuedExecutionInfo `json:"Enqueued"`
 }
 
 // ExecutorBuffer is a backend.Executor implementation that buffers executions locally until enough capacity is
 // available to be able to run them. The buffer accepts a delegate backend.Executor that will be used to run the jobs.
-// The buffer is implemented as a FIFO queue, where the order of the executions is determined by the order in which
-// they were enqueued. However, an execution with high resource usage requirements might be skipped if there are newer
-// jobs with lower resource usage requirements that can be executed immediately. This is done to improve utilization
-// of compute nodes, though it might result in starvation and should be re-evaluated in the future.
+// The enqueued executions are considered in the order of the ordering policy, and an execution with high resource
+// usage requirements is skipped if executions after it with lower requirements can be executed immediately. To avoid
+// starving the skipped executions, an execution that waited for longer than the max enqueued age reserves the
+// capacity being freed, and the executions after it wait until it runs.
+//
+// With preemption enabled, running executions of jobs with a lower priority are stopped when that frees enough
+// capacity to run an execution that doesn't fit, and are enqueued again with their original enqueue time.
+//
+// Once an execution is done, the capacity reserved for it is released, except for the disk used by its results which is
+// held until they are published.
 type ExecutorBuffer struct {
 	ID                         string
 	runningCapacity            capacity.Tracker
 	enqueuedCapacity           capacity.Tracker
+	resultsUsage               ResultsUsageProvider
 	delegateService            Executor
 	callback                   Callback
 	running                    map[string]*bufferTask
 	enqueued                   map[string]*bufferTask
 	enqueuedList               []string
+	results                    map[string]model.ResourceUsageData
 	defaultJobExecutionTimeout time.Duration
 	backoffDuration            time.Duration
 	backoffUntil               time.Time
+	orderingPolicy             OrderingPolicy
+	maxEnqueuedAge             time.Duration
+	preemption                 bool
 	mu                         sync.Mutex
 }
 
@@ -60,13 +125,21 @@ func NewExecutorBuffer(params ExecutorBufferParams) *ExecutorBuffer {
 		ID:                         params.ID,
 		runningCapacity:            params.RunningCapacityTracker,
 		enqueuedCapacity:           params.EnqueuedCapacityTracker,
+		resultsUsage:               params.ResultsUsage,
 		delegateService:            params.DelegateExecutor,
 		callback:                   params.Callback,
 		running:                    make(map[string]*bufferTask),
 		enqueued:                   make(map[string]*bufferTask),
 		enqueuedList:               make([]string, 0),
+		results:                    make(map[string]model.ResourceUsageData),
 		defaultJobExecutionTimeout: params.DefaultJobExecutionTimeout,
 		backoffDuration:            params.BackoffDuration,
+		orderingPolicy:             params.OrderingPolicy,
+		maxEnqueuedAge:             params.MaxEnqueuedAge,
+		preemption:                 params.Preemption,
+	}
+	if r.orderingPolicy == nil {
+		r.orderingPolicy = FIFOOrdering{}
 	}
 
 	r.mu.EnableTracerWithOpts(sync.Opts{
@@ -134,13 +207,22 @@ func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	ch := make(chan error)
+	ch := make(chan error, 1)
 	go func() {
 		ch <- s.delegateService.Run(ctx, task.execution)
 	}()
 
+	returned := false
+	var runErr error
 	select {
 	case <-ctx.Done():
+		var preempted ErrExecutionPreempted
+		if errors.As(context.Cause(ctx), &preempted) {
+			// wait for the delegate to stop the execution, unless it completed in the meantime
+			runErr = <-ch
+			returned = true
+			break
+		}
 		s.callback.OnComputeFailure(ctx, ComputeError{
 			ExecutionMetadata: NewExecutionMetadata(task.execution),
 			RoutingMetadata: RoutingMetadata{
@@ -149,19 +231,77 @@ func (s *ExecutorBuffer) doRun(ctx context.Context, task *bufferTask) {
 			},
 			Err: fmt.Sprintf("execution timed out after %s", timeout),
 		})
-	case <-ch:
-		// no need to check for run errors as they are already handled by the delegate backend.Executor and
-		// to the callback.
+	case runErr = <-ch:
+		returned = true
 	}
+	// no need to check for other run errors as they are already handled by the delegate backend.Executor and
+	// to the callback.
+	var preempted ErrExecutionPreempted
+	requeue := returned && errors.As(runErr, &preempted)
+	completed := returned && !requeue
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.runningCapacity.Remove(ctx, task.execution.ResourceUsage)
+	if completed {
+		s.holdResults(ctx, task.execution)
+	}
+	_, stillRunning := s.running[task.execution.ID]
 	delete(s.running, task.execution.ID)
+	task.cancel(nil)
+	if task.preemptedBy != "" {
+		if requeue && stillRunning {
+			s.requeue(task)
+		} else {
+			// the execution completed or was cancelled before it was stopped, and is not enqueued again
+			s.enqueuedCapacity.Remove(ctx, task.execution.ResourceUsage)
+		}
+	}
 	s.deque()
 }
 
-// deque tries to run the next execution in the queue if there is enough capacity.
+// requeue enqueues a preempted task again, keeping its original enqueue time. The enqueued capacity was reserved for
+// it when it was preempted. It is called with the lock held.
+func (s *ExecutorBuffer) requeue(task *bufferTask) {
+	log.Info().Msgf("execution %s enqueued again after it was preempted by execution %s",
+		task.execution.ID, task.preemptedBy)
+	task.preemptedBy = ""
+	task.preemptions++
+	task.startedAt = time.Time{}
+	task.cancel = nil
+	s.enqueued[task.execution.ID] = task
+	s.enqueuedList = append(s.enqueuedList, task.execution.ID)
+}
+
+// holdResults keeps the disk used by the results of the execution in the running capacity until they are published,
+// in place of the disk that was reserved for running it. It is called with the lock held.
+func (s *ExecutorBuffer) holdResults(ctx context.Context, execution store.Execution) {
+	if s.resultsUsage == nil {
+		return
+	}
+	usage := s.resultsUsage.GetResultsUsage(ctx, execution.ID)
+	if usage.IsZero() {
+		return
+	}
+	if !s.runningCapacity.AddIfHasCapacity(ctx, usage) {
+		log.Ctx(ctx).Warn().Msgf("not enough capacity to hold results of execution %s using %s", execution.ID, usage)
+		return
+	}
+	s.results[execution.ID] = usage
+}
+
+// releaseResults frees the capacity held by the results of the execution.
+func (s *ExecutorBuffer) releaseResults(ctx context.Context, executionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if usage, ok := s.results[executionID]; ok {
+		s.runningCapacity.Remove(ctx, usage)
+		delete(s.results, executionID)
+		s.deque()
+	}
+}
+
+// deque tries to run the next executions in the queue if there is enough capacity.
 // It is called every time a job is finished or enqueued, where a lock is already held.
 func (s *ExecutorBuffer) deque() {
 	// If last attempt was very recent, and we still have jobs running,
@@ -170,28 +310,115 @@ func (s *ExecutorBuffer) deque() {
 		return
 	}
 	ctx := context.Background()
+	now := time.Now()
+	s.sortEnqueued(now)
 
-	// We are maintain the order of enqueued executions treat it as a FIFO queue, while allowing to skip over jobs
-	// that require more resources than the current capacity. This is done to improve utilization of compute nodes,
-	// though it might result in starvation and should be re-evaluated in the future.
+	// Executions that don't fit in the available capacity are skipped in favour of the next ones to improve the
+	// utilization of the node, unless they reserve the capacity being freed for them.
 	remainingEnqueuedList := make([]string, 0, len(s.enqueuedList))
-
+	blocked := false
 	for _, executionID := range s.enqueuedList {
 		task := s.enqueued[executionID]
 
-		if s.runningCapacity.AddIfHasCapacity(ctx, task.execution.ResourceUsage) {
+		if !blocked && s.runningCapacity.AddIfHasCapacity(ctx, task.execution.ResourceUsage) {
 			s.enqueuedCapacity.Remove(ctx, task.execution.ResourceUsage)
 			delete(s.enqueued, executionID)
+			task.reserving = false
+			task.startedAt = now
 			s.running[executionID] = task
-			go s.doRun(logger.ContextWithNodeIDLogger(context.Background(), s.ID), task)
-		} else {
-			remainingEnqueuedList = append(remainingEnqueuedList, executionID)
+			runCtx, cancel := context.WithCancelCause(logger.ContextWithNodeIDLogger(context.Background(), s.ID))
+			task.cancel = cancel
+			go s.doRun(runCtx, task)
+			continue
+		}
+
+		remainingEnqueuedList = append(remainingEnqueuedList, executionID)
+		if !blocked && (task.reserving || s.isStarving(task, now) || s.preempt(ctx, task)) {
+			if !task.reserving {
+				log.Ctx(ctx).Debug().Msgf("execution %s reserves capacity after waiting %s", executionID, now.Sub(task.enqueuedAt))
+			}
+			task.reserving = true
+			blocked = true
 		}
 	}
 	s.enqueuedList = remainingEnqueuedList
 	s.backoffUntil = time.Now().Add(s.backoffDuration)
 }
 
+// sortEnqueued orders the enqueued executions by the ordering policy, after the executions that reserve capacity or
+// waited for longer than the max enqueued age, which are ordered by enqueue time. It is called with the lock held.
+func (s *ExecutorBuffer) sortEnqueued(now time.Time) {
+	sort.SliceStable(s.enqueuedList, func(i, j int) bool {
+		a, b := s.enqueued[s.enqueuedList[i]], s.enqueued[s.enqueuedList[j]]
+		urgentA, urgentB := a.reserving || s.isStarving(a, now), b.reserving || s.isStarving(b, now)
+		if urgentA != urgentB {
+			return urgentA
+		}
+		if urgentA {
+			return a.enqueuedAt.Before(b.enqueuedAt)
+		}
+		return s.orderingPolicy.Less(a.enqueuedExecution(), b.enqueuedExecution(), now)
+	})
+}
+
+func (s *ExecutorBuffer) isStarving(task *bufferTask, now time.Time) bool {
+	return s.maxEnqueuedAge > 0 && now.Sub(task.enqueuedAt) >= s.maxEnqueuedAge
+}
+
+// preempt stops running executions of jobs with a lower priority than the task, if that frees enough capacity to run
+// it, and returns whether it did. The preempted executions are enqueued again once they stopped, and the enqueued
+// capacity is reserved for them straight away. It is called with the lock held.
+func (s *ExecutorBuffer) preempt(ctx context.Context, task *bufferTask) bool {
+	if !s.preemption {
+		return false
+	}
+	priority := task.execution.Job.Spec.Priority
+	candidates := make([]*bufferTask, 0, len(s.running))
+	for _, running := range s.running {
+		if running.preemptedBy == "" && running.cancel != nil && running.execution.Job.Spec.Priority < priority {
+			candidates = append(candidates, running)
+		}
+	}
+	// stop the executions of the lowest priority first, and the ones that started most recently as they lose the least
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.execution.Job.Spec.Priority != b.execution.Job.Spec.Priority {
+			return a.execution.Job.Spec.Priority < b.execution.Job.Spec.Priority
+		}
+		return a.startedAt.After(b.startedAt)
+	})
+
+	required := task.execution.ResourceUsage
+	available := s.runningCapacity.GetAvailableCapacity(ctx)
+	var victims []*bufferTask
+	for _, candidate := range candidates {
+		if required.LessThanEq(available) {
+			break
+		}
+		available = available.Add(candidate.execution.ResourceUsage)
+		victims = append(victims, candidate)
+	}
+	if len(victims) == 0 || !required.LessThanEq(available) {
+		return false
+	}
+
+	for i, victim := range victims {
+		if !s.enqueuedCapacity.AddIfHasCapacity(ctx, victim.execution.ResourceUsage) {
+			for _, reserved := range victims[:i] {
+				s.enqueuedCapacity.Remove(ctx, reserved.execution.ResourceUsage)
+			}
+			return false
+		}
+	}
+	for _, victim := range victims {
+		log.Ctx(ctx).Info().Msgf("preempting execution %s of priority %d to run execution %s of priority %d",
+			victim.execution.ID, victim.execution.Job.Spec.Priority, task.execution.ID, priority)
+		victim.preemptedBy = task.execution.ID
+		victim.cancel(NewErrExecutionPreempted(victim.execution.ID, task.execution.ID))
+	}
+	return true
+}
+
 func (s *ExecutorBuffer) Publish(_ context.Context, execution store.Execution) error {
 	// TODO: Enqueue publish tasks
 	go func() {
@@ -201,6 +428,8 @@ func (s *ExecutorBuffer) Publish(_ context.Context, execution store.Execution) e
 		ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/compute.ExecutorBuffer.Publish")
 		defer span.End()
 		_ = s.delegateService.Publish(ctx, execution)
+		// the results are removed once published, and are not published again if publishing failed
+		s.releaseResults(ctx, execution.ID)
 	}()
 	return nil
 }
@@ -216,6 +445,8 @@ func (s *ExecutorBuffer) Cancel(_ context.Context, execution store.Execution) er
 
 		err := s.delegateService.Cancel(ctx, execution)
 		if err == nil {
+			s.releaseResults(ctx, execution.ID)
+
 			s.mu.Lock()
 			defer s.mu.Unlock()
 
@@ -235,6 +466,44 @@ func (s *ExecutorBuffer) EnqueuedExecutions() []store.Execution {
 	return s.mapValues(s.enqueued)
 }
 
+// Info describes the running executions, and the enqueued executions in the order they are considered to run
+func (s *ExecutorBuffer) Info() ExecutorBufferInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sortEnqueued(time.Now())
+
+	info := ExecutorBufferInfo{
+		OrderingPolicy: s.orderingPolicy.String(),
+		MaxEnqueuedAge: s.maxEnqueuedAge,
+		Preemption:     s.preemption,
+		Running:        make([]RunningExecutionInfo, 0, len(s.running)),
+		Enqueued:       make([]EnqueuedExecutionInfo, 0, len(s.enqueuedList)),
+	}
+	for _, task := range s.running {
+		info.Running = append(info.Running, RunningExecutionInfo{
+			ExecutionSummary: store.NewExecutionSummary(task.execution),
+			Priority:         task.execution.Job.Spec.Priority,
+			EnqueuedAt:       task.enqueuedAt,
+			StartedAt:        task.startedAt,
+			PreemptedBy:      task.preemptedBy,
+			Preemptions:      task.preemptions,
+		})
+	}
+	sort.Slice(info.Running, func(i, j int) bool { return info.Running[i].StartedAt.Before(info.Running[j].StartedAt) })
+	for i, executionID := range s.enqueuedList {
+		task := s.enqueued[executionID]
+		info.Enqueued = append(info.Enqueued, EnqueuedExecutionInfo{
+			ExecutionSummary: store.NewExecutionSummary(task.execution),
+			Priority:         task.execution.Job.Spec.Priority,
+			Position:         i + 1,
+			EnqueuedAt:       task.enqueuedAt,
+			Reserving:        task.reserving,
+			Preemptions:      task.preemptions,
+		})
+	}
+	return info
+}
+
 func (s *ExecutorBuffer) mapValues(m map[string]*bufferTask) []store.Execution {
 	s.mu.Lock()
 	defer s.mu.Unlock()
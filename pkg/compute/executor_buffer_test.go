@@ -0,0 +1,250 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+// resultsExecutor completes executions immediately, leaving results of a fixed size
+type resultsExecutor struct {
+	published chan string
+	diskUsed  uint64
+}
+
+func (e *resultsExecutor) Run(ctx context.Context, execution store.Execution) error {
+	return nil
+}
+
+func (e *resultsExecutor) Publish(ctx context.Context, execution store.Execution) error {
+	e.published <- execution.ID
+	return nil
+}
+
+func (e *resultsExecutor) Cancel(ctx context.Context, execution store.Execution) error {
+	return nil
+}
+
+func (e *resultsExecutor) GetResultsUsage(ctx context.Context, executionID string) model.ResourceUsageData {
+	return model.ResourceUsageData{Disk: e.diskUsed}
+}
+
+func TestExecutorBufferHoldsResultsDisk(t *testing.T) {
+	ctx := context.Background()
+	delegate := &resultsExecutor{published: make(chan string, 1), diskUsed: 30}
+	runningCapacity := capacity.NewLocalTracker(capacity.LocalTrackerParams{
+		MaxCapacity: model.ResourceUsageData{CPU: 1, Disk: 100},
+	})
+	buffer := NewExecutorBuffer(ExecutorBufferParams{
+		ID:                     "node",
+		DelegateExecutor:       delegate,
+		Callback:               CallbackMock{},
+		RunningCapacityTracker: runningCapacity,
+		EnqueuedCapacityTracker: capacity.NewLocalTracker(capacity.LocalTrackerParams{
+			MaxCapacity: model.ResourceUsageData{CPU: 1, Disk: 100},
+		}),
+		ResultsUsage:               delegate,
+		DefaultJobExecutionTimeout: time.Minute,
+	})
+
+	execution := *store.NewExecution("execution", model.Job{}, "requester", model.ResourceUsageData{CPU: 1, Disk: 50})
+	require.NoError(t, buffer.Run(ctx, execution))
+
+	// the reservation for running is replaced by the disk used by the results
+	require.Eventually(t, func() bool {
+		return runningCapacity.GetAvailableCapacity(ctx) == model.ResourceUsageData{CPU: 1, Disk: 70}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, buffer.Publish(ctx, execution))
+	require.Equal(t, execution.ID, <-delegate.published)
+	require.Eventually(t, func() bool {
+		return runningCapacity.GetAvailableCapacity(ctx) == model.ResourceUsageData{CPU: 1, Disk: 100}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// blockingExecutor runs executions until they are released or stopped, like BaseExecutor when they are preempted
+type blockingExecutor struct {
+	started  chan string
+	releases map[string]chan struct{}
+	mu       sync.Mutex
+}
+
+func newBlockingExecutor() *blockingExecutor {
+	return &blockingExecutor{started: make(chan string, 10), releases: make(map[string]chan struct{})}
+}
+
+func (e *blockingExecutor) releaseChan(executionID string) chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.releases[executionID]; !ok {
+		e.releases[executionID] = make(chan struct{})
+	}
+	return e.releases[executionID]
+}
+
+func (e *blockingExecutor) release(executionID string) {
+	close(e.releaseChan(executionID))
+}
+
+func (e *blockingExecutor) Run(ctx context.Context, execution store.Execution) error {
+	e.started <- execution.ID
+	select {
+	case <-e.releaseChan(execution.ID):
+		return nil
+	case <-ctx.Done():
+		var preempted ErrExecutionPreempted
+		if errors.As(context.Cause(ctx), &preempted) {
+			return preempted
+		}
+		return ctx.Err()
+	}
+}
+
+func (e *blockingExecutor) Publish(ctx context.Context, execution store.Execution) error {
+	return nil
+}
+
+func (e *blockingExecutor) Cancel(ctx context.Context, execution store.Execution) error {
+	return nil
+}
+
+func (e *blockingExecutor) requireStarted(t *testing.T, executionID string) {
+	select {
+	case started := <-e.started:
+		require.Equal(t, executionID, started)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "execution was not started", executionID)
+	}
+}
+
+func (e *blockingExecutor) requireNotStarted(t *testing.T) {
+	select {
+	case started := <-e.started:
+		require.FailNow(t, "execution was started", started)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func newTestBuffer(delegate Executor, maxCPU float64, params ExecutorBufferParams) *ExecutorBuffer {
+	params.ID = "node"
+	params.DelegateExecutor = delegate
+	params.Callback = CallbackMock{}
+	params.RunningCapacityTracker = capacity.NewLocalTracker(capacity.LocalTrackerParams{
+		MaxCapacity: model.ResourceUsageData{CPU: maxCPU},
+	})
+	params.EnqueuedCapacityTracker = capacity.NewLocalTracker(capacity.LocalTrackerParams{
+		MaxCapacity: model.ResourceUsageData{CPU: 10},
+	})
+	params.DefaultJobExecutionTimeout = time.Minute
+	return NewExecutorBuffer(params)
+}
+
+func newTestExecution(id string, priority int, cpu float64) store.Execution {
+	job := model.Job{Spec: model.Spec{Priority: priority}}
+	return *store.NewExecution(id, job, "requester", model.ResourceUsageData{CPU: cpu})
+}
+
+func enqueuedIDs(buffer *ExecutorBuffer) []string {
+	var ids []string
+	for _, info := range buffer.Info().Enqueued {
+		ids = append(ids, info.ExecutionID)
+	}
+	return ids
+}
+
+func TestExecutorBufferOrdersByPriority(t *testing.T) {
+	ctx := context.Background()
+	delegate := newBlockingExecutor()
+	buffer := newTestBuffer(delegate, 1, ExecutorBufferParams{OrderingPolicy: PriorityOrdering{}})
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution("running", 0, 1)))
+	delegate.requireStarted(t, "running")
+	require.NoError(t, buffer.Run(ctx, newTestExecution("low", 0, 1)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution("high", 5, 1)))
+	require.Equal(t, []string{"high", "low"}, enqueuedIDs(buffer))
+	require.Equal(t, "priority", buffer.Info().OrderingPolicy)
+
+	delegate.release("running")
+	delegate.requireStarted(t, "high")
+	delegate.release("high")
+	delegate.requireStarted(t, "low")
+}
+
+func TestExecutorBufferReservesCapacityForOldExecutions(t *testing.T) {
+	ctx := context.Background()
+	delegate := newBlockingExecutor()
+	buffer := newTestBuffer(delegate, 2, ExecutorBufferParams{MaxEnqueuedAge: 50 * time.Millisecond})
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution("running", 0, 1)))
+	delegate.requireStarted(t, "running")
+	require.NoError(t, buffer.Run(ctx, newTestExecution("large", 0, 2)))
+	time.Sleep(100 * time.Millisecond)
+
+	// the small execution fits, but the large one waited for too long and reserves the capacity
+	require.NoError(t, buffer.Run(ctx, newTestExecution("small", 0, 1)))
+	delegate.requireNotStarted(t)
+	enqueued := buffer.Info().Enqueued
+	require.Len(t, enqueued, 2)
+	require.Equal(t, "large", enqueued[0].ExecutionID)
+	require.True(t, enqueued[0].Reserving)
+
+	delegate.release("running")
+	delegate.requireStarted(t, "large")
+	delegate.release("large")
+	delegate.requireStarted(t, "small")
+}
+
+func TestExecutorBufferSkipsLargeExecutionsWithoutMaxEnqueuedAge(t *testing.T) {
+	ctx := context.Background()
+	delegate := newBlockingExecutor()
+	buffer := newTestBuffer(delegate, 2, ExecutorBufferParams{})
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution("running", 0, 1)))
+	delegate.requireStarted(t, "running")
+	require.NoError(t, buffer.Run(ctx, newTestExecution("large", 0, 2)))
+	require.NoError(t, buffer.Run(ctx, newTestExecution("small", 0, 1)))
+	delegate.requireStarted(t, "small")
+	require.Equal(t, []string{"large"}, enqueuedIDs(buffer))
+}
+
+func TestExecutorBufferPreemptsLowerPriorityExecutions(t *testing.T) {
+	ctx := context.Background()
+	delegate := newBlockingExecutor()
+	buffer := newTestBuffer(delegate, 1, ExecutorBufferParams{OrderingPolicy: PriorityOrdering{}, Preemption: true})
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution("low", 0, 1)))
+	delegate.requireStarted(t, "low")
+	require.NoError(t, buffer.Run(ctx, newTestExecution("high", 5, 1)))
+	delegate.requireStarted(t, "high")
+
+	// the preempted execution waits to run again
+	require.Eventually(t, func() bool {
+		enqueued := buffer.Info().Enqueued
+		return len(enqueued) == 1 && enqueued[0].ExecutionID == "low" && enqueued[0].Preemptions == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	delegate.release("high")
+	delegate.requireStarted(t, "low")
+}
+
+func TestExecutorBufferDoesNotPreemptWithoutPreemption(t *testing.T) {
+	ctx := context.Background()
+	delegate := newBlockingExecutor()
+	buffer := newTestBuffer(delegate, 1, ExecutorBufferParams{OrderingPolicy: PriorityOrdering{}})
+
+	require.NoError(t, buffer.Run(ctx, newTestExecution("low", 0, 1)))
+	delegate.requireStarted(t, "low")
+	require.NoError(t, buffer.Run(ctx, newTestExecution("high", 5, 1)))
+	delegate.requireNotStarted(t)
+	require.Equal(t, []string{"high"}, enqueuedIDs(buffer))
+	require.Empty(t, buffer.Info().Running[0].PreemptedBy)
+}
@@ -0,0 +1,89 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/wasm"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	noop_publisher "github.com/bacalhau-project/bacalhau/pkg/publisher/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	noop_verifier "github.com/bacalhau-project/bacalhau/pkg/verifier/noop"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+)
+
+// infiniteLoop is a WASM module exporting a _start function that never returns
+var infiniteLoop = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic and version
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type section: () -> ()
+	0x03, 0x02, 0x01, 0x00, // function section
+	0x07, 0x0a, 0x01, 0x06, '_', 's', 't', 'a', 'r', 't', 0x00, 0x00, // export section: "_start"
+	0x0a, 0x09, 0x01, 0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b, // code section: loop br 0 end
+}
+
+// TestBaseExecutorRequeuesPreemptedWasmExecution checks that a WASM execution is requeued when it is preempted, even
+// though the WASM executor returns the partial results of modules closed by their context without an error.
+func TestBaseExecutorRequeuesPreemptedWasmExecution(t *testing.T) {
+	ctx := context.Background()
+	storages := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceInline: inline.NewStorage(),
+	})
+	wasmExecutor, err := wasm.NewExecutor(ctx, storages, nil, nil)
+	require.NoError(t, err)
+	noopVerifier, err := noop_verifier.NewNoopVerifier(ctx, system.NewCleanupManager())
+	require.NoError(t, err)
+
+	executionStore := inmemory.NewStore()
+	runCompleted := make(chan RunResult, 1)
+	baseExecutor := NewBaseExecutor(BaseExecutorParams{
+		ID:    "node",
+		Store: executionStore,
+		Callback: CallbackMock{
+			OnRunCompleteHandler: func(ctx context.Context, result RunResult) { runCompleted <- result },
+		},
+		Executors: model.NewMappedProvider(map[model.Engine]executor.Executor{model.EngineWasm: wasmExecutor}),
+		Verifiers: model.NewMappedProvider(map[model.Verifier]verifier.Verifier{model.VerifierNoop: noopVerifier}),
+		Publishers: model.NewMappedProvider(map[model.Publisher]publisher.Publisher{
+			model.PublisherNoop: noop_publisher.NewNoopPublisher(),
+		}),
+	})
+
+	job := model.Job{
+		Metadata: model.Metadata{ID: "preempted"},
+		Spec: model.Spec{
+			Engine:   model.EngineWasm,
+			Verifier: model.VerifierNoop,
+			Wasm: model.JobSpecWasm{
+				EntryModule: model.StorageSpec{StorageSource: model.StorageSourceInline, URL: dataurl.EncodeBytes(infiniteLoop)},
+				EntryPoint:  "_start",
+			},
+		},
+	}
+	execution := *store.NewExecution("execution", job, "requester", model.ResourceUsageData{})
+	require.NoError(t, executionStore.CreateExecution(ctx, execution))
+	require.NoError(t, executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: execution.ID,
+		NewState:    store.ExecutionStateBidAccepted,
+	}))
+
+	runCtx, preempt := context.WithCancelCause(ctx)
+	time.AfterFunc(500*time.Millisecond, func() { preempt(NewErrExecutionPreempted(execution.ID, "other")) })
+	err = baseExecutor.Run(runCtx, execution)
+	require.ErrorAs(t, err, &ErrExecutionPreempted{})
+
+	requeued, err := executionStore.GetExecution(ctx, execution.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.ExecutionStateBidAccepted, requeued.State)
+	require.Empty(t, runCompleted)
+}
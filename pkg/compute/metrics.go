@@ -27,4 +27,9 @@ var (
 		"jobs_failed",
 		instrument.WithDescription("Number of jobs failed by the compute node."),
 	)
+
+	requestsRejected, _ = meter.Int64Counter(
+		"requests_rejected",
+		instrument.WithDescription("Number of requests rejected because they were not sent by the requester node of the execution."),
+	)
 )
@@ -17,8 +17,10 @@ type NodeInfoProviderParams struct {
 	Publisher          publisher.PublisherProvider
 	Storages           storage.StorageProvider
 	CapacityTracker    capacity.Tracker
+	GPUAllocator       capacity.GPUAllocator
 	ExecutorBuffer     *ExecutorBuffer
 	MaxJobRequirements model.ResourceUsageData
+	Sandbox            model.SandboxProfile
 }
 
 type NodeInfoProvider struct {
@@ -27,8 +29,10 @@ type NodeInfoProvider struct {
 	publishers         publisher.PublisherProvider
 	storages           storage.StorageProvider
 	capacityTracker    capacity.Tracker
+	gpuAllocator       capacity.GPUAllocator
 	executorBuffer     *ExecutorBuffer
 	maxJobRequirements model.ResourceUsageData
+	sandbox            model.SandboxProfile
 }
 
 func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
@@ -38,13 +42,15 @@ func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
 		publishers:         params.Publisher,
 		storages:           params.Storages,
 		capacityTracker:    params.CapacityTracker,
+		gpuAllocator:       params.GPUAllocator,
 		executorBuffer:     params.ExecutorBuffer,
 		maxJobRequirements: params.MaxJobRequirements,
+		sandbox:            params.Sandbox,
 	}
 }
 
 func (n *NodeInfoProvider) GetComputeInfo(ctx context.Context) model.ComputeNodeInfo {
-	return model.ComputeNodeInfo{
+	info := model.ComputeNodeInfo{
 		ExecutionEngines:   model.InstalledTypes[model.Engine, executor.Executor](ctx, n.executors, model.EngineTypes()),
 		Verifiers:          model.InstalledTypes[model.Verifier, verifier.Verifier](ctx, n.verifiers, model.VerifierTypes()),
 		Publishers:         model.InstalledTypes[model.Publisher, publisher.Publisher](ctx, n.publishers, model.PublisherTypes()),
@@ -55,6 +61,15 @@ func (n *NodeInfoProvider) GetComputeInfo(ctx context.Context) model.ComputeNode
 		RunningExecutions:  len(n.executorBuffer.RunningExecutions()),
 		EnqueuedExecutions: len(n.executorBuffer.EnqueuedExecutions()),
 	}
+	if n.gpuAllocator != nil {
+		info.GPUs = n.gpuAllocator.GetGPUs(ctx)
+		info.AvailableGPUs = n.gpuAllocator.GetAvailableGPUs(ctx)
+	}
+	if !n.sandbox.IsZero() {
+		sandbox := n.sandbox
+		info.Sandbox = &sandbox
+	}
+	return info
 }
 
 // compile-time interface check
@@ -0,0 +1,51 @@
+package outputs
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+)
+
+type ChainedInspector struct {
+	Inspectors []Inspector
+}
+
+func NewChainedInspector(inspectors ...Inspector) *ChainedInspector {
+	return &ChainedInspector{Inspectors: inspectors}
+}
+
+// FromOutputPolicy creates an Inspector that enforces the passed OutputPolicy.
+func FromOutputPolicy(policy model.OutputPolicy) Inspector {
+	return NewChainedInspector(
+		NewLimitsInspector(LimitsInspectorParams{
+			MaxVolumeSize:    policy.MaxVolumeSize,
+			AllowedFileTypes: policy.AllowedFileTypes,
+		}),
+		NewExternalCommandInspector(ExternalCommandInspectorParams{
+			Command: policy.InspectExec,
+		}),
+		NewExternalHTTPInspector(ExternalHTTPInspectorParams{
+			URL: policy.InspectHTTP,
+		}),
+	)
+}
+
+// Inspect asks each inspector in turn, and approves the outputs if all of them approve.
+func (c *ChainedInspector) Inspect(ctx context.Context, request InspectionRequest) (InspectionResponse, error) {
+	for _, inspector := range c.Inspectors {
+		response, err := inspector.Inspect(ctx, request)
+		if err != nil {
+			return InspectionResponse{}, err
+		}
+		if !response.Approved {
+			log.Ctx(ctx).Debug().Msgf("output inspector %s rejected outputs due to: %s",
+				reflect.TypeOf(inspector).String(), response.Reason)
+			return response, nil
+		}
+	}
+	return NewApprovedResponse(), nil
+}
+
+var _ Inspector = (*ChainedInspector)(nil)
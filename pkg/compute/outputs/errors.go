@@ -0,0 +1,17 @@
+package outputs
+
+import "fmt"
+
+// ErrOutputsRejected is returned when the outputs of an execution are not approved by the output policy of the node
+type ErrOutputsRejected struct {
+	ExecutionID string
+	Reason      string
+}
+
+func NewErrOutputsRejected(executionID, reason string) ErrOutputsRejected {
+	return ErrOutputsRejected{ExecutionID: executionID, Reason: reason}
+}
+
+func (e ErrOutputsRejected) Error() string {
+	return fmt.Sprintf("outputs of execution %s rejected: %s", e.ExecutionID, e.Reason)
+}
@@ -0,0 +1,132 @@
+package outputs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/logger"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/rs/zerolog/log"
+)
+
+type ExternalCommandInspectorParams struct {
+	Command string
+}
+
+// ExternalCommandInspector approves outputs if a command exits successfully. The command is given the details of the
+// execution as JSON on its stdin and in the BACALHAU_OUTPUT_INSPECTION_DATA environment variable, and can read the
+// results from the result path.
+type ExternalCommandInspector struct {
+	command string
+}
+
+func NewExternalCommandInspector(params ExternalCommandInspectorParams) *ExternalCommandInspector {
+	return &ExternalCommandInspector{
+		command: params.Command,
+	}
+}
+
+func (i *ExternalCommandInspector) Inspect(ctx context.Context, request InspectionRequest) (InspectionResponse, error) {
+	if i.command == "" {
+		return NewApprovedResponse(), nil
+	}
+
+	jsonData, err := model.JSONMarshalWithMax(getOutputInspectionData(request))
+	if err != nil {
+		return InspectionResponse{}, fmt.Errorf("ExternalCommandInspector: error marshaling output inspection data: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "bash", "-c", i.command) //nolint:gosec
+	cmd.Env = []string{
+		"BACALHAU_OUTPUT_INSPECTION_DATA=" + string(jsonData),
+		"PATH=" + os.Getenv("PATH"),
+	}
+	cmd.Stdin = bytes.NewReader(jsonData)
+	buf := bytes.Buffer{}
+	cmd.Stderr = &buf
+	err = cmd.Run()
+	if err != nil {
+		// we ignore this error because it might be the script exiting 1 on purpose
+		logger.LogStream(ctx, &buf)
+		log.Ctx(ctx).Debug().Err(err).Str("Command", i.command).Msg("We got an error back from an output inspector exec")
+	}
+
+	exitCode := cmd.ProcessState.ExitCode()
+	if exitCode == 0 {
+		return NewApprovedResponse(), nil
+	}
+	return InspectionResponse{
+		Approved: false,
+		Reason:   fmt.Sprintf("command `%s` returned non-zero exit code %d", i.command, exitCode),
+	}, nil
+}
+
+type ExternalHTTPInspectorParams struct {
+	URL string
+}
+
+// ExternalHTTPInspector approves outputs if a HTTP POST of the details of the execution succeeds. If the response is
+// JSON, it is decoded as an InspectionResponse that decides whether the outputs are approved.
+type ExternalHTTPInspector struct {
+	url string
+}
+
+func NewExternalHTTPInspector(params ExternalHTTPInspectorParams) *ExternalHTTPInspector {
+	return &ExternalHTTPInspector{
+		url: params.URL,
+	}
+}
+
+func (i *ExternalHTTPInspector) Inspect(ctx context.Context, request InspectionRequest) (InspectionResponse, error) {
+	if i.url == "" {
+		return NewApprovedResponse(), nil
+	}
+
+	jsonData, err := model.JSONMarshalWithMax(getOutputInspectionData(request))
+	if err != nil {
+		return InspectionResponse{}, fmt.Errorf("ExternalHTTPInspector: error marshaling output inspection data: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url, bytes.NewReader(jsonData))
+	if err != nil {
+		return InspectionResponse{}, fmt.Errorf("ExternalHTTPInspector: error creating request to %s: %w", i.url, err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req) //nolint:bodyclose
+	if err != nil {
+		return InspectionResponse{},
+			fmt.Errorf("ExternalHTTPInspector: error http POST output inspection data: %s %w", i.url, err)
+	}
+	defer closer.DrainAndCloseWithLogOnError(ctx, i.url, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return InspectionResponse{
+			Approved: false,
+			Reason:   fmt.Sprintf("url `%s` returned %d status code", i.url, resp.StatusCode),
+		}, nil
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return NewApprovedResponse(), nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(model.MaxSerializedStringInput)+1))
+	if err != nil {
+		return InspectionResponse{}, fmt.Errorf("ExternalHTTPInspector: error reading http response: %w", err)
+	}
+	var result InspectionResponse
+	if err = model.JSONUnmarshalWithMax(body, &result); err != nil {
+		return InspectionResponse{}, fmt.Errorf("ExternalHTTPInspector: error unmarshalling http response: %w", err)
+	}
+	return result, nil
+}
+
+var _ Inspector = (*ExternalCommandInspector)(nil)
+var _ Inspector = (*ExternalHTTPInspector)(nil)
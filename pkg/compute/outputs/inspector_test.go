@@ -0,0 +1,206 @@
+//go:build unit || !integration
+
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOutputs(t *testing.T, files map[string]string) InspectionRequest {
+	resultPath := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(resultPath, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return InspectionRequest{
+		ExecutionID: "execution",
+		Job: model.Job{
+			Metadata: model.Metadata{ID: "job"},
+			Spec: model.Spec{
+				Outputs: []model.StorageSpec{{Name: "outputs"}, {Name: "empty"}},
+			},
+		},
+		ResultPath: resultPath,
+	}
+}
+
+func TestLimitsInspector(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		params   LimitsInspectorParams
+		files    map[string]string
+		approved bool
+		reason   string
+	}{
+		{
+			name:     "no limits",
+			files:    map[string]string{"outputs/data.bin": "\x00\x01\x02"},
+			approved: true,
+		},
+		{
+			name:     "within size limit",
+			params:   LimitsInspectorParams{MaxVolumeSize: 10},
+			files:    map[string]string{"outputs/a.txt": "12345", "outputs/nested/b.txt": "12345"},
+			approved: true,
+		},
+		{
+			name:   "exceeds size limit",
+			params: LimitsInspectorParams{MaxVolumeSize: 10},
+			files:  map[string]string{"outputs/a.txt": "12345", "outputs/nested/b.txt": "123456"},
+			reason: "output volume outputs: size exceeds the maximum of 10B",
+		},
+		{
+			name:   "stdout exceeds size limit",
+			params: LimitsInspectorParams{MaxVolumeSize: 10},
+			files:  map[string]string{"stdout": "a long line of text"},
+			reason: "result stdout: size exceeds the maximum of 10B",
+		},
+		{
+			name:   "disallowed stderr type",
+			params: LimitsInspectorParams{AllowedFileTypes: []string{".csv"}},
+			files:  map[string]string{"outputs/result.csv": "a,b", "stderr": "\x00\x01\x02"},
+			reason: "result stderr: file stderr of type application/octet-stream is not allowed",
+		},
+		{
+			name:     "exit code is not inspected",
+			params:   LimitsInspectorParams{AllowedFileTypes: []string{".csv"}},
+			files:    map[string]string{"outputs/result.csv": "a,b", "exitCode": "0"},
+			approved: true,
+		},
+		{
+			name:     "allowed extension",
+			params:   LimitsInspectorParams{AllowedFileTypes: []string{".csv"}},
+			files:    map[string]string{"outputs/result.CSV": "a,b"},
+			approved: true,
+		},
+		{
+			name:   "allowed extension with other content",
+			params: LimitsInspectorParams{AllowedFileTypes: []string{".csv"}},
+			files:  map[string]string{"outputs/result.CSV": "\x00\x01"},
+			reason: "output volume outputs: file result.CSV of type application/octet-stream is not allowed",
+		},
+		{
+			name:     "allowed binary extension",
+			params:   LimitsInspectorParams{AllowedFileTypes: []string{".png"}},
+			files:    map[string]string{"outputs/plot.png": "\x89PNG\r\n\x1a\n"},
+			approved: true,
+		},
+		{
+			name:   "allowed binary extension with other content",
+			params: LimitsInspectorParams{AllowedFileTypes: []string{".png"}},
+			files:  map[string]string{"outputs/plot.png": "GIF89a"},
+			reason: "output volume outputs: file plot.png of type image/gif is not allowed",
+		},
+		{
+			name:     "allowed media type",
+			params:   LimitsInspectorParams{AllowedFileTypes: []string{"text/"}},
+			files:    map[string]string{"outputs/result": "plain text"},
+			approved: true,
+		},
+		{
+			name:   "disallowed file type",
+			params: LimitsInspectorParams{AllowedFileTypes: []string{".csv", "application/json"}},
+			files:  map[string]string{"outputs/dump.bin": "\x00\x01\x02"},
+			reason: "output volume outputs: file dump.bin of type application/octet-stream is not allowed",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			request := writeOutputs(t, test.files)
+			response, err := NewLimitsInspector(test.params).Inspect(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, test.approved, response.Approved)
+			require.Equal(t, test.reason, response.Reason)
+		})
+	}
+}
+
+func TestLimitsInspectorRejectsLinks(t *testing.T) {
+	request := writeOutputs(t, map[string]string{"outputs/a.txt": "text"})
+	require.NoError(t, os.Symlink("/etc/passwd", filepath.Join(request.ResultPath, "outputs", "passwd")))
+
+	response, err := NewLimitsInspector(LimitsInspectorParams{MaxVolumeSize: 1024}).Inspect(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, response.Approved)
+	require.Contains(t, response.Reason, "passwd is not a regular file")
+}
+
+func TestExternalCommandInspector(t *testing.T) {
+	request := writeOutputs(t, map[string]string{"outputs/a.txt": "text"})
+
+	for _, test := range []struct {
+		command  string
+		approved bool
+	}{
+		{"exit 0", true},
+		{"exit 1", false},
+		// the inspector can find the results from the data it is given
+		{`test -f "$(sed -E 's/.*"result_path":"([^"]*)".*/\1/')/outputs/a.txt"`, true},
+	} {
+		t.Run(test.command, func(t *testing.T) {
+			response, err := NewExternalCommandInspector(ExternalCommandInspectorParams{Command: test.command}).
+				Inspect(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, test.approved, response.Approved)
+		})
+	}
+}
+
+func TestExternalHTTPInspector(t *testing.T) {
+	request := writeOutputs(t, map[string]string{"outputs/a.txt": "text"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data OutputInspectionData
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		switch r.URL.Path {
+		case "/approve":
+			w.WriteHeader(http.StatusOK)
+		case "/error":
+			w.WriteHeader(http.StatusForbidden)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(InspectionResponse{Reason: "contains PII from " + data.JobID}))
+		}
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		path     string
+		approved bool
+		reason   string
+	}{
+		{"/approve", true, ""},
+		{"/error", false, "url `" + server.URL + "/error` returned 403 status code"},
+		{"/json", false, "contains PII from job"},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			response, err := NewExternalHTTPInspector(ExternalHTTPInspectorParams{URL: server.URL + test.path}).
+				Inspect(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, test.approved, response.Approved)
+			require.Equal(t, test.reason, response.Reason)
+		})
+	}
+}
+
+func TestFromOutputPolicy(t *testing.T) {
+	request := writeOutputs(t, map[string]string{"outputs/a.txt": "text"})
+
+	response, err := FromOutputPolicy(model.OutputPolicy{}).Inspect(context.Background(), request)
+	require.NoError(t, err)
+	require.True(t, response.Approved)
+
+	response, err = FromOutputPolicy(model.OutputPolicy{AllowedFileTypes: []string{".txt"}, InspectExec: "exit 1"}).
+		Inspect(context.Background(), request)
+	require.NoError(t, err)
+	require.False(t, response.Approved)
+}
@@ -0,0 +1,192 @@
+package outputs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/c2h5oh/datasize"
+	"go.uber.org/multierr"
+)
+
+// number of bytes http.DetectContentType looks at
+const sniffLength = 512
+
+type LimitsInspectorParams struct {
+	// MaxVolumeSize is the maximum total size of the files in each output volume, and of the stdout and stderr files.
+	// No limit if zero.
+	MaxVolumeSize uint64
+	// AllowedFileTypes are the file extensions or media types allowed in the outputs. Any type is allowed if empty.
+	AllowedFileTypes []string
+}
+
+// LimitsInspector rejects outputs whose volumes are too large, or hold files of types that are not allowed. Everything
+// else in the result folder, such as the stdout and stderr of the job, is published as well and is held to the same
+// limits. The type of a file is detected from its content, and is allowed if either it is an allowed media type, or the
+// extension of the file is allowed and its content matches it, so that renaming a file can't get it through.
+type LimitsInspector struct {
+	maxVolumeSize    uint64
+	allowedFileTypes []string
+}
+
+func NewLimitsInspector(params LimitsInspectorParams) *LimitsInspector {
+	return &LimitsInspector{
+		maxVolumeSize:    params.MaxVolumeSize,
+		allowedFileTypes: params.AllowedFileTypes,
+	}
+}
+
+func (i *LimitsInspector) Inspect(ctx context.Context, request InspectionRequest) (InspectionResponse, error) {
+	if i.maxVolumeSize == 0 && len(i.allowedFileTypes) == 0 {
+		return NewApprovedResponse(), nil
+	}
+
+	entries, err := os.ReadDir(request.ResultPath)
+	if err != nil {
+		return InspectionResponse{}, fmt.Errorf("failed to read results: %w", err)
+	}
+	volumes := make(map[string]bool, len(request.Job.Spec.Outputs))
+	for _, output := range request.Job.Spec.Outputs {
+		volumes[output.Name] = true
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == model.DownloadFilenameExitCode && entry.Type().IsRegular() {
+			// written by the executor, and only holds the exit code of the job
+			continue
+		}
+		kind := "result"
+		if volumes[name] {
+			kind = "output volume"
+		}
+		reason, err := i.inspectVolume(filepath.Join(request.ResultPath, name))
+		if err != nil {
+			return InspectionResponse{}, fmt.Errorf("failed to inspect %s %s: %w", kind, name, err)
+		}
+		if reason != "" {
+			return InspectionResponse{Approved: false, Reason: fmt.Sprintf("%s %s: %s", kind, name, reason)}, nil
+		}
+	}
+	return NewApprovedResponse(), nil
+}
+
+// inspectVolume returns why the volume or file at path is rejected, or an empty string if it is approved
+func (i *LimitsInspector) inspectVolume(path string) (reason string, err error) {
+	var totalSize uint64
+	err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			relativePath = filepath.Base(path)
+		}
+		if !entry.Type().IsRegular() {
+			// links could expose data outside of the volume when published
+			reason = fmt.Sprintf("%s is not a regular file", relativePath)
+			return filepath.SkipAll
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		totalSize += uint64(info.Size())
+		if i.maxVolumeSize > 0 && totalSize > i.maxVolumeSize {
+			reason = fmt.Sprintf("size exceeds the maximum of %s", datasize.ByteSize(i.maxVolumeSize))
+			return filepath.SkipAll
+		}
+
+		if len(i.allowedFileTypes) > 0 {
+			allowed, mediaType, err := i.isAllowedFileType(filePath)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				reason = fmt.Sprintf("file %s of type %s is not allowed", relativePath, mediaType)
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return reason, err
+}
+
+func (i *LimitsInspector) isAllowedFileType(path string) (allowed bool, mediaType string, err error) {
+	mediaType, err = detectMediaType(path)
+	if err != nil {
+		return false, "", err
+	}
+	extension := strings.ToLower(filepath.Ext(path))
+	for _, fileType := range i.allowedFileTypes {
+		switch {
+		case strings.HasPrefix(fileType, "."):
+			if strings.EqualFold(fileType, extension) && matchesExtension(mediaType, extension) {
+				return true, mediaType, nil
+			}
+		case strings.HasSuffix(fileType, "/"):
+			if strings.HasPrefix(mediaType, fileType) {
+				return true, mediaType, nil
+			}
+		case fileType == mediaType:
+			return true, mediaType, nil
+		}
+	}
+	return false, mediaType, nil
+}
+
+// matchesExtension returns whether content detected as the media type can have the extension. Content sniffing can't
+// tell text formats apart, so any text matches an extension of a text format, or an extension with no known type.
+func matchesExtension(mediaType, extension string) bool {
+	expected, _, err := mime.ParseMediaType(mime.TypeByExtension(extension))
+	if err != nil || isText(expected) {
+		return isText(mediaType)
+	}
+	return mediaType == expected
+}
+
+func isText(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript":
+		return true
+	}
+	return false
+}
+
+func detectMediaType(path string) (mediaType string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { err = multierr.Append(err, file.Close()) }()
+
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	mediaType, _, err = mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return mediaType, err
+}
+
+var _ Inspector = (*LimitsInspector)(nil)
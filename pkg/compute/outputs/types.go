@@ -0,0 +1,51 @@
+package outputs
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type InspectionRequest struct {
+	NodeID      string
+	ExecutionID string
+	Job         model.Job
+	// ResultPath is the local directory holding the results of the execution, with each output volume in a
+	// subdirectory named after the volume
+	ResultPath string
+}
+
+type InspectionResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+func NewApprovedResponse() InspectionResponse {
+	return InspectionResponse{
+		Approved: true,
+	}
+}
+
+// Inspector decides whether the outputs of an execution can leave the compute node
+type Inspector interface {
+	Inspect(ctx context.Context, request InspectionRequest) (InspectionResponse, error)
+}
+
+// the JSON data we send to http or exec inspectors
+type OutputInspectionData struct {
+	NodeID      string     `json:"node_id"`
+	JobID       string     `json:"job_id"`
+	ExecutionID string     `json:"execution_id"`
+	Spec        model.Spec `json:"spec"`
+	ResultPath  string     `json:"result_path"`
+}
+
+func getOutputInspectionData(request InspectionRequest) OutputInspectionData {
+	return OutputInspectionData{
+		NodeID:      request.NodeID,
+		JobID:       request.Job.ID(),
+		ExecutionID: request.ExecutionID,
+		Spec:        request.Job.Spec,
+		ResultPath:  request.ResultPath,
+	}
+}
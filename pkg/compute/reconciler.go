@@ -0,0 +1,124 @@
+package compute
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/multierr"
+	"golang.org/x/exp/slices"
+)
+
+const restartedComment = "compute node restarted while the execution was in progress"
+
+type ReconcilerParams struct {
+	ID        string
+	Store     store.ExecutionStore
+	Executors executor.ExecutorProvider
+	// Executor resumes the executions that were accepted but had not started running
+	Executor Executor
+	Callback Callback
+}
+
+// Reconciler brings the executions of a previous run of the compute node in line with the executors after a restart:
+//   - resources left behind in the executors by the previous run, such as containers, are removed.
+//   - executions that were bid on are kept, as the requester can still accept or reject the bid.
+//   - executions that were accepted but had not started running are resumed.
+//   - executions that were running, or had results waiting to be verified or published, are failed and the failure is
+//     reported to the requester, as their results are lost.
+type Reconciler struct {
+	id        string
+	store     store.ExecutionStore
+	executors executor.ExecutorProvider
+	executor  Executor
+	callback  Callback
+}
+
+func NewReconciler(params ReconcilerParams) *Reconciler {
+	return &Reconciler{
+		id:        params.ID,
+		store:     params.Store,
+		executors: params.Executors,
+		executor:  params.Executor,
+		callback:  params.Callback,
+	}
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context) error {
+	leftBehind, err := r.reconcileExecutors(ctx)
+
+	executions, getErr := r.store.GetActiveExecutions(ctx)
+	if getErr != nil {
+		return multierr.Append(err, fmt.Errorf("failed to get active executions: %w", getErr))
+	}
+	for _, execution := range executions {
+		switch execution.State {
+		case store.ExecutionStateCreated:
+			continue
+		case store.ExecutionStateBidAccepted:
+			log.Ctx(ctx).Debug().Str("execution", execution.ID).Msg("Resuming accepted execution")
+			err = multierr.Append(err, r.executor.Run(ctx, execution))
+		default:
+			comment := restartedComment
+			if slices.Contains(leftBehind, execution.ID) {
+				comment += ", its containers were removed"
+			}
+			err = multierr.Append(err, r.fail(ctx, execution, comment))
+		}
+	}
+	return err
+}
+
+// reconcileExecutors removes the resources left behind in the executors, and returns the IDs of their executions
+func (r *Reconciler) reconcileExecutors(ctx context.Context) (executionIDs []string, err error) {
+	for _, engine := range model.EngineTypes() {
+		if !r.executors.Has(ctx, engine) {
+			continue
+		}
+		e, getErr := r.executors.Get(ctx, engine)
+		if getErr != nil {
+			err = multierr.Append(err, getErr)
+			continue
+		}
+		reconciler, ok := e.(executor.Reconciler)
+		if !ok {
+			continue
+		}
+		ids, reconcileErr := reconciler.Reconcile(ctx)
+		if reconcileErr != nil {
+			err = multierr.Append(err, fmt.Errorf("failed to reconcile %s executor: %w", engine, reconcileErr))
+			continue
+		}
+		if len(ids) > 0 {
+			log.Ctx(ctx).Info().Strs("executions", ids).Msgf("Removed resources left behind in %s executor", engine)
+		}
+		executionIDs = append(executionIDs, ids...)
+	}
+	return executionIDs, err
+}
+
+func (r *Reconciler) fail(ctx context.Context, execution store.Execution, comment string) error {
+	log.Ctx(ctx).Warn().Str("execution", execution.ID).Msgf("Failing execution in state %s: %s", execution.State, comment)
+	err := r.store.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID:     execution.ID,
+		ExpectedState:   execution.State,
+		ExpectedVersion: execution.Version,
+		NewState:        store.ExecutionStateFailed,
+		Comment:         comment,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update execution %s state to failed: %w", execution.ID, err)
+	}
+	r.callback.OnComputeFailure(ctx, ComputeError{
+		ExecutionMetadata: NewExecutionMetadata(execution),
+		RoutingMetadata: RoutingMetadata{
+			SourcePeerID: r.id,
+			TargetPeerID: execution.RequesterNodeID,
+		},
+		Err: comment,
+	})
+	return nil
+}
@@ -0,0 +1,110 @@
+//go:build unit || !integration
+
+package compute
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/executor"
+	noop_executor "github.com/bacalhau-project/bacalhau/pkg/executor/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type leftBehindExecutor struct {
+	*noop_executor.NoopExecutor
+	executionIDs []string
+	reconciled   bool
+}
+
+func (e *leftBehindExecutor) Reconcile(ctx context.Context) ([]string, error) {
+	e.reconciled = true
+	return e.executionIDs, nil
+}
+
+type runRecorder struct {
+	Executor
+	runs []string
+}
+
+func (r *runRecorder) Run(ctx context.Context, execution store.Execution) error {
+	r.runs = append(r.runs, execution.ID)
+	return nil
+}
+
+func createExecutionInState(t *testing.T, executionStore store.ExecutionStore, state store.ExecutionState) store.Execution {
+	ctx := context.Background()
+	execution := *store.NewExecution(uuid.NewString(), model.Job{Metadata: model.Metadata{ID: uuid.NewString()}}, "requester", model.ResourceUsageData{})
+	require.NoError(t, executionStore.CreateExecution(ctx, execution))
+	if state != store.ExecutionStateCreated {
+		require.NoError(t, executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+			ExecutionID: execution.ID,
+			NewState:    state,
+		}))
+	}
+	execution, err := executionStore.GetExecution(ctx, execution.ID)
+	require.NoError(t, err)
+	return execution
+}
+
+func TestReconciler(t *testing.T) {
+	ctx := context.Background()
+	executionStore := inmemory.NewStore()
+	created := createExecutionInState(t, executionStore, store.ExecutionStateCreated)
+	accepted := createExecutionInState(t, executionStore, store.ExecutionStateBidAccepted)
+	running := createExecutionInState(t, executionStore, store.ExecutionStateRunning)
+	publishing := createExecutionInState(t, executionStore, store.ExecutionStatePublishing)
+	completed := createExecutionInState(t, executionStore, store.ExecutionStateCompleted)
+
+	dockerExecutor := &leftBehindExecutor{NoopExecutor: noop_executor.NewNoopExecutor(), executionIDs: []string{running.ID}}
+	recorder := &runRecorder{}
+	var failures []ComputeError
+	reconciler := NewReconciler(ReconcilerParams{
+		ID:    "compute",
+		Store: executionStore,
+		Executors: model.NewMappedProvider(map[model.Engine]executor.Executor{
+			model.EngineDocker: dockerExecutor,
+		}),
+		Executor: recorder,
+		Callback: CallbackMock{
+			OnComputeFailureHandler: func(ctx context.Context, err ComputeError) {
+				failures = append(failures, err)
+			},
+		},
+	})
+	require.NoError(t, reconciler.Reconcile(ctx))
+	require.True(t, dockerExecutor.reconciled)
+
+	// accepted executions are resumed
+	require.Equal(t, []string{accepted.ID}, recorder.runs)
+
+	// executions in progress are failed and reported to the requester
+	require.Len(t, failures, 2)
+	for _, failure := range failures {
+		require.Equal(t, "requester", failure.TargetPeerID)
+		require.Equal(t, "compute", failure.SourcePeerID)
+		require.Contains(t, []string{running.ID, publishing.ID}, failure.ExecutionID)
+		if failure.ExecutionID == running.ID {
+			require.Contains(t, failure.Err, "its containers were removed")
+		}
+	}
+
+	for _, test := range []struct {
+		execution store.Execution
+		state     store.ExecutionState
+	}{
+		{created, store.ExecutionStateCreated},
+		{accepted, store.ExecutionStateBidAccepted},
+		{running, store.ExecutionStateFailed},
+		{publishing, store.ExecutionStateFailed},
+		{completed, store.ExecutionStateCompleted},
+	} {
+		execution, err := executionStore.GetExecution(ctx, test.execution.ID)
+		require.NoError(t, err)
+		require.Equal(t, test.state, execution.State, test.execution.State.String())
+	}
+}
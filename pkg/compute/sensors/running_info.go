@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
-	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
 
@@ -13,7 +12,8 @@ type RunningExecutionsInfoProviderParams struct {
 	BackendBuffer *compute.ExecutorBuffer
 }
 
-// RunningExecutionsInfoProvider provides DebugInfo about the currently running executions.
+// RunningExecutionsInfoProvider provides DebugInfo about the currently running executions, and the executions
+// enqueued in the order they are considered to run.
 // The info can be used for logging, metric, or to handle /debug API implementation.
 type RunningExecutionsInfoProvider struct {
 	name          string
@@ -28,15 +28,9 @@ func NewRunningExecutionsInfoProvider(params RunningExecutionsInfoProviderParams
 }
 
 func (r RunningExecutionsInfoProvider) GetDebugInfo(ctx context.Context) (model.DebugInfo, error) {
-	executions := r.backendBuffer.RunningExecutions()
-	summaries := make([]store.ExecutionSummary, 0, len(executions))
-	for _, execution := range executions {
-		summaries = append(summaries, store.NewExecutionSummary(execution))
-	}
-
 	return model.DebugInfo{
 		Component: r.name,
-		Info:      summaries,
+		Info:      r.backendBuffer.Info(),
 	}, nil
 }
 
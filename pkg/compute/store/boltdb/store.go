@@ -0,0 +1,309 @@
+package boltdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+)
+
+const newExecutionComment = "Execution created"
+
+var (
+	executionsBucket = []byte("executions")
+	jobsBucket       = []byte("jobs")
+	historyBucket    = []byte("history")
+)
+
+// Store is a store.ExecutionStore that persists executions, their state and
+// history in an embedded BoltDB database so they survive compute node restarts.
+//
+// Executions are stored as JSON documents keyed by execution ID. The executions
+// of each job, and the history of each execution, are kept in nested buckets
+// keyed by a monotonically increasing sequence so that the insertion order is
+// preserved.
+type Store struct {
+	db *bolt.DB
+}
+
+// NewStore opens (or creates) the database at dbPath and makes sure all the
+// buckets used by the store exist.
+func NewStore(dbPath string) (*Store, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second}) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to open execution store database %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{executionsBucket, jobsBucket, historyBucket} {
+			if _, bucketErr := tx.CreateBucketIfNotExists(bucket); bucketErr != nil {
+				return bucketErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize execution store database %s: %w", dbPath, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close(_ context.Context) error {
+	return s.db.Close()
+}
+
+func (s *Store) GetExecution(_ context.Context, id string) (execution store.Execution, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		var found bool
+		execution, found, err = getExecution(tx, id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return store.NewErrExecutionNotFound(id)
+		}
+		return nil
+	})
+	return execution, err
+}
+
+func (s *Store) GetExecutions(_ context.Context, jobID string) ([]store.Execution, error) {
+	executions := []store.Execution{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		jobExecutions := tx.Bucket(jobsBucket).Bucket([]byte(jobID))
+		if jobExecutions == nil {
+			return store.NewErrExecutionsNotFoundForJob(jobID)
+		}
+		return jobExecutions.ForEach(func(_, id []byte) error {
+			execution, found, err := getExecution(tx, string(id))
+			if err != nil {
+				return err
+			}
+			if !found {
+				return fmt.Errorf("execution %s of job %s is missing", id, jobID)
+			}
+			executions = append(executions, execution)
+			return nil
+		})
+	})
+	if err != nil {
+		return []store.Execution{}, err
+	}
+	return executions, nil
+}
+
+func (s *Store) GetActiveExecutions(_ context.Context) ([]store.Execution, error) {
+	var executions []store.Execution
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(executionsBucket).ForEach(func(_, v []byte) error {
+			var execution store.Execution
+			if err := json.Unmarshal(v, &execution); err != nil {
+				return err
+			}
+			if execution.State.IsActive() {
+				executions = append(executions, execution)
+			}
+			return nil
+		})
+	})
+	return executions, err
+}
+
+func (s *Store) GetExecutionHistory(_ context.Context, id string) ([]store.ExecutionHistory, error) {
+	var history []store.ExecutionHistory
+	err := s.db.View(func(tx *bolt.Tx) error {
+		executionHistory := tx.Bucket(historyBucket).Bucket([]byte(id))
+		if executionHistory == nil {
+			return store.NewErrExecutionHistoryNotFound(id)
+		}
+		return executionHistory.ForEach(func(_, v []byte) error {
+			var entry store.ExecutionHistory
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			history = append(history, entry)
+			return nil
+		})
+	})
+	return history, err
+}
+
+func (s *Store) CreateExecution(ctx context.Context, execution store.Execution) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		key := []byte(execution.ID)
+		executions := tx.Bucket(executionsBucket)
+		if executions.Get(key) != nil {
+			return store.NewErrExecutionAlreadyExists(execution.ID)
+		}
+		if err := store.ValidateNewExecution(ctx, execution); err != nil {
+			return fmt.Errorf("CreateExecution failure: %w", err)
+		}
+		if err := putValue(executions, key, execution); err != nil {
+			return err
+		}
+
+		jobExecutions, err := tx.Bucket(jobsBucket).CreateBucketIfNotExists([]byte(execution.Job.ID()))
+		if err != nil {
+			return err
+		}
+		if err = putSequenced(jobExecutions, key); err != nil {
+			return err
+		}
+		return appendHistory(tx, execution, store.ExecutionStateUndefined, newExecutionComment)
+	})
+}
+
+func (s *Store) UpdateExecutionState(_ context.Context, request store.UpdateExecutionStateRequest) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		execution, found, err := getExecution(tx, request.ExecutionID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return store.NewErrExecutionNotFound(request.ExecutionID)
+		}
+		if request.ExpectedState != store.ExecutionStateUndefined && execution.State != request.ExpectedState {
+			return store.NewErrInvalidExecutionState(request.ExecutionID, execution.State, request.ExpectedState)
+		}
+		if request.ExpectedVersion != 0 && execution.Version != request.ExpectedVersion {
+			return store.NewErrInvalidExecutionVersion(request.ExecutionID, execution.Version, request.ExpectedVersion)
+		}
+		if execution.State.IsTerminal() {
+			return store.NewErrExecutionAlreadyTerminal(request.ExecutionID, execution.State, request.NewState)
+		}
+
+		previousState := execution.State
+		execution.State = request.NewState
+		if request.Bid != nil {
+			execution.Bid = request.Bid
+		}
+		if request.NewState == store.ExecutionStateWaitingVerification {
+			execution.ResultProposal = request.ResultProposal
+			execution.RunCommandResult = request.RunCommandResult
+		}
+		if request.NewState == store.ExecutionStateCompleted {
+			execution.PublishedResult = request.PublishedResult
+		}
+		execution.Version++
+		execution.UpdateTime = time.Now()
+		if err = putValue(tx.Bucket(executionsBucket), []byte(execution.ID), execution); err != nil {
+			return err
+		}
+		return appendHistory(tx, execution, previousState, request.Comment)
+	})
+}
+
+func (s *Store) DeleteExecution(_ context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		execution, found, err := getExecution(tx, id)
+		if err != nil || !found {
+			return err
+		}
+		key := []byte(id)
+		if err = tx.Bucket(executionsBucket).Delete(key); err != nil {
+			return err
+		}
+		if err = tx.Bucket(historyBucket).DeleteBucket(key); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+
+		jobs := tx.Bucket(jobsBucket)
+		jobKey := []byte(execution.Job.ID())
+		jobExecutions := jobs.Bucket(jobKey)
+		if jobExecutions == nil {
+			return nil
+		}
+		cursor := jobExecutions.Cursor()
+		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+			if string(v) == id {
+				if err = cursor.Delete(); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if k, _ := jobExecutions.Cursor().First(); k == nil {
+			return jobs.DeleteBucket(jobKey)
+		}
+		return nil
+	})
+}
+
+func (s *Store) GetExecutionCount(_ context.Context) (uint, error) {
+	var counter uint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(executionsBucket).ForEach(func(_, v []byte) error {
+			var execution store.Execution
+			if err := json.Unmarshal(v, &execution); err != nil {
+				return err
+			}
+			if execution.State == store.ExecutionStateCompleted {
+				counter++
+			}
+			return nil
+		})
+	})
+	return counter, err
+}
+
+func getExecution(tx *bolt.Tx, id string) (store.Execution, bool, error) {
+	var execution store.Execution
+	v := tx.Bucket(executionsBucket).Get([]byte(id))
+	if v == nil {
+		return execution, false, nil
+	}
+	if err := json.Unmarshal(v, &execution); err != nil {
+		return execution, false, err
+	}
+	return execution, true, nil
+}
+
+func appendHistory(tx *bolt.Tx, updatedExecution store.Execution, previousState store.ExecutionState, comment string) error {
+	historyEntry := store.ExecutionHistory{
+		ExecutionID:   updatedExecution.ID,
+		PreviousState: previousState,
+		NewState:      updatedExecution.State,
+		NewVersion:    updatedExecution.Version,
+		Comment:       comment,
+		Time:          updatedExecution.UpdateTime,
+	}
+	executionHistory, err := tx.Bucket(historyBucket).CreateBucketIfNotExists([]byte(updatedExecution.ID))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(historyEntry)
+	if err != nil {
+		return err
+	}
+	return putSequenced(executionHistory, data)
+}
+
+// putSequenced stores the value under the next sequence number of the bucket, so that iterating over the bucket
+// returns the values in insertion order
+func putSequenced(bucket *bolt.Bucket, value []byte) error {
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8) //nolint:gomnd // size of uint64
+	binary.BigEndian.PutUint64(key, seq)
+	return bucket.Put(key, value)
+}
+
+func putValue(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// compile-time check that we implement the interface ExecutionStore
+var _ store.ExecutionStore = (*Store)(nil)
@@ -0,0 +1,251 @@
+//go:build unit || !integration
+
+package boltdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type Suite struct {
+	suite.Suite
+	dbPath         string
+	executionStore *Store
+	execution      store.Execution
+}
+
+func (s *Suite) SetupTest() {
+	s.dbPath = filepath.Join(s.T().TempDir(), "executions.db")
+	var err error
+	s.executionStore, err = NewStore(s.dbPath)
+	s.Require().NoError(err)
+	s.execution = newExecution()
+}
+
+func (s *Suite) TearDownTest() {
+	s.NoError(s.executionStore.Close(context.Background()))
+}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(Suite))
+}
+
+func (s *Suite) TestCreateExecution() {
+	ctx := context.Background()
+	err := s.executionStore.CreateExecution(ctx, s.execution)
+	s.NoError(err)
+
+	readExecution, err := s.executionStore.GetExecution(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Equal(s.execution, readExecution)
+
+	history, err := s.executionStore.GetExecutionHistory(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Len(history, 1)
+	s.verifyHistory(history[0], readExecution, store.ExecutionStateUndefined, newExecutionComment)
+
+	err = s.executionStore.CreateExecution(ctx, s.execution)
+	s.ErrorAs(err, &store.ErrExecutionAlreadyExists{})
+}
+
+func (s *Suite) TestCreateExecution_InvalidState() {
+	s.execution.State = store.ExecutionStateBidAccepted
+	err := s.executionStore.CreateExecution(context.Background(), s.execution)
+	s.Error(err)
+}
+
+func (s *Suite) TestGetExecutions() {
+	ctx := context.Background()
+	anotherExecution := newExecution()
+	anotherExecution.Job = s.execution.Job
+	s.NoError(s.executionStore.CreateExecution(ctx, s.execution))
+	s.NoError(s.executionStore.CreateExecution(ctx, anotherExecution))
+
+	readExecutions, err := s.executionStore.GetExecutions(ctx, s.execution.Job.ID())
+	s.NoError(err)
+	s.Equal([]store.Execution{s.execution, anotherExecution}, readExecutions)
+
+	_, err = s.executionStore.GetExecutions(ctx, uuid.NewString())
+	s.ErrorAs(err, &store.ErrExecutionsNotFoundForJob{})
+}
+
+func (s *Suite) TestGetDoesntExist() {
+	ctx := context.Background()
+	_, err := s.executionStore.GetExecution(ctx, uuid.NewString())
+	s.ErrorAs(err, &store.ErrExecutionNotFound{})
+
+	_, err = s.executionStore.GetExecutionHistory(ctx, uuid.NewString())
+	s.ErrorAs(err, &store.ErrExecutionHistoryNotFound{})
+}
+
+func (s *Suite) TestUpdateExecution() {
+	ctx := context.Background()
+	s.NoError(s.executionStore.CreateExecution(ctx, s.execution))
+
+	request := store.UpdateExecutionStateRequest{
+		ExecutionID:     s.execution.ID,
+		ExpectedState:   s.execution.State,
+		ExpectedVersion: s.execution.Version,
+		NewState:        store.ExecutionStatePublishing,
+		Comment:         "Hello There!",
+	}
+	s.NoError(s.executionStore.UpdateExecutionState(ctx, request))
+
+	readExecution, err := s.executionStore.GetExecution(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Equal(request.NewState, readExecution.State)
+	s.Equal(s.execution.Version+1, readExecution.Version)
+
+	history, err := s.executionStore.GetExecutionHistory(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Len(history, 2)
+	s.verifyHistory(history[1], readExecution, s.execution.State, request.Comment)
+
+	// the update conditions are no longer met
+	err = s.executionStore.UpdateExecutionState(ctx, request)
+	s.ErrorAs(err, &store.ErrInvalidExecutionState{})
+	request.ExpectedState = store.ExecutionStatePublishing
+	err = s.executionStore.UpdateExecutionState(ctx, request)
+	s.ErrorAs(err, &store.ErrInvalidExecutionVersion{})
+
+	// terminal executions can't be updated
+	s.NoError(s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: s.execution.ID,
+		NewState:    store.ExecutionStateCompleted,
+	}))
+	err = s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: s.execution.ID,
+		NewState:    store.ExecutionStateFailed,
+	})
+	s.ErrorAs(err, &store.ErrExecutionAlreadyTerminal{})
+
+	count, err := s.executionStore.GetExecutionCount(ctx)
+	s.NoError(err)
+	s.Equal(uint(1), count)
+}
+
+func (s *Suite) TestDeleteExecution() {
+	ctx := context.Background()
+	secondExecution := newExecution()
+	secondExecution.Job = s.execution.Job
+	s.NoError(s.executionStore.CreateExecution(ctx, s.execution))
+	s.NoError(s.executionStore.CreateExecution(ctx, secondExecution))
+
+	s.NoError(s.executionStore.DeleteExecution(ctx, s.execution.ID))
+	_, err := s.executionStore.GetExecution(ctx, s.execution.ID)
+	s.ErrorAs(err, &store.ErrExecutionNotFound{})
+	_, err = s.executionStore.GetExecutionHistory(ctx, s.execution.ID)
+	s.ErrorAs(err, &store.ErrExecutionHistoryNotFound{})
+	executions, err := s.executionStore.GetExecutions(ctx, s.execution.Job.ID())
+	s.NoError(err)
+	s.Equal([]store.Execution{secondExecution}, executions)
+
+	s.NoError(s.executionStore.DeleteExecution(ctx, secondExecution.ID))
+	_, err = s.executionStore.GetExecutions(ctx, s.execution.Job.ID())
+	s.ErrorAs(err, &store.ErrExecutionsNotFoundForJob{})
+
+	s.NoError(s.executionStore.DeleteExecution(ctx, uuid.NewString()))
+}
+
+func (s *Suite) TestSurvivesRestart() {
+	ctx := context.Background()
+	completedExecution := newExecution()
+	s.NoError(s.executionStore.CreateExecution(ctx, s.execution))
+	s.NoError(s.executionStore.CreateExecution(ctx, completedExecution))
+	s.NoError(s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: s.execution.ID,
+		NewState:    store.ExecutionStateRunning,
+	}))
+	s.NoError(s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: completedExecution.ID,
+		NewState:    store.ExecutionStateCompleted,
+	}))
+	s.NoError(s.executionStore.Close(ctx))
+
+	var err error
+	s.executionStore, err = NewStore(s.dbPath)
+	s.Require().NoError(err)
+
+	readExecution, err := s.executionStore.GetExecution(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Equal(store.ExecutionStateRunning, readExecution.State)
+	s.Equal(2, readExecution.Version)
+
+	history, err := s.executionStore.GetExecutionHistory(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Len(history, 2)
+
+	active, err := s.executionStore.GetActiveExecutions(ctx)
+	s.NoError(err)
+	s.Equal([]store.Execution{readExecution}, active)
+
+	count, err := s.executionStore.GetExecutionCount(ctx)
+	s.NoError(err)
+	s.Equal(uint(1), count)
+}
+
+func (s *Suite) TestRecordsResults() {
+	ctx := context.Background()
+	s.NoError(s.executionStore.CreateExecution(ctx, s.execution))
+	bid := &store.Bid{Accepted: true, Reason: "this node is available"}
+	s.NoError(s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: s.execution.ID,
+		NewState:    store.ExecutionStateCreated,
+		Bid:         bid,
+	}))
+	runCommandResult := &model.RunCommandResult{STDOUT: "hello", ExitCode: 0}
+	s.NoError(s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID:      s.execution.ID,
+		NewState:         store.ExecutionStateWaitingVerification,
+		ResultProposal:   []byte("proposal"),
+		RunCommandResult: runCommandResult,
+	}))
+
+	// the results are kept across restarts, so that requesters that missed them can recover them
+	s.NoError(s.executionStore.Close(ctx))
+	var err error
+	s.executionStore, err = NewStore(s.dbPath)
+	s.Require().NoError(err)
+
+	readExecution, err := s.executionStore.GetExecution(ctx, s.execution.ID)
+	s.NoError(err)
+	s.Equal(bid, readExecution.Bid)
+	s.Equal([]byte("proposal"), readExecution.ResultProposal)
+	s.Equal(runCommandResult, readExecution.RunCommandResult)
+}
+
+func newExecution() store.Execution {
+	execution := *store.NewExecution(
+		uuid.NewString(),
+		model.Job{
+			Metadata: model.Metadata{
+				ID: uuid.NewString(),
+			},
+		},
+		"nodeID-1",
+		model.ResourceUsageData{
+			CPU:    1,
+			Memory: 2,
+		})
+	// times are read back from JSON without a monotonic clock reading
+	execution.CreateTime = time.Now().UTC().Round(0)
+	execution.UpdateTime = execution.CreateTime
+	return execution
+}
+
+func (s *Suite) verifyHistory(history store.ExecutionHistory, newExecution store.Execution, previousState store.ExecutionState, comment string) {
+	s.Equal(previousState, history.PreviousState)
+	s.Equal(newExecution.ID, history.ExecutionID)
+	s.Equal(newExecution.State, history.NewState)
+	s.Equal(newExecution.Version, history.NewVersion)
+	s.Equal(newExecution.UpdateTime, history.Time)
+	s.Equal(comment, history.Comment)
+}
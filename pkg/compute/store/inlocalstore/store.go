@@ -91,6 +91,11 @@ func (proxy *PersistentExecutionStore) GetExecutionCount(ctx context.Context) (u
 	return readCounter(proxy.stateFile)
 }
 
+// GetActiveExecutions implements store.ExecutionStore
+func (proxy *PersistentExecutionStore) GetActiveExecutions(ctx context.Context) ([]store.Execution, error) {
+	return proxy.store.GetActiveExecutions(ctx)
+}
+
 // GetExecutionHistory implements store.ExecutionStore
 func (proxy *PersistentExecutionStore) GetExecutionHistory(ctx context.Context, id string) ([]store.ExecutionHistory, error) {
 	return proxy.store.GetExecutionHistory(ctx, id)
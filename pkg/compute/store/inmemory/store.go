@@ -55,6 +55,18 @@ func (s *Store) GetExecutions(ctx context.Context, jobID string) ([]store.Execut
 	return executions, nil
 }
 
+func (s *Store) GetActiveExecutions(ctx context.Context) ([]store.Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var executions []store.Execution
+	for _, execution := range s.executionMap {
+		if execution.State.IsActive() {
+			executions = append(executions, execution)
+		}
+	}
+	return executions, nil
+}
+
 func (s *Store) GetExecutionHistory(ctx context.Context, id string) ([]store.ExecutionHistory, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -99,6 +111,16 @@ func (s *Store) UpdateExecutionState(ctx context.Context, request store.UpdateEx
 	}
 	previousState := execution.State
 	execution.State = request.NewState
+	if request.Bid != nil {
+		execution.Bid = request.Bid
+	}
+	if request.NewState == store.ExecutionStateWaitingVerification {
+		execution.ResultProposal = request.ResultProposal
+		execution.RunCommandResult = request.RunCommandResult
+	}
+	if request.NewState == store.ExecutionStateCompleted {
+		execution.PublishedResult = request.PublishedResult
+	}
 	execution.Version += 1
 	execution.UpdateTime = time.Now()
 	s.executionMap[execution.ID] = execution
@@ -170,6 +170,25 @@ func (s *Suite) TestUpdateExecution_ConditionsVersionFail() {
 	s.ErrorAs(err, &store.ErrInvalidExecutionVersion{})
 }
 
+func (s *Suite) TestGetActiveExecutions() {
+	ctx := context.Background()
+	err := s.executionStore.CreateExecution(ctx, s.execution)
+	s.NoError(err)
+
+	completedExecution := newExecution()
+	err = s.executionStore.CreateExecution(ctx, completedExecution)
+	s.NoError(err)
+	err = s.executionStore.UpdateExecutionState(ctx, store.UpdateExecutionStateRequest{
+		ExecutionID: completedExecution.ID,
+		NewState:    store.ExecutionStateCompleted,
+	})
+	s.NoError(err)
+
+	executions, err := s.executionStore.GetActiveExecutions(ctx)
+	s.NoError(err)
+	s.Equal([]store.Execution{s.execution}, executions)
+}
+
 func (s *Suite) TestDeleteExecution() {
 	err := s.executionStore.CreateExecution(context.Background(), s.execution)
 	s.NoError(err)
@@ -14,6 +14,13 @@ type Execution struct {
 	RequesterNodeID string
 	ResourceUsage   model.ResourceUsageData
 	State           ExecutionState
+	// Bid is the response of the compute node to the ask for bid, once it decided whether to bid on the execution
+	Bid *Bid
+	// ResultProposal and RunCommandResult are the results of the execution reported to the requester, once it ran
+	ResultProposal   []byte
+	RunCommandResult *model.RunCommandResult
+	// PublishedResult is where the results were published, once the execution is completed
+	PublishedResult model.StorageSpec
 	Version         int
 	CreateTime      time.Time
 	UpdateTime      time.Time
@@ -42,6 +49,12 @@ func (e Execution) String() string {
 	return fmt.Sprintf("{ID: %s, Job: %s}", e.ID, e.Job.Metadata.ID)
 }
 
+// Bid is the response of the compute node to the ask for bid on an execution
+type Bid struct {
+	Accepted bool
+	Reason   string
+}
+
 type ExecutionHistory struct {
 	ExecutionID   string
 	PreviousState ExecutionState
@@ -75,6 +88,14 @@ type UpdateExecutionStateRequest struct {
 	ExpectedState   ExecutionState
 	ExpectedVersion int
 	Comment         string
+	// Bid is recorded on the execution if not nil
+	Bid *Bid
+	// ResultProposal and RunCommandResult are recorded on the execution when it transitions to
+	// ExecutionStateWaitingVerification
+	ResultProposal   []byte
+	RunCommandResult *model.RunCommandResult
+	// PublishedResult is recorded on the execution when it transitions to ExecutionStateCompleted
+	PublishedResult model.StorageSpec
 }
 
 // ExecutionStore A metadata store of job executions handled by the current compute node
@@ -83,6 +104,8 @@ type ExecutionStore interface {
 	GetExecution(ctx context.Context, id string) (Execution, error)
 	// GetExecutions returns all the executions for a given job
 	GetExecutions(ctx context.Context, jobID string) ([]Execution, error)
+	// GetActiveExecutions returns all the executions that are not in a terminal state
+	GetActiveExecutions(ctx context.Context) ([]Execution, error)
 	// GetExecutionHistory returns the history of an execution
 	GetExecutionHistory(ctx context.Context, id string) ([]ExecutionHistory, error)
 	// CreateExecution creates a new execution for a given job
@@ -27,6 +27,9 @@ type Endpoint interface {
 	CancelExecution(context.Context, CancelExecutionRequest) (CancelExecutionResponse, error)
 	// ExecutionLogs returns the address of a suitable log server
 	ExecutionLogs(context.Context, ExecutionLogsRequest) (ExecutionLogsResponse, error)
+	// ExecutionStatus returns the current state of an execution on the compute node, which allows requesters to
+	// reconcile their view of executions, such as after a restart.
+	ExecutionStatus(context.Context, ExecutionStatusRequest) (ExecutionStatusResponse, error)
 }
 
 // Executor Backend service that is responsible for running and publishing executions.
@@ -40,6 +43,13 @@ type Executor interface {
 	Cancel(ctx context.Context, execution store.Execution) error
 }
 
+// ResultsUsageProvider returns the resources used by the results of executions that are kept on the compute node
+// after they ran, until they are published.
+type ResultsUsageProvider interface {
+	// GetResultsUsage returns the resources used by the results of the execution, or zero usage if it has none.
+	GetResultsUsage(ctx context.Context, executionID string) model.ResourceUsageData
+}
+
 // Callback Callbacks are used to notify the caller of the result of a job execution.
 type Callback interface {
 	OnBidComplete(ctx context.Context, result BidResult)
@@ -58,6 +68,11 @@ type RoutingMetadata struct {
 	TargetPeerID string
 }
 
+// Routing returns the routing metadata of the requests and results that embed it.
+func (m RoutingMetadata) Routing() RoutingMetadata {
+	return m
+}
+
 type ExecutionMetadata struct {
 	ExecutionID string
 	JobID       string
@@ -143,6 +158,25 @@ type ExecutionLogsResponse struct {
 	ExecutionFinished bool
 }
 
+type ExecutionStatusRequest struct {
+	RoutingMetadata
+	ExecutionID string
+}
+
+type ExecutionStatusResponse struct {
+	ExecutionMetadata
+	// State is the state of the execution on the compute node, or ExecutionStateUndefined if the compute node
+	// doesn't know about the execution.
+	State store.ExecutionState
+	// Bid is the response of the compute node to the ask for bid, or nil if it hasn't decided whether to bid yet
+	Bid *store.Bid
+	// ResultProposal and RunCommandResult are the results of the execution, once it ran
+	ResultProposal   []byte
+	RunCommandResult *model.RunCommandResult
+	// PublishResult is where the results were published, if the execution is completed
+	PublishResult model.StorageSpec
+}
+
 ///////////////////////////////////
 // Callback result models
 ///////////////////////////////////
@@ -16,6 +16,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/config"
 	"github.com/bacalhau-project/bacalhau/pkg/docker/tracing"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	dockerclient "github.com/docker/docker/client"
@@ -221,6 +222,26 @@ func (c *Client) ImagePlatforms(ctx context.Context, image string, dockerCreds c
 	return distribution.Platforms, nil
 }
 
+// ImageDigest returns the content digest of the local image, as repo@sha256:..., which identifies the image whatever
+// its tag points to.
+func (c *Client) ImageDigest(ctx context.Context, image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	inspect, _, err := c.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	for _, repoDigest := range inspect.RepoDigests {
+		digested, err := reference.ParseNormalizedNamed(repoDigest)
+		if err == nil && digested.Name() == named.Name() {
+			return repoDigest, nil
+		}
+	}
+	return "", fmt.Errorf("image %s has no digest in repository %s", image, named.Name())
+}
+
 func (c *Client) SupportedPlatforms(ctx context.Context) ([]v1.Platform, error) {
 	version, err := c.ServerVersion(ctx)
 	if err != nil {
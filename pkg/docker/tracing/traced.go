@@ -89,6 +89,14 @@ func (c TracedClient) ContainerStart(ctx context.Context, id string, options typ
 	return telemetry.RecordErrorOnSpan(span)(c.client.ContainerStart(ctx, id, options))
 }
 
+func (c TracedClient) ContainerStats(ctx context.Context, containerID string, stream bool) (io.ReadCloser, error) {
+	ctx, span := c.span(ctx, "container.stats")
+	// span ends when the io.ReadCloser is closed
+
+	stats, err := c.client.ContainerStats(ctx, containerID, stream)
+	return telemetry.RecordErrorOnSpanReadCloserAndClose(span)(stats.Body, err)
+}
+
 func (c TracedClient) ContainerStop(ctx context.Context, containerID string, timeout time.Duration) error {
 	ctx, span := c.span(ctx, "container.stop")
 	defer span.End()
@@ -2,13 +2,17 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/rs/zerolog/log"
 )
 
@@ -20,6 +24,9 @@ var specialFiles = map[string]bool{
 	model.DownloadFilenameExitCode: true,
 }
 
+// resultFolderNameLength is the length of the folder name of results that are not content addressed
+const resultFolderNameLength = 16
+
 // DownloadResult downloads published results from a storage source and saves
 // them to the specific download path. It supports downloading multiple results
 // from different jobs and will append the logs to the global log file. This
@@ -81,6 +88,10 @@ func DownloadResults( //nolint:funlen,gocyclo
 
 	if settings.SingleFile != "" {
 		for _, publishedResult := range publishedResults {
+			if publishedResult.Data.Encryption != nil {
+				return fmt.Errorf("cannot download a single file from encrypted results, download all the results instead")
+			}
+
 			downloader, err = downloadProvider.Get(ctx, publishedResult.Data.StorageSource) //nolint
 			if err != nil {
 				return err
@@ -115,6 +126,8 @@ func DownloadResults( //nolint:funlen,gocyclo
 			item := model.DownloadItem{
 				Name:       settings.SingleFile,
 				CID:        cid,
+				URL:        publishedResult.Data.URL,
+				S3:         publishedResult.Data.S3,
 				SourceType: publishedResult.Data.StorageSource,
 				Target:     targetFile,
 			}
@@ -133,13 +146,14 @@ func DownloadResults( //nolint:funlen,gocyclo
 				return err
 			}
 
-			cidDownloadDir := filepath.Join(cidParentDir, publishedResult.Data.CID)
-			_, alreadyExists := downloadedCids[publishedResult.Data.CID]
+			ident := resultIdentifier(publishedResult.Data)
+			cidDownloadDir := filepath.Join(cidParentDir, resultFolderName(publishedResult.Data))
+			_, alreadyExists := downloadedCids[ident]
 			if alreadyExists {
 				// We don't want to download the same CID twice, so we will just move
 				// on to the next item
 				log.Ctx(ctx).Debug().
-					Str("CID", publishedResult.Data.CID).
+					Str("CID", ident).
 					Msg("asked to download a CID a second time")
 				continue
 			}
@@ -147,6 +161,8 @@ func DownloadResults( //nolint:funlen,gocyclo
 			item := model.DownloadItem{
 				Name:       publishedResult.Data.Name,
 				CID:        publishedResult.Data.CID,
+				URL:        publishedResult.Data.URL,
+				S3:         publishedResult.Data.S3,
 				SourceType: publishedResult.Data.StorageSource,
 				Target:     cidDownloadDir,
 			}
@@ -156,7 +172,14 @@ func DownloadResults( //nolint:funlen,gocyclo
 				return err
 			}
 
-			downloadedCids[item.CID] = cidDownloadDir
+			if publishedResult.Data.Encryption != nil {
+				err = openSealedResult(ctx, publishedResult.Data, cidDownloadDir)
+				if err != nil {
+					return err
+				}
+			}
+
+			downloadedCids[ident] = cidDownloadDir
 		}
 	}
 
@@ -181,6 +204,57 @@ func DownloadResults( //nolint:funlen,gocyclo
 	}
 }
 
+// openSealedResult decrypts results that were sealed for this client by the
+// compute node, and replaces the downloaded archive with its content.
+func openSealedResult(ctx context.Context, spec model.StorageSpec, downloadDir string) error {
+	key, err := encrypted.UnwrapDataKey(ctx, func(_ context.Context, data []byte) ([]byte, error) {
+		return system.DecryptForClient(data)
+	}, spec.Encryption, system.GetClientID())
+	if err != nil {
+		return fmt.Errorf("failed to decrypt results %s: %w", spec.CID, err)
+	}
+
+	sealed, err := os.Open(filepath.Join(downloadDir, encrypted.SealedArchiveFilename))
+	if err != nil {
+		return err
+	}
+
+	openedDir := downloadDir + "-decrypted"
+	err = encrypted.OpenDirectory(sealed, openedDir, key)
+	closer.CloseWithLogOnError("sealed results", sealed)
+	if err != nil {
+		_ = os.RemoveAll(openedDir)
+		return fmt.Errorf("failed to decrypt results %s: %w", spec.CID, err)
+	}
+
+	if err = os.RemoveAll(downloadDir); err != nil {
+		return err
+	}
+	return os.Rename(openedDir, downloadDir)
+}
+
+// resultIdentifier returns a unique identifier of a published result, which is its CID for content addressed storage,
+// or its location otherwise.
+func resultIdentifier(spec model.StorageSpec) string {
+	switch {
+	case spec.CID != "":
+		return spec.CID
+	case spec.S3 != nil:
+		return fmt.Sprintf("s3://%s/%s?versionId=%s&endpoint=%s", spec.S3.Bucket, spec.S3.Key, spec.S3.VersionID, spec.S3.Endpoint)
+	default:
+		return spec.URL
+	}
+}
+
+// resultFolderName returns the name of the folder the result is downloaded to before being merged into the output
+func resultFolderName(spec model.StorageSpec) string {
+	if spec.CID != "" {
+		return spec.CID
+	}
+	hash := sha256.Sum256([]byte(resultIdentifier(spec)))
+	return hex.EncodeToString(hash[:])[:resultFolderNameLength]
+}
+
 func findSingleEntry(ctx context.Context, result model.PublishedResult, downloader Downloader, name string) (string, error) {
 	filemap, err := downloader.DescribeResult(ctx, result)
 	if err != nil {
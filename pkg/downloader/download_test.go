@@ -17,6 +17,7 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -390,3 +391,68 @@ func (ds *DownloaderSuite) TestCustomVolumeNames() {
 
 	requireFileExists(ds, "secrets", "private.pem")
 }
+
+func (ds *DownloaderSuite) TestEncryptedOutput() {
+	resultsDir := ds.T().TempDir()
+	stdout := mockFile(ds, resultsDir, model.DownloadFilenameStdout)
+	output := mockFile(ds, resultsDir, "outputs", "secret.txt")
+
+	key, err := encrypted.NewDataKey()
+	require.NoError(ds.T(), err)
+	wrappedKey, err := system.EncryptForClient(key, system.GetClientPublicKey())
+	require.NoError(ds.T(), err)
+
+	cid := mockOutput(ds, func(dir string) {
+		sealed, err := os.Create(filepath.Join(dir, encrypted.SealedArchiveFilename))
+		require.NoError(ds.T(), err)
+		defer closer.CloseWithLogOnError("sealed", sealed)
+		require.NoError(ds.T(), encrypted.SealDirectory(sealed, resultsDir, key))
+	})
+
+	result := model.PublishedResult{
+		NodeID: "testnode",
+		Data: model.StorageSpec{
+			StorageSource: model.StorageSourceIPFS,
+			Name:          "result-0",
+			CID:           cid,
+			Encryption: &model.EncryptionSpec{
+				Algorithm:   model.EncryptionAlgorithmAES256GCM,
+				WrappedKeys: map[string][]byte{system.GetClientID(): wrappedKey},
+			},
+		},
+	}
+
+	err = DownloadResults(context.Background(), []model.PublishedResult{result}, ds.downloadProvider, ds.downloadSettings)
+	require.NoError(ds.T(), err)
+
+	requireFile(ds, stdout, model.DownloadFilenameStdout)
+	requireFile(ds, output, "outputs", "secret.txt")
+	require.NoFileExists(ds.T(), filepath.Join(ds.outputDir, encrypted.SealedArchiveFilename))
+}
+
+func (ds *DownloaderSuite) TestEncryptedOutputForOtherClient() {
+	cid := mockOutput(ds, func(dir string) {
+		mockFile(ds, dir, encrypted.SealedArchiveFilename)
+	})
+
+	err := DownloadResults(
+		context.Background(),
+		[]model.PublishedResult{
+			{
+				NodeID: "testnode",
+				Data: model.StorageSpec{
+					StorageSource: model.StorageSourceIPFS,
+					Name:          "result-0",
+					CID:           cid,
+					Encryption: &model.EncryptionSpec{
+						Algorithm:   model.EncryptionAlgorithmAES256GCM,
+						WrappedKeys: map[string][]byte{"other-client": []byte("key")},
+					},
+				},
+			},
+		},
+		ds.downloadProvider,
+		ds.downloadSettings,
+	)
+	require.Error(ds.T(), err)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -80,6 +81,10 @@ func fetch(ctx context.Context, url string, filepath string) error {
 
 	defer closer.DrainAndCloseWithLogOnError(ctx, "http response", response.Body)
 
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to download %s: %s", url, response.Status)
+	}
+
 	// Write the contents of the response body to the file
 	_, err = io.Copy(out, response.Body)
 	if err != nil {
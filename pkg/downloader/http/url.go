@@ -0,0 +1,136 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/bacalhau-project/bacalhau/pkg/util/targzip"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog/log"
+)
+
+// maxArchivedFileSize is the maximum size of each file extracted from compressed results
+const maxArchivedFileSize = 100 * datasize.GB
+
+// URLDownloader fetches results published as a plain URL. The result is saved to the download folder under the
+// name of the last element of the URL path, or extracted into it if the URL points at a gzipped tarball.
+type URLDownloader struct {
+	Settings *model.DownloaderSettings
+}
+
+func NewURLDownloader(settings *model.DownloaderSettings) *URLDownloader {
+	return &URLDownloader{
+		Settings: settings,
+	}
+}
+
+func (d *URLDownloader) IsInstalled(context.Context) (bool, error) {
+	return true, nil
+}
+
+// DescribeResult returns the single file that the URL points at, unless it is an archive
+func (d *URLDownloader) DescribeResult(ctx context.Context, result model.PublishedResult) (map[string]string, error) {
+	if result.Data.URL == "" {
+		return nil, errors.New("published result has no URL")
+	}
+	name, err := fileName(result.Data.URL)
+	if err != nil {
+		return nil, err
+	}
+	if isArchive(name) {
+		return nil, fmt.Errorf("cannot describe compressed results at %s, download all the results instead", result.Data.URL)
+	}
+	return map[string]string{name: result.Data.URL}, nil
+}
+
+// FetchResult downloads the result to the target folder. If the item identifies a single file, as described by
+// DescribeResult, the file is downloaded to the target instead.
+func (d *URLDownloader) FetchResult(ctx context.Context, item model.DownloadItem) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/downloader/http.URLDownloader.FetchResult")
+	defer span.End()
+
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(d.Settings.Timeout))
+	defer cancel()
+
+	if item.CID != "" {
+		return d.fetch(ctx, item.CID, item.Target)
+	}
+
+	name, err := fileName(item.URL)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(item.Target, model.DownloadFolderPerm); err != nil {
+		return err
+	}
+	if !isArchive(name) {
+		return d.fetch(ctx, item.URL, filepath.Join(item.Target, name))
+	}
+
+	// download the archive next to the target, and extract it into the target
+	archive, err := os.CreateTemp(filepath.Dir(item.Target), "url-archive-*")
+	if err != nil {
+		return err
+	}
+	closer.CloseWithLogOnError("archive", archive)
+	defer func() {
+		if err := os.Remove(archive.Name()); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to remove temporary archive %s", archive.Name())
+		}
+	}()
+	if err = d.fetch(ctx, item.URL, archive.Name()); err != nil {
+		return err
+	}
+	if err = os.Remove(item.Target); err != nil {
+		return err
+	}
+	return extractArchive(archive.Name(), item.Target)
+}
+
+func (d *URLDownloader) fetch(ctx context.Context, url, target string) error {
+	log.Ctx(ctx).Debug().Msgf("Downloading result URL %s to '%s'...", url, target)
+	err := fetch(ctx, url, target)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Ctx(ctx).Error().Msg("Timed out while downloading result.")
+	}
+	return err
+}
+
+func extractArchive(archivePath, target string) error {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer closer.CloseWithLogOnError("archive", archive)
+	return targzip.DecompressWithMaxBytes(archive, target, maxArchivedFileSize)
+}
+
+func fileName(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return "", fmt.Errorf("cannot infer a file name from URL %s", rawURL)
+	}
+	return name, nil
+}
+
+func isArchive(name string) bool {
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// Compile-time check that URLDownloader implements the correct interface:
+var _ downloader.Downloader = (*URLDownloader)(nil)
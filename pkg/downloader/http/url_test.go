@@ -0,0 +1,77 @@
+//go:build unit || !integration
+
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLDownloader(t *testing.T) {
+	var archive bytes.Buffer
+	gw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "stdout", Typeflag: tar.TypeReg, Mode: 0644, Size: 5}))
+	_, err := tw.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/results/data.txt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("data"))
+	})
+	mux.HandleFunc("/results/job.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive.Bytes())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name       string
+		url        string
+		singleFile string
+		expected   map[string]string
+		shouldFail bool
+	}{
+		{name: "file", url: "/results/data.txt", expected: map[string]string{"data.txt": "data"}},
+		{name: "single file", url: "/results/data.txt", singleFile: "data.txt", expected: map[string]string{"data.txt": "data"}},
+		{name: "archive", url: "/results/job.tar.gz", expected: map[string]string{"stdout": "hello"}},
+		{name: "not found", url: "/results/missing.txt", shouldFail: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			settings := &model.DownloaderSettings{Timeout: time.Minute, OutputDir: t.TempDir(), SingleFile: tc.singleFile}
+			provider := model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
+				model.StorageSourceURLDownload: NewURLDownloader(settings),
+			})
+			result := model.PublishedResult{Data: model.StorageSpec{
+				StorageSource: model.StorageSourceURLDownload,
+				URL:           server.URL + tc.url,
+			}}
+
+			err := downloader.DownloadResults(context.Background(), []model.PublishedResult{result}, provider, settings)
+			if tc.shouldFail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for name, content := range tc.expected {
+				data, err := os.ReadFile(filepath.Join(settings.OutputDir, name))
+				require.NoError(t, err)
+				require.Equal(t, content, string(data))
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	s3helper "github.com/bacalhau-project/bacalhau/pkg/s3"
+	s3storage "github.com/bacalhau-project/bacalhau/pkg/storage/s3"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
+	"github.com/bacalhau-project/bacalhau/pkg/util/targzip"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog/log"
+)
+
+// archiveSuffix is appended by the S3 publisher to the key of compressed results
+const archiveSuffix = ".tar.gz"
+
+// maxArchivedFileSize is the maximum size of each file extracted from compressed results
+const maxArchivedFileSize = 100 * datasize.GB
+
+type DownloaderParams struct {
+	Settings       *model.DownloaderSettings
+	ClientProvider *s3helper.ClientProvider
+}
+
+// Downloader fetches results published to an S3 compatible storage by the S3 publisher, which are either all the
+// objects under a key prefix, or a single compressed archive of the results.
+type Downloader struct {
+	settings       *model.DownloaderSettings
+	clientProvider *s3helper.ClientProvider
+}
+
+func NewDownloader(params DownloaderParams) *Downloader {
+	return &Downloader{
+		settings:       params.Settings,
+		clientProvider: params.ClientProvider,
+	}
+}
+
+// IsInstalled returns true if the host has AWS credentials configured, as for the S3 storage provider
+func (d *Downloader) IsInstalled(context.Context) (bool, error) {
+	return d.clientProvider.IsInstalled(), nil
+}
+
+// DescribeResult maps the path of each file in the results to its object key. Compressed results can't be described
+// without downloading them.
+func (d *Downloader) DescribeResult(ctx context.Context, result model.PublishedResult) (map[string]string, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/downloader/s3.Downloader.DescribeResult")
+	defer span.End()
+
+	spec, err := s3Spec(result.Data.S3)
+	if err != nil {
+		return nil, err
+	}
+	if isArchive(spec) {
+		return nil, fmt.Errorf("cannot describe compressed results at s3://%s/%s, download all the results instead",
+			spec.Bucket, spec.Key)
+	}
+
+	client := d.clientProvider.GetClient(spec.Endpoint, spec.Region)
+	prefix := directoryPrefix(spec.Key)
+	entries := make(map[string]string)
+	var continuationToken *string
+	for {
+		resp, err := client.S3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+			Bucket:            aws.String(spec.Bucket),
+			Prefix:            aws.String(prefix),
+			ContinuationToken: continuationToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range resp.Contents {
+			key := aws.ToString(object.Key)
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+			entries[strings.TrimPrefix(key, prefix)] = key
+		}
+		if !resp.IsTruncated {
+			break
+		}
+		continuationToken = resp.NextContinuationToken
+	}
+	return entries, nil
+}
+
+// FetchResult downloads the results to the target directory, extracting them if they were compressed. If the item
+// identifies a single object, as described by DescribeResult, only that object is downloaded to the target file.
+func (d *Downloader) FetchResult(ctx context.Context, item model.DownloadItem) error {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/downloader/s3.Downloader.FetchResult")
+	defer span.End()
+
+	spec, err := s3Spec(item.S3)
+	if err != nil {
+		return err
+	}
+
+	if d.settings != nil && d.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.settings.Timeout)
+		defer cancel()
+	}
+
+	log.Ctx(ctx).Debug().Msgf("Downloading result s3://%s/%s to '%s'...", spec.Bucket, spec.Key, item.Target)
+	switch {
+	case item.CID != "":
+		// a single object of the results
+		objectSpec := *spec
+		objectSpec.Key = item.CID
+		objectSpec.VersionID = ""
+		objectSpec.ChecksumSHA256 = ""
+		return d.download(ctx, objectSpec, item.Target, func(dir string) error {
+			return os.Rename(filepath.Join(dir, filepath.Base(item.CID)), item.Target)
+		})
+	case isArchive(spec):
+		return d.download(ctx, *spec, item.Target, func(dir string) error {
+			return extractArchive(filepath.Join(dir, filepath.Base(spec.Key)), item.Target)
+		})
+	default:
+		// list all the objects under the prefix of the results, rather than the prefix itself
+		prefixSpec := *spec
+		prefixSpec.Key = directoryPrefix(spec.Key) + "*"
+		return d.download(ctx, prefixSpec, item.Target, func(dir string) error {
+			return os.Rename(dir, item.Target)
+		})
+	}
+}
+
+// download fetches the objects matching the spec to a temporary directory next to the target, and calls move to move
+// them to the target.
+func (d *Downloader) download(ctx context.Context, spec model.S3StorageSpec, target string, move func(dir string) error) error {
+	// the temporary directory is created in the parent directory of the target, so that the results can be moved
+	// without copying them across file systems
+	storage := s3storage.NewStorage(s3storage.StorageProviderParams{
+		LocalDir:       filepath.Dir(target),
+		ClientProvider: d.clientProvider,
+	})
+	volume, err := storage.PrepareStorage(ctx, model.StorageSpec{
+		StorageSource: model.StorageSourceS3,
+		S3:            &spec,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download s3://%s/%s: %w", spec.Bucket, spec.Key, err)
+	}
+	defer func() {
+		if err := os.RemoveAll(volume.Source); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msgf("failed to remove temporary download folder %s", volume.Source)
+		}
+	}()
+	return move(volume.Source)
+}
+
+func extractArchive(archivePath, target string) error {
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer closer.CloseWithLogOnError("archive", archive)
+	return targzip.DecompressWithMaxBytes(archive, target, maxArchivedFileSize)
+}
+
+func s3Spec(spec *model.S3StorageSpec) (*model.S3StorageSpec, error) {
+	if spec == nil || spec.Bucket == "" || spec.Key == "" {
+		return nil, fmt.Errorf("published result has no S3 bucket or key")
+	}
+	return spec, nil
+}
+
+func isArchive(spec *model.S3StorageSpec) bool {
+	return strings.HasSuffix(spec.Key, archiveSuffix)
+}
+
+func directoryPrefix(key string) string {
+	key = strings.TrimSuffix(key, "*")
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	return key
+}
+
+// Compile-time check that Downloader implements the correct interface:
+var _ downloader.Downloader = (*Downloader)(nil)
@@ -0,0 +1,230 @@
+//go:build unit || !integration
+
+package s3
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	s3helper "github.com/bacalhau-project/bacalhau/pkg/s3"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const testBucket = "results"
+
+// fakeS3 is a minimal S3 compatible server, serving path style requests for objects held in memory
+type fakeS3 struct {
+	objects map[string][]byte
+}
+
+type listBucketResult struct {
+	XMLName     xml.Name        `xml:"ListBucketResult"`
+	Name        string          `xml:"Name"`
+	Prefix      string          `xml:"Prefix"`
+	KeyCount    int             `xml:"KeyCount"`
+	IsTruncated bool            `xml:"IsTruncated"`
+	Contents    []listedObjects `xml:"Contents"`
+}
+
+type listedObjects struct {
+	Key  string `xml:"Key"`
+	Size int64  `xml:"Size"`
+	ETag string `xml:"ETag"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testBucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	if key == "" && r.URL.Query().Get("list-type") == "2" {
+		prefix := r.URL.Query().Get("prefix")
+		result := listBucketResult{Name: bucket, Prefix: prefix}
+		for objectKey, content := range f.objects {
+			if strings.HasPrefix(objectKey, prefix) {
+				result.Contents = append(result.Contents, listedObjects{Key: objectKey, Size: int64(len(content)), ETag: etag(objectKey)})
+			}
+		}
+		sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+		result.KeyCount = len(result.Contents)
+		w.Header().Set("Content-Type", "application/xml")
+		_ = xml.NewEncoder(w).Encode(result)
+		return
+	}
+
+	content, ok := f.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("ETag", etag(key))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(content))
+}
+
+func etag(key string) string {
+	return fmt.Sprintf("%q", key)
+}
+
+type DownloaderTestSuite struct {
+	suite.Suite
+	server     *httptest.Server
+	fake       *fakeS3
+	downloader *Downloader
+	outputDir  string
+}
+
+func TestDownloaderTestSuite(t *testing.T) {
+	suite.Run(t, new(DownloaderTestSuite))
+}
+
+func (s *DownloaderTestSuite) SetupTest() {
+	s.fake = &fakeS3{objects: map[string][]byte{}}
+	s.server = httptest.NewServer(s.fake)
+	s.T().Cleanup(s.server.Close)
+
+	s.outputDir = s.T().TempDir()
+	s.downloader = NewDownloader(DownloaderParams{
+		Settings: &model.DownloaderSettings{Timeout: time.Minute, OutputDir: s.outputDir},
+		ClientProvider: s3helper.NewClientProvider(s3helper.ClientProviderParams{
+			AWSConfig: aws.Config{
+				Region: "us-east-1",
+				Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+					return aws.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"}, nil
+				}),
+			},
+		}),
+	})
+}
+
+func (s *DownloaderTestSuite) download(result model.StorageSpec, singleFile string) {
+	provider := model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
+		model.StorageSourceS3: s.downloader,
+	})
+	err := downloader.DownloadResults(context.Background(), []model.PublishedResult{{NodeID: "node", Data: result}}, provider,
+		&model.DownloaderSettings{Timeout: time.Minute, OutputDir: s.outputDir, SingleFile: singleFile})
+	s.Require().NoError(err)
+}
+
+func (s *DownloaderTestSuite) result(key string) model.StorageSpec {
+	return model.StorageSpec{
+		StorageSource: model.StorageSourceS3,
+		Name:          fmt.Sprintf("s3://%s/%s", testBucket, key),
+		S3: &model.S3StorageSpec{
+			Bucket:   testBucket,
+			Key:      key,
+			Endpoint: s.server.URL,
+		},
+	}
+}
+
+func (s *DownloaderTestSuite) requireFile(path, content string) {
+	data, err := os.ReadFile(filepath.Join(s.outputDir, path))
+	s.Require().NoError(err)
+	s.Require().Equal(content, string(data))
+}
+
+func (s *DownloaderTestSuite) TestIsInstalled() {
+	installed, err := s.downloader.IsInstalled(context.Background())
+	s.Require().NoError(err)
+	s.Require().True(installed)
+}
+
+func (s *DownloaderTestSuite) TestDownloadPrefix() {
+	s.fake.objects["job/stdout"] = []byte("hello")
+	s.fake.objects["job/exitCode"] = []byte("0")
+	s.fake.objects["job/outputs/data.txt"] = []byte("data")
+	s.fake.objects["other/stdout"] = []byte("other")
+
+	s.download(s.result("job"), "")
+
+	s.requireFile("stdout", "hello")
+	s.requireFile("exitCode", "0")
+	s.requireFile("outputs/data.txt", "data")
+	s.NoDirExists(filepath.Join(s.outputDir, model.DownloadCIDsFolderName))
+}
+
+func (s *DownloaderTestSuite) TestDownloadArchive() {
+	s.fake.objects["job.tar.gz"] = archive(s.T(), map[string]string{
+		"stdout":           "hello",
+		"outputs/data.txt": "data",
+	})
+
+	s.download(s.result("job.tar.gz"), "")
+
+	s.requireFile("stdout", "hello")
+	s.requireFile("outputs/data.txt", "data")
+}
+
+func (s *DownloaderTestSuite) TestDownloadSingleFile() {
+	s.fake.objects["job/stdout"] = []byte("hello")
+	s.fake.objects["job/outputs/data.txt"] = []byte("data")
+
+	s.download(s.result("job"), "outputs/data.txt")
+
+	s.requireFile("outputs/data.txt", "data")
+	s.NoFileExists(filepath.Join(s.outputDir, "stdout"))
+}
+
+func (s *DownloaderTestSuite) TestDescribeResult() {
+	s.fake.objects["job/stdout"] = []byte("hello")
+	s.fake.objects["job/outputs/data.txt"] = []byte("data")
+
+	entries, err := s.downloader.DescribeResult(context.Background(), model.PublishedResult{Data: s.result("job/")})
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]string{
+		"stdout":           "job/stdout",
+		"outputs/data.txt": "job/outputs/data.txt",
+	}, entries)
+
+	_, err = s.downloader.DescribeResult(context.Background(), model.PublishedResult{Data: s.result("job.tar.gz")})
+	s.Require().Error(err)
+}
+
+func (s *DownloaderTestSuite) TestDownloadMissingResult() {
+	provider := model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
+		model.StorageSourceS3: s.downloader,
+	})
+	err := downloader.DownloadResults(context.Background(), []model.PublishedResult{{Data: s.result("missing.tar.gz")}}, provider,
+		&model.DownloaderSettings{Timeout: time.Minute, OutputDir: s.outputDir})
+	s.Require().Error(err)
+}
+
+// archive compresses the files the same way as the S3 publisher
+func archive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: ".", Typeflag: tar.TypeDir, Mode: 0755}))
+	dirs := map[string]bool{}
+	for name, content := range files {
+		if dir := filepath.Dir(name); dir != "." && !dirs[dir] {
+			dirs[dir] = true
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}))
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
@@ -6,9 +6,13 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/downloader"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/estuary"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/http"
 	"github.com/bacalhau-project/bacalhau/pkg/downloader/ipfs"
+	"github.com/bacalhau-project/bacalhau/pkg/downloader/s3"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	s3helper "github.com/bacalhau-project/bacalhau/pkg/s3"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/rs/zerolog/log"
 )
 
 func NewDownloadSettings() *model.DownloaderSettings {
@@ -32,9 +36,23 @@ func NewStandardDownloaders(
 	settings *model.DownloaderSettings) downloader.DownloaderProvider {
 	ipfsDownloader := ipfs.NewIPFSDownloader(cm, settings)
 	estuaryDownloader := estuary.NewEstuaryDownloader(cm, settings)
+	urlDownloader := http.NewURLDownloader(settings)
 
-	return model.NewMappedProvider(map[model.StorageSourceType]downloader.Downloader{
-		model.StorageSourceIPFS:    ipfsDownloader,
-		model.StorageSourceEstuary: estuaryDownloader,
-	})
+	downloaders := map[model.StorageSourceType]downloader.Downloader{
+		model.StorageSourceIPFS:        ipfsDownloader,
+		model.StorageSourceEstuary:     estuaryDownloader,
+		model.StorageSourceURLDownload: urlDownloader,
+	}
+
+	awsConfig, err := s3helper.DefaultAWSConfig()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load AWS config, results published to S3 can't be downloaded")
+	} else {
+		downloaders[model.StorageSourceS3] = s3.NewDownloader(s3.DownloaderParams{
+			Settings:       settings,
+			ClientProvider: s3helper.NewClientProvider(s3helper.ClientProviderParams{AWSConfig: awsConfig}),
+		})
+	}
+
+	return model.NewMappedProvider(downloaders)
 }
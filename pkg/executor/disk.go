@@ -0,0 +1,142 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/c2h5oh/datasize"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultDiskQuotaCheckInterval is how often the output volumes of an execution are measured while it runs
+const DefaultDiskQuotaCheckInterval = time.Second
+
+// ErrDiskQuotaExceeded is returned when an execution writes more to its output volumes than the disk requested by the
+// job
+type ErrDiskQuotaExceeded struct {
+	Limit uint64
+	Used  uint64
+}
+
+func NewErrDiskQuotaExceeded(limit, used uint64) ErrDiskQuotaExceeded {
+	return ErrDiskQuotaExceeded{Limit: limit, Used: used}
+}
+
+func (e ErrDiskQuotaExceeded) Error() string {
+	return fmt.Sprintf("outputs exceeded the disk limit of %s with %s written", datasize.ByteSize(e.Limit), datasize.ByteSize(e.Used))
+}
+
+type DiskQuotaParams struct {
+	// ResultsDir is the directory holding the output volumes of the execution
+	ResultsDir string
+	// Outputs are the output volumes of the job
+	Outputs []model.StorageSpec
+	// Limit is the maximum number of bytes the execution can write to its output volumes. No limit if zero.
+	Limit uint64
+	// CheckInterval is how often the output volumes are measured. DefaultDiskQuotaCheckInterval if zero.
+	CheckInterval time.Duration
+}
+
+// DiskQuota enforces the disk limit of an execution on the output volumes it writes to, which are directories on the
+// compute node that would otherwise be able to fill its disk. Executors watch the quota while the execution runs to
+// stop it as soon as the limit is exceeded, and check it once the execution is done to report the disk it used.
+type DiskQuota struct {
+	resultsDir    string
+	outputs       []model.StorageSpec
+	limit         uint64
+	checkInterval time.Duration
+}
+
+func NewDiskQuota(params DiskQuotaParams) *DiskQuota {
+	checkInterval := params.CheckInterval
+	if checkInterval == 0 {
+		checkInterval = DefaultDiskQuotaCheckInterval
+	}
+	return &DiskQuota{
+		resultsDir:    params.ResultsDir,
+		outputs:       params.Outputs,
+		limit:         params.Limit,
+		checkInterval: checkInterval,
+	}
+}
+
+// Watch measures the output volumes periodically until ctx is done, and calls onExceeded once if the execution writes
+// more than the limit. It returns immediately if there is no limit.
+func (q *DiskQuota) Watch(ctx context.Context, onExceeded func(used uint64)) {
+	if q.limit == 0 {
+		return
+	}
+	ticker := time.NewTicker(q.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			used, err := q.Usage()
+			if err != nil {
+				// files can be removed by the execution while they are measured, try again on the next tick
+				log.Ctx(ctx).Debug().Err(err).Msg("failed to measure output volumes")
+				continue
+			}
+			if used > q.limit {
+				onExceeded(used)
+				return
+			}
+		}
+	}
+}
+
+// Check returns the number of bytes written to the output volumes, and an ErrDiskQuotaExceeded if it is above the
+// limit.
+func (q *DiskQuota) Check() (uint64, error) {
+	used, err := q.Usage()
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure output volumes: %w", err)
+	}
+	if q.limit > 0 && used > q.limit {
+		return used, NewErrDiskQuotaExceeded(q.limit, used)
+	}
+	return used, nil
+}
+
+// Usage returns the number of bytes written to the output volumes
+func (q *DiskQuota) Usage() (uint64, error) {
+	var used uint64
+	for _, output := range q.outputs {
+		size, err := dirSize(filepath.Join(q.resultsDir, output.Name))
+		if err != nil {
+			return 0, err
+		}
+		used += size
+	}
+	return used, nil
+}
+
+func dirSize(path string) (uint64, error) {
+	var size uint64
+	err := filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == path {
+				// the volume was not created
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += uint64(info.Size())
+		return nil
+	})
+	return size, err
+}
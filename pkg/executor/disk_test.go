@@ -0,0 +1,70 @@
+//go:build unit || !integration
+
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOutput(t *testing.T, resultsDir, name string, size int) {
+	require.NoError(t, os.MkdirAll(filepath.Join(resultsDir, name, "nested"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(resultsDir, name, "nested", "data"), make([]byte, size), os.ModePerm))
+}
+
+func TestDiskQuotaCheck(t *testing.T) {
+	resultsDir := t.TempDir()
+	writeOutput(t, resultsDir, "outputs", 100)
+	writeOutput(t, resultsDir, "other", 50)
+	// files that are not in an output volume are not counted
+	require.NoError(t, os.WriteFile(filepath.Join(resultsDir, "stdout"), make([]byte, 1000), os.ModePerm))
+	outputs := []model.StorageSpec{{Name: "outputs"}, {Name: "other"}, {Name: "missing"}}
+
+	used, err := NewDiskQuota(DiskQuotaParams{ResultsDir: resultsDir, Outputs: outputs}).Check()
+	require.NoError(t, err)
+	require.Equal(t, uint64(150), used)
+
+	used, err = NewDiskQuota(DiskQuotaParams{ResultsDir: resultsDir, Outputs: outputs, Limit: 150}).Check()
+	require.NoError(t, err)
+	require.Equal(t, uint64(150), used)
+
+	used, err = NewDiskQuota(DiskQuotaParams{ResultsDir: resultsDir, Outputs: outputs, Limit: 149}).Check()
+	require.ErrorIs(t, err, NewErrDiskQuotaExceeded(149, 150))
+	require.Equal(t, uint64(150), used)
+}
+
+func TestDiskQuotaWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resultsDir := t.TempDir()
+	quota := NewDiskQuota(DiskQuotaParams{
+		ResultsDir:    resultsDir,
+		Outputs:       []model.StorageSpec{{Name: "outputs"}},
+		Limit:         10,
+		CheckInterval: 10 * time.Millisecond,
+	})
+
+	exceeded := make(chan uint64, 1)
+	go quota.Watch(ctx, func(used uint64) { exceeded <- used })
+
+	writeOutput(t, resultsDir, "outputs", 5)
+	select {
+	case <-exceeded:
+		require.Fail(t, "quota should not be exceeded")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writeOutput(t, resultsDir, "outputs", 20)
+	select {
+	case used := <-exceeded:
+		require.Equal(t, uint64(20), used)
+	case <-ctx.Done():
+		require.Fail(t, "quota should be exceeded")
+	}
+}
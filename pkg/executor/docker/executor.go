@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
@@ -17,11 +18,13 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	pkgUtil "github.com/bacalhau-project/bacalhau/pkg/util"
 	dockertypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -31,6 +34,9 @@ import (
 
 const NanoCPUCoefficient = 1000000000
 
+// how long to wait for the last resource usage sample of a container once it has stopped
+const usageSamplingTimeout = 2 * time.Second
+
 const (
 	labelExecutorName = "bacalhau-executor"
 	labelJobName      = "bacalhau-jobID"
@@ -42,8 +48,16 @@ type Executor struct {
 	ID string
 	// the storage providers we can implement for a job
 	StorageProvider storage.StorageProvider
-	activeFlags     map[string]chan struct{}
-	client          *docker.Client
+	// hands out the GPU devices requested by jobs. Devices are picked by docker if not set.
+	gpuAllocator capacity.GPUAllocator
+	// decrypts the secrets of jobs, which are then passed to the container as environment variables
+	secrets *encrypted.SecretsDecrypter
+	// isolates the containers from the host
+	sandbox *sandbox
+	// set once docker refuses to limit the size of the writable layer of containers, which then are not limited
+	storageOptUnsupported atomic.Bool
+	activeFlags           map[string]chan struct{}
+	client                *docker.Client
 }
 
 func NewExecutor(
@@ -51,7 +65,15 @@ func NewExecutor(
 	cm *system.CleanupManager,
 	id string,
 	storageProvider storage.StorageProvider,
+	gpuAllocator capacity.GPUAllocator,
+	secrets *encrypted.SecretsDecrypter,
+	sandboxProfile model.SandboxProfile,
 ) (*Executor, error) {
+	sandbox, err := newSandbox(sandboxProfile)
+	if err != nil {
+		return nil, err
+	}
+
 	dockerClient, err := docker.NewDockerClient()
 	if err != nil {
 		return nil, err
@@ -60,6 +82,9 @@ func NewExecutor(
 	de := &Executor{
 		ID:              id,
 		StorageProvider: storageProvider,
+		gpuAllocator:    gpuAllocator,
+		secrets:         secrets,
+		sandbox:         sandbox,
 		client:          dockerClient,
 		activeFlags:     make(map[string]chan struct{}),
 	}
@@ -161,6 +186,12 @@ func (e *Executor) Run(
 		if err != nil {
 			return executor.FailResult(err)
 		}
+		if e.sandbox.profile.IsNonRoot() {
+			// the user the container runs as doesn't own the output directory
+			if err = os.Chmod(srcd, util.OS_ALL_RWX); err != nil {
+				return executor.FailResult(err)
+			}
+		}
 
 		log.Ctx(ctx).Trace().Msgf("Output Volume: %+v", output)
 
@@ -187,17 +218,23 @@ func (e *Executor) Run(
 		}
 	}
 
+	secrets, err := e.secrets.Decrypt(ctx, job.Spec.Secrets)
+	if err != nil {
+		return executor.FailResult(err)
+	}
+
 	// json the job spec and pass it into all containers
 	// TODO: check if this will overwrite a user supplied version of this value
 	// (which is what we actually want to happen)
-	log.Ctx(ctx).Debug().Msgf("Job Spec: %+v", job.Spec)
-	jsonJobSpec, err := model.JSONMarshalWithMax(job.Spec)
+	redactedSpec := job.Spec.Redacted()
+	log.Ctx(ctx).Debug().Msgf("Job Spec: %+v", redactedSpec)
+	jsonJobSpec, err := model.JSONMarshalWithMax(redactedSpec)
 	if err != nil {
 		return executor.FailResult(err)
 	}
 	log.Ctx(ctx).Debug().Msgf("Job Spec JSON: %s", jsonJobSpec)
 
-	useEnv := append(job.Spec.Docker.EnvironmentVariables,
+	useEnv := append(append([]string{}, job.Spec.Docker.EnvironmentVariables...),
 		fmt.Sprintf("BACALHAU_JOB_SPEC=%s", string(jsonJobSpec)),
 	)
 
@@ -212,17 +249,22 @@ func (e *Executor) Run(
 
 	log.Ctx(ctx).Trace().Msgf("Container: %+v %+v", containerConfig, mounts)
 
+	// secrets are added after logging the container config so that their values are never logged
+	for _, name := range job.Spec.Secrets.Names() {
+		containerConfig.Env = append(containerConfig.Env, fmt.Sprintf("%s=%s", name, secrets[name]))
+	}
+
 	resourceRequirements := capacity.ParseResourceUsageConfig(job.Spec.Resources)
 
 	// Create GPU request if the job requests it
 	var deviceRequests []container.DeviceRequest
 	if resourceRequirements.GPU > 0 {
-		deviceRequests = append(deviceRequests,
-			container.DeviceRequest{
-				DeviceIDs:    []string{"0"}, // TODO: how do we know which device ID to use?
-				Capabilities: [][]string{{"gpu"}},
-			},
-		)
+		deviceRequest, err := e.gpuDeviceRequest(ctx, executionID, resourceRequirements.GPU)
+		if err != nil {
+			return executor.FailResult(err)
+		}
+		defer e.releaseGPUs(ctx, executionID)
+		deviceRequests = append(deviceRequests, deviceRequest)
 		log.Ctx(ctx).Trace().Msgf("Adding %d GPUs to request", resourceRequirements.GPU)
 	}
 
@@ -234,6 +276,8 @@ func (e *Executor) Run(
 			DeviceRequests: deviceRequests,
 		},
 	}
+	e.sandbox.apply(containerConfig, hostConfig)
+	limitScratchSpace(hostConfig, resourceRequirements.Disk)
 
 	// Create a network if the job requests it
 	err = e.setupNetworkForJob(ctx, executionID, job, containerConfig, hostConfig)
@@ -241,14 +285,7 @@ func (e *Executor) Run(
 		return executor.FailResult(err)
 	}
 
-	jobContainer, err := e.client.ContainerCreate(
-		ctx,
-		containerConfig,
-		hostConfig,
-		nil,
-		nil,
-		e.containerName(executionID, job),
-	)
+	jobContainer, err := e.createContainer(ctx, containerConfig, hostConfig, e.containerName(executionID, job))
 	if err != nil {
 		return executor.FailResult(errors.Wrap(err, "failed to create container"))
 	}
@@ -272,6 +309,24 @@ func (e *Executor) Run(
 		return executor.FailResult(internalContainerStartError)
 	}
 
+	sampler := e.sampleUsage(ctx, jobContainer.ID)
+
+	// the output volumes are bind mounts on the host, so the disk limit of the job is enforced by stopping the
+	// container as soon as it writes more than the limit to them
+	quota := executor.NewDiskQuota(executor.DiskQuotaParams{
+		ResultsDir: jobResultsDir,
+		Outputs:    job.Spec.Outputs,
+		Limit:      resourceRequirements.Disk,
+	})
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	go quota.Watch(watchCtx, func(used uint64) {
+		log.Ctx(ctx).Warn().Uint64("DiskWritten", used).Msg("Stopping container that exceeded its disk limit")
+		if stopErr := e.client.ContainerStop(ctx, jobContainer.ID, 0); stopErr != nil {
+			log.Ctx(ctx).Error().Err(stopErr).Msg("failed to stop container that exceeded its disk limit")
+		}
+	})
+
 	// the idea here is even if the container errors
 	// we want to capture stdout, stderr and feed it back to the user
 	var containerError error
@@ -290,6 +345,10 @@ func (e *Executor) Run(
 			containerError = errors.New(exitStatus.Error.Message)
 		}
 	}
+	stopWatching()
+	usage := sampler.stop(usageSamplingTimeout)
+	var quotaErr error
+	usage.DiskWritten, quotaErr = quota.Check()
 
 	// Can't use the original context as it may have already been timed out
 	detachedContext, cancel := context.WithTimeout(pkgUtil.NewDetachedContext(ctx), 3*time.Second)
@@ -297,13 +356,39 @@ func (e *Executor) Run(
 	stdoutPipe, stderrPipe, logsErr := e.client.FollowLogs(detachedContext, jobContainer.ID)
 	log.Ctx(detachedContext).Debug().Err(logsErr).Msg("Captured stdout/stderr for container")
 
-	return executor.WriteJobResults(
+	result, err := executor.WriteJobResults(
 		jobResultsDir,
 		stdoutPipe,
 		stderrPipe,
 		int(containerExitStatusCode),
-		multierr.Combine(containerError, logsErr),
+		multierr.Combine(containerError, logsErr, quotaErr),
 	)
+	if result != nil {
+		result.ResourceUsage = &usage
+	}
+	return result, err
+}
+
+// createContainer creates the container, without limiting the size of its writable layer if the storage driver of
+// docker doesn't support it. Its output volumes and tmpfs mounts are still limited then.
+func (e *Executor) createContainer(
+	ctx context.Context,
+	containerConfig *container.Config,
+	hostConfig *container.HostConfig,
+	name string,
+) (container.CreateResponse, error) {
+	if e.storageOptUnsupported.Load() {
+		delete(hostConfig.StorageOpt, storageOptSize)
+	}
+	created, err := e.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, name)
+	if err != nil && hostConfig.StorageOpt[storageOptSize] != "" && isStorageOptUnsupported(err) {
+		log.Ctx(ctx).Warn().Err(err).Msg("docker storage driver can't limit the size of containers. " +
+			"Only the output volumes and tmpfs mounts of jobs are limited to their disk limit")
+		e.storageOptUnsupported.Store(true)
+		delete(hostConfig.StorageOpt, storageOptSize)
+		return e.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, name)
+	}
+	return created, err
 }
 
 func (e *Executor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
@@ -341,6 +426,33 @@ func (e *Executor) GetOutputStream(ctx context.Context, executionID string, with
 	return reader, nil
 }
 
+// gpuDeviceRequest reserves distinct GPU devices for the execution, or lets docker pick them if the executor is not
+// tracking the devices of the node.
+func (e *Executor) gpuDeviceRequest(ctx context.Context, executionID string, count uint64) (container.DeviceRequest, error) {
+	request := container.DeviceRequest{
+		Capabilities: [][]string{{"gpu"}},
+	}
+	if e.gpuAllocator == nil {
+		request.Count = int(count)
+		return request, nil
+	}
+
+	gpus, err := e.gpuAllocator.Allocate(ctx, executionID, count)
+	if err != nil {
+		return container.DeviceRequest{}, err
+	}
+	for _, gpu := range gpus {
+		request.DeviceIDs = append(request.DeviceIDs, strconv.FormatUint(gpu.Index, 10))
+	}
+	return request, nil
+}
+
+func (e *Executor) releaseGPUs(ctx context.Context, executionID string) {
+	if e.gpuAllocator != nil {
+		e.gpuAllocator.Release(ctx, executionID)
+	}
+}
+
 func (e *Executor) cleanupExecution(ctx context.Context, executionID string) {
 	// Use a detached context in case the current one has already been canceled
 	separateCtx, cancel := context.WithTimeout(pkgUtil.NewDetachedContext(ctx), 1*time.Minute)
@@ -361,6 +473,34 @@ func (e *Executor) cleanupExecution(ctx context.Context, executionID string) {
 	log.Ctx(ctx).WithLevel(logLevel).Err(err).Msg("Cleaned up job Docker resources")
 }
 
+// Reconcile removes the containers and networks left behind by executions of a previous run of the node. Their
+// results can't be collected anymore, as the results directories of the previous run are gone.
+func (e *Executor) Reconcile(ctx context.Context) ([]string, error) {
+	if config.ShouldKeepStack() || !e.client.IsInstalled(ctx) {
+		return nil, nil
+	}
+
+	containers, err := e.client.ContainerList(ctx, dockertypes.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", fmt.Sprintf("%s=%s", labelExecutorName, e.ID))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	executionIDs := make([]string, 0, len(containers))
+	for _, ctr := range containers {
+		if executionID, ok := strings.CutPrefix(ctr.Labels[labelExecutionID], e.ID); ok {
+			executionIDs = append(executionIDs, executionID)
+		}
+	}
+
+	err = e.client.RemoveObjectsWithLabel(ctx, labelExecutorName, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	return executionIDs, nil
+}
+
 func (e *Executor) cleanupAll(ctx context.Context) error {
 	// We have to use a detached context, rather than the one passed in to `NewExecutor`, as it may have already been
 	// canceled and so would prevent us from performing any cleanup work.
@@ -404,3 +544,4 @@ func (e *Executor) labelExecutionValue(executionID string) string {
 
 // Compile-time interface check:
 var _ executor.Executor = (*Executor)(nil)
+var _ executor.Reconciler = (*Executor)(nil)
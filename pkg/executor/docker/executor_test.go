@@ -49,6 +49,9 @@ func (s *ExecutorTestSuite) SetupTest() {
 		s.cm,
 		"bacalhau-executor-unittest",
 		model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{}),
+		nil,
+		nil,
+		model.SandboxProfile{},
 	)
 	require.NoError(s.T(), err)
 
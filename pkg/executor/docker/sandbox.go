@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// sandbox applies the sandbox profile of the compute node to the containers of jobs
+type sandbox struct {
+	profile      model.SandboxProfile
+	securityOpts []string
+}
+
+// newSandbox validates the profile and loads the seccomp profile it references,
+// so that a misconfigured node fails on startup rather than on every job.
+func newSandbox(profile model.SandboxProfile) (*sandbox, error) {
+	if err := profile.IsValid(); err != nil {
+		return nil, fmt.Errorf("invalid sandbox profile: %w", err)
+	}
+
+	var securityOpts []string
+	if profile.NoNewPrivileges {
+		securityOpts = append(securityOpts, "no-new-privileges")
+	}
+	if profile.SeccompProfile != "" {
+		data, err := os.ReadFile(profile.SeccompProfile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read seccomp profile: %w", err)
+		}
+		// docker expects the content of the profile rather than its path
+		var compacted bytes.Buffer
+		if err = json.Compact(&compacted, data); err != nil {
+			return nil, fmt.Errorf("invalid seccomp profile %s: %w", profile.SeccompProfile, err)
+		}
+		securityOpts = append(securityOpts, "seccomp="+compacted.String())
+	}
+	if profile.AppArmorProfile != "" {
+		securityOpts = append(securityOpts, "apparmor="+profile.AppArmorProfile)
+	}
+
+	return &sandbox{
+		profile:      profile,
+		securityOpts: securityOpts,
+	}, nil
+}
+
+// apply configures the container to run in the sandbox
+func (s *sandbox) apply(containerConfig *container.Config, hostConfig *container.HostConfig) {
+	containerConfig.User = s.profile.User
+
+	hostConfig.ReadonlyRootfs = s.profile.ReadOnlyRootfs
+	if paths := s.profile.TmpfsPaths(); len(paths) > 0 {
+		hostConfig.Tmpfs = make(map[string]string, len(paths))
+		for _, path := range paths {
+			hostConfig.Tmpfs[path] = ""
+		}
+	}
+	hostConfig.CapDrop = s.profile.CapDrop
+	hostConfig.CapAdd = s.profile.CapAdd
+	hostConfig.SecurityOpt = s.securityOpts
+	hostConfig.Runtime = s.profile.Runtime
+
+	if s.profile.PidsLimit > 0 {
+		pidsLimit := s.profile.PidsLimit
+		hostConfig.Resources.PidsLimit = &pidsLimit
+	}
+	for _, ulimit := range s.profile.Ulimits {
+		hostConfig.Resources.Ulimits = append(hostConfig.Resources.Ulimits, &units.Ulimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+}
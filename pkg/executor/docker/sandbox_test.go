@@ -0,0 +1,78 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-units"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxApply(t *testing.T) {
+	seccompProfile := filepath.Join(t.TempDir(), "seccomp.json")
+	require.NoError(t, os.WriteFile(seccompProfile, []byte(`{
+		"defaultAction": "SCMP_ACT_ERRNO"
+	}`), 0644))
+
+	sandbox, err := newSandbox(model.SandboxProfile{
+		ReadOnlyRootfs:  true,
+		CapDrop:         []string{"ALL"},
+		CapAdd:          []string{"CHOWN"},
+		NoNewPrivileges: true,
+		SeccompProfile:  seccompProfile,
+		AppArmorProfile: "bacalhau-jobs",
+		PidsLimit:       128,
+		Ulimits:         []model.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+		User:            "65534:65534",
+		Runtime:         "runsc",
+	})
+	require.NoError(t, err)
+
+	containerConfig := &container.Config{}
+	hostConfig := &container.HostConfig{}
+	sandbox.apply(containerConfig, hostConfig)
+
+	require.Equal(t, "65534:65534", containerConfig.User)
+	require.True(t, hostConfig.ReadonlyRootfs)
+	require.Equal(t, map[string]string{"/tmp": ""}, hostConfig.Tmpfs)
+	require.Equal(t, strslice.StrSlice{"ALL"}, hostConfig.CapDrop)
+	require.Equal(t, strslice.StrSlice{"CHOWN"}, hostConfig.CapAdd)
+	require.Equal(t, []string{
+		"no-new-privileges",
+		`seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`,
+		"apparmor=bacalhau-jobs",
+	}, hostConfig.SecurityOpt)
+	require.Equal(t, "runsc", hostConfig.Runtime)
+	require.Equal(t, int64(128), *hostConfig.Resources.PidsLimit)
+	require.Equal(t, []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}}, hostConfig.Resources.Ulimits)
+}
+
+func TestSandboxDefaults(t *testing.T) {
+	sandbox, err := newSandbox(model.SandboxProfile{})
+	require.NoError(t, err)
+
+	containerConfig := &container.Config{}
+	hostConfig := &container.HostConfig{}
+	sandbox.apply(containerConfig, hostConfig)
+	require.Equal(t, &container.Config{}, containerConfig)
+	require.Equal(t, &container.HostConfig{}, hostConfig)
+}
+
+func TestSandboxInvalidProfile(t *testing.T) {
+	_, err := newSandbox(model.SandboxProfile{SeccompProfile: filepath.Join(t.TempDir(), "missing.json")})
+	require.Error(t, err)
+
+	invalidProfile := filepath.Join(t.TempDir(), "seccomp.json")
+	require.NoError(t, os.WriteFile(invalidProfile, []byte(`not json`), 0644))
+	_, err = newSandbox(model.SandboxProfile{SeccompProfile: invalidProfile})
+	require.Error(t, err)
+
+	_, err = newSandbox(model.SandboxProfile{Tmpfs: []string{"relative"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package docker
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// storageOptSize is the storage driver option limiting the size of the writable layer of a container
+const storageOptSize = "size"
+
+// limitScratchSpace limits the disk the container can write to outside of its output volumes to the disk limit of the
+// job: its writable layer, which includes /tmp unless it is a tmpfs mount, and each of its tmpfs mounts. The output
+// volumes are bind mounts that are limited by a disk quota instead. Nothing is limited if the job has no disk limit.
+func limitScratchSpace(hostConfig *container.HostConfig, disk uint64) {
+	if disk == 0 {
+		return
+	}
+	size := strconv.FormatUint(disk, 10)
+	if hostConfig.StorageOpt == nil {
+		hostConfig.StorageOpt = make(map[string]string, 1)
+	}
+	hostConfig.StorageOpt[storageOptSize] = size
+	for path, options := range hostConfig.Tmpfs {
+		if options != "" {
+			options += ","
+		}
+		hostConfig.Tmpfs[path] = options + "size=" + size
+	}
+}
+
+// isStorageOptUnsupported returns whether the container could not be created because the storage driver of docker
+// doesn't support limiting the size of containers, such as overlay2 on a filesystem other than xfs with project quotas.
+func isStorageOptUnsupported(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "storage-opt") || strings.Contains(message, "storage opt")
+}
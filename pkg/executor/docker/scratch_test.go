@@ -0,0 +1,29 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitScratchSpace(t *testing.T) {
+	hostConfig := &container.HostConfig{Tmpfs: map[string]string{"/tmp": "", "/run": "noexec"}}
+	limitScratchSpace(hostConfig, 1024)
+	require.Equal(t, map[string]string{"size": "1024"}, hostConfig.StorageOpt)
+	require.Equal(t, map[string]string{"/tmp": "size=1024", "/run": "noexec,size=1024"}, hostConfig.Tmpfs)
+
+	unlimited := &container.HostConfig{Tmpfs: map[string]string{"/tmp": ""}}
+	limitScratchSpace(unlimited, 0)
+	require.Nil(t, unlimited.StorageOpt)
+	require.Equal(t, map[string]string{"/tmp": ""}, unlimited.Tmpfs)
+}
+
+func TestIsStorageOptUnsupported(t *testing.T) {
+	require.True(t, isStorageOptUnsupported(errors.New(
+		"Error response from daemon: --storage-opt is supported only for overlay over xfs with 'pquota' mount option")))
+	require.False(t, isStorageOptUnsupported(errors.New("Error response from daemon: No such image: ubuntu:latest")))
+}
@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/docker/docker/api/types"
+	"github.com/rs/zerolog/log"
+)
+
+// usageSampler samples the resource usage of a container from the stats streamed by docker while it runs. The cpu
+// time and network counters are cumulative, so the last sample holds the totals, while memory is the peak over all
+// samples.
+type usageSampler struct {
+	usage  model.ResourceUsageMetrics
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// sampleUsage starts sampling the usage of the container until it stops
+func (e *Executor) sampleUsage(ctx context.Context, containerID string) *usageSampler {
+	ctx, cancel := context.WithCancel(ctx)
+	sampler := &usageSampler{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(sampler.done)
+		stats, err := e.client.ContainerStats(ctx, containerID, true)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to sample container resource usage")
+			return
+		}
+		defer stats.Close()
+
+		decoder := json.NewDecoder(stats)
+		for {
+			var sample types.StatsJSON
+			if err = decoder.Decode(&sample); err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					log.Ctx(ctx).Debug().Err(err).Msg("stopped sampling container resource usage")
+				}
+				return
+			}
+			sampler.add(sample)
+		}
+	}()
+	return sampler
+}
+
+func (s *usageSampler) add(sample types.StatsJSON) {
+	if sample.Read.IsZero() {
+		// docker sends an empty sample once the container has stopped
+		return
+	}
+	if cpuSeconds := float64(sample.CPUStats.CPUUsage.TotalUsage) / float64(time.Second); cpuSeconds > s.usage.CPUSeconds {
+		s.usage.CPUSeconds = cpuSeconds
+	}
+	// the peak is only reported by cgroup v1, so fall back to the current usage
+	for _, memory := range []uint64{sample.MemoryStats.MaxUsage, sample.MemoryStats.Usage} {
+		if memory > s.usage.PeakMemory {
+			s.usage.PeakMemory = memory
+		}
+	}
+	var rxBytes, txBytes uint64
+	for _, network := range sample.Networks {
+		rxBytes += network.RxBytes
+		txBytes += network.TxBytes
+	}
+	if rxBytes > s.usage.NetworkRxBytes {
+		s.usage.NetworkRxBytes = rxBytes
+	}
+	if txBytes > s.usage.NetworkTxBytes {
+		s.usage.NetworkTxBytes = txBytes
+	}
+}
+
+// stop returns the usage sampled once the container has stopped. Docker ends the stream of stats when the container
+// stops, but sampling is stopped after the timeout if it doesn't.
+func (s *usageSampler) stop(timeout time.Duration) model.ResourceUsageMetrics {
+	select {
+	case <-s.done:
+	case <-time.After(timeout):
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+	return s.usage
+}
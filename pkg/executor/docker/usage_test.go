@@ -0,0 +1,45 @@
+//go:build unit || !integration
+
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/require"
+)
+
+func statsSample(cpu time.Duration, maxMemory, memory uint64, rx, tx uint64) types.StatsJSON {
+	var sample types.StatsJSON
+	sample.Read = time.Now()
+	sample.CPUStats.CPUUsage.TotalUsage = uint64(cpu)
+	sample.MemoryStats.MaxUsage = maxMemory
+	sample.MemoryStats.Usage = memory
+	sample.Networks = map[string]types.NetworkStats{
+		"eth0": {RxBytes: rx, TxBytes: tx},
+		"eth1": {RxBytes: 1, TxBytes: 1},
+	}
+	return sample
+}
+
+func TestUsageSamplerAdd(t *testing.T) {
+	sampler := &usageSampler{}
+	sampler.add(statsSample(time.Second, 0, 100, 10, 20))
+	sampler.add(statsSample(3*time.Second, 0, 300, 30, 40))
+	sampler.add(statsSample(4*time.Second, 0, 200, 50, 60))
+	// the last sample sent once the container has stopped is empty
+	sampler.add(types.StatsJSON{})
+
+	require.Equal(t, model.ResourceUsageMetrics{
+		CPUSeconds:     4,
+		PeakMemory:     300,
+		NetworkRxBytes: 51,
+		NetworkTxBytes: 61,
+	}, sampler.usage)
+
+	// cgroup v1 reports the peak memory
+	sampler.add(statsSample(4*time.Second, 1000, 200, 50, 60))
+	require.Equal(t, uint64(1000), sampler.usage.PeakMemory)
+}
@@ -43,3 +43,11 @@ type Executor interface {
 		resultsDir string,
 	) (*model.RunCommandResult, error)
 }
+
+// Reconciler is implemented by executors that run executions outside of the compute node process, e.g. in containers,
+// which can be left behind when the node stops.
+type Reconciler interface {
+	// Reconcile removes the resources left behind by executions of a previous run of the node, and returns the IDs of
+	// those executions.
+	Reconcile(ctx context.Context) ([]string, error)
+}
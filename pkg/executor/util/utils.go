@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/config"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	"github.com/bacalhau-project/bacalhau/pkg/executor/docker"
@@ -17,6 +18,7 @@ import (
 	s3helper "github.com/bacalhau-project/bacalhau/pkg/s3"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/combo"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
 	filecoinunsealed "github.com/bacalhau-project/bacalhau/pkg/storage/filecoin_unsealed"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
 	ipfs_storage "github.com/bacalhau-project/bacalhau/pkg/storage/ipfs"
@@ -26,6 +28,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/storage/tracing"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/url/urldownload"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
 )
 
 type StandardStorageProviderOptions struct {
@@ -33,11 +36,21 @@ type StandardStorageProviderOptions struct {
 	FilecoinUnsealedPath string
 	DownloadPath         string
 	EstuaryAPIKey        string
+	// NodeID and Decrypter are used to decrypt inputs and secrets that were encrypted by the client for this node.
+	// Encrypted inputs and secrets are not supported if Decrypter is not set.
+	NodeID    string
+	Decrypter verifier.DecrypterFunction
 }
 
 type StandardExecutorOptions struct {
 	DockerID string
 	Storage  StandardStorageProviderOptions
+	// GPUAllocator hands out the GPU devices of the node to docker jobs
+	GPUAllocator capacity.GPUAllocator
+	// WasmModuleCache configures the cache of compiled modules shared by WASM jobs
+	WasmModuleCache wasm.ModuleCacheParams
+	// Sandbox isolates the containers of docker jobs from the host
+	Sandbox model.SandboxProfile
 }
 
 func NewStandardStorageProvider(
@@ -109,7 +122,7 @@ func NewStandardStorageProvider(
 		useIPFSDriver = comboDriver
 	}
 
-	return model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+	storages := map[model.StorageSourceType]storage.Storage{
 		model.StorageSourceIPFS:             tracing.Wrap(useIPFSDriver),
 		model.StorageSourceURLDownload:      tracing.Wrap(urlDownloadStorage),
 		model.StorageSourceFilecoinUnsealed: tracing.Wrap(filecoinUnsealedStorage),
@@ -117,7 +130,14 @@ func NewStandardStorageProvider(
 		model.StorageSourceRepoClone:        tracing.Wrap(repoCloneStorage),
 		model.StorageSourceRepoCloneLFS:     tracing.Wrap(repoCloneStorage),
 		model.StorageSourceS3:               tracing.Wrap(s3Storage),
-	}), nil
+	}
+	if options.Decrypter != nil {
+		storages = encrypted.WrapProvider(storages, encrypted.StorageParams{
+			NodeID:    options.NodeID,
+			Decrypter: options.Decrypter,
+		})
+	}
+	return model.NewMappedProvider(storages), nil
 }
 
 func configureS3StorageProvider(cm *system.CleanupManager) (*s3.StorageProvider, error) {
@@ -166,12 +186,34 @@ func NewStandardExecutorProvider(
 		return nil, err
 	}
 
-	dockerExecutor, err := docker.NewExecutor(ctx, cm, executorOptions.DockerID, storageProvider)
+	var secrets *encrypted.SecretsDecrypter
+	if executorOptions.Storage.Decrypter != nil {
+		secrets = encrypted.NewSecretsDecrypter(encrypted.StorageParams{
+			NodeID:    executorOptions.Storage.NodeID,
+			Decrypter: executorOptions.Storage.Decrypter,
+		})
+	}
+
+	dockerExecutor, err := docker.NewExecutor(
+		ctx,
+		cm,
+		executorOptions.DockerID,
+		storageProvider,
+		executorOptions.GPUAllocator,
+		secrets,
+		executorOptions.Sandbox,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	moduleCache, err := wasm.NewModuleCache(executorOptions.WasmModuleCache)
 	if err != nil {
 		return nil, err
 	}
+	cm.RegisterCallbackWithContext(moduleCache.Close)
 
-	wasmExecutor, err := wasm.NewExecutor(ctx, storageProvider)
+	wasmExecutor, err := wasm.NewExecutor(ctx, storageProvider, moduleCache, secrets)
 	if err != nil {
 		return nil, err
 	}
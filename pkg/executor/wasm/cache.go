@@ -0,0 +1,209 @@
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tetratelabs/wazero"
+)
+
+type ModuleCacheParams struct {
+	// Dir is where compiled modules are persisted. If empty, modules are only shared by concurrent executions.
+	Dir string
+	// MaxSize is the maximum size in bytes of the compiled modules kept in Dir. Unbounded if zero.
+	MaxSize uint64
+}
+
+// ModuleCacheStats counts how often modules were found compiled in the cache.
+type ModuleCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// HitRate returns the fraction of compilations served by the cache.
+func (s ModuleCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ModuleCache is a compilation cache shared across the executions of the WASM executor, so that modules are only
+// compiled once. Compiled modules are persisted by wazero in the cache directory, keyed by the SHA-256 hash of the
+// module, which makes the cache content-addressed: the same module is found compiled whichever CID or URL it was
+// loaded from. The least recently used modules are evicted once the cache grows over its maximum size.
+type ModuleCache struct {
+	cache   wazero.CompilationCache
+	dir     string
+	maxSize uint64
+
+	mu sync.Mutex
+	// wazero keeps compiled modules in memory until they are closed, so the modules in use are also cached
+	inUse   map[string]int
+	modules map[wazero.CompiledModule]string
+	stats   ModuleCacheStats
+}
+
+func NewModuleCache(params ModuleCacheParams) (*ModuleCache, error) {
+	c := &ModuleCache{
+		dir:     params.Dir,
+		maxSize: params.MaxSize,
+		inUse:   make(map[string]int),
+		modules: make(map[wazero.CompiledModule]string),
+	}
+	if params.Dir == "" {
+		c.cache = wazero.NewCompilationCache()
+		return c, nil
+	}
+
+	var err error
+	c.cache, err = wazero.NewCompilationCacheWithDir(params.Dir)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Configure returns a runtime config that compiles modules through the cache.
+func (c *ModuleCache) Configure(config wazero.RuntimeConfig) wazero.RuntimeConfig {
+	return config.WithCompilationCache(c.cache)
+}
+
+// Compile compiles the module using a runtime configured through Configure, and records whether the module was
+// already in the cache. The compiled module must be released once no longer used.
+func (c *ModuleCache) Compile(ctx context.Context, runtime wazero.Runtime, binary []byte) (wazero.CompiledModule, error) {
+	sum := sha256.Sum256(binary)
+	key := hex.EncodeToString(sum[:])
+	hit := c.contains(key)
+
+	module, err := runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inUse[key]++
+	c.modules[module] = key
+	if hit {
+		c.stats.Hits++
+		moduleCacheHits.Add(ctx, 1)
+	} else {
+		c.stats.Misses++
+		moduleCacheMisses.Add(ctx, 1)
+	}
+	log.Ctx(ctx).Debug().Str("Module", key).Bool("Hit", hit).Msg("Compiled WASM module")
+
+	c.touch(key)
+	c.evict(ctx)
+	return module, nil
+}
+
+// Release closes a module returned by Compile, which frees its compiled code from memory once no other execution
+// is using it. The module is kept in the cache directory.
+func (c *ModuleCache) Release(ctx context.Context, module wazero.CompiledModule) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.modules[module]
+	if !ok {
+		return nil
+	}
+	delete(c.modules, module)
+	c.inUse[key]--
+	if c.inUse[key] > 0 {
+		return nil
+	}
+	delete(c.inUse, key)
+	return module.Close(ctx)
+}
+
+// Stats returns how often modules were found compiled in the cache.
+func (c *ModuleCache) Stats() ModuleCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Close releases the compiled modules held in memory. Modules persisted in the cache directory are kept.
+func (c *ModuleCache) Close(ctx context.Context) error {
+	return c.cache.Close(ctx)
+}
+
+func (c *ModuleCache) contains(key string) bool {
+	c.mu.Lock()
+	_, ok := c.inUse[key]
+	c.mu.Unlock()
+	return ok || (c.dir != "" && len(c.files(key)) > 0)
+}
+
+// files returns the compiled files of the module. wazero keeps them in a directory per wazero version and platform.
+func (c *ModuleCache) files(key string) []string {
+	files, _ := filepath.Glob(filepath.Join(c.dir, "*", key))
+	return files
+}
+
+// touch marks the module as recently used, so that it is evicted last
+func (c *ModuleCache) touch(key string) {
+	now := time.Now()
+	for _, file := range c.files(key) {
+		_ = os.Chtimes(file, now, now)
+	}
+}
+
+type cachedFile struct {
+	path    string
+	size    uint64
+	modTime time.Time
+}
+
+// evict removes the least recently used modules until the cache fits its maximum size, keeping the modules in use
+func (c *ModuleCache) evict(ctx context.Context) {
+	if c.dir == "" || c.maxSize == 0 {
+		return
+	}
+
+	var files []cachedFile
+	var totalSize uint64
+	err := filepath.WalkDir(c.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, cachedFile{path: path, size: uint64(info.Size()), modTime: info.ModTime()})
+		totalSize += uint64(info.Size())
+		return nil
+	})
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("Dir", c.dir).Msg("Failed to list WASM module cache")
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files {
+		if totalSize <= c.maxSize {
+			break
+		}
+		if _, ok := c.inUse[filepath.Base(file.path)]; ok {
+			continue
+		}
+		if err = os.Remove(file.path); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("Path", file.path).Msg("Failed to evict WASM module from cache")
+			continue
+		}
+		totalSize -= file.size
+		c.stats.Evictions++
+		moduleCacheEvictions.Add(ctx, 1)
+	}
+}
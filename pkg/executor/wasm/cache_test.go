@@ -0,0 +1,109 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+)
+
+// module returns a WASM module exporting a function returning the passed value, so that each value gives a module
+// with a different hash
+func module(value byte) []byte {
+	return []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic and version
+		0x01, 0x05, 0x01, 0x60, 0x00, 0x01, 0x7f, // type section: () -> i32
+		0x03, 0x02, 0x01, 0x00, // function section
+		0x07, 0x05, 0x01, 0x01, 0x66, 0x00, 0x00, // export section: "f"
+		0x0a, 0x06, 0x01, 0x04, 0x00, 0x41, value & 0x3f, 0x0b, // code section: i32.const value
+	}
+}
+
+// compile compiles the module as an execution would, and returns a function to release it
+func compile(t *testing.T, cache *ModuleCache, binary []byte) func() {
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, cache.Configure(wazero.NewRuntimeConfig()))
+	module, err := cache.Compile(ctx, runtime, binary)
+	require.NoError(t, err)
+	return func() {
+		require.NoError(t, cache.Release(ctx, module))
+		require.NoError(t, runtime.Close(ctx))
+	}
+}
+
+func cachedFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestModuleCacheHits(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewModuleCache(ModuleCacheParams{Dir: dir})
+	require.NoError(t, err)
+
+	compile(t, cache, module(1))()
+	compile(t, cache, module(1))()
+	compile(t, cache, module(2))()
+	require.Equal(t, ModuleCacheStats{Hits: 1, Misses: 2}, cache.Stats())
+	require.InDelta(t, 1.0/3, cache.Stats().HitRate(), 0.001)
+	require.Len(t, cachedFiles(t, dir), 2)
+	require.NoError(t, cache.Close(context.Background()))
+
+	// compiled modules survive restarts
+	cache, err = NewModuleCache(ModuleCacheParams{Dir: dir})
+	require.NoError(t, err)
+	compile(t, cache, module(2))()
+	require.Equal(t, ModuleCacheStats{Hits: 1}, cache.Stats())
+}
+
+func TestModuleCacheInMemory(t *testing.T) {
+	cache, err := NewModuleCache(ModuleCacheParams{})
+	require.NoError(t, err)
+
+	// modules are shared while in use
+	release := compile(t, cache, module(1))
+	compile(t, cache, module(1))()
+	require.Equal(t, ModuleCacheStats{Hits: 1, Misses: 1}, cache.Stats())
+
+	release()
+	compile(t, cache, module(1))()
+	require.Equal(t, ModuleCacheStats{Hits: 1, Misses: 2}, cache.Stats())
+}
+
+func TestModuleCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewModuleCache(ModuleCacheParams{Dir: dir})
+	require.NoError(t, err)
+	compile(t, cache, module(1))()
+	files := cachedFiles(t, dir)
+	require.Len(t, files, 1)
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+
+	// room for two modules
+	cache, err = NewModuleCache(ModuleCacheParams{Dir: dir, MaxSize: uint64(2*info.Size() + info.Size()/2)})
+	require.NoError(t, err)
+	compile(t, cache, module(2))()
+	compile(t, cache, module(1))() // module 2 is now the least recently used
+	compile(t, cache, module(3))()
+	require.Len(t, cachedFiles(t, dir), 2)
+	require.Equal(t, uint64(1), cache.Stats().Evictions)
+
+	compile(t, cache, module(1))()
+	compile(t, cache, module(2))()
+	require.Equal(t, ModuleCacheStats{Hits: 2, Misses: 3, Evictions: 2}, cache.Stats())
+
+	// modules in use are not evicted, even if least recently used
+	release := compile(t, cache, module(1))
+	compile(t, cache, module(2))()
+	compile(t, cache, module(3))()
+	release()
+	compile(t, cache, module(1))()
+	require.Equal(t, ModuleCacheStats{Hits: 5, Misses: 4, Evictions: 3}, cache.Stats())
+}
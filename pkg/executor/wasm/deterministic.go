@@ -0,0 +1,66 @@
+package wasm
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// clockTick is how much the deterministic clock advances each time it is read,
+// so that programs measuring elapsed time see time passing.
+const clockTick = time.Millisecond
+
+// nondeterministicImports are the host functions that expose details of the
+// node, such as file timestamps and inode numbers, which differ between nodes
+// even with a fake clock.
+var nondeterministicImports = map[string]map[string]struct{}{
+	wasi_snapshot_preview1.ModuleName: {
+		"fd_filestat_get":   {},
+		"fd_readdir":        {},
+		"path_filestat_get": {},
+	},
+}
+
+// deterministicClock is a fake clock that starts at the creation time of the
+// job and only advances when read or slept on, so that it returns the same
+// times on every node as long as the program does the same calls.
+type deterministicClock struct {
+	start   time.Time
+	elapsed time.Duration
+}
+
+func (c *deterministicClock) walltime() (sec int64, nsec int32) {
+	c.elapsed += clockTick
+	now := c.start.Add(c.elapsed)
+	return now.Unix(), int32(now.Nanosecond())
+}
+
+func (c *deterministicClock) nanotime() int64 {
+	c.elapsed += clockTick
+	return c.elapsed.Nanoseconds()
+}
+
+func (c *deterministicClock) nanosleep(ns int64) {
+	c.elapsed += time.Duration(ns)
+}
+
+// withDeterministicSys configures the module to use a fake clock and a random
+// source seeded from the job ID, instead of the clock and random source of the
+// host.
+func withDeterministicSys(config wazero.ModuleConfig, job model.Job) wazero.ModuleConfig {
+	clock := &deterministicClock{start: job.Metadata.CreatedAt.UTC()}
+	seed := sha256.Sum256([]byte(job.ID()))
+	random := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[:])))) //nolint:gosec // determinism is the point
+
+	return config.
+		WithWalltime(clock.walltime, sys.ClockResolution(clockTick.Nanoseconds())).
+		WithNanotime(clock.nanotime, sys.ClockResolution(clockTick.Nanoseconds())).
+		WithNanosleep(clock.nanosleep).
+		WithRandSource(random)
+}
@@ -0,0 +1,78 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+)
+
+func TestDeterministicClock(t *testing.T) {
+	start := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	read := func() (walltimes []time.Time, nanotimes []int64) {
+		clock := &deterministicClock{start: start}
+		for i := 0; i < 3; i++ {
+			sec, nsec := clock.walltime()
+			walltimes = append(walltimes, time.Unix(sec, int64(nsec)).UTC())
+			nanotimes = append(nanotimes, clock.nanotime())
+			clock.nanosleep(time.Second.Nanoseconds())
+		}
+		return walltimes, nanotimes
+	}
+
+	walltimes, nanotimes := read()
+	otherWalltimes, otherNanotimes := read()
+	require.Equal(t, walltimes, otherWalltimes)
+	require.Equal(t, nanotimes, otherNanotimes)
+
+	require.Equal(t, start.Add(clockTick), walltimes[0])
+	for i := 1; i < len(walltimes); i++ {
+		require.True(t, walltimes[i].After(walltimes[i-1]))
+		require.Greater(t, nanotimes[i], nanotimes[i-1]+time.Second.Nanoseconds())
+	}
+}
+
+// importingModule returns a WASM module importing a () -> () function with the passed name from WASI
+func importingModule(name string) []byte {
+	module := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic and version
+		0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type section: () -> ()
+	}
+	moduleName := "wasi_snapshot_preview1"
+	entry := append([]byte{byte(len(moduleName))}, moduleName...)
+	entry = append(entry, byte(len(name)))
+	entry = append(entry, name...)
+	entry = append(entry, 0x00, 0x00) // function of type 0
+	module = append(module, 0x02, byte(len(entry)+1), 0x01)
+	return append(module, entry...)
+}
+
+func TestValidateModuleIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+
+	for name, deterministic := range map[string]bool{
+		"fd_write":          true,
+		"random_get":        true,
+		"clock_time_get":    true,
+		"fd_readdir":        false,
+		"fd_filestat_get":   false,
+		"path_filestat_get": false,
+	} {
+		t.Run(name, func(t *testing.T) {
+			module, err := runtime.CompileModule(ctx, importingModule(name))
+			require.NoError(t, err)
+			err = ValidateModuleIsDeterministic(module)
+			if deterministic {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package wasm
+
+import "fmt"
+
+// ErrFuelExhausted is returned when a job runs more instructions than its budget allows.
+type ErrFuelExhausted struct {
+	Budget uint64
+}
+
+func NewErrFuelExhausted(budget uint64) ErrFuelExhausted {
+	return ErrFuelExhausted{Budget: budget}
+}
+
+func (e ErrFuelExhausted) Error() string {
+	return fmt.Sprintf("fuel exhausted: the job ran more than the %d instructions allowed by its CPU and timeout", e.Budget)
+}
@@ -9,12 +9,15 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	wasmlogs "github.com/bacalhau-project/bacalhau/pkg/logger/wasm"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
 	"github.com/bacalhau-project/bacalhau/pkg/storage/util"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
@@ -32,12 +35,24 @@ import (
 
 type Executor struct {
 	StorageProvider storage.StorageProvider
+	ModuleCache     *ModuleCache
 	logManagers     generic.SyncMap[string, *wasmlogs.LogManager]
+	secrets         *encrypted.SecretsDecrypter
 }
 
-func NewExecutor(_ context.Context, storageProvider storage.StorageProvider) (*Executor, error) {
+// NewExecutor returns a WASM executor. If moduleCache is not nil, compiled
+// modules are shared across executions through the cache. The secrets of jobs
+// are decrypted with secrets and passed to the module as environment variables.
+func NewExecutor(
+	_ context.Context,
+	storageProvider storage.StorageProvider,
+	moduleCache *ModuleCache,
+	secrets *encrypted.SecretsDecrypter,
+) (*Executor, error) {
 	return &Executor{
 		StorageProvider: storageProvider,
+		ModuleCache:     moduleCache,
+		secrets:         secrets,
 	}, nil
 }
 
@@ -164,6 +179,10 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		engineConfig = engineConfig.WithMemoryLimitPages(uint32(pageLimit))
 	}
 
+	if e.ModuleCache != nil {
+		engineConfig = e.ModuleCache.Configure(engineConfig)
+	}
+
 	engine := tracedRuntime{wazero.NewRuntimeWithConfig(ctx, engineConfig)}
 	defer closer.ContextCloserWithLogOnError(ctx, "engine", engine)
 
@@ -201,11 +220,16 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		WithStdout(stdout).
 		WithStderr(stderr).
 		WithArgs(args...).
-		WithSysNanosleep().
-		WithSysNanotime().
-		WithSysWalltime().
 		WithFS(rootFs)
 
+	// Deterministic jobs must produce the same outputs on every node, so they
+	// can't see the clock and random source of the host.
+	if job.Spec.Wasm.Deterministic {
+		config = withDeterministicSys(config, job)
+	} else {
+		config = config.WithSysNanosleep().WithSysNanotime().WithSysWalltime()
+	}
+
 	keys := maps.Keys(job.Spec.Wasm.EnvironmentVariables)
 	sort.Strings(keys)
 	for _, key := range keys {
@@ -213,8 +237,35 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		config = config.WithEnv(key, job.Spec.Wasm.EnvironmentVariables[key])
 	}
 
+	secrets, err := e.secrets.Decrypt(ctx, job.Spec.Secrets)
+	if err != nil {
+		return executor.FailResult(err)
+	}
+	for _, name := range job.Spec.Secrets.Names() {
+		config = config.WithEnv(name, secrets[name])
+	}
+
+	// Meter the instructions run by the job if it requested a CPU limit, as
+	// the runtime can't otherwise enforce it. All of its modules consume the
+	// fuel of the same tank.
+	budget := FuelBudget(job)
+	var tank *fuelTank
+	if budget > 0 {
+		if tank, err = newFuelTank(ctx, engine, budget); err != nil {
+			return executor.FailResult(err)
+		}
+	}
+
 	// Load and instantiate imported modules
-	loader := NewModuleLoader(engine, config, e.StorageProvider)
+	loader := NewModuleLoader(ModuleLoaderParams{
+		Runtime:         engine,
+		Config:          config,
+		StorageProvider: e.StorageProvider,
+		Cache:           e.ModuleCache,
+		Deterministic:   job.Spec.Wasm.Deterministic,
+		Metered:         budget > 0,
+	})
+	defer closer.ContextCloserWithLogOnError(ctx, "module loader", loader)
 	for _, importModule := range job.Spec.Wasm.ImportModules {
 		_, ierr := loader.InstantiateRemoteModule(ctx, importModule)
 		err = multierr.Append(err, ierr)
@@ -223,6 +274,10 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 	// Load and instantiate the entry module.
 	instance, err := loader.InstantiateRemoteModule(ctx, job.Spec.Wasm.EntryModule)
 	if err != nil {
+		// the start functions of the modules run when they are instantiated, and can run out of fuel
+		if _, exhausted := tank.consumed(); exhausted {
+			err = NewErrFuelExhausted(budget)
+		}
 		return executor.FailResult(err)
 	}
 
@@ -237,7 +292,33 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		Msg("Running WASM job")
 	entryFunc := instance.ExportedFunction(job.Spec.Wasm.EntryPoint)
 	exitCode := -1
-	_, wasmErr := entryFunc.Call(ctx)
+
+	// the runtime closes the module when the context is done, which stops the job as soon as it writes more than
+	// its disk limit to the output volumes
+	quota := executor.NewDiskQuota(executor.DiskQuotaParams{
+		ResultsDir: jobResultsDir,
+		Outputs:    job.Spec.Outputs,
+		Limit:      capacity.ConvertBytesString(job.Spec.Resources.Disk),
+	})
+	runCtx, stopRun := context.WithCancel(ctx)
+	defer stopRun()
+	go quota.Watch(runCtx, func(used uint64) {
+		log.Ctx(ctx).Warn().Uint64("diskUsed", used).Msg("Stopping WASM job that exceeded its disk limit")
+		stopRun()
+	})
+	started := time.Now()
+	_, wasmErr := entryFunc.Call(runCtx)
+	stopRun()
+
+	// the job runs on a single thread, so its cpu time is the time spent in the call. The memory of modules can only
+	// grow, so their current size is the peak.
+	usage := model.ResourceUsageMetrics{CPUSeconds: time.Since(started).Seconds()}
+	for _, module := range loader.Instances() {
+		// modules without memory return a nil memory that can't be used through the interface
+		if len(module.ExportedMemoryDefinitions()) > 0 {
+			usage.PeakMemory += uint64(module.Memory().Size())
+		}
+	}
 
 	var errExit *sys.ExitError
 	if errors.As(wasmErr, &errExit) {
@@ -245,12 +326,35 @@ func (e *Executor) Run(ctx context.Context, executionID string, job model.Job, j
 		wasmErr = nil
 	}
 
+	fuelConsumed, exhausted := tank.consumed()
+	if exhausted {
+		wasmErr = NewErrFuelExhausted(budget)
+	}
+	var quotaErr error
+	usage.DiskWritten, quotaErr = quota.Check()
+	if quotaErr != nil {
+		wasmErr = quotaErr
+	}
+	if budget > 0 {
+		fuelConsumedCounter.Add(ctx, int64(fuelConsumed))
+		log.Ctx(ctx).Debug().
+			Str("execution", executionID).
+			Uint64("fuelConsumed", fuelConsumed).
+			Uint64("fuelBudget", budget).
+			Msg("WASM job consumed fuel")
+	}
+
 	// execution has finished and there's nothing else to read from so inform
 	// the logs that it is time to drain any remaining items.
 	logs.Drain()
 
 	stdoutReader, stderrReader := logs.GetDefaultReaders(false)
-	return executor.WriteJobResults(jobResultsDir, stdoutReader, stderrReader, exitCode, wasmErr)
+	result, err := executor.WriteJobResults(jobResultsDir, stdoutReader, stderrReader, exitCode, wasmErr)
+	if result != nil {
+		result.FuelConsumed = fuelConsumed
+		result.ResourceUsage = &usage
+	}
+	return result, err
 }
 
 func (e *Executor) GetOutputStream(ctx context.Context, executionID string, withHistory bool, follow bool) (io.ReadCloser, error) {
@@ -0,0 +1,840 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// FuelPerCPUSecond is the number of WASM instructions a job can run per second
+// of its timeout for each CPU it requested.
+const FuelPerCPUSecond = 1_000_000_000
+
+// fuelModuleName is the name of the module holding the fuel of an execution,
+// and fuelGlobalName the name of the global it exports with the fuel left.
+// Metered modules import the global, so that they all consume the same fuel.
+const (
+	fuelModuleName = "bacalhau_fuel"
+	fuelGlobalName = "bacalhau_fuel"
+)
+
+// Sections of a WASM module, in the order they must appear in.
+const (
+	sectionCustom    byte = 0
+	sectionType      byte = 1
+	sectionImport    byte = 2
+	sectionFunction  byte = 3
+	sectionTable     byte = 4
+	sectionMemory    byte = 5
+	sectionGlobal    byte = 6
+	sectionExport    byte = 7
+	sectionStart     byte = 8
+	sectionElement   byte = 9
+	sectionCode      byte = 10
+	sectionData      byte = 11
+	sectionDataCount byte = 12
+	sectionTag       byte = 13
+)
+
+var sectionOrder = map[byte]int{
+	sectionType: 1, sectionImport: 2, sectionFunction: 3, sectionTable: 4, sectionMemory: 5, sectionTag: 6,
+	sectionGlobal: 7, sectionExport: 8, sectionStart: 9, sectionElement: 10, sectionDataCount: 11, sectionCode: 12,
+	sectionData: 13,
+}
+
+// Instructions used or recognised by the metering.
+const (
+	opUnreachable byte = 0x00
+	opBlock       byte = 0x02
+	opLoop        byte = 0x03
+	opIf          byte = 0x04
+	opTry         byte = 0x06
+	opEnd         byte = 0x0b
+	opDelegate    byte = 0x18
+	opGlobalGet   byte = 0x23
+	opGlobalSet   byte = 0x24
+	opI64Const    byte = 0x42
+	opI64LtS      byte = 0x53
+	opI64Sub      byte = 0x7d
+	opMiscPrefix  byte = 0xfc
+	opSIMDPrefix  byte = 0xfd
+	opAtomPrefix  byte = 0xfe
+
+	blockTypeEmpty byte = 0x40
+	valTypeI64     byte = 0x7e
+	exportGlobal   byte = 0x03
+)
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+// FuelBudget returns how many instructions the job can run, derived from the
+// CPU it requested and its timeout. Jobs that don't request CPU or have no
+// timeout are not metered and get a budget of 0.
+func FuelBudget(job model.Job) uint64 {
+	cpu := capacity.ConvertCPUString(job.Spec.Resources.CPU)
+	budget := cpu * job.Spec.GetTimeout().Seconds() * FuelPerCPUSecond
+	if budget <= 0 {
+		return 0
+	}
+	return uint64(math.Min(budget, math.MaxInt64))
+}
+
+// fuelTank holds the fuel shared by the metered modules of an execution.
+type fuelTank struct {
+	budget uint64
+	fuel   api.Global
+}
+
+// newFuelTank instantiates the module holding the fuel of an execution, filled
+// with its budget. It must be instantiated before the metered modules, which
+// consume fuel as soon as they are instantiated if they have a start function.
+func newFuelTank(ctx context.Context, runtime wazero.Runtime, budget uint64) (*fuelTank, error) {
+	module, err := runtime.InstantiateWithConfig(ctx, fuelModule(budget), wazero.NewModuleConfig().WithName(fuelModuleName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate the fuel module: %w", err)
+	}
+	return &fuelTank{budget: budget, fuel: module.ExportedGlobal(fuelGlobalName)}, nil
+}
+
+// consumed returns the fuel consumed by all the modules, and whether they ran out of fuel.
+func (t *fuelTank) consumed() (consumed uint64, exhausted bool) {
+	if t == nil {
+		return 0, false
+	}
+	left := int64(t.fuel.Get())
+	if left < 0 {
+		return t.budget, true
+	}
+	return t.budget - uint64(left), false
+}
+
+// fuelModule returns a module exporting the mutable fuel global, set to the budget.
+func fuelModule(budget uint64) []byte {
+	fuelGlobal := appendSLEB([]byte{valTypeI64, 0x01, opI64Const}, int64(budget))
+	fuelGlobal = append(fuelGlobal, opEnd)
+	fuelExport := append(appendName(nil, fuelGlobalName), exportGlobal, 0x00)
+
+	module := append([]byte{}, wasmHeader...)
+	module = appendSection(module, sectionGlobal, appendVec(nil, 0, fuelGlobal))
+	return appendSection(module, sectionExport, appendVec(nil, 0, fuelExport))
+}
+
+// meterModule instruments the module so that it consumes fuel as it runs, and
+// traps once it runs out. The fuel left is kept in a mutable i64 global
+// imported from the fuel module, which must be instantiated first. The global
+// is imported after the other globals of the module, whose indices are shifted
+// to make room for it.
+//
+// Fuel is consumed at the start of each function for the instructions of the
+// function outside of loops, and at the start of each loop iteration for the
+// instructions of the loop outside of nested loops. Straight-line code is only
+// as long as the module, so this bounds the instructions run by the module
+// without metering each block.
+func meterModule(binary []byte) ([]byte, error) {
+	r := &wasmReader{b: binary}
+	header, err := r.bytes(len(wasmHeader))
+	if err != nil || string(header) != string(wasmHeader) {
+		return nil, fmt.Errorf("not a WASM module")
+	}
+
+	type section struct {
+		id      byte
+		content []byte
+	}
+	var sections []section
+	var importedGlobals uint64
+	for !r.done() {
+		id, size, err := r.sectionHeader()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		if id == sectionImport {
+			if importedGlobals, err = countImportedGlobals(content); err != nil {
+				return nil, err
+			}
+		}
+		sections = append(sections, section{id: id, content: content})
+	}
+
+	fuelIndex := importedGlobals
+	fuelImport := appendName(appendName(nil, fuelModuleName), fuelGlobalName)
+	fuelImport = append(fuelImport, exportGlobal, valTypeI64, 0x01)
+
+	out := append([]byte{}, wasmHeader...)
+	wroteImport := false
+	for _, s := range sections {
+		if !wroteImport && s.id != sectionCustom && sectionOrder[s.id] > sectionOrder[sectionImport] {
+			out = appendSection(out, sectionImport, appendVec(nil, 0, fuelImport))
+			wroteImport = true
+		}
+		content := s.content
+		var err error
+		switch s.id {
+		case sectionImport:
+			content, err = appendToVec(content, fuelImport)
+			wroteImport = true
+		case sectionGlobal:
+			content, err = shiftGlobalSection(content, fuelIndex)
+		case sectionExport:
+			content, err = shiftExportSection(content, fuelIndex)
+		case sectionElement:
+			content, err = shiftElementSection(content, fuelIndex)
+		case sectionData:
+			content, err = shiftDataSection(content, fuelIndex)
+		case sectionCode:
+			content, err = meterCode(content, fuelIndex)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = appendSection(out, s.id, content)
+	}
+	if !wroteImport {
+		out = appendSection(out, sectionImport, appendVec(nil, 0, fuelImport))
+	}
+	return out, nil
+}
+
+// countImportedGlobals returns the number of globals imported by the module. Modules importing
+// fuelGlobalName or from fuelModuleName are rejected, as they could reset the fuel of the execution.
+func countImportedGlobals(content []byte) (globals uint64, err error) {
+	r := &wasmReader{b: content}
+	count, err := r.uleb()
+	if err != nil {
+		return 0, err
+	}
+	for i := uint64(0); i < count; i++ {
+		module, err := r.name()
+		if err != nil {
+			return 0, err
+		}
+		name, err := r.name()
+		if err != nil {
+			return 0, err
+		}
+		if name == fuelGlobalName || module == fuelModuleName {
+			return 0, fmt.Errorf("module imports %s", fuelGlobalName)
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0x00: // function
+			err = r.skipLEB()
+		case 0x01: // table
+			if _, err = r.byte(); err == nil {
+				err = r.skipLimits()
+			}
+		case 0x02: // memory
+			err = r.skipLimits()
+		case 0x03: // global
+			globals++
+			_, err = r.bytes(2) //nolint:gomnd // value type and mutability
+		case 0x04: // tag
+			if _, err = r.byte(); err == nil {
+				err = r.skipLEB()
+			}
+		default:
+			err = fmt.Errorf("unknown import kind %#x", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return globals, nil
+}
+
+// shiftGlobals copies an expression, up to and including its final end, and increments the indices of the globals
+// from the passed index.
+func shiftGlobals(r *wasmReader, out []byte, from uint64) ([]byte, error) {
+	for depth := 1; depth > 0; {
+		start := r.pos
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if op == opGlobalGet || op == opGlobalSet {
+			index, err := r.uleb()
+			if err != nil {
+				return nil, err
+			}
+			if index >= from {
+				index++
+			}
+			out = appendULEB(append(out, op), index)
+			continue
+		}
+		if err = r.skipImmediates(op); err != nil {
+			return nil, err
+		}
+		out = append(out, r.b[start:r.pos]...)
+		switch op {
+		case opBlock, opLoop, opIf, opTry:
+			depth++
+		case opEnd, opDelegate:
+			depth--
+		}
+	}
+	return out, nil
+}
+
+func shiftGlobalSection(content []byte, from uint64) ([]byte, error) {
+	r := &wasmReader{b: content}
+	count, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	out := appendULEB(nil, count)
+	for i := uint64(0); i < count; i++ {
+		globalType, err := r.bytes(2) //nolint:gomnd // value type and mutability
+		if err != nil {
+			return nil, err
+		}
+		if out, err = shiftGlobals(r, append(out, globalType...), from); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func shiftExportSection(content []byte, from uint64) ([]byte, error) {
+	r := &wasmReader{b: content}
+	count, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	out := appendULEB(nil, count)
+	for i := uint64(0); i < count; i++ {
+		start := r.pos
+		if err = r.skipName(); err != nil {
+			return nil, err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, content[start:r.pos]...)
+		index, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		if kind == exportGlobal && index >= from {
+			index++
+		}
+		out = appendULEB(out, index)
+	}
+	return out, nil
+}
+
+//nolint:gomnd // follows the encoding of element segments in the WASM specification
+func shiftElementSection(content []byte, from uint64) ([]byte, error) {
+	r := &wasmReader{b: content}
+	count, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	out := appendULEB(nil, count)
+	for i := uint64(0); i < count; i++ {
+		flags, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		out = appendULEB(out, flags)
+		// active segments have an offset, and a table index if the second bit is set
+		if flags&0x01 == 0 {
+			if flags&0x02 != 0 {
+				if out, err = r.copyLEB(out); err != nil {
+					return nil, err
+				}
+			}
+			if out, err = shiftGlobals(r, out, from); err != nil {
+				return nil, err
+			}
+		}
+		// all segments but the legacy active ones have an element kind or reference type
+		if flags&0x03 != 0 {
+			if out, err = r.copyBytes(out, 1); err != nil {
+				return nil, err
+			}
+		}
+		elements, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		out = appendULEB(out, elements)
+		for j := uint64(0); j < elements; j++ {
+			// the third bit is set when elements are expressions rather than function indices
+			if flags&0x04 != 0 {
+				out, err = shiftGlobals(r, out, from)
+			} else {
+				out, err = r.copyLEB(out)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+//nolint:gomnd // follows the encoding of data segments in the WASM specification
+func shiftDataSection(content []byte, from uint64) ([]byte, error) {
+	r := &wasmReader{b: content}
+	count, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	out := appendULEB(nil, count)
+	for i := uint64(0); i < count; i++ {
+		flags, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		out = appendULEB(out, flags)
+		// active segments have an offset, and a memory index if they are of kind 2, while passive ones are of kind 1
+		if flags == 0x02 {
+			if out, err = r.copyLEB(out); err != nil {
+				return nil, err
+			}
+		}
+		if flags != 0x01 {
+			if out, err = shiftGlobals(r, out, from); err != nil {
+				return nil, err
+			}
+		}
+		size, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		if out, err = r.copyBytes(appendULEB(out, size), int(size)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func meterCode(content []byte, fuelIndex uint64) ([]byte, error) {
+	r := &wasmReader{b: content}
+	count, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	out := appendULEB(nil, count)
+	for i := uint64(0); i < count; i++ {
+		size, err := r.uleb()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		metered, err := meterFunction(body, fuelIndex)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		out = appendULEB(out, uint64(len(metered)))
+		out = append(out, metered...)
+	}
+	return out, nil
+}
+
+// meterPoint is where fuel is consumed in a function, and how much.
+type meterPoint struct {
+	offset int
+	cost   uint64
+}
+
+func meterFunction(body []byte, fuelIndex uint64) ([]byte, error) {
+	r := &wasmReader{b: body}
+	locals, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < locals; i++ {
+		if err = r.skipLEB(); err != nil {
+			return nil, err
+		}
+		if _, err = r.byte(); err != nil {
+			return nil, err
+		}
+	}
+	start := r.pos
+	body, err = shiftGlobals(r, append([]byte{}, body[:start]...), fuelIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("unexpected instructions after the end of the function")
+	}
+	r = &wasmReader{b: body, pos: start}
+
+	points := []*meterPoint{{offset: r.pos}}
+	// the meter points of the function and of the loops being decoded
+	scopes := []*meterPoint{points[0]}
+	// whether each of the blocks being decoded is a loop, starting with the function itself
+	blocks := []bool{false}
+	for len(blocks) > 0 {
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		scopes[len(scopes)-1].cost++
+		if err = r.skipImmediates(op); err != nil {
+			return nil, err
+		}
+		switch op {
+		case opBlock, opIf, opTry:
+			blocks = append(blocks, false)
+		case opLoop:
+			blocks = append(blocks, true)
+			point := &meterPoint{offset: r.pos}
+			points = append(points, point)
+			scopes = append(scopes, point)
+		case opEnd, opDelegate:
+			if blocks[len(blocks)-1] {
+				scopes = scopes[:len(scopes)-1]
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+
+	out := make([]byte, 0, len(body)+len(points)*24) //nolint:gomnd // approximate size of the metering instructions
+	previous := 0
+	for _, point := range points {
+		out = append(out, body[previous:point.offset]...)
+		out = appendConsumeFuel(out, fuelIndex, point.cost)
+		previous = point.offset
+	}
+	return append(out, body[previous:]...), nil
+}
+
+// appendConsumeFuel appends instructions subtracting cost from the fuel, and trapping if there isn't enough left
+func appendConsumeFuel(out []byte, fuelIndex, cost uint64) []byte {
+	out = appendULEB(append(out, opGlobalGet), fuelIndex)
+	out = appendSLEB(append(out, opI64Const), int64(cost))
+	out = append(out, opI64Sub)
+	out = appendULEB(append(out, opGlobalSet), fuelIndex)
+	out = appendULEB(append(out, opGlobalGet), fuelIndex)
+	out = append(out, opI64Const, 0x00, opI64LtS, opIf, blockTypeEmpty, opUnreachable, opEnd)
+	return out
+}
+
+// wasmReader decodes the binary format of WASM modules.
+type wasmReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *wasmReader) done() bool {
+	return r.pos >= len(r.b)
+}
+
+func (r *wasmReader) byte() (byte, error) {
+	if r.done() {
+		return 0, fmt.Errorf("unexpected end of WASM module")
+	}
+	r.pos++
+	return r.b[r.pos-1], nil
+}
+
+func (r *wasmReader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, fmt.Errorf("unexpected end of WASM module")
+	}
+	r.pos += n
+	return r.b[r.pos-n : r.pos], nil
+}
+
+func (r *wasmReader) uleb() (value uint64, err error) {
+	for shift := 0; shift < 64; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid LEB128 integer in WASM module")
+}
+
+// copyLEB copies a signed or unsigned LEB128 integer to out.
+func (r *wasmReader) copyLEB(out []byte) ([]byte, error) {
+	start := r.pos
+	if err := r.skipLEB(); err != nil {
+		return nil, err
+	}
+	return append(out, r.b[start:r.pos]...), nil
+}
+
+func (r *wasmReader) copyBytes(out []byte, n int) ([]byte, error) {
+	b, err := r.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, b...), nil
+}
+
+// skipLEB skips a signed or unsigned LEB128 integer.
+func (r *wasmReader) skipLEB() error {
+	_, err := r.uleb()
+	return err
+}
+
+func (r *wasmReader) sectionHeader() (id byte, size uint64, err error) {
+	if id, err = r.byte(); err != nil {
+		return 0, 0, err
+	}
+	size, err = r.uleb()
+	return id, size, err
+}
+
+func (r *wasmReader) name() (string, error) {
+	size, err := r.uleb()
+	if err != nil {
+		return "", err
+	}
+	name, err := r.bytes(int(size))
+	return string(name), err
+}
+
+func (r *wasmReader) skipName() error {
+	_, err := r.name()
+	return err
+}
+
+func (r *wasmReader) skipLimits() error {
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if err = r.skipLEB(); err != nil {
+		return err
+	}
+	if flags&0x01 != 0 {
+		return r.skipLEB()
+	}
+	return nil
+}
+
+func (r *wasmReader) skipMemArg() error {
+	if err := r.skipLEB(); err != nil {
+		return err
+	}
+	return r.skipLEB()
+}
+
+func (r *wasmReader) skipBlockType() error {
+	b, err := r.byte()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case blockTypeEmpty, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f:
+		return nil
+	default:
+		// the index of a function type, as a signed LEB128 integer
+		r.pos--
+		return r.skipLEB()
+	}
+}
+
+// skipImmediates skips the immediate arguments of the instruction.
+//
+//nolint:gocyclo,gomnd // follows the opcode table of the WASM specification
+func (r *wasmReader) skipImmediates(op byte) error {
+	switch {
+	case op == opBlock || op == opLoop || op == opIf || op == opTry:
+		return r.skipBlockType()
+	case op == 0x07 || op == 0x08 || op == 0x09 || op == opDelegate: // catch, throw, rethrow, delegate
+		return r.skipLEB()
+	case op == 0x0c || op == 0x0d: // br, br_if
+		return r.skipLEB()
+	case op == 0x0e: // br_table
+		count, err := r.uleb()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i <= count; i++ {
+			if err = r.skipLEB(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case op == 0x10 || op == 0x12: // call, return_call
+		return r.skipLEB()
+	case op == 0x11 || op == 0x13: // call_indirect, return_call_indirect
+		if err := r.skipLEB(); err != nil {
+			return err
+		}
+		return r.skipLEB()
+	case op == 0x1c: // select with types
+		count, err := r.uleb()
+		if err != nil {
+			return err
+		}
+		_, err = r.bytes(int(count))
+		return err
+	case op >= 0x20 && op <= 0x26: // local, global and table variables
+		return r.skipLEB()
+	case op >= 0x28 && op <= 0x3e: // loads and stores
+		return r.skipMemArg()
+	case op == 0x3f || op == 0x40: // memory.size, memory.grow
+		_, err := r.byte()
+		return err
+	case op == 0x41 || op == opI64Const:
+		return r.skipLEB()
+	case op == 0x43: // f32.const
+		_, err := r.bytes(4)
+		return err
+	case op == 0x44: // f64.const
+		_, err := r.bytes(8)
+		return err
+	case op == 0xd0: // ref.null
+		_, err := r.byte()
+		return err
+	case op == 0xd2: // ref.func
+		return r.skipLEB()
+	case op == opMiscPrefix:
+		return r.skipMiscImmediates()
+	case op == opSIMDPrefix:
+		return r.skipSIMDImmediates()
+	case op == opAtomPrefix:
+		return r.skipAtomicImmediates()
+	case op <= 0x0f || op == 0x19 || op == 0x1a || op == 0x1b || (op >= 0x45 && op <= 0xc4) || op == 0xd1:
+		return nil
+	default:
+		return fmt.Errorf("unknown instruction %#x", op)
+	}
+}
+
+//nolint:gomnd // follows the opcode table of the WASM specification
+func (r *wasmReader) skipMiscImmediates() error {
+	op, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	switch {
+	case op <= 7: // saturating truncations
+		return nil
+	case op == 8: // memory.init
+		if err = r.skipLEB(); err != nil {
+			return err
+		}
+		_, err = r.byte()
+		return err
+	case op == 9 || op == 13 || (op >= 15 && op <= 17): // data.drop, elem.drop, table.grow, table.size, table.fill
+		return r.skipLEB()
+	case op == 10: // memory.copy
+		_, err = r.bytes(2)
+		return err
+	case op == 11: // memory.fill
+		_, err = r.byte()
+		return err
+	case op == 12 || op == 14: // table.init, table.copy
+		if err = r.skipLEB(); err != nil {
+			return err
+		}
+		return r.skipLEB()
+	default:
+		return fmt.Errorf("unknown instruction 0xfc %d", op)
+	}
+}
+
+//nolint:gomnd // follows the opcode table of the WASM specification
+func (r *wasmReader) skipSIMDImmediates() error {
+	op, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	switch {
+	case op <= 11 || op == 92 || op == 93: // loads and stores
+		return r.skipMemArg()
+	case op == 12 || op == 13: // v128.const, i8x16.shuffle
+		_, err = r.bytes(16)
+		return err
+	case op >= 21 && op <= 34: // lane extraction and replacement
+		_, err = r.byte()
+		return err
+	case op >= 84 && op <= 91: // lane loads and stores
+		if err = r.skipMemArg(); err != nil {
+			return err
+		}
+		_, err = r.byte()
+		return err
+	default:
+		return nil
+	}
+}
+
+func (r *wasmReader) skipAtomicImmediates() error {
+	op, err := r.uleb()
+	if err != nil {
+		return err
+	}
+	if op == 0x03 { // atomic.fence
+		_, err = r.byte()
+		return err
+	}
+	return r.skipMemArg()
+}
+
+func appendULEB(out []byte, value uint64) []byte {
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendSLEB(out []byte, value int64) []byte {
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendName(out []byte, name string) []byte {
+	return append(appendULEB(out, uint64(len(name))), name...)
+}
+
+func appendSection(out []byte, id byte, content []byte) []byte {
+	out = appendULEB(append(out, id), uint64(len(content)))
+	return append(out, content...)
+}
+
+// appendVec returns a vector of count+1 entries, made of the encoded entries followed by entry
+func appendVec(entries []byte, count uint64, entry []byte) []byte {
+	out := appendULEB(nil, count+1)
+	out = append(out, entries...)
+	return append(out, entry...)
+}
+
+// appendToVec appends an encoded entry to an encoded vector
+func appendToVec(vec []byte, entry []byte) ([]byte, error) {
+	r := &wasmReader{b: vec}
+	count, err := r.uleb()
+	if err != nil {
+		return nil, err
+	}
+	return appendVec(vec[r.pos:], count, entry), nil
+}
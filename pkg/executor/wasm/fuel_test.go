@@ -0,0 +1,201 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/vincent-petithory/dataurl"
+)
+
+// infiniteLoop is a WASM module exporting a _start function that never returns
+var infiniteLoop = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, // magic and version
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type section: () -> ()
+	0x03, 0x02, 0x01, 0x00, // function section
+	0x07, 0x0a, 0x01, 0x06, '_', 's', 't', 'a', 'r', 't', 0x00, 0x00, // export section: "_start"
+	0x0a, 0x09, 0x01, 0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b, // code section: loop br 0 end
+}
+
+func TestFuelBudget(t *testing.T) {
+	for _, test := range []struct {
+		cpu     string
+		timeout float64
+		budget  uint64
+	}{
+		{cpu: "", timeout: 10, budget: 0},
+		{cpu: "1", timeout: 0, budget: 0},
+		{cpu: "1", timeout: 10, budget: 10 * FuelPerCPUSecond},
+		{cpu: "500m", timeout: 2, budget: FuelPerCPUSecond},
+	} {
+		job := model.Job{Spec: model.Spec{Resources: model.ResourceUsageConfig{CPU: test.cpu}, Timeout: test.timeout}}
+		require.Equal(t, test.budget, FuelBudget(job), "cpu %q timeout %v", test.cpu, test.timeout)
+	}
+}
+
+func TestMeterModule(t *testing.T) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+	_, err := wasi_snapshot_preview1.Instantiate(ctx, runtime)
+	require.NoError(t, err)
+
+	modules, err := filepath.Glob("../../../testdata/wasm/*/main.wasm")
+	require.NoError(t, err)
+	require.NotEmpty(t, modules)
+	modules = append(modules, "") // a module without globals or exports
+	for _, path := range modules {
+		t.Run(path, func(t *testing.T) {
+			binary := importingModule("fd_write")
+			if path != "" {
+				binary, err = os.ReadFile(path)
+				require.NoError(t, err)
+			}
+			metered, err := meterModule(binary)
+			require.NoError(t, err)
+
+			module, err := runtime.CompileModule(ctx, metered)
+			require.NoError(t, err)
+			require.NoError(t, module.Close(ctx))
+		})
+	}
+
+	// modules are run as usual with enough fuel
+	tank, err := newFuelTank(ctx, runtime, FuelPerCPUSecond)
+	require.NoError(t, err)
+	metered, err := meterModule(readTestModule(t, "noop"))
+	require.NoError(t, err)
+	instance, err := runtime.InstantiateWithConfig(ctx, metered, wazero.NewModuleConfig().WithStartFunctions())
+	require.NoError(t, err)
+	_, err = instance.ExportedFunction("_start").Call(ctx)
+	require.ErrorContains(t, err, "exit_code(0)")
+	consumed, exhausted := tank.consumed()
+	require.False(t, exhausted)
+	require.Greater(t, consumed, uint64(0))
+	require.Less(t, consumed, uint64(FuelPerCPUSecond))
+}
+
+func TestMeterModuleExhaustsFuel(t *testing.T) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+
+	tank, err := newFuelTank(ctx, runtime, 1000)
+	require.NoError(t, err)
+	metered, err := meterModule(infiniteLoop)
+	require.NoError(t, err)
+	var instances []api.Module
+	for _, name := range []string{"first", "second"} {
+		instance, err := runtime.InstantiateWithConfig(ctx, metered, wazero.NewModuleConfig().WithName(name).WithStartFunctions())
+		require.NoError(t, err)
+		instances = append(instances, instance)
+	}
+
+	_, err = instances[0].ExportedFunction("_start").Call(ctx)
+	require.Error(t, err)
+	consumed, exhausted := tank.consumed()
+	require.True(t, exhausted)
+	require.Equal(t, uint64(1000), consumed)
+
+	// the modules of an execution share the same budget, so the second one has no fuel left
+	_, err = instances[1].ExportedFunction("_start").Call(ctx)
+	require.Error(t, err)
+	consumed, _ = tank.consumed()
+	require.Equal(t, uint64(1000), consumed)
+
+	_, err = meterModule(metered)
+	require.Error(t, err, "modules can't be metered twice")
+}
+
+func TestMeterModuleWithStartSection(t *testing.T) {
+	// a module whose start section runs a function of three instructions when it is instantiated
+	module := append([]byte{}, wasmHeader...)
+	module = appendSection(module, sectionType, []byte{0x01, 0x60, 0x00, 0x00})
+	module = appendSection(module, sectionFunction, []byte{0x01, 0x00})
+	module = appendSection(module, sectionStart, []byte{0x00})
+	module = appendSection(module, sectionCode, appendVec(nil, 0, []byte{0x04, 0x00, 0x01, 0x01, opEnd}))
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+	tank, err := newFuelTank(ctx, runtime, 1000)
+	require.NoError(t, err)
+	metered, err := meterModule(module)
+	require.NoError(t, err)
+	_, err = runtime.InstantiateWithConfig(ctx, metered, wazero.NewModuleConfig().WithStartFunctions())
+	require.NoError(t, err)
+
+	consumed, exhausted := tank.consumed()
+	require.False(t, exhausted)
+	require.Equal(t, uint64(3), consumed)
+}
+
+func TestMeterModuleRejectsFuelImport(t *testing.T) {
+	// a module importing the fuel global of the "main" module and setting it to the maximum
+	fuelImport := appendName(appendName(nil, "main"), fuelGlobalName)
+	fuelImport = append(fuelImport, exportGlobal, valTypeI64, 0x01)
+	resetFuel := appendSLEB([]byte{0x00, opI64Const}, math.MaxInt64)
+	resetFuel = append(resetFuel, opGlobalSet, 0x00, opEnd)
+
+	module := append([]byte{}, wasmHeader...)
+	module = appendSection(module, sectionType, []byte{0x01, 0x60, 0x00, 0x00})
+	module = appendSection(module, sectionImport, appendVec(nil, 0, fuelImport))
+	module = appendSection(module, sectionFunction, []byte{0x01, 0x00})
+	module = appendSection(module, sectionExport, appendVec(nil, 0, append(appendName(nil, "_start"), 0x00, 0x00)))
+	module = appendSection(module, sectionCode, appendVec(nil, 0, append(appendULEB(nil, uint64(len(resetFuel))), resetFuel...)))
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntime(ctx)
+	defer runtime.Close(ctx)
+	_, err := runtime.CompileModule(ctx, module)
+	require.NoError(t, err)
+
+	_, err = meterModule(module)
+	require.ErrorContains(t, err, "module imports "+fuelGlobalName)
+}
+
+func TestExecutorFuelExhausted(t *testing.T) {
+	ctx := context.Background()
+	provider := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceInline: inline.NewStorage(),
+	})
+	e, err := NewExecutor(ctx, provider, nil, nil)
+	require.NoError(t, err)
+
+	job := model.Job{
+		Metadata: model.Metadata{ID: "fuel"},
+		Spec: model.Spec{
+			Engine:    model.EngineWasm,
+			Resources: model.ResourceUsageConfig{CPU: "1m"},
+			Timeout:   1,
+			Wasm: model.JobSpecWasm{
+				EntryModule: model.StorageSpec{
+					StorageSource: model.StorageSourceInline,
+					URL:           dataurl.EncodeBytes(infiniteLoop),
+				},
+				EntryPoint: "_start",
+			},
+		},
+	}
+	result, err := e.Run(ctx, "execution", job, t.TempDir())
+	require.ErrorAs(t, err, &ErrFuelExhausted{})
+	require.Contains(t, result.ErrorMsg, "fuel exhausted")
+	require.Equal(t, FuelBudget(job), result.FuelConsumed)
+}
+
+func readTestModule(t *testing.T, name string) []byte {
+	binary, err := os.ReadFile(filepath.Join("../../../testdata/wasm", name, "main.wasm"))
+	require.NoError(t, err)
+	return binary
+}
@@ -26,17 +26,46 @@ import (
 // ModuleLoader handles the loading of WebAssembly modules from remote storage
 // and the automatic resolution of required imports.
 type ModuleLoader struct {
-	runtime  wazero.Runtime
-	config   wazero.ModuleConfig
-	provider storage.StorageProvider
+	runtime       wazero.Runtime
+	config        wazero.ModuleConfig
+	provider      storage.StorageProvider
+	cache         *ModuleCache
+	deterministic bool
+	metered       bool
+	// modules instantiated from remote storage
+	instances []api.Module
+	// modules compiled through the cache, which must be released once the loader is no longer used
+	compiled []wazero.CompiledModule
 
 	// Runtime will throw an error if the same module is instantiated more than
 	// once. So we use this mutex around checking for modules and instantiating
 	mtx sync.Mutex
 }
 
-func NewModuleLoader(runtime wazero.Runtime, config wazero.ModuleConfig, proivder storage.StorageProvider) *ModuleLoader {
-	return &ModuleLoader{runtime: runtime, config: config, provider: proivder}
+type ModuleLoaderParams struct {
+	Runtime         wazero.Runtime
+	Config          wazero.ModuleConfig
+	StorageProvider storage.StorageProvider
+	// Cache compiles modules through a shared cache if not nil. The runtime
+	// must have been configured with it.
+	Cache *ModuleCache
+	// Deterministic refuses to load modules importing host functions whose
+	// results can differ between nodes.
+	Deterministic bool
+	// Metered instruments modules to consume fuel as they run. The fuel tank
+	// of the execution must be instantiated in the runtime before any module.
+	Metered bool
+}
+
+func NewModuleLoader(params ModuleLoaderParams) *ModuleLoader {
+	return &ModuleLoader{
+		runtime:       params.Runtime,
+		config:        params.Config,
+		provider:      params.StorageProvider,
+		cache:         params.Cache,
+		deterministic: params.Deterministic,
+		metered:       params.Metered,
+	}
 }
 
 // Load comiples and returns a module located at the passed path.
@@ -51,6 +80,24 @@ func (loader *ModuleLoader) Load(ctx context.Context, path string) (wazero.Compi
 		return nil, err
 	}
 
+	if loader.metered {
+		bytes, err = meterModule(bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to meter WASM module %s: %w", path, err)
+		}
+	}
+
+	if loader.cache != nil {
+		module, err := loader.cache.Compile(ctx, loader.runtime, bytes)
+		if err != nil {
+			return nil, err
+		}
+		loader.mtx.Lock()
+		loader.compiled = append(loader.compiled, module)
+		loader.mtx.Unlock()
+		return module, nil
+	}
+
 	module, err := loader.runtime.CompileModule(ctx, bytes)
 	if err != nil {
 		return nil, err
@@ -59,6 +106,18 @@ func (loader *ModuleLoader) Load(ctx context.Context, path string) (wazero.Compi
 	return module, nil
 }
 
+// Close releases the modules compiled through the cache. Modules compiled
+// without a cache are released when their runtime is closed.
+func (loader *ModuleLoader) Close(ctx context.Context) (err error) {
+	loader.mtx.Lock()
+	defer loader.mtx.Unlock()
+	for _, module := range loader.compiled {
+		err = multierr.Append(err, loader.cache.Release(ctx, module))
+	}
+	loader.compiled = nil
+	return err
+}
+
 // LoadRemoteModules loads and compiles all of the modules located by the passed storage specs.
 func (loader *ModuleLoader) LoadRemoteModules(ctx context.Context, specs ...model.StorageSpec) ([]wazero.CompiledModule, error) {
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/executor/wasm.ModuleLoader.LoadRemoteModules")
@@ -121,6 +180,9 @@ func (loader *ModuleLoader) InstantiateRemoteModule(ctx context.Context, spec mo
 	span.SetAttributes(attribute.String("ModuleName", spec.Name))
 	defer span.End()
 
+	if loader.metered && spec.Name == fuelModuleName {
+		return nil, fmt.Errorf("module name %s is reserved", fuelModuleName)
+	}
 	if module := loader.runtime.Module(spec.Name); module != nil {
 		// Module already instantiated.
 		return module, nil
@@ -133,6 +195,12 @@ func (loader *ModuleLoader) InstantiateRemoteModule(ctx context.Context, spec mo
 	}
 	module := modules[0]
 
+	if loader.deterministic {
+		if err = ValidateModuleIsDeterministic(module); err != nil {
+			return nil, err
+		}
+	}
+
 	// Examine its imports and recursively load them.
 	var wg multierrgroup.Group
 	for _, importedFunc := range module.ImportedFunctions() {
@@ -153,7 +221,19 @@ func (loader *ModuleLoader) InstantiateRemoteModule(ctx context.Context, spec mo
 	if module := loader.runtime.Module(spec.Name); module != nil {
 		return module, nil
 	}
-	return loader.runtime.InstantiateModule(ctx, module, loader.config.WithName(spec.Name))
+	instance, err := loader.runtime.InstantiateModule(ctx, module, loader.config.WithName(spec.Name))
+	if err != nil {
+		return nil, err
+	}
+	loader.instances = append(loader.instances, instance)
+	return instance, nil
+}
+
+// Instances returns the modules instantiated from remote storage.
+func (loader *ModuleLoader) Instances() []api.Module {
+	loader.mtx.Lock()
+	defer loader.mtx.Unlock()
+	return append([]api.Module{}, loader.instances...)
 }
 
 func (loader *ModuleLoader) loadModuleByName(ctx context.Context, moduleName string) (api.Module, error) {
@@ -0,0 +1,30 @@
+package wasm
+
+import (
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Metrics for monitoring the WASM executor:
+var (
+	meter              = global.MeterProvider().Meter("wasm")
+	moduleCacheHits, _ = meter.Int64Counter(
+		"wasm_module_cache_hits",
+		instrument.WithDescription("Number of WASM modules found compiled in the module cache"),
+	)
+
+	moduleCacheMisses, _ = meter.Int64Counter(
+		"wasm_module_cache_misses",
+		instrument.WithDescription("Number of WASM modules compiled because they were not in the module cache"),
+	)
+
+	moduleCacheEvictions, _ = meter.Int64Counter(
+		"wasm_module_cache_evictions",
+		instrument.WithDescription("Number of compiled WASM modules evicted from the module cache"),
+	)
+
+	fuelConsumedCounter, _ = meter.Int64Counter(
+		"wasm_fuel_consumed",
+		instrument.WithDescription("Number of WASM instructions metered while running jobs"),
+	)
+)
@@ -0,0 +1,80 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/testdata/wasm/env"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestExecutorInjectsSecrets(t *testing.T) {
+	ctx := context.Background()
+	privateKey, publicKey, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	require.NoError(t, err)
+	nodeID, err := peer.IDFromPublicKey(publicKey)
+	require.NoError(t, err)
+	marshaledPublicKey, err := crypto.MarshalPublicKey(publicKey)
+	require.NoError(t, err)
+	encrypter := verifier.NewEncrypter(privateKey)
+
+	key, err := encrypted.NewDataKey()
+	require.NoError(t, err)
+	encryption, err := encrypted.WrapDataKey(ctx, encrypter.Encrypt, key, []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: nodeID}, PublicKey: marshaledPublicKey},
+	})
+	require.NoError(t, err)
+	secrets, err := encrypted.EncryptSecrets(map[string]string{"API_TOKEN": "top-secret"}, key, encryption)
+	require.NoError(t, err)
+
+	provider := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceInline: inline.NewStorage(),
+	})
+	e, err := NewExecutor(ctx, provider, nil, encrypted.NewSecretsDecrypter(encrypted.StorageParams{
+		NodeID:    nodeID.String(),
+		Decrypter: encrypter.Decrypt,
+	}))
+	require.NoError(t, err)
+
+	job := model.Job{
+		Metadata: model.Metadata{ID: "secrets"},
+		Spec: model.Spec{
+			Engine: model.EngineWasm,
+			Wasm: model.JobSpecWasm{
+				EntryModule: model.StorageSpec{
+					StorageSource: model.StorageSourceInline,
+					URL:           dataurl.EncodeBytes(env.Program()),
+				},
+				EntryPoint:           "_start",
+				EnvironmentVariables: map[string]string{"TEST": "yes"},
+			},
+			Secrets: secrets,
+		},
+	}
+	resultsDir := t.TempDir()
+	result, err := e.Run(ctx, "execution", job, resultsDir)
+	require.NoError(t, err)
+	require.Empty(t, result.ErrorMsg)
+
+	stdout, err := os.ReadFile(filepath.Join(resultsDir, "stdout"))
+	require.NoError(t, err)
+	require.Contains(t, string(stdout), "API_TOKEN=top-secret")
+	require.Contains(t, string(stdout), "TEST=yes")
+
+	// nodes that can't decrypt the secrets fail the execution
+	e.secrets = nil
+	_, err = e.Run(ctx, "execution", job, t.TempDir())
+	require.Error(t, err)
+}
@@ -0,0 +1,44 @@
+//go:build unit || !integration
+
+package wasm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/bacalhau-project/bacalhau/testdata/wasm/env"
+	"github.com/stretchr/testify/require"
+	"github.com/vincent-petithory/dataurl"
+)
+
+func TestExecutorReportsResourceUsage(t *testing.T) {
+	ctx := context.Background()
+	provider := model.NewMappedProvider(map[model.StorageSourceType]storage.Storage{
+		model.StorageSourceInline: inline.NewStorage(),
+	})
+	e, err := NewExecutor(ctx, provider, nil, nil)
+	require.NoError(t, err)
+
+	job := model.Job{
+		Metadata: model.Metadata{ID: "usage"},
+		Spec: model.Spec{
+			Engine: model.EngineWasm,
+			Wasm: model.JobSpecWasm{
+				EntryModule: model.StorageSpec{
+					StorageSource: model.StorageSourceInline,
+					URL:           dataurl.EncodeBytes(env.Program()),
+				},
+				EntryPoint: "_start",
+			},
+		},
+	}
+	result, err := e.Run(ctx, "execution", job, t.TempDir())
+	require.NoError(t, err)
+	require.NotNil(t, result.ResourceUsage)
+	require.Greater(t, result.ResourceUsage.CPUSeconds, float64(0))
+	require.Greater(t, result.ResourceUsage.PeakMemory, uint64(0))
+	require.Zero(t, result.ResourceUsage.NetworkRxBytes)
+}
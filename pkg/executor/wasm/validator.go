@@ -71,6 +71,18 @@ reqImport:
 	return nil
 }
 
+// ValidateModuleIsDeterministic returns an error if the passed module imports
+// host functions whose results can differ between nodes running the same job.
+func ValidateModuleIsDeterministic(module wazero.CompiledModule) error {
+	for _, requiredImport := range module.ImportedFunctions() {
+		importNamespace, funcName, _ := requiredImport.Import()
+		if _, ok := nondeterministicImports[importNamespace][funcName]; ok {
+			return fmt.Errorf("'%s::%s' required by module is not deterministic", importNamespace, funcName)
+		}
+	}
+	return nil
+}
+
 // ValidateModuleAsEntryPoint returns an error if the passed module is not
 // capable of being an entry point to a job, i.e. that it contains a function of
 // the passed name that meets the specification of:
@@ -2,10 +2,12 @@ package job
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"reflect"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
 )
 
 // VerifyJobCreatePayload verifies the values in a job creation request are legal.
@@ -18,6 +20,18 @@ func VerifyJobCreatePayload(ctx context.Context, jc *model.JobCreatePayload) err
 		return fmt.Errorf("APIVersion is empty")
 	}
 
+	if len(jc.ClientPublicKey) > 0 {
+		ok, err := system.PublicKeyMatchesID(base64.StdEncoding.EncodeToString(jc.ClientPublicKey), jc.ClientID)
+		if err != nil {
+			return fmt.Errorf("invalid ClientPublicKey: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("ClientPublicKey does not match ClientID")
+		}
+	} else if jc.Spec != nil && jc.Spec.PublisherSpec.Encrypt {
+		return fmt.Errorf("ClientPublicKey is required to encrypt results")
+	}
+
 	return VerifyJob(ctx, &model.Job{
 		APIVersion: jc.APIVersion,
 		Spec:       *jc.Spec,
@@ -62,6 +76,22 @@ func VerifyJob(ctx context.Context, j *model.Job) error {
 		return fmt.Errorf("the deal confidence cannot be higher than the concurrency")
 	}
 
+	if err := j.Spec.DifferentialPrivacy.IsValid(); err != nil {
+		return err
+	}
+
+	if j.Spec.DifferentialPrivacy != nil && j.Spec.Verifier == model.VerifierDeterministic {
+		return fmt.Errorf("differentially private results are noisy, and can't be verified deterministically")
+	}
+
+	if j.Spec.Engine == model.EngineWasm && j.Spec.Verifier == model.VerifierDeterministic && !j.Spec.Wasm.Deterministic {
+		return fmt.Errorf("WASM jobs must run in deterministic mode to be verified deterministically")
+	}
+
+	if err := j.Spec.Secrets.IsValid(); err != nil {
+		return err
+	}
+
 	for _, inputVolume := range j.Spec.Inputs {
 		if !model.IsValidStorageSourceType(inputVolume.StorageSource) {
 			return fmt.Errorf("invalid input volume type: %s", inputVolume.StorageSource.String())
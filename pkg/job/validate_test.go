@@ -0,0 +1,39 @@
+//go:build unit || !integration
+
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyJobDeterministicWasm(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		verifier      model.Verifier
+		deterministic bool
+		valid         bool
+	}{
+		{name: "deterministic verifier in deterministic mode", verifier: model.VerifierDeterministic, deterministic: true, valid: true},
+		{name: "deterministic verifier", verifier: model.VerifierDeterministic, valid: false},
+		{name: "noop verifier", verifier: model.VerifierNoop, valid: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			j, err := model.NewJobWithSaneProductionDefaults()
+			require.NoError(t, err)
+			j.Spec.Engine = model.EngineWasm
+			j.Spec.Verifier = test.verifier
+			j.Spec.Wasm.Deterministic = test.deterministic
+
+			err = VerifyJob(context.Background(), j)
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
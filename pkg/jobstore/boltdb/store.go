@@ -0,0 +1,533 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/imdario/mergo"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/exp/slices"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bacerrors"
+	jobutils "github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+const newJobComment = "Job created"
+
+var (
+	jobsBucket       = []byte("jobs")
+	statesBucket     = []byte("states")
+	historyBucket    = []byte("history")
+	inProgressBucket = []byte("inprogress")
+	moderationBucket = []byte("moderation")
+)
+
+// JobStore is a jobstore.Store that persists jobs, their state and history
+// in an embedded BoltDB database so they survive requester restarts.
+//
+// Values are stored as JSON documents keyed by job ID. History and moderation
+// entries are kept in a nested bucket per job, keyed by a monotonically
+// increasing sequence so that the insertion order is preserved.
+type JobStore struct {
+	db *bolt.DB
+}
+
+// NewJobStore opens (or creates) the database at dbPath and makes sure all
+// the buckets used by the store exist.
+func NewJobStore(dbPath string) (*JobStore, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second}) //nolint:gomnd
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job store database %s: %w", dbPath, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{jobsBucket, statesBucket, historyBucket, inProgressBucket, moderationBucket} {
+			if _, bucketErr := tx.CreateBucketIfNotExists(bucket); bucketErr != nil {
+				return bucketErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize job store database %s: %w", dbPath, err)
+	}
+	return &JobStore{db: db}, nil
+}
+
+// Close closes the underlying database.
+func (d *JobStore) Close(_ context.Context) error {
+	return d.db.Close()
+}
+
+// Gets a job from the datastore.
+//
+// Errors:
+//
+//   - error-job-not-found        		  -- if the job is not found
+func (d *JobStore) GetJob(_ context.Context, id string) (job model.Job, err error) {
+	err = d.db.View(func(tx *bolt.Tx) error {
+		job, err = d.getJob(tx, id)
+		return err
+	})
+	return job, err
+}
+
+func (d *JobStore) GetJobs(ctx context.Context, query jobstore.JobQuery) ([]model.Job, error) {
+	var result []model.Job
+
+	if query.ID != "" {
+		j, err := d.GetJob(ctx, query.ID)
+		if err != nil {
+			return nil, err
+		}
+		return []model.Job{j}, nil
+	}
+
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, v []byte) error {
+			if query.Limit > 0 && len(result) == query.Limit {
+				return nil
+			}
+
+			var j model.Job
+			if err := json.Unmarshal(v, &j); err != nil {
+				return err
+			}
+
+			if !query.ReturnAll && query.ClientID != "" && query.ClientID != j.Metadata.ClientID {
+				// Job is not for the requesting client, so ignore it.
+				return nil
+			}
+
+			// If we are not using include tags, by default every job is included.
+			// If a job is specifically included, that overrides it being excluded.
+			included := len(query.IncludeTags) == 0
+			for _, tag := range j.Spec.Annotations {
+				if slices.Contains(query.IncludeTags, model.IncludedTag(tag)) {
+					included = true
+					break
+				}
+				if slices.Contains(query.ExcludeTags, model.ExcludedTag(tag)) {
+					included = false
+					break
+				}
+			}
+
+			if included {
+				result = append(result, j)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listSorter := func(i, j int) bool {
+		switch query.SortBy {
+		case "id":
+			if query.SortReverse {
+				return result[i].Metadata.ID > result[j].Metadata.ID
+			} else {
+				return result[i].Metadata.ID < result[j].Metadata.ID
+			}
+		case "created_at":
+			if query.SortReverse {
+				return result[i].Metadata.CreatedAt.UTC().Unix() > result[j].Metadata.CreatedAt.UTC().Unix()
+			} else {
+				return result[i].Metadata.CreatedAt.UTC().Unix() < result[j].Metadata.CreatedAt.UTC().Unix()
+			}
+		default:
+			return false
+		}
+	}
+	sort.Slice(result, listSorter)
+	return result, nil
+}
+
+func (d *JobStore) GetJobState(_ context.Context, jobID string) (state model.JobState, err error) {
+	err = d.db.View(func(tx *bolt.Tx) error {
+		var found bool
+		state, found, err = getJobState(tx, jobID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return bacerrors.NewJobNotFound(jobID)
+		}
+		return nil
+	})
+	return state, err
+}
+
+func (d *JobStore) GetInProgressJobs(_ context.Context) ([]model.JobWithInfo, error) {
+	var result []model.JobWithInfo
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(inProgressBucket).ForEach(func(k, _ []byte) error {
+			var info model.JobWithInfo
+			if err := getValue(tx.Bucket(jobsBucket), k, &info.Job); err != nil {
+				return err
+			}
+			if err := getValue(tx.Bucket(statesBucket), k, &info.State); err != nil {
+				return err
+			}
+			result = append(result, info)
+			return nil
+		})
+	})
+	return result, err
+}
+
+func (d *JobStore) GetJobHistory(_ context.Context, jobID string, options jobstore.JobHistoryFilterOptions) ([]model.JobHistory, error) {
+	var history []model.JobHistory
+	err := d.db.View(func(tx *bolt.Tx) error {
+		jobHistory := tx.Bucket(historyBucket).Bucket([]byte(jobID))
+		if jobHistory == nil {
+			return jobstore.NewErrJobNotFound(jobID)
+		}
+
+		// We want to filter events to only those that happened after the timestamp provided
+		sinceTime := options.Since
+		history = make([]model.JobHistory, 0, jobHistory.Stats().KeyN)
+		return jobHistory.ForEach(func(_, v []byte) error {
+			var event model.JobHistory
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			if options.ExcludeExecutionLevel && event.Type == model.JobHistoryTypeExecutionLevel {
+				return nil
+			}
+			if options.ExcludeJobLevel && event.Type == model.JobHistoryTypeJobLevel {
+				return nil
+			}
+			if event.Time.Unix() >= sinceTime {
+				history = append(history, event)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Time.UTC().Before(history[j].Time.UTC()) })
+	return history, nil
+}
+
+func (d *JobStore) GetJobsCount(ctx context.Context, query jobstore.JobQuery) (int, error) {
+	useQuery := query
+	useQuery.Limit = 0
+	useQuery.Offset = 0
+	jobs, err := d.GetJobs(ctx, useQuery)
+	if err != nil {
+		return 0, err
+	}
+	return len(jobs), nil
+}
+
+func (d *JobStore) CreateJob(_ context.Context, job model.Job) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobsBucket)
+		key := []byte(job.Metadata.ID)
+		if jobs.Get(key) != nil {
+			return jobstore.NewErrJobAlreadyExists(job.Metadata.ID)
+		}
+		if err := putValue(jobs, key, job); err != nil {
+			return err
+		}
+
+		// populate job state
+		jobState := model.JobState{
+			JobID:      job.Metadata.ID,
+			State:      model.JobStateNew,
+			Version:    1,
+			CreateTime: time.Now(),
+			UpdateTime: time.Now(),
+		}
+		if err := putValue(tx.Bucket(statesBucket), key, jobState); err != nil {
+			return err
+		}
+		if err := tx.Bucket(inProgressBucket).Put(key, []byte{}); err != nil {
+			return err
+		}
+		return appendJobHistory(tx, jobState, model.JobStateNew, newJobComment)
+	})
+}
+
+// helper method to read a single job from the database. This is used by both GetJob and GetJobs.
+// It supports short job IDs by seeking to the first job ID with the given prefix.
+func (d *JobStore) getJob(tx *bolt.Tx, id string) (model.Job, error) {
+	if len(id) < model.ShortIDLength {
+		return model.Job{}, bacerrors.NewJobNotFound(id)
+	}
+
+	jobs := tx.Bucket(jobsBucket)
+	key := []byte(id)
+	// support for short job IDs
+	if jobutils.ShortID(id) == id {
+		// passed in a short id, need to resolve the long id first
+		if k, _ := jobs.Cursor().Seek(key); k != nil && bytes.HasPrefix(k, key) {
+			key = k
+		}
+	}
+
+	var j model.Job
+	v := jobs.Get(key)
+	if v == nil {
+		return model.Job{}, bacerrors.NewJobNotFound(id)
+	}
+	if err := json.Unmarshal(v, &j); err != nil {
+		return model.Job{}, err
+	}
+	return j, nil
+}
+
+func (d *JobStore) UpdateJobState(_ context.Context, request jobstore.UpdateJobStateRequest) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		// get the existing job state
+		jobState, found, err := getJobState(tx, request.JobID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return jobstore.NewErrJobNotFound(request.JobID)
+		}
+
+		// check the expected state
+		if err = request.Condition.Validate(jobState); err != nil {
+			return err
+		}
+		if jobState.State.IsTerminal() {
+			return jobstore.NewErrJobAlreadyTerminal(request.JobID, jobState.State, request.NewState)
+		}
+
+		// update the job state
+		previousState := jobState.State
+		jobState.State = request.NewState
+		jobState.Version++
+		jobState.UpdateTime = time.Now()
+		if err = putValue(tx.Bucket(statesBucket), []byte(request.JobID), jobState); err != nil {
+			return err
+		}
+		if request.NewState.IsTerminal() {
+			if err = tx.Bucket(inProgressBucket).Delete([]byte(request.JobID)); err != nil {
+				return err
+			}
+		}
+		return appendJobHistory(tx, jobState, previousState, request.Comment)
+	})
+}
+
+func (d *JobStore) CreateExecution(_ context.Context, execution model.ExecutionState) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		jobState, found, err := getJobState(tx, execution.JobID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return jobstore.NewErrJobNotFound(execution.JobID)
+		}
+		for _, e := range jobState.Executions {
+			if e.ID() == execution.ID() {
+				return jobstore.NewErrExecutionAlreadyExists(execution.ID())
+			}
+		}
+		if execution.CreateTime.IsZero() {
+			execution.CreateTime = time.Now()
+		}
+		if execution.UpdateTime.IsZero() {
+			execution.UpdateTime = execution.CreateTime
+		}
+		if execution.Version == 0 {
+			execution.Version = 1
+		}
+		jobState.Executions = append(jobState.Executions, execution)
+		if err = putValue(tx.Bucket(statesBucket), []byte(execution.JobID), jobState); err != nil {
+			return err
+		}
+		return appendExecutionHistory(tx, execution, model.ExecutionStateNew, "")
+	})
+}
+
+func (d *JobStore) UpdateExecution(_ context.Context, request jobstore.UpdateExecutionRequest) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		// find the existing execution
+		jobState, found, err := getJobState(tx, request.ExecutionID.JobID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return jobstore.NewErrJobNotFound(request.ExecutionID.JobID)
+		}
+		var existingExecution model.ExecutionState
+		executionIndex := -1
+		for i, e := range jobState.Executions {
+			if e.ID() == request.ExecutionID {
+				existingExecution = e
+				executionIndex = i
+				break
+			}
+		}
+		if executionIndex == -1 {
+			return jobstore.NewErrExecutionNotFound(request.ExecutionID)
+		}
+
+		// check the expected state
+		if err = request.Condition.Validate(existingExecution); err != nil {
+			return err
+		}
+		if existingExecution.State.IsTerminal() {
+			return jobstore.NewErrExecutionAlreadyTerminal(request.ExecutionID, existingExecution.State, request.NewValues.State)
+		}
+
+		// populate default values
+		newExecution := request.NewValues
+		if newExecution.CreateTime.IsZero() {
+			newExecution.CreateTime = time.Now()
+		}
+		if newExecution.UpdateTime.IsZero() {
+			newExecution.UpdateTime = existingExecution.CreateTime
+		}
+		if newExecution.Version == 0 {
+			newExecution.Version = existingExecution.Version + 1
+		}
+
+		if err = mergo.Merge(&newExecution, existingExecution); err != nil {
+			return err
+		}
+
+		// update the execution
+		previousState := existingExecution.State
+		jobState.Executions[executionIndex] = newExecution
+		if err = putValue(tx.Bucket(statesBucket), []byte(newExecution.JobID), jobState); err != nil {
+			return err
+		}
+		return appendExecutionHistory(tx, newExecution, previousState, request.Comment)
+	})
+}
+
+func (d *JobStore) AddJobModeration(_ context.Context, moderation model.JobModeration) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(jobsBucket).Get([]byte(moderation.JobID)) == nil {
+			return jobstore.NewErrJobNotFound(moderation.JobID)
+		}
+		jobModeration, err := tx.Bucket(moderationBucket).CreateBucketIfNotExists([]byte(moderation.JobID))
+		if err != nil {
+			return err
+		}
+		return putSequenced(jobModeration, moderation)
+	})
+}
+
+func (d *JobStore) GetJobModerations(_ context.Context, jobID string) ([]model.JobModeration, error) {
+	var moderations []model.JobModeration
+	err := d.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(jobsBucket).Get([]byte(jobID)) == nil {
+			return jobstore.NewErrJobNotFound(jobID)
+		}
+		jobModeration := tx.Bucket(moderationBucket).Bucket([]byte(jobID))
+		if jobModeration == nil {
+			return nil
+		}
+		return jobModeration.ForEach(func(_, v []byte) error {
+			var moderation model.JobModeration
+			if err := json.Unmarshal(v, &moderation); err != nil {
+				return err
+			}
+			moderations = append(moderations, moderation)
+			return nil
+		})
+	})
+	return moderations, err
+}
+
+func getJobState(tx *bolt.Tx, jobID string) (model.JobState, bool, error) {
+	var state model.JobState
+	v := tx.Bucket(statesBucket).Get([]byte(jobID))
+	if v == nil {
+		return state, false, nil
+	}
+	if err := json.Unmarshal(v, &state); err != nil {
+		return state, false, err
+	}
+	return state, true, nil
+}
+
+func appendJobHistory(tx *bolt.Tx, updateJob model.JobState, previousState model.JobStateType, comment string) error {
+	historyEntry := model.JobHistory{
+		Type:  model.JobHistoryTypeJobLevel,
+		JobID: updateJob.JobID,
+		JobState: &model.StateChange[model.JobStateType]{
+			Previous: previousState,
+			New:      updateJob.State,
+		},
+		NewVersion: updateJob.Version,
+		Comment:    comment,
+		Time:       updateJob.UpdateTime,
+	}
+	return appendHistory(tx, historyEntry)
+}
+
+func appendExecutionHistory(
+	tx *bolt.Tx, updatedExecution model.ExecutionState, previousState model.ExecutionStateType, comment string) error {
+	historyEntry := model.JobHistory{
+		Type:             model.JobHistoryTypeExecutionLevel,
+		JobID:            updatedExecution.JobID,
+		NodeID:           updatedExecution.NodeID,
+		ComputeReference: updatedExecution.ComputeReference,
+		ExecutionState: &model.StateChange[model.ExecutionStateType]{
+			Previous: previousState,
+			New:      updatedExecution.State,
+		},
+		NewVersion: updatedExecution.Version,
+		Comment:    comment,
+		Time:       updatedExecution.UpdateTime,
+	}
+	return appendHistory(tx, historyEntry)
+}
+
+func appendHistory(tx *bolt.Tx, historyEntry model.JobHistory) error {
+	jobHistory, err := tx.Bucket(historyBucket).CreateBucketIfNotExists([]byte(historyEntry.JobID))
+	if err != nil {
+		return err
+	}
+	return putSequenced(jobHistory, historyEntry)
+}
+
+// putSequenced stores the value under the next sequence of the bucket, so that values are iterated in insertion order
+func putSequenced(bucket *bolt.Bucket, value interface{}) error {
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8) //nolint:gomnd // size of uint64
+	binary.BigEndian.PutUint64(key, seq)
+	return putValue(bucket, key, value)
+}
+
+func getValue(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	v := bucket.Get(key)
+	if v == nil {
+		return fmt.Errorf("missing value for key %s", key)
+	}
+	return json.Unmarshal(v, value)
+}
+
+func putValue(bucket *bolt.Bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, data)
+}
+
+// Static check to ensure that JobStore implements jobstore.Store:
+var _ jobstore.Store = (*JobStore)(nil)
@@ -0,0 +1,84 @@
+//go:build unit || !integration
+
+package boltdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	jobstoretest "github.com/bacalhau-project/bacalhau/pkg/jobstore/test"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+func TestBoltDBStoreSuite(t *testing.T) {
+	testingSuite := new(jobstoretest.StoreSuite)
+	var store *JobStore
+	testingSuite.SetupHandler = func() jobstore.Store {
+		var err error
+		store, err = NewJobStore(filepath.Join(t.TempDir(), "jobs.db"))
+		require.NoError(t, err)
+		return store
+	}
+	testingSuite.AppendHistoryHandler = func(entry model.JobHistory) {
+		require.NoError(t, store.db.Update(func(tx *bolt.Tx) error {
+			return appendHistory(tx, entry)
+		}))
+	}
+	testingSuite.TeardownHandler = func() {
+		require.NoError(t, store.Close(context.Background()))
+	}
+	suite.Run(t, testingSuite)
+}
+
+func TestBoltDBStoreSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "jobs.db")
+
+	store, err := NewJobStore(dbPath)
+	require.NoError(t, err)
+
+	job := model.Job{Metadata: model.Metadata{ID: uuid.NewString(), ClientID: "client"}}
+	require.NoError(t, store.CreateJob(ctx, job))
+	execution := model.ExecutionState{
+		JobID:            job.ID(),
+		NodeID:           "node",
+		ComputeReference: "e-" + uuid.NewString(),
+		State:            model.ExecutionStateAskForBid,
+	}
+	require.NoError(t, store.CreateExecution(ctx, execution))
+	require.NoError(t, store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateInProgress,
+	}))
+	require.NoError(t, store.Close(ctx))
+
+	store, err = NewJobStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close(ctx) //nolint:errcheck
+
+	readJob, err := store.GetJob(ctx, job.ID())
+	require.NoError(t, err)
+	require.Equal(t, job.Metadata.ClientID, readJob.Metadata.ClientID)
+
+	state, err := store.GetJobState(ctx, job.ID())
+	require.NoError(t, err)
+	require.Equal(t, model.JobStateInProgress, state.State)
+	require.Equal(t, 2, state.Version)
+	require.Len(t, state.Executions, 1)
+	require.Equal(t, execution.ID(), state.Executions[0].ID())
+
+	inProgress, err := store.GetInProgressJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, inProgress, 1)
+
+	history, err := store.GetJobHistory(ctx, job.ID(), jobstore.JobHistoryFilterOptions{})
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+}
@@ -23,6 +23,7 @@ type JobStore struct {
 	jobs       map[string]model.Job
 	states     map[string]model.JobState
 	history    map[string][]model.JobHistory
+	moderation map[string][]model.JobModeration
 	inprogress map[string]struct{}
 	mtx        sync.RWMutex
 }
@@ -32,6 +33,7 @@ func NewJobStore() *JobStore {
 		jobs:       make(map[string]model.Job),
 		states:     make(map[string]model.JobState),
 		history:    make(map[string][]model.JobHistory),
+		moderation: make(map[string][]model.JobModeration),
 		inprogress: make(map[string]struct{}),
 	}
 	res.mtx.EnableTracerWithOpts(sync.Opts{
@@ -348,6 +350,25 @@ func (d *JobStore) UpdateExecution(_ context.Context, request jobstore.UpdateExe
 	return nil
 }
 
+func (d *JobStore) AddJobModeration(_ context.Context, moderation model.JobModeration) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, ok := d.jobs[moderation.JobID]; !ok {
+		return jobstore.NewErrJobNotFound(moderation.JobID)
+	}
+	d.moderation[moderation.JobID] = append(d.moderation[moderation.JobID], moderation)
+	return nil
+}
+
+func (d *JobStore) GetJobModerations(_ context.Context, jobID string) ([]model.JobModeration, error) {
+	d.mtx.RLock()
+	defer d.mtx.RUnlock()
+	if _, ok := d.jobs[jobID]; !ok {
+		return nil, jobstore.NewErrJobNotFound(jobID)
+	}
+	return append([]model.JobModeration(nil), d.moderation[jobID]...), nil
+}
+
 func (d *JobStore) appendJobHistory(updateJob model.JobState, previousState model.JobStateType, comment string) {
 	historyEntry := model.JobHistory{
 		Type:  model.JobHistoryTypeJobLevel,
@@ -0,0 +1,468 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// StoreSuite is a suite of tests that every jobstore.Store implementation is expected to pass.
+// Implementations run it by providing a SetupHandler that returns a fresh and empty store, and an
+// AppendHistoryHandler that writes a history entry to that store as is, so that the history tests
+// can control the time of each event.
+type StoreSuite struct {
+	suite.Suite
+	SetupHandler         func() jobstore.Store
+	TeardownHandler      func()
+	AppendHistoryHandler func(entry model.JobHistory)
+	Store                jobstore.Store
+	ctx                  context.Context
+}
+
+func (s *StoreSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.Store = s.SetupHandler()
+}
+
+func (s *StoreSuite) TearDownTest() {
+	if s.TeardownHandler != nil {
+		s.TeardownHandler()
+	}
+}
+
+func (s *StoreSuite) newJob(clientID string, annotations ...string) model.Job {
+	j := model.Job{
+		APIVersion: model.APIVersionLatest().String(),
+		Metadata: model.Metadata{
+			ID:        uuid.NewString(),
+			ClientID:  clientID,
+			CreatedAt: time.Now(),
+		},
+		Spec: model.Spec{
+			Engine:      model.EngineNoop,
+			Annotations: annotations,
+			Deal:        model.Deal{Concurrency: 1},
+		},
+	}
+	s.Require().NoError(s.Store.CreateJob(s.ctx, j))
+	return j
+}
+
+func (s *StoreSuite) newExecution(jobID string, state model.ExecutionStateType) model.ExecutionState {
+	execution := model.ExecutionState{
+		JobID:            jobID,
+		NodeID:           "node-" + uuid.NewString(),
+		ComputeReference: "e-" + uuid.NewString(),
+		State:            state,
+	}
+	s.Require().NoError(s.Store.CreateExecution(s.ctx, execution))
+	return execution
+}
+
+func (s *StoreSuite) TestCreateJob() {
+	j := s.newJob("client")
+
+	readJob, err := s.Store.GetJob(s.ctx, j.ID())
+	s.Require().NoError(err)
+	s.Equal(j.ID(), readJob.ID())
+	s.Equal(j.Metadata.ClientID, readJob.Metadata.ClientID)
+
+	state, err := s.Store.GetJobState(s.ctx, j.ID())
+	s.Require().NoError(err)
+	s.Equal(model.JobStateNew, state.State)
+	s.Equal(1, state.Version)
+
+	err = s.Store.CreateJob(s.ctx, j)
+	s.ErrorAs(err, &jobstore.ErrJobAlreadyExists{})
+}
+
+func (s *StoreSuite) TestGetJobShortID() {
+	j := s.newJob("client")
+	readJob, err := s.Store.GetJob(s.ctx, model.ShortID(j.ID()))
+	s.Require().NoError(err)
+	s.Equal(j.ID(), readJob.ID())
+}
+
+func (s *StoreSuite) TestGetJobNotFound() {
+	_, err := s.Store.GetJob(s.ctx, uuid.NewString())
+	s.Error(err)
+	_, err = s.Store.GetJobState(s.ctx, uuid.NewString())
+	s.Error(err)
+}
+
+func (s *StoreSuite) TestGetJobs() {
+	first := s.newJob("client1", "apple")
+	second := s.newJob("client1", "banana")
+	third := s.newJob("client2", "apple")
+
+	jobs, err := s.Store.GetJobs(s.ctx, jobstore.JobQuery{ClientID: "client1", SortBy: "id"})
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{first.ID(), second.ID()}, jobIDs(jobs))
+
+	jobs, err = s.Store.GetJobs(s.ctx, jobstore.JobQuery{IncludeTags: []model.IncludedTag{"apple"}})
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{first.ID(), third.ID()}, jobIDs(jobs))
+
+	jobs, err = s.Store.GetJobs(s.ctx, jobstore.JobQuery{ExcludeTags: []model.ExcludedTag{"apple"}})
+	s.Require().NoError(err)
+	s.ElementsMatch([]string{second.ID()}, jobIDs(jobs))
+
+	jobs, err = s.Store.GetJobs(s.ctx, jobstore.JobQuery{ID: third.ID()})
+	s.Require().NoError(err)
+	s.Equal([]string{third.ID()}, jobIDs(jobs))
+
+	count, err := s.Store.GetJobsCount(s.ctx, jobstore.JobQuery{Limit: 1})
+	s.Require().NoError(err)
+	s.Equal(3, count)
+}
+
+func (s *StoreSuite) TestUpdateJobState() {
+	j := s.newJob("client")
+
+	err := s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:     j.ID(),
+		Condition: jobstore.UpdateJobCondition{ExpectedState: model.JobStateNew, ExpectedVersion: 1},
+		NewState:  model.JobStateInProgress,
+		Comment:   "started",
+	})
+	s.Require().NoError(err)
+
+	state, err := s.Store.GetJobState(s.ctx, j.ID())
+	s.Require().NoError(err)
+	s.Equal(model.JobStateInProgress, state.State)
+	s.Equal(2, state.Version)
+
+	// wrong expected state
+	err = s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:     j.ID(),
+		Condition: jobstore.UpdateJobCondition{ExpectedState: model.JobStateQueued},
+		NewState:  model.JobStateCompleted,
+	})
+	s.ErrorAs(err, &jobstore.ErrInvalidJobState{})
+
+	// wrong expected version
+	err = s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:     j.ID(),
+		Condition: jobstore.UpdateJobCondition{ExpectedVersion: 1},
+		NewState:  model.JobStateCompleted,
+	})
+	s.ErrorAs(err, &jobstore.ErrInvalidJobVersion{})
+
+	// unexpected state
+	err = s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:     j.ID(),
+		Condition: jobstore.UpdateJobCondition{UnexpectedStates: []model.JobStateType{model.JobStateInProgress}},
+		NewState:  model.JobStateCompleted,
+	})
+	s.ErrorAs(err, &jobstore.ErrInvalidJobState{})
+
+	// unknown job
+	err = s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    uuid.NewString(),
+		NewState: model.JobStateCompleted,
+	})
+	s.ErrorAs(err, &jobstore.ErrJobNotFound{})
+}
+
+func (s *StoreSuite) TestUpdateJobStateTerminal() {
+	j := s.newJob("client")
+
+	inProgress, err := s.Store.GetInProgressJobs(s.ctx)
+	s.Require().NoError(err)
+	s.Len(inProgress, 1)
+	s.Equal(j.ID(), inProgress[0].Job.ID())
+
+	err = s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    j.ID(),
+		NewState: model.JobStateCompleted,
+	})
+	s.Require().NoError(err)
+
+	inProgress, err = s.Store.GetInProgressJobs(s.ctx)
+	s.Require().NoError(err)
+	s.Empty(inProgress)
+
+	err = s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    j.ID(),
+		NewState: model.JobStateInProgress,
+	})
+	s.ErrorAs(err, &jobstore.ErrJobAlreadyTerminal{})
+}
+
+func (s *StoreSuite) TestCreateExecution() {
+	j := s.newJob("client")
+	execution := s.newExecution(j.ID(), model.ExecutionStateAskForBid)
+
+	state, err := s.Store.GetJobState(s.ctx, j.ID())
+	s.Require().NoError(err)
+	s.Require().Len(state.Executions, 1)
+	s.Equal(execution.ID(), state.Executions[0].ID())
+	s.Equal(1, state.Executions[0].Version)
+	s.False(state.Executions[0].CreateTime.IsZero())
+
+	err = s.Store.CreateExecution(s.ctx, execution)
+	s.ErrorAs(err, &jobstore.ErrExecutionAlreadyExists{})
+
+	execution.JobID = uuid.NewString()
+	err = s.Store.CreateExecution(s.ctx, execution)
+	s.ErrorAs(err, &jobstore.ErrJobNotFound{})
+}
+
+func (s *StoreSuite) TestUpdateExecution() {
+	j := s.newJob("client")
+	execution := s.newExecution(j.ID(), model.ExecutionStateAskForBid)
+
+	err := s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		Condition: jobstore.UpdateExecutionCondition{
+			ExpectedState:   model.ExecutionStateAskForBid,
+			ExpectedVersion: 1,
+		},
+		NewValues: model.ExecutionState{
+			State:             model.ExecutionStateAskForBidAccepted,
+			AcceptedAskForBid: true,
+		},
+	})
+	s.Require().NoError(err)
+
+	state, err := s.Store.GetJobState(s.ctx, j.ID())
+	s.Require().NoError(err)
+	updated := state.Executions[0]
+	s.Equal(model.ExecutionStateAskForBidAccepted, updated.State)
+	s.Equal(2, updated.Version)
+	s.True(updated.AcceptedAskForBid)
+	// values not part of the update are merged from the existing execution
+	s.Equal(execution.NodeID, updated.NodeID)
+	s.Equal(execution.ComputeReference, updated.ComputeReference)
+
+	// wrong expected state
+	err = s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		Condition:   jobstore.UpdateExecutionCondition{ExpectedState: model.ExecutionStateAskForBid},
+		NewValues:   model.ExecutionState{State: model.ExecutionStateBidAccepted},
+	})
+	s.ErrorAs(err, &jobstore.ErrInvalidExecutionState{})
+
+	// wrong expected version
+	err = s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		Condition:   jobstore.UpdateExecutionCondition{ExpectedVersion: 1},
+		NewValues:   model.ExecutionState{State: model.ExecutionStateBidAccepted},
+	})
+	s.ErrorAs(err, &jobstore.ErrInvalidExecutionVersion{})
+
+	// terminal execution
+	err = s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		NewValues:   model.ExecutionState{State: model.ExecutionStateFailed},
+	})
+	s.Require().NoError(err)
+	err = s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		NewValues:   model.ExecutionState{State: model.ExecutionStateBidAccepted},
+	})
+	s.ErrorAs(err, &jobstore.ErrExecutionAlreadyTerminal{})
+
+	// unknown execution
+	unknownID := execution.ID()
+	unknownID.ExecutionID = "e-" + uuid.NewString()
+	err = s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: unknownID,
+		NewValues:   model.ExecutionState{State: model.ExecutionStateBidAccepted},
+	})
+	s.ErrorAs(err, &jobstore.ErrExecutionNotFound{})
+}
+
+func (s *StoreSuite) TestJobHistory() {
+	j := s.newJob("client")
+	execution := s.newExecution(j.ID(), model.ExecutionStateAskForBid)
+	s.Require().NoError(s.Store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    j.ID(),
+		NewState: model.JobStateInProgress,
+	}))
+	s.Require().NoError(s.Store.UpdateExecution(s.ctx, jobstore.UpdateExecutionRequest{
+		ExecutionID: execution.ID(),
+		NewValues:   model.ExecutionState{State: model.ExecutionStateAskForBidAccepted},
+		Comment:     "bid accepted",
+	}))
+
+	history, err := s.Store.GetJobHistory(s.ctx, j.ID(), jobstore.JobHistoryFilterOptions{})
+	s.Require().NoError(err)
+	s.Require().Len(history, 4)
+	for i := 1; i < len(history); i++ {
+		s.False(history[i].Time.Before(history[i-1].Time), "history is not ordered by time")
+	}
+
+	history, err = s.Store.GetJobHistory(s.ctx, j.ID(), jobstore.JobHistoryFilterOptions{ExcludeExecutionLevel: true})
+	s.Require().NoError(err)
+	s.Require().Len(history, 2)
+	s.Equal(model.JobStateNew, history[0].JobState.New)
+	s.Equal(model.JobStateInProgress, history[1].JobState.New)
+
+	history, err = s.Store.GetJobHistory(s.ctx, j.ID(), jobstore.JobHistoryFilterOptions{ExcludeJobLevel: true})
+	s.Require().NoError(err)
+	s.Require().Len(history, 2)
+	s.Equal(model.ExecutionStateAskForBid, history[0].ExecutionState.New)
+	s.Equal(model.ExecutionStateAskForBidAccepted, history[1].ExecutionState.New)
+	s.Equal("bid accepted", history[1].Comment)
+
+	history, err = s.Store.GetJobHistory(s.ctx, j.ID(), jobstore.JobHistoryFilterOptions{
+		Since: time.Now().Add(time.Hour).Unix(),
+	})
+	s.Require().NoError(err)
+	s.Empty(history)
+
+	_, err = s.Store.GetJobHistory(s.ctx, uuid.NewString(), jobstore.JobHistoryFilterOptions{})
+	s.ErrorAs(err, &jobstore.ErrJobNotFound{})
+}
+
+func (s *StoreSuite) TestJobModerations() {
+	j := s.newJob("client")
+	moderations, err := s.Store.GetJobModerations(s.ctx, j.ID())
+	s.Require().NoError(err)
+	s.Empty(moderations)
+
+	for _, moderation := range []model.JobModeration{
+		{JobID: j.ID(), Action: model.JobModerationRequested, Reason: "needs approval", Time: time.Now()},
+		{JobID: j.ID(), ClientID: "alice", Roles: []string{"admin"}, Action: model.JobModerationApproved, Time: time.Now()},
+		{JobID: j.ID(), ClientID: "bob", Action: model.JobModerationRejected, Reason: "too big", Time: time.Now()},
+	} {
+		s.Require().NoError(s.Store.AddJobModeration(s.ctx, moderation))
+	}
+
+	moderations, err = s.Store.GetJobModerations(s.ctx, j.ID())
+	s.Require().NoError(err)
+	s.Require().Len(moderations, 3)
+	s.Equal(model.JobModerationRequested, moderations[0].Action)
+	s.Equal("needs approval", moderations[0].Reason)
+	s.Equal("alice", moderations[1].ClientID)
+	s.Equal([]string{"admin"}, moderations[1].Roles)
+	s.Equal(model.JobModerationRejected, moderations[2].Action)
+
+	// moderations of other jobs are kept apart
+	other := s.newJob("client")
+	moderations, err = s.Store.GetJobModerations(s.ctx, other.ID())
+	s.Require().NoError(err)
+	s.Empty(moderations)
+
+	err = s.Store.AddJobModeration(s.ctx, model.JobModeration{JobID: uuid.NewString(), Action: model.JobModerationApproved})
+	s.ErrorAs(err, &jobstore.ErrJobNotFound{})
+	_, err = s.Store.GetJobModerations(s.ctx, uuid.NewString())
+	s.ErrorAs(err, &jobstore.ErrJobNotFound{})
+}
+
+// setupHistoryFixture writes the history of job "1", with one event per second starting from the epoch
+func (s *StoreSuite) setupHistoryFixture() {
+	var logicalClock int64 = 0
+
+	jobFixtures := []struct {
+		id              string
+		totalEntries    int
+		jobStates       []model.JobStateType
+		executionStates []model.ExecutionStateType
+	}{
+		{
+			id:              "1",
+			jobStates:       []model.JobStateType{model.JobStateQueued, model.JobStateInProgress, model.JobStateCancelled},
+			executionStates: []model.ExecutionStateType{model.ExecutionStateAskForBid, model.ExecutionStateAskForBidAccepted, model.ExecutionStateFailed, model.ExecutionStateCanceled},
+		},
+	}
+
+	for _, fixture := range jobFixtures {
+		for i, state := range fixture.jobStates {
+			oldState := model.JobStateNew
+			if i > 0 {
+				oldState = fixture.jobStates[i-1]
+			}
+
+			s.AppendHistoryHandler(model.JobHistory{
+				Type:     model.JobHistoryTypeJobLevel,
+				JobID:    fixture.id,
+				JobState: &model.StateChange[model.JobStateType]{Previous: oldState, New: state},
+				Time:     time.Unix(logicalClock, 0),
+			})
+			logicalClock += 1
+		}
+
+		for i, state := range fixture.executionStates {
+			oldState := model.ExecutionStateNew
+			if i > 0 {
+				oldState = fixture.executionStates[i-1]
+			}
+
+			s.AppendHistoryHandler(model.JobHistory{
+				Type:           model.JobHistoryTypeExecutionLevel,
+				JobID:          fixture.id,
+				ExecutionState: &model.StateChange[model.ExecutionStateType]{Previous: oldState, New: state},
+				Time:           time.Unix(logicalClock, 0),
+			})
+			logicalClock += 1
+		}
+	}
+}
+
+func (s *StoreSuite) TestUnfilteredJobHistory() {
+	s.setupHistoryFixture()
+	history, err := s.Store.GetJobHistory(s.ctx, "1", jobstore.JobHistoryFilterOptions{})
+	require.NoError(s.T(), err, "failed to get job history")
+	require.Equal(s.T(), 7, len(history))
+}
+
+func (s *StoreSuite) TestJobHistoryOrdering() {
+	s.setupHistoryFixture()
+	history, err := s.Store.GetJobHistory(s.ctx, "1", jobstore.JobHistoryFilterOptions{})
+	require.NoError(s.T(), err, "failed to get job history")
+	require.Equal(s.T(), 7, len(history))
+
+	values := make([]int64, len(history))
+	for i, h := range history {
+		values[i] = h.Time.Unix()
+	}
+
+	require.Equal(s.T(), []int64{0, 1, 2, 3, 4, 5, 6}, values)
+}
+
+func (s *StoreSuite) TestTimeFilteredJobHistory() {
+	s.setupHistoryFixture()
+	options := jobstore.JobHistoryFilterOptions{
+		Since: 3,
+	}
+
+	history, err := s.Store.GetJobHistory(s.ctx, "1", options)
+	require.NoError(s.T(), err, "failed to get job history")
+	require.Equal(s.T(), 4, len(history))
+}
+
+func (s *StoreSuite) TestLevelFilteredJobHistory() {
+	s.setupHistoryFixture()
+	jobOptions := jobstore.JobHistoryFilterOptions{
+		ExcludeExecutionLevel: true,
+	}
+	execOptions := jobstore.JobHistoryFilterOptions{
+		ExcludeJobLevel: true,
+	}
+
+	history, err := s.Store.GetJobHistory(s.ctx, "1", jobOptions)
+	require.NoError(s.T(), err, "failed to get job history")
+	require.Equal(s.T(), 3, len(history))
+	require.Equal(s.T(), model.JobStateQueued, history[0].JobState.New)
+
+	history, err = s.Store.GetJobHistory(s.ctx, "1", execOptions)
+	require.NoError(s.T(), err, "failed to get job history")
+	require.Equal(s.T(), 4, len(history))
+	require.Equal(s.T(), model.ExecutionStateAskForBid, history[0].ExecutionState.New)
+}
+
+func jobIDs(jobs []model.Job) []string {
+	ids := make([]string, len(jobs))
+	for i, j := range jobs {
+		ids[i] = j.ID()
+	}
+	return ids
+}
@@ -34,6 +34,10 @@ type Store interface {
 	CreateExecution(ctx context.Context, execution model.ExecutionState) error
 	// UpdateExecution updates the Job state
 	UpdateExecution(ctx context.Context, request UpdateExecutionRequest) error
+	// AddJobModeration appends an entry to the moderation audit trail of a job
+	AddJobModeration(ctx context.Context, moderation model.JobModeration) error
+	// GetJobModerations returns the moderation audit trail of a job, oldest first
+	GetJobModerations(ctx context.Context, jobID string) ([]model.JobModeration, error)
 }
 
 type UpdateJobStateRequest struct {
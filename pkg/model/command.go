@@ -18,6 +18,12 @@ type RunCommandResult struct {
 
 	// Runner error
 	ErrorMsg string `json:"runnerError"`
+
+	// number of instructions metered while running a WASM job
+	FuelConsumed uint64 `json:"fuelConsumed,omitempty"`
+
+	// resources actually consumed by the run, as measured by the executor
+	ResourceUsage *ResourceUsageMetrics `json:"resourceUsage,omitempty"`
 }
 
 func NewRunCommandResult() *RunCommandResult {
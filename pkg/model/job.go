@@ -27,6 +27,12 @@ func (j Job) String() string {
 	return j.Metadata.ID
 }
 
+// Redacted returns a copy of the job that is safe to show to users, without the values of its secrets.
+func (j Job) Redacted() Job {
+	j.Spec = j.Spec.Redacted()
+	return j
+}
+
 type Metadata struct {
 	// The unique global ID of this job in the bacalhau network.
 	ID string `json:"ID,omitempty" example:"92d5d4ee-3765-4f78-8353-623f5f26df08"`
@@ -37,6 +43,10 @@ type Metadata struct {
 	// The ID of the client that created this job.
 	ClientID string `json:"ClientID,omitempty" example:"ac13188e93c97a9c2e7cf8e86c7313156a73436036f30da1ececc2ce79f9ea51"`
 
+	// The public key of the client that created this job, matching ClientID.
+	// This can be used to encrypt the job results back to the client.
+	ClientPublicKey PublicKey `json:"ClientPublicKey,omitempty"`
+
 	Requester JobRequester `json:"Requester,omitempty"`
 }
 type JobRequester struct {
@@ -142,6 +152,9 @@ type LabelSelectorRequirement struct {
 type PublisherSpec struct {
 	Type   Publisher              `json:"Type,omitempty"`
 	Params map[string]interface{} `json:"Params,omitempty"`
+	// Encrypt the results with the client's public key before publishing them,
+	// so that only the client that submitted the job can read them.
+	Encrypt bool `json:"Encrypt,omitempty"`
 }
 
 // Spec is a complete specification of a job that can be run on some
@@ -172,6 +185,10 @@ type Spec struct {
 	// This includes the time required to run, verify and publish results
 	Timeout float64 `json:"Timeout,omitempty"`
 
+	// Priority of the job over other jobs waiting to be scheduled by the requester. Jobs with a higher priority are
+	// scheduled first, and jobs with the same priority are shared fairly between the clients that submitted them.
+	Priority int `json:"Priority,omitempty"`
+
 	// the data volumes we will read in the job
 	// for example "read this ipfs cid"
 	// TODO: #667 Replace with "Inputs", "Outputs" (note the caps) for yaml/json when we update the n.js file
@@ -190,6 +207,13 @@ type Spec struct {
 	// Do not track specified by the client
 	DoNotTrack bool `json:"DoNotTrack,omitempty"`
 
+	// DifferentialPrivacy only releases noisy declared outputs of the job, to protect the individuals in its inputs
+	DifferentialPrivacy *DifferentialPrivacySpec `json:"DifferentialPrivacy,omitempty"`
+
+	// Secrets are environment variables encrypted for the compute nodes running the job. They are only decrypted
+	// by the executor, and are redacted when the job is returned by the API or sent in events.
+	Secrets *SecretsSpec `json:"Secrets,omitempty"`
+
 	// The deal the client has made, such as which job bids they have accepted.
 	Deal Deal `json:"Deal,omitempty"`
 }
@@ -199,6 +223,12 @@ func (s *Spec) GetTimeout() time.Duration {
 	return time.Duration(s.Timeout * float64(time.Second))
 }
 
+// Redacted returns a copy of the spec without the values of its secrets.
+func (s Spec) Redacted() Spec {
+	s.Secrets = s.Secrets.Redacted()
+	return s
+}
+
 // Return pointers to all the storage specs in the spec.
 func (s *Spec) AllStorageSpecs() []*StorageSpec {
 	storages := []*StorageSpec{
@@ -266,6 +296,12 @@ type JobSpecWasm struct {
 	// TODO #880: Other WASM modules whose exports will be available as imports
 	// to the EntryModule.
 	ImportModules []StorageSpec `json:"ImportModules,omitempty"`
+
+	// Deterministic runs the modules with a fake clock and a random source
+	// seeded from the job ID, and refuses modules that import host functions
+	// whose results differ between nodes, so that every node running the job
+	// produces the same outputs. Required by the deterministic verifier.
+	Deterministic bool `json:"Deterministic,omitempty"`
 }
 
 // we emit these to other nodes so they update their
@@ -314,6 +350,9 @@ type JobCreatePayload struct {
 	// the id of the client that is submitting the job
 	ClientID string `json:"ClientID,omitempty" validate:"required"`
 
+	// the public key of the client, which must match ClientID
+	ClientPublicKey PublicKey `json:"ClientPublicKey,omitempty"`
+
 	APIVersion string `json:"APIVersion,omitempty" example:"V1beta1" validate:"required"`
 
 	// The specification of this job.
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// JobModerationAction is what happened in a single entry of the moderation audit trail of a job
+type JobModerationAction string
+
+const (
+	// JobModerationRequested is recorded by the requester when the job is held for moderation
+	JobModerationRequested JobModerationAction = "requested"
+	// JobModerationApproved is recorded when an approver votes to run the job
+	JobModerationApproved JobModerationAction = "approved"
+	// JobModerationRejected is recorded when an approver votes not to run the job
+	JobModerationRejected JobModerationAction = "rejected"
+)
+
+// JobModeration is a single entry in the moderation audit trail of a job.
+// ClientID and Roles are those of the approver, and are empty for the entries
+// recorded by the requester.
+type JobModeration struct {
+	JobID    string              `json:"JobID"`
+	ClientID string              `json:"ClientID,omitempty"`
+	Roles    []string            `json:"Roles,omitempty"`
+	Action   JobModerationAction `json:"Action"`
+	Reason   string              `json:"Reason,omitempty"`
+	Time     time.Time           `json:"Time"`
+}
@@ -38,6 +38,9 @@ type JobSelectionPolicy struct {
 	// if either of these are given they will override the data locality settings
 	ProbeHTTP string `json:"probe_http,omitempty"`
 	ProbeExec string `json:"probe_exec,omitempty"`
+	// a file describing who may compute over which datasets held by this node,
+	// reloaded when it changes
+	AccessPolicyFile string `json:"access_policy_file,omitempty"`
 }
 
 // generate a default empty job selection policy
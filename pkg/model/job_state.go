@@ -80,6 +80,9 @@ type JobState struct {
 	UpdateTime time.Time `json:"UpdateTime"`
 	// TimeoutAt is the time when the job will be timed out if it is not completed.
 	TimeoutAt time.Time `json:"TimeoutAt,omitempty"`
+	// QueuePosition is the position of the job in the requester's queue of jobs waiting to start,
+	// starting at 1. It is only populated by the requester API, and is zero when the job is not waiting in the queue.
+	QueuePosition int `json:"QueuePosition,omitempty"`
 }
 
 // GroupExecutionsByState groups the executions by state
@@ -27,6 +27,12 @@ type NodeInfo struct {
 	NodeType        NodeType          `json:"NodeType"`
 	Labels          map[string]string `json:"Labels"`
 	ComputeNodeInfo *ComputeNodeInfo  `json:"ComputeNodeInfo"`
+	// PublicKey is the marshaled libp2p public key of the node, used by clients to encrypt data for the node
+	PublicKey PublicKey `json:"PublicKey,omitempty"`
+	// Protocols maps the name of each bacalhau protocol the node serves or dials, without its version, to the
+	// versions of that protocol the node supports from the most to the least preferred.
+	// Nodes that predate protocol negotiation don't advertise their protocols.
+	Protocols map[string][]string `json:"Protocols,omitempty"`
 }
 
 // IsComputeNode returns true if the node is a compute node
@@ -34,6 +40,19 @@ func (n NodeInfo) IsComputeNode() bool {
 	return n.NodeType == NodeTypeCompute
 }
 
+// SelectorLabels returns the labels jobs can select the node on, which are the
+// labels of the node and the ones derived from its sandbox profile.
+func (n NodeInfo) SelectorLabels() map[string]string {
+	if n.ComputeNodeInfo == nil || n.ComputeNodeInfo.Sandbox == nil {
+		return n.Labels
+	}
+	labels := n.ComputeNodeInfo.Sandbox.Labels()
+	for key, value := range n.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
 type ComputeNodeInfo struct {
 	ExecutionEngines   []Engine            `json:"ExecutionEngines"`
 	Verifiers          []Verifier          `json:"Verifiers"`
@@ -44,4 +63,17 @@ type ComputeNodeInfo struct {
 	MaxJobRequirements ResourceUsageData   `json:"MaxJobRequirements"`
 	RunningExecutions  int                 `json:"RunningExecutions"`
 	EnqueuedExecutions int                 `json:"EnqueuedExecutions"`
+	// GPUs are the GPU devices the node runs jobs on, and AvailableGPUs those not in use by an execution
+	GPUs          []GPU `json:"GPUs,omitempty"`
+	AvailableGPUs []GPU `json:"AvailableGPUs,omitempty"`
+	// Sandbox is the profile the node isolates the containers of docker jobs with, if any
+	Sandbox *SandboxProfile `json:"Sandbox,omitempty"`
+}
+
+// GPU is a GPU device of a compute node
+type GPU struct {
+	// Index of the device, as used to select it when running containers
+	Index uint64 `json:"Index"`
+	// Name is the model of the device
+	Name string `json:"Name"`
 }
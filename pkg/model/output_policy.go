@@ -0,0 +1,17 @@
+package model
+
+// describe the rules a compute node enforces on the outputs of the jobs it
+// runs, before the outputs are proposed for verification
+type OutputPolicy struct {
+	// the maximum total size in bytes of each output volume
+	// zero means no limit
+	MaxVolumeSize uint64 `json:"max_volume_size,omitempty"`
+	// the types of the files allowed in the outputs, as file extensions
+	// (e.g. ".csv") or media types (e.g. "text/plain", or "text/" for any text)
+	// any file type is allowed if empty
+	AllowedFileTypes []string `json:"allowed_file_types,omitempty"`
+	// external hooks that must approve the outputs, in the same way as the
+	// job selection probes
+	InspectHTTP string `json:"inspect_http,omitempty"`
+	InspectExec string `json:"inspect_exec,omitempty"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+type PrivacyMechanism string
+
+const (
+	// PrivacyMechanismLaplace adds Laplace noise calibrated to the L1 sensitivity, for pure epsilon-DP.
+	PrivacyMechanismLaplace PrivacyMechanism = "laplace"
+	// PrivacyMechanismGaussian adds Gaussian noise calibrated to the L2 sensitivity, for (epsilon, delta)-DP with
+	// epsilon < 1.
+	PrivacyMechanismGaussian PrivacyMechanism = "gaussian"
+)
+
+// DifferentialPrivacySpec asks the compute node to release only the declared numeric outputs of a job, after adding
+// noise calibrated so that the release is differentially private. Everything else produced by the job, including its
+// stdout and stderr, is discarded.
+type DifferentialPrivacySpec struct {
+	Mechanism PrivacyMechanism `json:"Mechanism,omitempty"`
+	// Epsilon is the privacy loss of the job, spent from the privacy budget of each of its inputs
+	Epsilon float64 `json:"Epsilon,omitempty"`
+	// Delta is the probability of exceeding Epsilon, only used by the gaussian mechanism
+	Delta float64 `json:"Delta,omitempty"`
+	// Sensitivity is how much all the numeric values of the declared outputs can change together when the data of a
+	// single individual is added or removed, measured with the L1 norm for laplace and the L2 norm for gaussian
+	Sensitivity float64 `json:"Sensitivity,omitempty"`
+	// Outputs are the CSV or JSON files to release, relative to the results directory (e.g. outputs/stats.csv)
+	Outputs []string `json:"Outputs,omitempty"`
+}
+
+func (s *DifferentialPrivacySpec) IsValid() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Mechanism {
+	case PrivacyMechanismLaplace:
+	case PrivacyMechanismGaussian:
+		if s.Delta <= 0 || s.Delta >= 1 {
+			return fmt.Errorf("differential privacy delta must be between 0 and 1 for the gaussian mechanism")
+		}
+		// the calibration of the gaussian mechanism doesn't guarantee (epsilon, delta)-DP for larger epsilons
+		if s.Epsilon >= 1 {
+			return fmt.Errorf("differential privacy epsilon must be < 1 for the gaussian mechanism")
+		}
+	default:
+		return fmt.Errorf("invalid differential privacy mechanism %q", s.Mechanism)
+	}
+	if s.Epsilon <= 0 {
+		return fmt.Errorf("differential privacy epsilon must be > 0")
+	}
+	if s.Sensitivity <= 0 {
+		return fmt.Errorf("differential privacy sensitivity must be > 0")
+	}
+	if len(s.Outputs) == 0 {
+		return fmt.Errorf("differential privacy requires at least one declared output")
+	}
+	for _, output := range s.Outputs {
+		if !filepath.IsLocal(output) || path.Clean(output) != output {
+			return fmt.Errorf("differential privacy output %q must be a clean path relative to the results", output)
+		}
+		if ext := filepath.Ext(output); ext != ".csv" && ext != ".json" {
+			return fmt.Errorf("differential privacy output %q must be a .csv or .json file", output)
+		}
+	}
+	return nil
+}
@@ -136,3 +136,23 @@ type ResourceUsageProfile struct {
 	// what is the total amount of resources available to the system
 	SystemTotal ResourceUsageData `json:"SystemTotal,omitempty"`
 }
+
+// ResourceUsageMetrics are the resources actually consumed by an execution, as sampled by the executor while it ran,
+// as opposed to the ResourceUsageData requested by the job and reserved on the compute node.
+type ResourceUsageMetrics struct {
+	// cpu time in seconds
+	CPUSeconds float64 `json:"CPUSeconds,omitempty" example:"1.5"`
+	// bytes
+	PeakMemory uint64 `json:"PeakMemory,omitempty" example:"104857600"`
+	// bytes written to the output volumes
+	DiskWritten uint64 `json:"DiskWritten,omitempty" example:"1048576"`
+	// bytes received and sent over the network
+	NetworkRxBytes uint64 `json:"NetworkRxBytes,omitempty" example:"2048"`
+	NetworkTxBytes uint64 `json:"NetworkTxBytes,omitempty" example:"1024"`
+}
+
+// return string representation of ResourceUsageMetrics
+func (r ResourceUsageMetrics) String() string {
+	return fmt.Sprintf("{CPUSeconds: %f, PeakMemory: %d, DiskWritten: %d, NetworkRxBytes: %d, NetworkTxBytes: %d}",
+		r.CPUSeconds, r.PeakMemory, r.DiskWritten, r.NetworkRxBytes, r.NetworkTxBytes)
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// SandboxLabelPrefix prefixes the labels derived from the sandbox profile of
+// compute nodes, so that jobs can require a sandbox with node selectors
+// (e.g. --selector sandbox.bacalhau.org/runtime=runsc).
+const SandboxLabelPrefix = "sandbox.bacalhau.org/"
+
+// SandboxProfile describes how a compute node isolates the containers of
+// docker jobs from the host. The zero value runs containers with the docker
+// defaults.
+type SandboxProfile struct {
+	// mount the root filesystem of the container as read-only
+	ReadOnlyRootfs bool `json:"ReadOnlyRootfs,omitempty"`
+	// paths mounted as writable in-memory scratch space, which is /tmp if
+	// not set and the root filesystem is read-only
+	Tmpfs []string `json:"Tmpfs,omitempty"`
+	// kernel capabilities removed from and added to the container, e.g. ALL
+	CapDrop []string `json:"CapDrop,omitempty"`
+	CapAdd  []string `json:"CapAdd,omitempty"`
+	// prevent processes from gaining privileges, e.g. through setuid binaries
+	NoNewPrivileges bool `json:"NoNewPrivileges,omitempty"`
+	// path on the compute node of a seccomp profile in JSON
+	SeccompProfile string `json:"SeccompProfile,omitempty"`
+	// name of an AppArmor profile loaded on the compute node
+	AppArmorProfile string `json:"AppArmorProfile,omitempty"`
+	// maximum number of processes in the container, zero means no limit
+	PidsLimit int64 `json:"PidsLimit,omitempty"`
+	// resource limits of the processes in the container
+	Ulimits []Ulimit `json:"Ulimits,omitempty"`
+	// user and optionally group the container runs as, e.g. 65534:65534
+	User string `json:"User,omitempty"`
+	// name of the OCI runtime registered with docker to run the container
+	// with, e.g. runsc for gVisor
+	Runtime string `json:"Runtime,omitempty"`
+}
+
+// Ulimit is a resource limit of the processes in a container, e.g. nofile
+type Ulimit struct {
+	Name string `json:"Name"`
+	Soft int64  `json:"Soft"`
+	Hard int64  `json:"Hard"`
+}
+
+// ParseUlimit parses a ulimit in name=soft[:hard] form, as used by docker
+func ParseUlimit(s string) (Ulimit, error) {
+	name, limits, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return Ulimit{}, fmt.Errorf("invalid ulimit %q, expected name=soft[:hard]", s)
+	}
+	softStr, hardStr, hasHard := strings.Cut(limits, ":")
+	soft, err := strconv.ParseInt(softStr, 10, 64)
+	if err != nil {
+		return Ulimit{}, fmt.Errorf("invalid soft limit in ulimit %q: %w", s, err)
+	}
+	hard := soft
+	if hasHard {
+		if hard, err = strconv.ParseInt(hardStr, 10, 64); err != nil {
+			return Ulimit{}, fmt.Errorf("invalid hard limit in ulimit %q: %w", s, err)
+		}
+	}
+	if soft > hard {
+		return Ulimit{}, fmt.Errorf("soft limit of ulimit %q is greater than its hard limit", s)
+	}
+	return Ulimit{Name: name, Soft: soft, Hard: hard}, nil
+}
+
+func (u Ulimit) String() string {
+	return fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard)
+}
+
+// IsZero returns true if the profile runs containers with the docker defaults
+func (p SandboxProfile) IsZero() bool {
+	return reflect.DeepEqual(p, SandboxProfile{})
+}
+
+// TmpfsPaths returns the paths mounted as in-memory scratch space
+func (p SandboxProfile) TmpfsPaths() []string {
+	if len(p.Tmpfs) == 0 && p.ReadOnlyRootfs {
+		return []string{"/tmp"}
+	}
+	return p.Tmpfs
+}
+
+// IsNonRoot returns true if containers run as a user other than root
+func (p SandboxProfile) IsNonRoot() bool {
+	user, _, _ := strings.Cut(p.User, ":")
+	return user != "" && user != "0" && user != "root"
+}
+
+// IsValid returns an error if the profile can't be applied to containers
+func (p SandboxProfile) IsValid() error {
+	for _, path := range p.TmpfsPaths() {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("tmpfs path %q must be absolute", path)
+		}
+	}
+	if p.PidsLimit < 0 {
+		return fmt.Errorf("pids limit must be >= 0")
+	}
+	for _, ulimit := range p.Ulimits {
+		if ulimit.Name == "" || ulimit.Soft > ulimit.Hard {
+			return fmt.Errorf("invalid ulimit %s", ulimit)
+		}
+	}
+	return nil
+}
+
+// Labels returns labels describing the profile that jobs can select compute
+// nodes on. No labels are returned for the zero profile.
+func (p SandboxProfile) Labels() map[string]string {
+	labels := make(map[string]string)
+	setIf := func(name string, set bool) {
+		if set {
+			labels[SandboxLabelPrefix+name] = "true"
+		}
+	}
+	setIf("read-only-rootfs", p.ReadOnlyRootfs)
+	setIf("drop-all-capabilities", slices.ContainsFunc(p.CapDrop, func(c string) bool { return strings.EqualFold(c, "ALL") }))
+	setIf("no-new-privileges", p.NoNewPrivileges)
+	setIf("seccomp", p.SeccompProfile != "")
+	setIf("apparmor", p.AppArmorProfile != "")
+	setIf("non-root", p.IsNonRoot())
+	if p.PidsLimit > 0 {
+		labels[SandboxLabelPrefix+"pids-limit"] = strconv.FormatInt(p.PidsLimit, 10)
+	}
+	if p.Runtime != "" {
+		labels[SandboxLabelPrefix+"runtime"] = p.Runtime
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUlimit(t *testing.T) {
+	ulimit, err := ParseUlimit("nofile=1024:2048")
+	require.NoError(t, err)
+	require.Equal(t, Ulimit{Name: "nofile", Soft: 1024, Hard: 2048}, ulimit)
+	require.Equal(t, "nofile=1024:2048", ulimit.String())
+
+	ulimit, err = ParseUlimit("nproc=64")
+	require.NoError(t, err)
+	require.Equal(t, Ulimit{Name: "nproc", Soft: 64, Hard: 64}, ulimit)
+
+	for _, invalid := range []string{"nofile", "=1", "nofile=a", "nofile=1:b", "nofile=2:1"} {
+		_, err = ParseUlimit(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestSandboxProfile_Labels(t *testing.T) {
+	require.True(t, SandboxProfile{}.IsZero())
+	require.Empty(t, SandboxProfile{}.Labels())
+
+	profile := SandboxProfile{
+		ReadOnlyRootfs:  true,
+		CapDrop:         []string{"all"},
+		NoNewPrivileges: true,
+		SeccompProfile:  "/etc/bacalhau/seccomp.json",
+		PidsLimit:       128,
+		User:            "65534:65534",
+		Runtime:         "runsc",
+	}
+	require.False(t, profile.IsZero())
+	require.Equal(t, map[string]string{
+		"sandbox.bacalhau.org/read-only-rootfs":      "true",
+		"sandbox.bacalhau.org/drop-all-capabilities": "true",
+		"sandbox.bacalhau.org/no-new-privileges":     "true",
+		"sandbox.bacalhau.org/seccomp":               "true",
+		"sandbox.bacalhau.org/non-root":              "true",
+		"sandbox.bacalhau.org/pids-limit":            "128",
+		"sandbox.bacalhau.org/runtime":               "runsc",
+	}, profile.Labels())
+	require.Equal(t, []string{"/tmp"}, profile.TmpfsPaths())
+
+	require.False(t, SandboxProfile{User: "0:1000"}.IsNonRoot())
+	require.False(t, SandboxProfile{User: "root"}.IsNonRoot())
+}
+
+func TestSandboxProfile_IsValid(t *testing.T) {
+	require.NoError(t, SandboxProfile{}.IsValid())
+	require.NoError(t, SandboxProfile{ReadOnlyRootfs: true, Tmpfs: []string{"/scratch"}}.IsValid())
+	require.Error(t, SandboxProfile{Tmpfs: []string{"scratch"}}.IsValid())
+	require.Error(t, SandboxProfile{PidsLimit: -1}.IsValid())
+	require.Error(t, SandboxProfile{Ulimits: []Ulimit{{Name: "nofile", Soft: 2, Hard: 1}}}.IsValid())
+}
+
+func TestNodeInfo_SelectorLabels(t *testing.T) {
+	node := NodeInfo{Labels: map[string]string{"region": "eu"}}
+	require.Equal(t, map[string]string{"region": "eu"}, node.SelectorLabels())
+
+	node.ComputeNodeInfo = &ComputeNodeInfo{Sandbox: &SandboxProfile{Runtime: "runsc"}}
+	require.Equal(t, map[string]string{
+		"region":                       "eu",
+		"sandbox.bacalhau.org/runtime": "runsc",
+	}, node.SelectorLabels())
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SecretsSpec holds environment variables of a job whose values must not be visible outside of the compute nodes
+// running it. The values are encrypted by the client with a random data key, which is wrapped with the public key of
+// each compute node allowed to run the job, the same way as encrypted storage specs.
+type SecretsSpec struct {
+	// Values maps the name of each environment variable to its value encrypted with the data key.
+	Values map[string][]byte `json:"Values,omitempty"`
+
+	// Encryption holds the data key wrapped for each compute node that can decrypt the values.
+	Encryption *EncryptionSpec `json:"Encryption,omitempty"`
+}
+
+// Names returns the sorted names of the secrets.
+func (s *SecretsSpec) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.Values))
+	for name := range s.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redacted returns a copy of the secrets that only holds their names, so that it can be shown to users and sent
+// in events without exposing the encrypted values or the wrapped keys.
+func (s *SecretsSpec) Redacted() *SecretsSpec {
+	if s == nil {
+		return nil
+	}
+	values := make(map[string][]byte, len(s.Values))
+	for name := range s.Values {
+		values[name] = nil
+	}
+	return &SecretsSpec{Values: values}
+}
+
+// IsValid returns an error if the secrets can't be decrypted or injected as environment variables.
+func (s *SecretsSpec) IsValid() error {
+	if s == nil || len(s.Values) == 0 {
+		return nil
+	}
+	if s.Encryption == nil || len(s.Encryption.WrappedKeys) == 0 {
+		return fmt.Errorf("secrets must be encrypted for at least one compute node")
+	}
+	for name := range s.Values {
+		if name == "" || strings.ContainsAny(name, "= \t\n") {
+			return fmt.Errorf("invalid secret name %q", name)
+		}
+	}
+	return nil
+}
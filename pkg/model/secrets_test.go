@@ -0,0 +1,57 @@
+//go:build unit || !integration
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsSpec_Redacted(t *testing.T) {
+	job := Job{Spec: Spec{Secrets: &SecretsSpec{
+		Values: map[string][]byte{"TOKEN": []byte("encrypted"), "API_KEY": []byte("encrypted")},
+		Encryption: &EncryptionSpec{
+			Algorithm:   EncryptionAlgorithmAES256GCM,
+			WrappedKeys: map[string][]byte{"node": []byte("wrapped")},
+		},
+	}}}
+
+	redacted := job.Redacted()
+	require.Equal(t, []string{"API_KEY", "TOKEN"}, redacted.Spec.Secrets.Names())
+	require.Nil(t, redacted.Spec.Secrets.Encryption)
+	data, err := json.Marshal(redacted)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "ZW5jcnlwdGVk") // base64 of "encrypted"
+	require.NotContains(t, string(data), "d3JhcHBlZA")   // base64 of "wrapped"
+
+	// the original job is left untouched
+	require.Equal(t, []byte("encrypted"), job.Spec.Secrets.Values["TOKEN"])
+	require.NotNil(t, job.Spec.Secrets.Encryption)
+	require.Nil(t, Job{}.Redacted().Spec.Secrets)
+}
+
+func TestSecretsSpec_IsValid(t *testing.T) {
+	encryption := &EncryptionSpec{WrappedKeys: map[string][]byte{"node": []byte("wrapped")}}
+	tests := []struct {
+		name    string
+		secrets *SecretsSpec
+		wantErr bool
+	}{
+		{name: "no-secrets", secrets: nil},
+		{name: "encrypted", secrets: &SecretsSpec{Values: map[string][]byte{"TOKEN": nil}, Encryption: encryption}},
+		{name: "not-encrypted", secrets: &SecretsSpec{Values: map[string][]byte{"TOKEN": nil}}, wantErr: true},
+		{name: "invalid-name", secrets: &SecretsSpec{Values: map[string][]byte{"A=B": nil}, Encryption: encryption}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.secrets.IsValid()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -36,6 +36,10 @@ type StorageSpec struct {
 
 	// Additional properties specific to each driver
 	Metadata map[string]string `json:"Metadata,omitempty"`
+
+	// Encryption is set if the data was encrypted by the client, and can only
+	// be decrypted by the compute nodes the data key was wrapped for.
+	Encryption *EncryptionSpec `json:"Encryption,omitempty"`
 }
 
 type S3StorageSpec struct {
@@ -59,6 +63,34 @@ type DownloadItem struct {
 	Name       string
 	CID        string
 	URL        string
+	S3         *S3StorageSpec
 	SourceType StorageSourceType
 	Target     string
 }
+
+// EncryptionAlgorithm is the symmetric cipher used to encrypt the data of a storage spec
+type EncryptionAlgorithm string
+
+const (
+	// EncryptionAlgorithmAES256GCM encrypts the data in fixed size chunks with AES-256 in GCM mode
+	EncryptionAlgorithmAES256GCM EncryptionAlgorithm = "AES-256-GCM"
+)
+
+// EncryptionSpec describes how the data of a storage spec was encrypted by the client. The data is encrypted with a
+// random data key, which is then wrapped with the public key of every compute node that is allowed to read the data.
+type EncryptionSpec struct {
+	Algorithm EncryptionAlgorithm `json:"Algorithm,omitempty"`
+
+	// WrappedKeys maps the ID of each compute node that can decrypt the data to
+	// the data key encrypted with that node's public key.
+	WrappedKeys map[string][]byte `json:"WrappedKeys,omitempty"`
+}
+
+// CanDecrypt returns true if the data key was wrapped for the given node
+func (e *EncryptionSpec) CanDecrypt(nodeID string) bool {
+	if e == nil {
+		return true
+	}
+	_, ok := e.WrappedKeys[nodeID]
+	return ok
+}
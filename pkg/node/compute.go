@@ -12,11 +12,12 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity/disk"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/logstream"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/outputs"
 	compute_publicapi "github.com/bacalhau-project/bacalhau/pkg/compute/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/sensors"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store/boltdb"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inlocalstore"
-	"github.com/bacalhau-project/bacalhau/pkg/compute/store/inmemory"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	executor_util "github.com/bacalhau-project/bacalhau/pkg/executor/util"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -28,8 +29,10 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/transport/bprotocol"
 	simulator_protocol "github.com/bacalhau-project/bacalhau/pkg/transport/simulator"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
 	"github.com/libp2p/go-libp2p/core/host"
+	"go.uber.org/multierr"
 )
 
 type Compute struct {
@@ -44,6 +47,7 @@ type Compute struct {
 	computeCallback     *bprotocol.CallbackProxy
 	cleanupFunc         func(ctx context.Context)
 	computeInfoProvider model.ComputeNodeInfoProvider
+	reconciler          *compute.Reconciler
 }
 
 //nolint:funlen
@@ -58,9 +62,18 @@ func NewComputeNode(
 	storages storage.StorageProvider,
 	executors executor.ExecutorProvider,
 	verifiers verifier.VerifierProvider,
-	publishers publisher.PublisherProvider) (*Compute, error) {
+	publishers publisher.PublisherProvider,
+	protocolVersions []string) (*Compute, error) {
+	orderingPolicy, err := compute.NewOrderingPolicy(compute.OrderingPolicyParams{
+		Ordering:                   config.ExecutorBufferOrdering,
+		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// create the execution store
-	executionStore, err := createExecutionStore(host)
+	executionStore, closeExecutionStore, err := createExecutionStore(host)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +89,8 @@ func NewComputeNode(
 	// Callback to send compute events (i.e. requester endpoint)
 	var computeCallback compute.Callback
 	standardComputeCallback := bprotocol.NewCallbackProxy(bprotocol.CallbackProxyParams{
-		Host: host,
+		Host:     host,
+		Versions: protocolVersions,
 	})
 	if simulatorNodeID != "" {
 		simulatorProxy := simulator_protocol.NewCallbackProxy(simulator_protocol.CallbackProxyParams{
@@ -95,6 +109,13 @@ func NewComputeNode(
 		computeCallback = standardComputeCallback
 	}
 
+	outputInspector := outputs.NewChainedInspector(
+		outputs.FromOutputPolicy(config.OutputPolicy),
+		// the output size limits of the access policy can only be checked once the job ran
+		bidstrategy.NewAccessPolicyStrategy(bidstrategy.AccessPolicyStrategyParams{
+			Path: config.JobSelectionPolicy.AccessPolicyFile,
+		}),
+	)
 	baseExecutor := compute.NewBaseExecutor(compute.BaseExecutorParams{
 		ID:              host.ID().String(),
 		Callback:        computeCallback,
@@ -102,6 +123,7 @@ func NewComputeNode(
 		Executors:       executors,
 		Verifiers:       verifiers,
 		Publishers:      publishers,
+		OutputInspector: outputInspector,
 		SimulatorConfig: config.SimulatorConfig,
 	})
 
@@ -111,8 +133,12 @@ func NewComputeNode(
 		Callback:                   computeCallback,
 		RunningCapacityTracker:     runningCapacityTracker,
 		EnqueuedCapacityTracker:    enqueuedCapacityTracker,
+		ResultsUsage:               baseExecutor,
 		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
 		BackoffDuration:            config.ExecutorBufferBackoffDuration,
+		OrderingPolicy:             orderingPolicy,
+		MaxEnqueuedAge:             config.ExecutorBufferMaxEnqueuedAge,
+		Preemption:                 config.ExecutorBufferPreemption,
 	})
 	runningInfoProvider := sensors.NewRunningExecutionsInfoProvider(sensors.RunningExecutionsInfoProviderParams{
 		Name:          "ActiveJobs",
@@ -202,8 +228,10 @@ func NewComputeNode(
 		Publisher:          publishers,
 		Storages:           storages,
 		CapacityTracker:    runningCapacityTracker,
+		GPUAllocator:       config.GPUAllocator,
 		ExecutorBuffer:     bufferRunner,
 		MaxJobRequirements: config.JobResourceLimits,
+		Sandbox:            config.Sandbox,
 	})
 
 	bidder := compute.NewBidder(compute.BidderParams{
@@ -230,11 +258,15 @@ func NewComputeNode(
 		bprotocol.NewComputeHandler(bprotocol.ComputeHandlerParams{
 			Host:            host,
 			ComputeEndpoint: simulatorRequestHandler,
+			Versions:        protocolVersions,
+			TrustedPeers:    trustedPeers(simulatorNodeID),
 		})
 	} else {
 		bprotocol.NewComputeHandler(bprotocol.ComputeHandlerParams{
 			Host:            host,
 			ComputeEndpoint: baseEndpoint,
+			Versions:        protocolVersions,
+			TrustedPeers:    trustedPeers(simulatorNodeID),
 		})
 	}
 
@@ -258,9 +290,18 @@ func NewComputeNode(
 
 	// A single cleanup function to make sure the order of closing dependencies is correct
 	cleanupFunc := func(ctx context.Context) {
-		// pass
+		cleanupErr := closeExecutionStore(ctx)
+		util.LogDebugIfContextCancelled(ctx, cleanupErr, "execution store")
 	}
 
+	reconciler := compute.NewReconciler(compute.ReconcilerParams{
+		ID:        host.ID().String(),
+		Store:     executionStore,
+		Executors: executors,
+		Executor:  bufferRunner,
+		Callback:  computeCallback,
+	})
+
 	return &Compute{
 		ID:                  host.ID().String(),
 		LocalEndpoint:       baseEndpoint,
@@ -272,6 +313,7 @@ func NewComputeNode(
 		computeCallback:     standardComputeCallback,
 		cleanupFunc:         cleanupFunc,
 		computeInfoProvider: nodeInfoProvider,
+		reconciler:          reconciler,
 	}, nil
 }
 
@@ -279,23 +321,37 @@ func (c *Compute) RegisterLocalComputeCallback(callback compute.Callback) {
 	c.computeCallback.RegisterLocalComputeCallback(callback)
 }
 
-func createExecutionStore(host host.Host) (store.ExecutionStore, error) {
+func createExecutionStore(host host.Host) (store.ExecutionStore, func(context.Context) error, error) {
 	// include the host id in the state root dir to avoid conflicts when running multiple nodes on the same machine,
 	// e.g. when running tests or when running devstack
 	configDir, err := system.EnsureConfigDir()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	stateRootDir := filepath.Join(configDir, "execution-state-"+host.ID().String())
 	err = os.MkdirAll(stateRootDir, os.ModePerm)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return inlocalstore.NewPersistentExecutionStore(inlocalstore.PersistentJobStoreParams{
-		Store:   inmemory.NewStore(),
+	boltStore, err := boltdb.NewStore(filepath.Join(stateRootDir, "executions.db"))
+	if err != nil {
+		return nil, nil, err
+	}
+	executionStore, err := inlocalstore.NewPersistentExecutionStore(inlocalstore.PersistentJobStoreParams{
+		Store:   boltStore,
 		RootDir: stateRootDir,
 	})
+	if err != nil {
+		return nil, nil, multierr.Append(err, boltStore.Close(context.Background()))
+	}
+	return executionStore, boltStore.Close, nil
+}
+
+// Reconcile brings the executions of a previous run of the node in line with the executors. It should be called once
+// the callbacks to local requesters are registered, so that they are told about the executions that failed.
+func (c *Compute) Reconcile(ctx context.Context) error {
+	return c.reconciler.Reconcile(ctx)
 }
 
 func (c *Compute) cleanup(ctx context.Context) {
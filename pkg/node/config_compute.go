@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
@@ -18,8 +19,13 @@ type ComputeConfigParams struct {
 	DefaultJobResourceLimits     model.ResourceUsageData
 	PhysicalResourcesProvider    capacity.Provider
 	IgnorePhysicalResourceLimits bool
+	// GPUProvider lists the GPU devices jobs can run on
+	GPUProvider capacity.GPUProvider
 
 	ExecutorBufferBackoffDuration time.Duration
+	ExecutorBufferOrdering        compute.BufferOrdering
+	ExecutorBufferMaxEnqueuedAge  time.Duration
+	ExecutorBufferPreemption      bool
 
 	// Timeout config
 	JobNegotiationTimeout      time.Duration
@@ -32,6 +38,15 @@ type ComputeConfigParams struct {
 	// Bid strategies config
 	JobSelectionPolicy model.JobSelectionPolicy
 
+	// Rules enforced on the outputs of executions
+	OutputPolicy model.OutputPolicy
+
+	// Maximum size in bytes of the compiled WASM modules cached on disk
+	WasmModuleCacheSize uint64
+
+	// How the containers of docker jobs are isolated from the host
+	Sandbox model.SandboxProfile
+
 	// logging running executions
 	LogRunningExecutionsInterval time.Duration
 
@@ -47,9 +62,19 @@ type ComputeConfig struct {
 	JobResourceLimits            model.ResourceUsageData
 	DefaultJobResourceLimits     model.ResourceUsageData
 	IgnorePhysicalResourceLimits bool
+	// GPUAllocator hands out the GPU devices of the node to executions
+	GPUAllocator capacity.GPUAllocator
 
 	// How long the buffer would backoff before polling the queue again for new jobs
 	ExecutorBufferBackoffDuration time.Duration
+	// ExecutorBufferOrdering is the policy ordering the executions waiting in the buffer for capacity to run them
+	ExecutorBufferOrdering compute.BufferOrdering
+	// ExecutorBufferMaxEnqueuedAge is how long an execution can wait in the buffer before it reserves the capacity
+	// being freed, and the executions after it wait until it runs. Executions never reserve capacity if zero.
+	ExecutorBufferMaxEnqueuedAge time.Duration
+	// ExecutorBufferPreemption enables stopping running executions of jobs with a lower priority to run an execution,
+	// in which case they wait in the buffer to run again.
+	ExecutorBufferPreemption bool
 
 	// JobNegotiationTimeout default timeout value to hold a bid for a job
 	JobNegotiationTimeout time.Duration
@@ -70,6 +95,17 @@ type ComputeConfig struct {
 	// Bid strategies config
 	JobSelectionPolicy model.JobSelectionPolicy
 
+	// Rules enforced on the outputs of executions
+	OutputPolicy model.OutputPolicy
+
+	// WasmModuleCacheSize is the maximum size in bytes of the compiled WASM modules cached on disk. Least recently
+	// used modules are evicted once the cache grows over it.
+	WasmModuleCacheSize uint64
+
+	// Sandbox is the profile the containers of docker jobs are isolated from the host with. It is advertised to
+	// requester nodes so that jobs can select nodes on it.
+	Sandbox model.SandboxProfile
+
 	// logging running executions
 	LogRunningExecutionsInterval time.Duration
 
@@ -108,6 +144,12 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 	if params.ExecutorBufferBackoffDuration == 0 {
 		params.ExecutorBufferBackoffDuration = DefaultComputeConfig.ExecutorBufferBackoffDuration
 	}
+	if params.ExecutorBufferOrdering == "" {
+		params.ExecutorBufferOrdering = DefaultComputeConfig.ExecutorBufferOrdering
+	}
+	if params.WasmModuleCacheSize == 0 {
+		params.WasmModuleCacheSize = DefaultComputeConfig.WasmModuleCacheSize
+	}
 
 	// Get available physical resources in the host
 	physicalResourcesProvider := params.PhysicalResourcesProvider
@@ -123,6 +165,19 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 		Intersect(DefaultComputeConfig.TotalResourceLimits).
 		Intersect(physicalResources)
 
+	gpuProvider := params.GPUProvider
+	if gpuProvider == nil {
+		gpuProvider = DefaultComputeConfig.GPUProvider
+	}
+	gpus, err := gpuProvider.GetGPUs(context.Background())
+	if err != nil {
+		return
+	}
+	// only hand out as many devices as the node is allowed to use
+	if uint64(len(gpus)) > totalResourceLimits.GPU {
+		gpus = gpus[:totalResourceLimits.GPU]
+	}
+
 	// populate job resource limits with default values and total resource limits if not set
 	jobResourceLimits := params.JobResourceLimits.
 		Intersect(DefaultComputeConfig.JobResourceLimits).
@@ -144,7 +199,11 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 		JobResourceLimits:             jobResourceLimits,
 		DefaultJobResourceLimits:      defaultJobResourceLimits,
 		IgnorePhysicalResourceLimits:  params.IgnorePhysicalResourceLimits,
+		GPUAllocator:                  capacity.NewLocalGPUAllocator(capacity.LocalGPUAllocatorParams{GPUs: gpus}),
 		ExecutorBufferBackoffDuration: params.ExecutorBufferBackoffDuration,
+		ExecutorBufferOrdering:        params.ExecutorBufferOrdering,
+		ExecutorBufferMaxEnqueuedAge:  params.ExecutorBufferMaxEnqueuedAge,
+		ExecutorBufferPreemption:      params.ExecutorBufferPreemption,
 
 		JobNegotiationTimeout:      params.JobNegotiationTimeout,
 		MinJobExecutionTimeout:     params.MinJobExecutionTimeout,
@@ -154,6 +213,10 @@ func NewComputeConfigWith(params ComputeConfigParams) (config ComputeConfig) {
 		JobExecutionTimeoutClientIDBypassList: params.JobExecutionTimeoutClientIDBypassList,
 
 		JobSelectionPolicy: params.JobSelectionPolicy,
+		OutputPolicy:       params.OutputPolicy,
+
+		WasmModuleCacheSize: params.WasmModuleCacheSize,
+		Sandbox:             params.Sandbox,
 
 		LogRunningExecutionsInterval: params.LogRunningExecutionsInterval,
 		SimulatorConfig:              params.SimulatorConfig,
@@ -3,23 +3,28 @@ package node
 import (
 	"time"
 
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
 	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity/system"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 )
 
 var DefaultComputeConfig = ComputeConfigParams{
 	PhysicalResourcesProvider: system.NewPhysicalCapacityProvider(),
+	GPUProvider:               system.NewNvidiaGPUProvider(),
 	DefaultJobResourceLimits: model.ResourceUsageData{
 		CPU:    0.1,               // 100m
 		Memory: 100 * 1024 * 1024, // 100Mi
 	},
 	ExecutorBufferBackoffDuration: 50 * time.Millisecond,
+	ExecutorBufferOrdering:        compute.BufferOrderingFIFO,
 
 	JobNegotiationTimeout:      3 * time.Minute,
 	MinJobExecutionTimeout:     500 * time.Millisecond,
 	MaxJobExecutionTimeout:     60 * time.Minute,
 	DefaultJobExecutionTimeout: 10 * time.Minute,
 
+	WasmModuleCacheSize: 1024 * 1024 * 1024, // 1Gi
+
 	LogRunningExecutionsInterval: 10 * time.Second,
 }
 
@@ -28,8 +33,10 @@ var DefaultRequesterConfig = RequesterConfigParams{
 	DefaultJobExecutionTimeout: 30 * time.Minute,
 
 	HousekeepingBackgroundTaskInterval: 30 * time.Second,
+	JobRecoveryDelay:                   10 * time.Second,
 	NodeRankRandomnessRange:            5,
 	OverAskForBidsFactor:               3,
+	PrivacyBudget:                      10,
 
 	MinBacalhauVersion: model.BuildVersionInfo{
 		Major: "0", Minor: "3", GitVersion: "v0.3.26",
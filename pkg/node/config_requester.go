@@ -6,6 +6,7 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 )
 
 type RequesterConfigParams struct {
@@ -14,6 +15,7 @@ type RequesterConfigParams struct {
 	DefaultJobExecutionTimeout time.Duration
 
 	HousekeepingBackgroundTaskInterval time.Duration
+	JobRecoveryDelay                   time.Duration
 	NodeRankRandomnessRange            int
 	OverAskForBidsFactor               int
 	JobSelectionPolicy                 model.JobSelectionPolicy
@@ -23,6 +25,23 @@ type RequesterConfigParams struct {
 	MinBacalhauVersion model.BuildVersionInfo
 
 	RetryStrategy requester.RetryStrategy
+
+	// measurements of the runtimes trusted to run jobs verified by attestation
+	TrustedMeasurements []string
+
+	// maximum cumulative epsilon a client can spend on a dataset with differentially private jobs. Zero uses the
+	// default budget, and a negative budget disables the limit.
+	PrivacyBudget float64
+
+	// how long a job can wait in the queue for enough nodes to run it
+	MaxJobQueueTime time.Duration
+
+	// fair share weights of clients, and the limits on the work each client can have running
+	ClientWeights map[string]float64
+	ClientQuota   requester.ClientQuota
+
+	// approvers and quorum rules of the jobs held for moderation
+	ModerationPolicy *moderation.Policy
 }
 
 type RequesterConfig struct {
@@ -35,6 +54,9 @@ type RequesterConfig struct {
 
 	// HousekeepingBackgroundTaskInterval background task interval that periodically checks for expired states
 	HousekeepingBackgroundTaskInterval time.Duration
+	// JobRecoveryDelay how long to wait after startup before resuming in-progress jobs found in the job store,
+	// giving the node time to discover compute nodes and route requests to them
+	JobRecoveryDelay time.Duration
 	// NodeRankRandomnessRange defines the range of randomness used to rank nodes
 	NodeRankRandomnessRange int
 	OverAskForBidsFactor    int
@@ -45,6 +67,30 @@ type RequesterConfig struct {
 	MinBacalhauVersion model.BuildVersionInfo
 
 	RetryStrategy requester.RetryStrategy
+
+	// TrustedMeasurements are the measurements of the runtimes trusted to run jobs verified by attestation.
+	// Executions attested by any other runtime are rejected.
+	TrustedMeasurements []string
+
+	// PrivacyBudget is the maximum cumulative epsilon a client can spend on a dataset with differentially private jobs.
+	// Jobs that would exceed it are refused. A negative budget disables the limit.
+	PrivacyBudget float64
+
+	// MaxJobQueueTime is how long a job can wait in the queue for enough nodes to run it before it fails. Jobs that
+	// can't be placed are re-evaluated whenever compute nodes are discovered or updated. Jobs fail straight away when
+	// there are not enough nodes to run them if zero.
+	MaxJobQueueTime time.Duration
+
+	// ClientWeights are the fair share weights of clients by client ID. Jobs of the same priority are started first
+	// for the clients with the fewest running executions relative to their weight. Clients weigh 1 by default.
+	ClientWeights map[string]float64
+	// ClientQuota limits the executions and resources each client can have running at once. Jobs that would exceed it
+	// wait in the queue if MaxJobQueueTime is set, and fail otherwise.
+	ClientQuota requester.ClientQuota
+
+	// ModerationPolicy decides who can approve or reject the jobs held for moderation by the JobSelectionPolicy,
+	// and how many approvals they need. Defaults to the single approver named by BACALHAU_JOB_APPROVER if nil.
+	ModerationPolicy *moderation.Policy
 }
 
 func NewRequesterConfigWithDefaults() RequesterConfig {
@@ -69,12 +115,18 @@ func NewRequesterConfigWith(params RequesterConfigParams) (config RequesterConfi
 	if params.HousekeepingBackgroundTaskInterval == 0 {
 		params.HousekeepingBackgroundTaskInterval = DefaultRequesterConfig.HousekeepingBackgroundTaskInterval
 	}
+	if params.JobRecoveryDelay == 0 {
+		params.JobRecoveryDelay = DefaultRequesterConfig.JobRecoveryDelay
+	}
 	if params.NodeRankRandomnessRange == 0 {
 		params.NodeRankRandomnessRange = DefaultRequesterConfig.NodeRankRandomnessRange
 	}
 	if params.OverAskForBidsFactor == 0 {
 		params.OverAskForBidsFactor = DefaultRequesterConfig.OverAskForBidsFactor
 	}
+	if params.PrivacyBudget == 0 {
+		params.PrivacyBudget = DefaultRequesterConfig.PrivacyBudget
+	}
 	if params.MinBacalhauVersion == (model.BuildVersionInfo{}) {
 		params.MinBacalhauVersion = DefaultRequesterConfig.MinBacalhauVersion
 	}
@@ -83,12 +135,19 @@ func NewRequesterConfigWith(params RequesterConfigParams) (config RequesterConfi
 		MinJobExecutionTimeout:             params.MinJobExecutionTimeout,
 		DefaultJobExecutionTimeout:         params.DefaultJobExecutionTimeout,
 		HousekeepingBackgroundTaskInterval: params.HousekeepingBackgroundTaskInterval,
+		JobRecoveryDelay:                   params.JobRecoveryDelay,
 		JobSelectionPolicy:                 params.JobSelectionPolicy,
 		NodeRankRandomnessRange:            params.NodeRankRandomnessRange,
 		OverAskForBidsFactor:               params.OverAskForBidsFactor,
 		SimulatorConfig:                    params.SimulatorConfig,
 		MinBacalhauVersion:                 params.MinBacalhauVersion,
 		RetryStrategy:                      params.RetryStrategy,
+		TrustedMeasurements:                params.TrustedMeasurements,
+		PrivacyBudget:                      params.PrivacyBudget,
+		MaxJobQueueTime:                    params.MaxJobQueueTime,
+		ClientWeights:                      params.ClientWeights,
+		ClientQuota:                        params.ClientQuota,
+		ModerationPolicy:                   params.ModerationPolicy,
 	}
 
 	return config
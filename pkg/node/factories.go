@@ -3,14 +3,20 @@ package node
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
+	"github.com/bacalhau-project/bacalhau/pkg/docker"
 	"github.com/bacalhau-project/bacalhau/pkg/executor"
 	executor_util "github.com/bacalhau-project/bacalhau/pkg/executor/util"
+	"github.com/bacalhau-project/bacalhau/pkg/executor/wasm"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
 	publisher_util "github.com/bacalhau-project/bacalhau/pkg/publisher/util"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/attestation"
 	verifier_util "github.com/bacalhau-project/bacalhau/pkg/verifier/util"
 )
 
@@ -95,6 +101,13 @@ func NewStandardStorageProvidersFactory() *StandardStorageProvidersFactory {
 type StandardExecutorsFactory struct{}
 
 func (f *StandardExecutorsFactory) Get(ctx context.Context, nodeConfig NodeConfig) (executor.ExecutorProvider, error) {
+	encrypter := verifier.NewEncrypter(nodeConfig.Host.Peerstore().PrivKey(nodeConfig.Host.ID()))
+	configDir, err := system.EnsureConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	// include the host id to avoid nodes running on the same machine sharing the cache
+	wasmCacheDir := filepath.Join(configDir, "wasm-cache-"+nodeConfig.Host.ID().String())
 	provider, err := executor_util.NewStandardExecutorProvider(
 		ctx,
 		nodeConfig.CleanupManager,
@@ -104,7 +117,15 @@ func (f *StandardExecutorsFactory) Get(ctx context.Context, nodeConfig NodeConfi
 				API:                  nodeConfig.IPFSClient,
 				FilecoinUnsealedPath: nodeConfig.FilecoinUnsealedPath,
 				EstuaryAPIKey:        nodeConfig.EstuaryAPIKey,
+				NodeID:               nodeConfig.Host.ID().String(),
+				Decrypter:            encrypter.Decrypt,
+			},
+			GPUAllocator: nodeConfig.ComputeConfig.GPUAllocator,
+			WasmModuleCache: wasm.ModuleCacheParams{
+				Dir:     wasmCacheDir,
+				MaxSize: nodeConfig.ComputeConfig.WasmModuleCacheSize,
 			},
+			Sandbox: nodeConfig.ComputeConfig.Sandbox,
 		},
 	)
 	return model.NewConfiguredProvider[model.Engine, executor.Executor](provider, nodeConfig.DisabledFeatures.Engines), err
@@ -119,16 +140,33 @@ type StandardVerifiersFactory struct{}
 func (f *StandardVerifiersFactory) Get(
 	ctx context.Context,
 	nodeConfig NodeConfig) (verifier.VerifierProvider, error) {
-	encrypter := verifier.NewEncrypter(nodeConfig.Host.Peerstore().PrivKey(nodeConfig.Host.ID()))
+	privateKey := nodeConfig.Host.Peerstore().PrivKey(nodeConfig.Host.ID())
+	encrypter := verifier.NewEncrypter(privateKey)
 	provider, err := verifier_util.NewStandardVerifiers(
 		ctx,
 		nodeConfig.CleanupManager,
 		encrypter.Encrypt,
 		encrypter.Decrypt,
+		attestation.VerifierParams{
+			NodeID:              nodeConfig.Host.ID().String(),
+			Provider:            attestation.NewSoftwareProvider(privateKey),
+			TrustedMeasurements: nodeConfig.RequesterNodeConfig.TrustedMeasurements,
+			ResolveImage:        resolveDockerImage,
+		},
 	)
 	return model.NewConfiguredProvider[model.Verifier, verifier.Verifier](provider, nodeConfig.DisabledFeatures.Verifiers), err
 }
 
+// resolveDockerImage returns the content digest of a docker image pulled by the node
+func resolveDockerImage(ctx context.Context, image string) (string, error) {
+	client, err := docker.NewDockerClient()
+	if err != nil {
+		return "", err
+	}
+	defer closer.CloseWithLogOnError("docker client", client)
+	return client.ImageDigest(ctx, image)
+}
+
 func NewStandardVerifiersFactory() *StandardVerifiersFactory {
 	return &StandardVerifiersFactory{}
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/routing/inmemory"
 	"github.com/bacalhau-project/bacalhau/pkg/simulator"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/transport/bprotocol"
 	"github.com/bacalhau-project/bacalhau/pkg/util"
 	"github.com/bacalhau-project/bacalhau/pkg/version"
 	"github.com/imdario/mergo"
@@ -61,6 +62,9 @@ type NodeConfig struct {
 	Labels                    map[string]string
 	NodeInfoPublisherInterval time.Duration
 	DependencyInjector        NodeDependencyInjector
+	// ProtocolVersions are the versions of the bacalhau protocol the node speaks, from the most to the least
+	// preferred. Defaults to all versions supported by this build.
+	ProtocolVersions []string
 }
 
 // Lazy node dependency injector that generate instances of different
@@ -111,6 +115,13 @@ func NewNode(
 		return nil, err
 	}
 
+	if len(config.ProtocolVersions) == 0 {
+		config.ProtocolVersions = bprotocol.SupportedVersions
+	}
+	if err = bprotocol.ValidateVersions(config.ProtocolVersions); err != nil {
+		return nil, err
+	}
+
 	storageProviders, err := config.DependencyInjector.StorageProvidersFactory.Get(ctx, config)
 	if err != nil {
 		return nil, err
@@ -170,6 +181,7 @@ func NewNode(
 		IdentityService: basicHost.IDService(),
 		Labels:          config.Labels,
 		BacalhauVersion: *version.Get(),
+		Protocols:       bprotocol.Protocols(config.ProtocolVersions),
 	})
 
 	// node info publisher
@@ -189,14 +201,6 @@ func NewNode(
 	})
 	routedHost := routedhost.Wrap(config.Host, nodeInfoStore)
 
-	// register consumers of node info published over gossipSub
-	nodeInfoSubscriber := pubsub.NewChainedSubscriber[model.NodeInfo](true)
-	nodeInfoSubscriber.Add(pubsub.SubscriberFunc[model.NodeInfo](nodeInfoStore.Add))
-	err = nodeInfoPubSub.Subscribe(ctx, nodeInfoSubscriber)
-	if err != nil {
-		return nil, err
-	}
-
 	// public http api server
 	apiServer, err := publicapi.NewAPIServer(publicapi.APIServerParams{
 		Address:          config.HostAddress,
@@ -227,12 +231,25 @@ func NewNode(
 			storageProviders,
 			gossipSub,
 			nodeInfoStore,
+			config.ProtocolVersions,
 		)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// register consumers of node info published over gossipSub. Jobs waiting in the requester's queue for enough
+	// nodes to run them are re-evaluated once the node info store is updated.
+	nodeInfoSubscriber := pubsub.NewChainedSubscriber[model.NodeInfo](true)
+	nodeInfoSubscriber.Add(pubsub.SubscriberFunc[model.NodeInfo](nodeInfoStore.Add))
+	if requesterNode != nil {
+		nodeInfoSubscriber.Add(pubsub.SubscriberFunc[model.NodeInfo](requesterNode.queue.NodeInfoUpdated))
+	}
+	err = nodeInfoPubSub.Subscribe(ctx, nodeInfoSubscriber)
+	if err != nil {
+		return nil, err
+	}
+
 	if config.IsComputeNode {
 		// setup compute node
 		computeNode, err = NewComputeNode(
@@ -247,6 +264,7 @@ func NewNode(
 			executors,
 			verifiers,
 			publishers,
+			config.ProtocolVersions,
 		)
 		if err != nil {
 			return nil, err
@@ -278,6 +296,13 @@ func NewNode(
 		requesterNode.RegisterLocalComputeEndpoint(computeNode.LocalEndpoint)
 	}
 
+	if computeNode != nil {
+		// executions of a previous run of the node are reconciled once failures can be reported to local requesters
+		if reconcileErr := computeNode.Reconcile(ctx); reconcileErr != nil {
+			log.Ctx(ctx).Error().Err(reconcileErr).Msg("Failed to reconcile executions of a previous run of the node")
+		}
+	}
+
 	// Eagerly publish node info to the network. Do this in a goroutine so that
 	// slow plugins don't slow down the node from booting.
 	go func() {
@@ -344,3 +369,12 @@ func mergeDependencyInjectors(injector NodeDependencyInjector, defaultInjector N
 	}
 	return injector
 }
+
+// trustedPeers returns the peers allowed to relay bacalhau protocol messages on behalf of other nodes,
+// which is only the simulator node when running in simulator mode.
+func trustedPeers(simulatorNodeID string) []string {
+	if simulatorNodeID == "" {
+		return nil
+	}
+	return []string{simulatorNodeID}
+}
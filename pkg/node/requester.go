@@ -15,6 +15,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/pubsub/libp2p"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/discovery"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	requester_publicapi "github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/ranking"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/retry"
@@ -37,6 +38,7 @@ type Requester struct {
 	Endpoint           requester.Endpoint
 	JobStore           jobstore.Store
 	NodeDiscoverer     requester.NodeDiscoverer
+	queue              requester.Queue
 	computeProxy       *bprotocol.ComputeProxy
 	localCallback      compute.Callback
 	requesterAPIServer *requester_publicapi.RequesterAPIServer
@@ -57,6 +59,7 @@ func NewRequesterNode(
 	storageProviders storage.StorageProvider,
 	gossipSub *libp2p_pubsub.PubSub,
 	nodeInfoStore routing.NodeInfoStore,
+	protocolVersions []string,
 ) (*Requester, error) {
 	// prepare event handlers
 	tracerContextProvider := eventhandler.NewTracerContextProvider(host.ID().String())
@@ -65,7 +68,8 @@ func NewRequesterNode(
 	// compute proxy
 	var computeProxy compute.Endpoint
 	standardComputeProxy := bprotocol.NewComputeProxy(bprotocol.ComputeProxyParams{
-		Host: host,
+		Host:     host,
+		Versions: protocolVersions,
 	})
 	// if we are running in simulator mode, then we use the simulator proxy to forward all requests to th simulator node.
 	if simulatorNodeID != "" {
@@ -104,9 +108,12 @@ func NewRequesterNode(
 		ranking.NewVerifiersNodeRanker(),
 		ranking.NewPublishersNodeRanker(),
 		ranking.NewStoragesNodeRanker(),
+		ranking.NewEncryptionNodeRanker(),
 		ranking.NewLabelsNodeRanker(),
 		ranking.NewMaxUsageNodeRanker(),
+		ranking.NewGPUsNodeRanker(),
 		ranking.NewMinVersionNodeRanker(ranking.MinVersionNodeRankerParams{MinVersion: config.MinBacalhauVersion}),
+		ranking.NewProtocolsNodeRanker(ranking.ProtocolsNodeRankerParams{Protocols: bprotocol.Protocols(protocolVersions)}),
 		ranking.NewPreviousExecutionsNodeRanker(ranking.PreviousExecutionsNodeRankerParams{JobStore: jobStore}),
 		// arbitrary rankers
 		ranking.NewRandomNodeRanker(ranking.RandomNodeRankerParams{
@@ -143,7 +150,18 @@ func NewRequesterNode(
 		StorageProviders:     storageProviders,
 		EventEmitter:         emitter,
 	})
-	queue := requester.NewQueue(jobStore, scheduler, emitter)
+	queue := requester.NewQueue(requester.QueueParams{
+		JobStore:     jobStore,
+		Scheduler:    scheduler,
+		EventEmitter: emitter,
+		NodeSelector: nodeSelector,
+		SchedulingPolicy: requester.NewFairSharePolicy(requester.FairSharePolicyParams{
+			JobStore:      jobStore,
+			ClientWeights: config.ClientWeights,
+			ClientQuota:   config.ClientQuota,
+		}),
+		MaxQueueTime: config.MaxJobQueueTime,
+	})
 
 	publicKey := host.Peerstore().PubKey(host.ID())
 	marshaledPublicKey, err := crypto.MarshalPublicKey(publicKey)
@@ -152,11 +170,16 @@ func NewRequesterNode(
 	}
 
 	selectionStrategy := bidstrategy.FromJobSelectionPolicy(config.JobSelectionPolicy)
+	moderator := moderation.NewModerator(moderation.ModeratorParams{
+		JobStore: jobStore,
+		Policy:   config.ModerationPolicy,
+	})
 
 	endpoint := requester.NewBaseEndpoint(&requester.BaseEndpointParams{
 		ID:                         host.ID().String(),
 		PublicKey:                  marshaledPublicKey,
 		Selector:                   selectionStrategy,
+		Moderator:                  moderator,
 		ComputeEndpoint:            computeProxy,
 		Store:                      jobStore,
 		Queue:                      queue,
@@ -164,6 +187,7 @@ func NewRequesterNode(
 		StorageProviders:           storageProviders,
 		MinJobExecutionTimeout:     config.MinJobExecutionTimeout,
 		DefaultJobExecutionTimeout: config.DefaultJobExecutionTimeout,
+		PrivacyBudget:              config.PrivacyBudget,
 		GetBiddingCallback: func() *url.URL {
 			return apiServer.GetURI().JoinPath(requester_publicapi.APIPrefix, requester_publicapi.ApprovalRoute)
 		},
@@ -176,23 +200,36 @@ func NewRequesterNode(
 		Interval: config.HousekeepingBackgroundTaskInterval,
 	})
 
+	// resume jobs that were in progress before the node was restarted, once compute nodes had a chance to be discovered
+	recoveryTimer := time.AfterFunc(config.JobRecoveryDelay, func() {
+		recoveryErr := scheduler.RecoverJobs(ctx, requester.RecoverJobsRequest{Queue: queue, Moderator: moderator})
+		if recoveryErr != nil {
+			log.Ctx(ctx).Error().Err(recoveryErr).Msg("failed to recover in-progress jobs")
+		}
+	})
+
 	// if this node is the simulator, then we pass incoming requests to the simulator before passing them to the endpoint
 	if simulatorRequestHandler != nil {
 		bprotocol.NewCallbackHandler(bprotocol.CallbackHandlerParams{
-			Host:     host,
-			Callback: simulatorRequestHandler,
+			Host:         host,
+			Callback:     simulatorRequestHandler,
+			Versions:     protocolVersions,
+			TrustedPeers: trustedPeers(simulatorNodeID),
 		})
 	} else {
 		// register a handler for the bacalhau protocol handler that will forward requests to the scheduler
 		bprotocol.NewCallbackHandler(bprotocol.CallbackHandlerParams{
-			Host:     host,
-			Callback: scheduler,
+			Host:         host,
+			Callback:     scheduler,
+			Versions:     protocolVersions,
+			TrustedPeers: trustedPeers(simulatorNodeID),
 		})
 	}
 
 	// register debug info providers for the /debug endpoint
 	debugInfoProviders := []model.DebugInfoProvider{
 		discovery.NewDebugInfoProvider(nodeDiscoveryChain),
+		queue,
 	}
 
 	// register requester public http apis
@@ -202,6 +239,9 @@ func NewRequesterNode(
 		DebugInfoProviders: debugInfoProviders,
 		JobStore:           jobStore,
 		StorageProviders:   storageProviders,
+		NodeDiscoverer:     nodeDiscoveryChain,
+		Queue:              queue,
+		Moderator:          moderator,
 	})
 	err = requesterAPIServer.RegisterAllHandlers()
 	if err != nil {
@@ -250,6 +290,7 @@ func NewRequesterNode(
 	cleanupFunc := func(ctx context.Context) {
 		// stop the housekeeping background task
 		housekeeping.Stop()
+		recoveryTimer.Stop()
 
 		cleanupErr := bufferedJobEventPubSub.Close(ctx)
 		util.LogDebugIfContextCancelled(ctx, cleanupErr, "buffered job event pubsub")
@@ -270,6 +311,7 @@ func NewRequesterNode(
 		localCallback:      scheduler,
 		NodeDiscoverer:     nodeDiscoveryChain,
 		JobStore:           jobStore,
+		queue:              queue,
 		computeProxy:       standardComputeProxy,
 		cleanupFunc:        cleanupFunc,
 		requesterAPIServer: requesterAPIServer,
@@ -0,0 +1,71 @@
+package privacy
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// NoiseFunc returns a value with noise added to it
+type NoiseFunc func(value float64) float64
+
+// NewNoise returns a function adding noise to values as configured by the spec. The noise is drawn from a
+// cryptographically secure source, as predictable noise could be subtracted from the released values.
+func NewNoise(spec *model.DifferentialPrivacySpec) (NoiseFunc, error) {
+	if err := spec.IsValid(); err != nil {
+		return nil, err
+	}
+	rng := mathrand.New(cryptoSource{})
+
+	switch spec.Mechanism {
+	case model.PrivacyMechanismLaplace:
+		scale := spec.Sensitivity / spec.Epsilon
+		return func(value float64) float64 {
+			return value + laplace(rng, scale)
+		}, nil
+	case model.PrivacyMechanismGaussian:
+		// the classic calibration of the gaussian mechanism, which is only (epsilon, delta)-DP for epsilon < 1, as
+		// enforced by the spec validation
+		sigma := spec.Sensitivity * math.Sqrt(2*math.Log(1.25/spec.Delta)) / spec.Epsilon
+		return func(value float64) float64 {
+			return value + rng.NormFloat64()*sigma
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported differential privacy mechanism %q", spec.Mechanism)
+	}
+}
+
+// laplace samples the Laplace distribution centered on 0 using inverse transform sampling
+func laplace(rng *mathrand.Rand, scale float64) float64 {
+	u := rng.Float64() - 0.5
+	for u == -0.5 {
+		u = rng.Float64() - 0.5
+	}
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
+
+// cryptoSource is a math/rand source reading from crypto/rand
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %s", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() & (1<<63 - 1))
+}
+
+func (cryptoSource) Seed(int64) {}
+
+var _ mathrand.Source64 = cryptoSource{}
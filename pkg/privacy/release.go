@@ -0,0 +1,196 @@
+package privacy
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"go.uber.org/multierr"
+)
+
+// Release replaces the results in resultPath with the declared outputs of the spec, after adding noise to their
+// numeric values. Everything else is removed, so that only the noisy outputs are verified and published.
+func Release(resultPath string, spec *model.DifferentialPrivacySpec) error {
+	noise, err := NewNoise(spec)
+	if err != nil {
+		return err
+	}
+
+	releaseDir, err := os.MkdirTemp(filepath.Dir(resultPath), "privacy-release")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(releaseDir)
+
+	for _, output := range spec.Outputs {
+		src, err := resolveOutput(resultPath, output)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(releaseDir, filepath.FromSlash(output))
+		if err = os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+			return err
+		}
+		if err = releaseFile(src, dst, noise); err != nil {
+			return fmt.Errorf("failed to release output %s: %w", output, err)
+		}
+	}
+
+	entries, err := os.ReadDir(resultPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = os.RemoveAll(filepath.Join(resultPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	entries, err = os.ReadDir(releaseDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = os.Rename(filepath.Join(releaseDir, entry.Name()), filepath.Join(resultPath, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedactRunOutput removes the logs of a run, which are not released for differentially private jobs. The resources
+// consumed by the run are kept.
+func RedactRunOutput(result *model.RunCommandResult) *model.RunCommandResult {
+	if result == nil {
+		return nil
+	}
+	return &model.RunCommandResult{
+		ExitCode:      result.ExitCode,
+		ErrorMsg:      result.ErrorMsg,
+		FuelConsumed:  result.FuelConsumed,
+		ResourceUsage: result.ResourceUsage,
+	}
+}
+
+// resolveOutput returns the path of a declared output, making sure it does not escape the results through symlinks
+func resolveOutput(resultPath, output string) (string, error) {
+	root, err := filepath.EvalSymlinks(resultPath)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(resultPath, filepath.FromSlash(output)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("declared output %s was not produced by the job", output)
+	} else if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(resolved, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("declared output %s is outside of the results", output)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("declared output %s is not a regular file", output)
+	}
+	return resolved, nil
+}
+
+func releaseFile(src, dst string, noise NoiseFunc) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, in.Close()) }()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, out.Close()) }()
+
+	if filepath.Ext(dst) == ".csv" {
+		return releaseCSV(in, out, noise)
+	}
+	return releaseJSON(in, out, noise)
+}
+
+// releaseCSV adds noise to the numeric cells of a CSV file, and keeps the others, such as headers and labels, as is
+func releaseCSV(in io.Reader, out io.Writer, noise NoiseFunc) error {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	writer := csv.NewWriter(out)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, cell := range record {
+			value, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+			if err == nil && !math.IsNaN(value) && !math.IsInf(value, 0) {
+				record[i] = strconv.FormatFloat(noise(value), 'g', -1, 64)
+			}
+		}
+		if err = writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// releaseJSON adds noise to all the numbers of a JSON document
+func releaseJSON(in io.Reader, out io.Writer, noise NoiseFunc) error {
+	decoder := json.NewDecoder(in)
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("expected a single JSON document")
+	}
+
+	document, err := addNoiseJSON(document, noise)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func addNoiseJSON(value interface{}, noise NoiseFunc) (interface{}, error) {
+	var err error
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return noise(number), nil
+	case map[string]interface{}:
+		for key, item := range v {
+			if v[key], err = addNoiseJSON(item, noise); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if v[i], err = addNoiseJSON(item, noise); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,148 @@
+//go:build unit || !integration
+
+package privacy
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReleaseSuite struct {
+	suite.Suite
+	resultPath string
+	spec       *model.DifferentialPrivacySpec
+}
+
+func TestReleaseSuite(t *testing.T) {
+	suite.Run(t, new(ReleaseSuite))
+}
+
+func (s *ReleaseSuite) SetupTest() {
+	s.resultPath = filepath.Join(s.T().TempDir(), "results")
+	s.writeFile("stdout", "raw personal data")
+	s.writeFile("outputs/stats.csv", "name,count\nalice,10\nbob,20\n")
+	s.writeFile("outputs/stats.json", `{"label": "total", "count": 30, "values": [1.5, 2.5]}`)
+	s.writeFile("outputs/private.csv", "alice,42\n")
+
+	s.spec = &model.DifferentialPrivacySpec{
+		Mechanism:   model.PrivacyMechanismLaplace,
+		Epsilon:     1,
+		Sensitivity: 1,
+		Outputs:     []string{"outputs/stats.csv", "outputs/stats.json"},
+	}
+}
+
+func (s *ReleaseSuite) writeFile(name, content string) {
+	path := filepath.Join(s.resultPath, filepath.FromSlash(name))
+	s.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	s.Require().NoError(os.WriteFile(path, []byte(content), 0644))
+}
+
+func (s *ReleaseSuite) TestRelease() {
+	s.Require().NoError(Release(s.resultPath, s.spec))
+
+	s.NoFileExists(filepath.Join(s.resultPath, "stdout"))
+	s.NoFileExists(filepath.Join(s.resultPath, "outputs", "private.csv"))
+
+	file, err := os.Open(filepath.Join(s.resultPath, "outputs", "stats.csv"))
+	s.Require().NoError(err)
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	s.Require().NoError(err)
+	s.Require().Len(records, 3)
+	s.Equal([]string{"name", "count"}, records[0])
+	s.Equal("alice", records[1][0])
+	s.Equal("bob", records[2][0])
+	noisy, err := strconv.ParseFloat(records[1][1], 64)
+	s.Require().NoError(err)
+	s.NotEqual(10.0, noisy)
+
+	content, err := os.ReadFile(filepath.Join(s.resultPath, "outputs", "stats.json"))
+	s.Require().NoError(err)
+	var document struct {
+		Label  string    `json:"label"`
+		Count  float64   `json:"count"`
+		Values []float64 `json:"values"`
+	}
+	s.Require().NoError(json.Unmarshal(content, &document))
+	s.Equal("total", document.Label)
+	s.NotEqual(30.0, document.Count)
+	s.Len(document.Values, 2)
+}
+
+func (s *ReleaseSuite) TestMissingOutput() {
+	s.spec.Outputs = append(s.spec.Outputs, "outputs/missing.csv")
+	s.Error(Release(s.resultPath, s.spec))
+}
+
+func (s *ReleaseSuite) TestSymlinkOutsideResults() {
+	outside := filepath.Join(s.T().TempDir(), "secret.csv")
+	s.Require().NoError(os.WriteFile(outside, []byte("1,2,3\n"), 0644))
+	s.Require().NoError(os.Symlink(outside, filepath.Join(s.resultPath, "outputs", "link.csv")))
+
+	s.spec.Outputs = []string{"outputs/link.csv"}
+	s.Error(Release(s.resultPath, s.spec))
+}
+
+func (s *ReleaseSuite) TestRedactRunOutputKeepsUsage() {
+	usage := &model.ResourceUsageMetrics{CPUSeconds: 1.5, PeakMemory: 1024, DiskWritten: 2048}
+	redacted := RedactRunOutput(&model.RunCommandResult{
+		STDOUT:        "secret",
+		STDERR:        "secret",
+		ExitCode:      1,
+		ErrorMsg:      "failed",
+		FuelConsumed:  42,
+		ResourceUsage: usage,
+	})
+	s.Require().Equal(&model.RunCommandResult{
+		ExitCode:      1,
+		ErrorMsg:      "failed",
+		FuelConsumed:  42,
+		ResourceUsage: usage,
+	}, redacted)
+	s.Require().Nil(RedactRunOutput(nil))
+}
+
+func (s *ReleaseSuite) TestGaussianRequiresSmallEpsilon() {
+	spec := &model.DifferentialPrivacySpec{
+		Mechanism: model.PrivacyMechanismGaussian, Epsilon: 1, Delta: 1e-5, Sensitivity: 2, Outputs: []string{"a.csv"},
+	}
+	_, err := NewNoise(spec)
+	s.Require().Error(err, "expected the gaussian mechanism to reject epsilon %f", spec.Epsilon)
+}
+
+func (s *ReleaseSuite) TestNoiseScale() {
+	for _, spec := range []*model.DifferentialPrivacySpec{
+		{Mechanism: model.PrivacyMechanismLaplace, Epsilon: 0.5, Sensitivity: 2, Outputs: []string{"a.csv"}},
+		{Mechanism: model.PrivacyMechanismGaussian, Epsilon: 0.5, Delta: 1e-5, Sensitivity: 2, Outputs: []string{"a.csv"}},
+	} {
+		noise, err := NewNoise(spec)
+		s.Require().NoError(err)
+
+		const samples = 20000
+		var sum, sumSquares float64
+		for i := 0; i < samples; i++ {
+			value := noise(100) - 100
+			sum += value
+			sumSquares += value * value
+		}
+		mean := sum / samples
+		stddev := math.Sqrt(sumSquares/samples - mean*mean)
+
+		// laplace with scale b has a standard deviation of b*sqrt(2)
+		expected := math.Sqrt2 * spec.Sensitivity / spec.Epsilon
+		if spec.Mechanism == model.PrivacyMechanismGaussian {
+			expected = spec.Sensitivity * math.Sqrt(2*math.Log(1.25/spec.Delta)) / spec.Epsilon
+		}
+		s.Require().InDelta(expected, stddev, 0.1*expected, "standard deviation of the %s noise", spec.Mechanism)
+		s.Require().InDelta(0, mean, 0.1*expected, "mean of the %s noise", spec.Mechanism)
+	}
+}
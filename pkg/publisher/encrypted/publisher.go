@@ -0,0 +1,97 @@
+package encrypted
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bacalhau-project/bacalhau/pkg/config"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/multierr"
+)
+
+// encryptingPublisher seals the results of jobs that asked for their results to be encrypted with a new data key,
+// which is wrapped with the public key of the client that submitted the job. Only the sealed archive is handed to the
+// delegate publisher, and the wrapped key is returned in the published storage spec so that the client can decrypt
+// the results after downloading them.
+type encryptingPublisher struct {
+	delegate publisher.Publisher
+}
+
+func Wrap(delegate publisher.Publisher) publisher.Publisher {
+	return &encryptingPublisher{
+		delegate: delegate,
+	}
+}
+
+func (e *encryptingPublisher) IsInstalled(ctx context.Context) (bool, error) {
+	return e.delegate.IsInstalled(ctx)
+}
+
+func (e *encryptingPublisher) ValidateJob(ctx context.Context, j model.Job) error {
+	if j.Spec.PublisherSpec.Encrypt && len(j.Metadata.ClientPublicKey) == 0 {
+		return fmt.Errorf("job %s requires encrypted results, but has no client public key", j.ID())
+	}
+	return e.delegate.ValidateJob(ctx, j)
+}
+
+func (e *encryptingPublisher) PublishResult(
+	ctx context.Context, executionID string, j model.Job, resultPath string,
+) (model.StorageSpec, error) {
+	if !j.Spec.PublisherSpec.Encrypt {
+		return e.delegate.PublishResult(ctx, executionID, j, resultPath)
+	}
+	if len(j.Metadata.ClientPublicKey) == 0 {
+		return model.StorageSpec{}, fmt.Errorf("job %s requires encrypted results, but has no client public key", j.ID())
+	}
+
+	key, err := encrypted.NewDataKey()
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	wrappedKey, err := system.EncryptForClient(key, base64.StdEncoding.EncodeToString(j.Metadata.ClientPublicKey))
+	if err != nil {
+		return model.StorageSpec{}, fmt.Errorf("failed to wrap results key for client %s: %w", j.Metadata.ClientID, err)
+	}
+
+	sealedDir, err := os.MkdirTemp(config.GetStoragePath(), "bacalhau-encrypted-results")
+	if err != nil {
+		return model.StorageSpec{}, err
+	}
+	defer func() {
+		if cleanupErr := os.RemoveAll(sealedDir); cleanupErr != nil {
+			log.Ctx(ctx).Warn().Err(cleanupErr).Msgf("failed to cleanup encrypted results %s", sealedDir)
+		}
+	}()
+
+	if err = sealResults(resultPath, filepath.Join(sealedDir, encrypted.SealedArchiveFilename), key); err != nil {
+		return model.StorageSpec{}, fmt.Errorf("failed to encrypt results: %w", err)
+	}
+
+	spec, err := e.delegate.PublishResult(ctx, executionID, j, sealedDir)
+	if err != nil {
+		return spec, err
+	}
+	spec.Encryption = &model.EncryptionSpec{
+		Algorithm:   model.EncryptionAlgorithmAES256GCM,
+		WrappedKeys: map[string][]byte{j.Metadata.ClientID: wrappedKey},
+	}
+	return spec, nil
+}
+
+func sealResults(resultPath, sealedPath string, key []byte) (err error) {
+	file, err := os.Create(sealedPath)
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, file.Close()) }()
+	return encrypted.SealDirectory(file, resultPath, key)
+}
+
+var _ publisher.Publisher = (*encryptingPublisher)(nil)
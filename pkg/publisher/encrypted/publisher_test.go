@@ -0,0 +1,107 @@
+//go:build unit || !integration
+
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/encrypted"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/stretchr/testify/suite"
+)
+
+type EncryptedPublisherSuite struct {
+	suite.Suite
+	ctx        context.Context
+	resultPath string
+	published  map[string][]byte
+	publisher  *encryptingPublisher
+}
+
+func TestEncryptedPublisherSuite(t *testing.T) {
+	suite.Run(t, new(EncryptedPublisherSuite))
+}
+
+func (s *EncryptedPublisherSuite) SetupTest() {
+	system.InitConfigForTesting(s.T())
+	s.ctx = context.Background()
+
+	s.resultPath = s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(s.resultPath, "stdout"), []byte("hello"), 0644))
+
+	// record the content of the published directory, as it is removed once published
+	s.published = make(map[string][]byte)
+	delegate := noop.NewNoopPublisherWithConfig(noop.PublisherConfig{
+		ExternalHooks: noop.PublisherExternalHooks{
+			PublishResult: func(ctx context.Context, executionID string, job model.Job, resultPath string) (model.StorageSpec, error) {
+				entries, err := os.ReadDir(resultPath)
+				s.Require().NoError(err)
+				for _, entry := range entries {
+					content, err := os.ReadFile(filepath.Join(resultPath, entry.Name()))
+					s.Require().NoError(err)
+					s.published[entry.Name()] = content
+				}
+				return model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "cid"}, nil
+			},
+		},
+	})
+	s.publisher = Wrap(delegate).(*encryptingPublisher)
+}
+
+func (s *EncryptedPublisherSuite) job(encrypt bool) model.Job {
+	clientPublicKey, err := base64.StdEncoding.DecodeString(system.GetClientPublicKey())
+	s.Require().NoError(err)
+
+	j := model.Job{
+		Metadata: model.Metadata{
+			ID:              "job-id",
+			ClientID:        system.GetClientID(),
+			ClientPublicKey: clientPublicKey,
+		},
+	}
+	j.Spec.PublisherSpec.Encrypt = encrypt
+	return j
+}
+
+func (s *EncryptedPublisherSuite) TestPublishEncrypted() {
+	spec, err := s.publisher.PublishResult(s.ctx, "execution-id", s.job(true), s.resultPath)
+	s.Require().NoError(err)
+	s.Equal("cid", spec.CID)
+	s.Require().NotNil(spec.Encryption)
+	s.Equal(model.EncryptionAlgorithmAES256GCM, spec.Encryption.Algorithm)
+
+	s.Len(s.published, 1)
+	sealed, ok := s.published[encrypted.SealedArchiveFilename]
+	s.Require().True(ok)
+
+	key, err := system.DecryptForClient(spec.Encryption.WrappedKeys[system.GetClientID()])
+	s.Require().NoError(err)
+	opened := filepath.Join(s.T().TempDir(), "opened")
+	s.Require().NoError(encrypted.OpenDirectory(bytes.NewReader(sealed), opened, key))
+	content, err := os.ReadFile(filepath.Join(opened, "stdout"))
+	s.Require().NoError(err)
+	s.Equal("hello", string(content))
+}
+
+func (s *EncryptedPublisherSuite) TestPublishNotEncrypted() {
+	spec, err := s.publisher.PublishResult(s.ctx, "execution-id", s.job(false), s.resultPath)
+	s.Require().NoError(err)
+	s.Nil(spec.Encryption)
+	s.Equal([]byte("hello"), s.published["stdout"])
+}
+
+func (s *EncryptedPublisherSuite) TestRequiresClientPublicKey() {
+	j := s.job(true)
+	j.Metadata.ClientPublicKey = nil
+	s.Error(s.publisher.ValidateJob(s.ctx, j))
+	_, err := s.publisher.PublishResult(s.ctx, "execution-id", j, s.resultPath)
+	s.Error(err)
+	s.Empty(s.published)
+}
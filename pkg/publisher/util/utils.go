@@ -11,6 +11,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/combo"
+	"github.com/bacalhau-project/bacalhau/pkg/publisher/encrypted"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/estuary"
 	filecoinlotus "github.com/bacalhau-project/bacalhau/pkg/publisher/filecoin_lotus"
 	"github.com/bacalhau-project/bacalhau/pkg/publisher/ipfs"
@@ -62,11 +63,13 @@ func NewIPFSPublishers(
 		return nil, err
 	}
 	return model.NewMappedProvider(map[model.Publisher]publisher.Publisher{
-		model.PublisherNoop:     tracing.Wrap(noopPublisher),
-		model.PublisherIpfs:     tracing.Wrap(ipfsPublisher),
-		model.PublisherS3:       tracing.Wrap(s3Publisher),
-		model.PublisherEstuary:  tracing.Wrap(estuaryPublisher),
-		model.PublisherFilecoin: combo.NewPiggybackedPublisher(tracing.Wrap(ipfsPublisher), tracing.Wrap(lotus)),
+		model.PublisherNoop:    encrypted.Wrap(tracing.Wrap(noopPublisher)),
+		model.PublisherIpfs:    encrypted.Wrap(tracing.Wrap(ipfsPublisher)),
+		model.PublisherS3:      encrypted.Wrap(tracing.Wrap(s3Publisher)),
+		model.PublisherEstuary: encrypted.Wrap(tracing.Wrap(estuaryPublisher)),
+		model.PublisherFilecoin: encrypted.Wrap(
+			combo.NewPiggybackedPublisher(tracing.Wrap(ipfsPublisher), tracing.Wrap(lotus)),
+		),
 	}), nil
 }
 
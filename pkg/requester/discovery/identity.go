@@ -9,6 +9,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
 )
 
 type IdentityNodeDiscovererParams struct {
@@ -27,12 +28,16 @@ func NewIdentityNodeDiscoverer(params IdentityNodeDiscovererParams) *IdentityNod
 
 func (d *IdentityNodeDiscoverer) ListNodes(ctx context.Context) ([]model.NodeInfo, error) {
 	var peers []peer.ID
+	// compute nodes may serve any of the versions of the protocol. Compatibility with the versions this node
+	// speaks is left to node ranking and stream negotiation.
+	askForBidProtocolIDs := bprotocol.ProtocolIDs(bprotocol.AskForBidProtocolID, bprotocol.SupportedVersions)
 
 	// check local protocols in case the current node is also a compute node
 	// peerstore doesn't seem to hold protocols of the current node
 	for _, protocol := range d.host.Mux().Protocols() {
-		if protocol == bprotocol.AskForBidProtocolID {
+		if slices.Contains(askForBidProtocolIDs, protocol) {
 			peers = append(peers, d.host.ID())
+			break
 		}
 	}
 
@@ -40,7 +45,7 @@ func (d *IdentityNodeDiscoverer) ListNodes(ctx context.Context) ([]model.NodeInf
 		if peerID == d.host.ID() {
 			continue
 		}
-		supportedProtocols, err := d.host.Peerstore().SupportsProtocols(peerID, bprotocol.AskForBidProtocolID)
+		supportedProtocols, err := d.host.Peerstore().SupportsProtocols(peerID, askForBidProtocolIDs...)
 		if err != nil {
 			log.Ctx(ctx).Warn().Err(err).Msgf("failed to get supported protocols for peer %s", peerID)
 			continue
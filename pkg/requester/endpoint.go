@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"os"
 	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
@@ -12,11 +11,11 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/requester/jobtransform"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
 	"github.com/google/uuid"
-	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -32,7 +31,11 @@ type BaseEndpointParams struct {
 	StorageProviders           storage.StorageProvider
 	MinJobExecutionTimeout     time.Duration
 	DefaultJobExecutionTimeout time.Duration
+	PrivacyBudget              float64
 	GetBiddingCallback         func() *url.URL
+	// Moderator decides whether the jobs held for moderation by the Selector should run.
+	// Defaults to a moderator with the default moderation policy.
+	Moderator *moderation.Moderator
 }
 
 // BaseEndpoint base implementation of requester Endpoint
@@ -42,6 +45,7 @@ type BaseEndpoint struct {
 	store      jobstore.Store
 	computesvc compute.Endpoint
 	selector   bidstrategy.BidStrategy
+	moderator  *moderation.Moderator
 	callback   func() *url.URL
 	transforms []jobtransform.Transformer
 }
@@ -53,6 +57,12 @@ func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
 		jobtransform.NewRequesterInfo(params.ID, params.PublicKey),
 		jobtransform.RepoExistsOnIPFS(params.StorageProviders),
 		jobtransform.NewPublisherMigrator(),
+		jobtransform.NewPrivacyBudgetEnforcer(params.Store, params.PrivacyBudget),
+	}
+
+	moderator := params.Moderator
+	if moderator == nil {
+		moderator = moderation.NewModerator(moderation.ModeratorParams{JobStore: params.Store})
 	}
 
 	return &BaseEndpoint{
@@ -60,6 +70,7 @@ func NewBaseEndpoint(params *BaseEndpointParams) *BaseEndpoint {
 		queue:      params.Queue,
 		computesvc: params.ComputeEndpoint,
 		selector:   params.Selector,
+		moderator:  moderator,
 		store:      params.Store,
 		transforms: transforms,
 		callback:   params.GetBiddingCallback,
@@ -96,9 +107,10 @@ func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePay
 	job := &model.Job{
 		APIVersion: data.APIVersion,
 		Metadata: model.Metadata{
-			ID:        jobID,
-			ClientID:  data.ClientID,
-			CreatedAt: time.Now(),
+			ID:              jobID,
+			ClientID:        data.ClientID,
+			ClientPublicKey: data.ClientPublicKey,
+			CreatedAt:       time.Now(),
 		},
 		Spec: *data.Spec,
 	}
@@ -133,21 +145,32 @@ func (node *BaseEndpoint) SubmitJob(ctx context.Context, data model.JobCreatePay
 	return job, node.handleBidResponse(ctx, *job, response)
 }
 
+// ApproveJob records the vote of an approver on a job held for moderation. The job is started once it reaches
+// its quorum of approvals, and cancelled as soon as it is rejected.
 func (node *BaseEndpoint) ApproveJob(ctx context.Context, approval bidstrategy.ModerateJobRequest) error {
-	// We deliberately expect this to be the empty string if unset. This is so
-	// that if this env variable is (accidentally) left unset, no jobs can be
-	// approved because an empty ClientID is invalid.
-	approvingClient := os.Getenv("BACALHAU_JOB_APPROVER")
-	if approval.ClientID != approvingClient {
-		return errors.New("approval submitted by unknown client")
-	}
-
-	job, err := node.store.GetJob(ctx, approval.JobID)
+	job, decision, err := node.moderator.Moderate(ctx, approval)
 	if err != nil {
 		return err
 	}
+	if approval.Response.ShouldWait {
+		return node.handleBidResponse(ctx, job, approval.Response)
+	}
 
-	return node.handleBidResponse(ctx, job, approval.Response)
+	switch decision.Status {
+	case moderation.StatusApproved:
+		return node.handleBidResponse(ctx, job, bidstrategy.BidStrategyResponse{ShouldBid: true, Reason: decision.Reason})
+	case moderation.StatusRejected:
+		return node.handleBidResponse(ctx, job, bidstrategy.BidStrategyResponse{ShouldBid: false, Reason: decision.Reason})
+	default:
+		return node.store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+			JobID: job.ID(),
+			Condition: jobstore.UpdateJobCondition{
+				ExpectedState: model.JobStateQueued,
+			},
+			NewState: model.JobStateQueued,
+			Comment:  decision.Reason,
+		})
+	}
 }
 
 func (node *BaseEndpoint) CancelJob(ctx context.Context, request CancelJobRequest) (CancelJobResult, error) {
@@ -200,7 +223,7 @@ func (node *BaseEndpoint) ReadLogs(ctx context.Context, request ReadLogsRequest)
 
 func (node *BaseEndpoint) handleBidResponse(ctx context.Context, job model.Job, response bidstrategy.BidStrategyResponse) error {
 	if response.ShouldWait {
-		return node.store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		err := node.store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
 			JobID: job.ID(),
 			Condition: jobstore.UpdateJobCondition{
 				ExpectedState: model.JobStateQueued,
@@ -208,6 +231,10 @@ func (node *BaseEndpoint) handleBidResponse(ctx context.Context, job model.Job,
 			NewState: model.JobStateQueued,
 			Comment:  response.Reason,
 		})
+		if err != nil {
+			return err
+		}
+		return node.moderator.Hold(ctx, job.ID(), response.Reason)
 	}
 
 	if response.ShouldBid {
@@ -12,6 +12,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	noop_storage "github.com/bacalhau-project/bacalhau/pkg/storage/noop"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
@@ -37,6 +38,10 @@ func (m *mockBidStrategy) ShouldBidBasedOnUsage(context.Context, bidstrategy.Bid
 var _ bidstrategy.BidStrategy = (*mockBidStrategy)(nil)
 
 func getTestEndpoint(t *testing.T, strategy bidstrategy.BidStrategy) (Endpoint, jobstore.Store) {
+	return getModeratedTestEndpoint(t, strategy, nil)
+}
+
+func getModeratedTestEndpoint(t *testing.T, strategy bidstrategy.BidStrategy, policy *moderation.Policy) (Endpoint, jobstore.Store) {
 	cm := system.NewCleanupManager()
 	t.Cleanup(func() { cm.Cleanup(context.Background()) })
 
@@ -61,12 +66,13 @@ func getTestEndpoint(t *testing.T, strategy bidstrategy.BidStrategy) (Endpoint,
 		}),
 	})
 	endpoint := NewBaseEndpoint(&BaseEndpointParams{
-		Queue:              NewQueue(store, scheduler, emitter),
+		Queue:              NewQueue(QueueParams{JobStore: store, Scheduler: scheduler, EventEmitter: emitter}),
 		Selector:           strategy,
 		Store:              store,
 		Verifiers:          model.NewNoopProvider[model.Verifier, verifier.Verifier](verifier_mock),
 		StorageProviders:   model.NewNoopProvider[model.StorageSourceType, storage.Storage](storage_mock),
 		GetBiddingCallback: func() *url.URL { return nil },
+		Moderator:          moderation.NewModerator(moderation.ModeratorParams{JobStore: store, Policy: policy}),
 	})
 
 	return endpoint, store
@@ -153,3 +159,51 @@ func TestEndpointAcceptsApprovals(t *testing.T) {
 		runTest(t, true, model.JobStateQueued)
 	})
 }
+
+func TestEndpointWaitsForQuorumOfApprovals(t *testing.T) {
+	ctx := context.Background()
+	strategy := mockBidStrategy{
+		response: bidstrategy.BidStrategyResponse{ShouldWait: true, Reason: "needs approval"},
+	}
+	endpoint, store := getModeratedTestEndpoint(t, &strategy, &moderation.Policy{
+		Approvers: []moderation.Approver{
+			{ClientID: "alice", Roles: []moderation.Role{"security"}},
+			{ClientID: "bob", Roles: []moderation.Role{"security"}},
+			{ClientID: "carol", Roles: []moderation.Role{"security"}},
+		},
+		Rules: []moderation.Rule{{Networked: true, Role: "security", Quorum: 2}},
+	})
+
+	job, err := endpoint.SubmitJob(ctx, model.JobCreatePayload{
+		Spec: &model.Spec{Network: model.NetworkConfig{Type: model.NetworkFull}},
+	})
+	require.NoError(t, err)
+
+	approve := func(clientID string) error {
+		return endpoint.ApproveJob(ctx, bidstrategy.ModerateJobRequest{
+			ClientID: clientID,
+			JobID:    job.ID(),
+			Response: bidstrategy.BidStrategyResponse{ShouldBid: true},
+		})
+	}
+	requireState := func(expected model.JobStateType) {
+		state, stateErr := store.GetJobState(ctx, job.ID())
+		require.NoError(t, stateErr)
+		require.Equal(t, expected, state.State)
+	}
+
+	require.NoError(t, approve("alice"))
+	requireState(model.JobStateQueued)
+	require.Error(t, approve("alice"))
+	require.Error(t, approve("mallory"))
+	requireState(model.JobStateQueued)
+
+	require.NoError(t, approve("bob"))
+	requireState(model.JobStateInProgress)
+
+	moderations, err := store.GetJobModerations(ctx, job.ID())
+	require.NoError(t, err)
+	require.Len(t, moderations, 3)
+	require.Equal(t, model.JobModerationRequested, moderations[0].Action)
+	require.Equal(t, "needs approval", moderations[0].Reason)
+}
@@ -3,6 +3,7 @@ package requester
 import (
 	"fmt"
 
+	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -47,3 +48,54 @@ func NewErrJobAlreadyTerminal(jobID string) ErrJobAlreadyTerminal {
 func (e ErrJobAlreadyTerminal) Error() string {
 	return fmt.Errorf("job %s is already in a terminal state", e.JobID).Error()
 }
+
+// ErrExecutionNotRecoverable is returned when an execution could not be reconciled with the compute node after a restart
+type ErrExecutionNotRecoverable struct {
+	ExecutionID model.ExecutionID
+	State       model.ExecutionStateType
+	Reason      string
+}
+
+func NewErrExecutionNotRecoverable(execution model.ExecutionState, reason string) ErrExecutionNotRecoverable {
+	return ErrExecutionNotRecoverable{ExecutionID: execution.ID(), State: execution.State, Reason: reason}
+}
+
+func (e ErrExecutionNotRecoverable) Error() string {
+	return fmt.Sprintf("unable to recover execution %s in state %s: %s", e.ExecutionID, e.State, e.Reason)
+}
+
+// ErrExecutionNodeMismatch is returned when a node sends a callback for an execution assigned to another node
+type ErrExecutionNodeMismatch struct {
+	ExecutionID  model.ExecutionID
+	SourcePeerID string
+}
+
+func NewErrExecutionNodeMismatch(executionID model.ExecutionID, sourcePeerID string) ErrExecutionNodeMismatch {
+	return ErrExecutionNodeMismatch{ExecutionID: executionID, SourcePeerID: sourcePeerID}
+}
+
+func (e ErrExecutionNodeMismatch) Error() string {
+	return fmt.Sprintf("execution %s is not assigned to node %s", e.ExecutionID, e.SourcePeerID)
+}
+
+// ErrClientQuotaExceeded is returned when running a job would take its client over its quota
+type ErrClientQuotaExceeded struct {
+	ClientID string
+	Quota    ClientQuota
+	Usage    clientUsage
+}
+
+func NewErrClientQuotaExceeded(clientID string, quota ClientQuota, usage clientUsage) ErrClientQuotaExceeded {
+	return ErrClientQuotaExceeded{ClientID: clientID, Quota: quota, Usage: usage}
+}
+
+func (e ErrClientQuotaExceeded) Error() string {
+	msg := fmt.Sprintf("client %s has reached its quota. running executions: %d", e.ClientID, e.Usage.Executions)
+	if e.Quota.MaxConcurrentExecutions > 0 {
+		msg += fmt.Sprintf("/%d", e.Quota.MaxConcurrentExecutions)
+	}
+	if !e.Quota.MaxResources.IsZero() {
+		msg += fmt.Sprintf(", resources: %s/%s", e.Usage.Resources, e.Quota.MaxResources)
+	}
+	return msg
+}
@@ -34,7 +34,7 @@ func (e EventEmitter) EmitJobCreated(
 	event := model.JobEvent{
 		APIVersion:   job.APIVersion,
 		ClientID:     job.Metadata.ClientID,
-		Spec:         job.Spec,
+		Spec:         job.Spec.Redacted(),
 		Deal:         job.Spec.Deal,
 		SourceNodeID: job.Metadata.Requester.RequesterNodeID,
 		JobID:        job.Metadata.ID,
@@ -0,0 +1,163 @@
+package requester
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+// DefaultClientWeight is the fair share weight of clients that were not given one
+const DefaultClientWeight = 1.0
+
+// ClientQuota limits the work a single client can have running at once. Zero values are unlimited.
+type ClientQuota struct {
+	// MaxConcurrentExecutions is the maximum number of running executions across all the jobs of a client
+	MaxConcurrentExecutions int
+	// MaxResources is the maximum total resources requested by the running executions of a client
+	MaxResources model.ResourceUsageData
+}
+
+// IsZero returns true if the quota doesn't limit clients
+func (q ClientQuota) IsZero() bool {
+	return q.MaxConcurrentExecutions == 0 && q.MaxResources.IsZero()
+}
+
+// clientUsage is the work a client has running, as counted against its quota and fair share
+type clientUsage struct {
+	Executions int
+	Resources  model.ResourceUsageData
+}
+
+type FairSharePolicyParams struct {
+	JobStore jobstore.Store
+	// ClientWeights are the fair share weights of clients by client ID. A client with twice the weight of another
+	// can have twice as many executions running before its jobs are scheduled after the other client's.
+	ClientWeights map[string]float64
+	// ClientQuota is applied to every client
+	ClientQuota ClientQuota
+}
+
+// FairSharePolicy schedules pending jobs by priority, and shares the network fairly between the clients that
+// submitted jobs of the same priority. Jobs of the client with the fewest running executions relative to its weight
+// are scheduled first, and jobs that would take a client over its quota are held until its other jobs complete.
+type FairSharePolicy struct {
+	jobStore      jobstore.Store
+	clientWeights map[string]float64
+	clientQuota   ClientQuota
+}
+
+func NewFairSharePolicy(params FairSharePolicyParams) *FairSharePolicy {
+	return &FairSharePolicy{
+		jobStore:      params.JobStore,
+		clientWeights: params.ClientWeights,
+		clientQuota:   params.ClientQuota,
+	}
+}
+
+// Order sorts the jobs by descending priority, then by ascending weighted usage of their clients. The order of jobs
+// with the same priority from clients with the same weighted usage is preserved.
+func (p *FairSharePolicy) Order(ctx context.Context, jobs []model.Job) ([]model.Job, error) {
+	usage, err := p.usageByClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := append([]model.Job(nil), jobs...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Spec.Priority != ordered[j].Spec.Priority {
+			return ordered[i].Spec.Priority > ordered[j].Spec.Priority
+		}
+		return p.weightedUsage(ordered[i].Metadata.ClientID, usage) < p.weightedUsage(ordered[j].Metadata.ClientID, usage)
+	})
+	return ordered, nil
+}
+
+// Admit returns ErrClientQuotaExceeded if running the job would take its client over its quota
+func (p *FairSharePolicy) Admit(ctx context.Context, job model.Job) error {
+	if p.clientQuota.IsZero() {
+		return nil
+	}
+	usage, err := p.usageByClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	clientID := job.Metadata.ClientID
+	executions, resources := jobUsage(job)
+	requested := clientUsage{
+		Executions: usage[clientID].Executions + executions,
+		Resources:  usage[clientID].Resources.Add(resources),
+	}
+	if p.exceedsQuota(requested) {
+		return NewErrClientQuotaExceeded(clientID, p.clientQuota, usage[clientID])
+	}
+	return nil
+}
+
+// usageByClient sums the executions that are not yet terminal across the in progress jobs of each client. Jobs that
+// were started but have no executions yet count as running as many executions as their concurrency.
+func (p *FairSharePolicy) usageByClient(ctx context.Context) (map[string]clientUsage, error) {
+	jobs, err := p.jobStore.GetInProgressJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]clientUsage)
+	for _, jobWithInfo := range jobs {
+		if jobWithInfo.State.State == model.JobStateQueued {
+			continue
+		}
+		executions, resources := jobUsage(jobWithInfo.Job)
+		if running := runningExecutions(jobWithInfo.State); running > 0 {
+			executions = running
+			resources = capacity.ParseResourceUsageConfig(jobWithInfo.Job.Spec.Resources).Multi(float64(running))
+		}
+		clientID := jobWithInfo.Job.Metadata.ClientID
+		usage[clientID] = clientUsage{
+			Executions: usage[clientID].Executions + executions,
+			Resources:  usage[clientID].Resources.Add(resources),
+		}
+	}
+	return usage, nil
+}
+
+func (p *FairSharePolicy) weightedUsage(clientID string, usage map[string]clientUsage) float64 {
+	weight, ok := p.clientWeights[clientID]
+	if !ok || weight <= 0 {
+		weight = DefaultClientWeight
+	}
+	return float64(usage[clientID].Executions) / weight
+}
+
+func (p *FairSharePolicy) exceedsQuota(usage clientUsage) bool {
+	quota := p.clientQuota
+	maxResources := quota.MaxResources
+	return (quota.MaxConcurrentExecutions > 0 && usage.Executions > quota.MaxConcurrentExecutions) ||
+		(maxResources.CPU > 0 && usage.Resources.CPU > maxResources.CPU) ||
+		(maxResources.Memory > 0 && usage.Resources.Memory > maxResources.Memory) ||
+		(maxResources.Disk > 0 && usage.Resources.Disk > maxResources.Disk) ||
+		(maxResources.GPU > 0 && usage.Resources.GPU > maxResources.GPU)
+}
+
+// jobUsage returns the executions and resources the job needs to run
+func jobUsage(job model.Job) (int, model.ResourceUsageData) {
+	executions := system.Max(1, job.Spec.Deal.Concurrency)
+	resources := capacity.ParseResourceUsageConfig(job.Spec.Resources).Multi(float64(executions))
+	return executions, resources
+}
+
+func runningExecutions(state model.JobState) int {
+	count := 0
+	for _, execution := range state.Executions {
+		if !execution.State.IsTerminal() {
+			count++
+		}
+	}
+	return count
+}
+
+// compile-time check that FairSharePolicy implements the expected interface
+var _ SchedulingPolicy = (*FairSharePolicy)(nil)
@@ -0,0 +1,132 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type FairSharePolicyTestSuite struct {
+	suite.Suite
+	ctx   context.Context
+	store jobstore.Store
+}
+
+func TestFairSharePolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(FairSharePolicyTestSuite))
+}
+
+func (s *FairSharePolicyTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = inmemory.NewJobStore()
+}
+
+func newFairShareJob(clientID string, priority, concurrency int, cpu string) model.Job {
+	return model.Job{
+		Metadata: model.Metadata{ID: uuid.NewString(), ClientID: clientID},
+		Spec: model.Spec{
+			Priority:  priority,
+			Deal:      model.Deal{Concurrency: concurrency},
+			Resources: model.ResourceUsageConfig{CPU: cpu},
+		},
+	}
+}
+
+// runJob creates a job in the store in the given state
+func (s *FairSharePolicyTestSuite) runJob(job model.Job, state model.JobStateType) {
+	s.Require().NoError(s.store.CreateJob(s.ctx, job))
+	s.Require().NoError(s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: state,
+	}))
+}
+
+func (s *FairSharePolicyTestSuite) order(policy *FairSharePolicy, jobs ...model.Job) []string {
+	ordered, err := policy.Order(s.ctx, jobs)
+	s.Require().NoError(err)
+	ids := make([]string, len(ordered))
+	for i, job := range ordered {
+		ids[i] = job.ID()
+	}
+	return ids
+}
+
+func (s *FairSharePolicyTestSuite) TestOrdersByPriority() {
+	policy := NewFairSharePolicy(FairSharePolicyParams{JobStore: s.store})
+	low := newFairShareJob("a", 0, 1, "")
+	high := newFairShareJob("a", 10, 1, "")
+	other := newFairShareJob("b", 0, 1, "")
+	s.Equal([]string{high.ID(), low.ID(), other.ID()}, s.order(policy, low, high, other))
+}
+
+func (s *FairSharePolicyTestSuite) TestOrdersByWeightedUsage() {
+	s.runJob(newFairShareJob("a", 0, 2, ""), model.JobStateInProgress)
+	s.runJob(newFairShareJob("b", 0, 1, ""), model.JobStateInProgress)
+	// queued jobs don't count towards the usage of their client
+	s.runJob(newFairShareJob("b", 0, 5, ""), model.JobStateQueued)
+
+	jobA := newFairShareJob("a", 0, 1, "")
+	jobB := newFairShareJob("b", 0, 1, "")
+	jobC := newFairShareJob("c", 0, 1, "")
+
+	policy := NewFairSharePolicy(FairSharePolicyParams{JobStore: s.store})
+	s.Equal([]string{jobC.ID(), jobB.ID(), jobA.ID()}, s.order(policy, jobA, jobB, jobC))
+
+	weighted := NewFairSharePolicy(FairSharePolicyParams{
+		JobStore:      s.store,
+		ClientWeights: map[string]float64{"a": 4},
+	})
+	s.Equal([]string{jobC.ID(), jobA.ID(), jobB.ID()}, s.order(weighted, jobA, jobB, jobC))
+}
+
+func (s *FairSharePolicyTestSuite) TestCountsRunningExecutions() {
+	job := newFairShareJob("a", 0, 3, "")
+	s.runJob(job, model.JobStateInProgress)
+	for _, state := range []model.ExecutionStateType{model.ExecutionStateBidAccepted, model.ExecutionStateFailed} {
+		s.Require().NoError(s.store.CreateExecution(s.ctx, model.ExecutionState{
+			JobID:            job.ID(),
+			NodeID:           uuid.NewString(),
+			ComputeReference: uuid.NewString(),
+			State:            state,
+		}))
+	}
+
+	policy := NewFairSharePolicy(FairSharePolicyParams{
+		JobStore:    s.store,
+		ClientQuota: ClientQuota{MaxConcurrentExecutions: 2},
+	})
+	s.NoError(policy.Admit(s.ctx, newFairShareJob("a", 0, 1, "")))
+	s.ErrorAs(policy.Admit(s.ctx, newFairShareJob("a", 0, 2, "")), &ErrClientQuotaExceeded{})
+}
+
+func (s *FairSharePolicyTestSuite) TestAdmitsWithinQuota() {
+	s.runJob(newFairShareJob("a", 0, 2, "500m"), model.JobStateInProgress)
+
+	policy := NewFairSharePolicy(FairSharePolicyParams{
+		JobStore: s.store,
+		ClientQuota: ClientQuota{
+			MaxConcurrentExecutions: 4,
+			MaxResources:            model.ResourceUsageData{CPU: 2},
+		},
+	})
+	s.NoError(policy.Admit(s.ctx, newFairShareJob("a", 0, 2, "500m")))
+	s.NoError(policy.Admit(s.ctx, newFairShareJob("b", 0, 4, "500m")))
+
+	// over the execution quota
+	s.ErrorAs(policy.Admit(s.ctx, newFairShareJob("a", 0, 3, "")), &ErrClientQuotaExceeded{})
+	// over the resource quota
+	s.ErrorAs(policy.Admit(s.ctx, newFairShareJob("a", 0, 1, "2")), &ErrClientQuotaExceeded{})
+}
+
+func (s *FairSharePolicyTestSuite) TestAdmitsEverythingWithoutQuota() {
+	s.runJob(newFairShareJob("a", 0, 100, "100"), model.JobStateInProgress)
+	policy := NewFairSharePolicy(FairSharePolicyParams{JobStore: s.store})
+	s.NoError(policy.Admit(s.ctx, newFairShareJob("a", 0, 100, "100")))
+}
@@ -0,0 +1,41 @@
+package jobtransform
+
+import (
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// ErrPrivacyBudgetExceeded is returned when a differentially private job would spend more than the remaining privacy
+// budget of its client on a dataset
+type ErrPrivacyBudgetExceeded struct {
+	ClientID string
+	Dataset  string
+	Spent    float64
+	Epsilon  float64
+	Budget   float64
+}
+
+func NewErrPrivacyBudgetExceeded(clientID, dataset string, spent, epsilon, budget float64) ErrPrivacyBudgetExceeded {
+	return ErrPrivacyBudgetExceeded{ClientID: clientID, Dataset: dataset, Spent: spent, Epsilon: epsilon, Budget: budget}
+}
+
+func (e ErrPrivacyBudgetExceeded) Error() string {
+	return fmt.Sprintf("privacy budget exceeded for dataset %s: client %s already spent epsilon %g of %g, and the job requires %g",
+		e.Dataset, e.ClientID, e.Spent, e.Budget, e.Epsilon)
+}
+
+// ErrUnidentifiedDataset is returned when an input of a differentially private job has no identifier the privacy
+// budget could be charged to
+type ErrUnidentifiedDataset struct {
+	Input model.StorageSpec
+}
+
+func NewErrUnidentifiedDataset(input model.StorageSpec) ErrUnidentifiedDataset {
+	return ErrUnidentifiedDataset{Input: input}
+}
+
+func (e ErrUnidentifiedDataset) Error() string {
+	return fmt.Sprintf("input %s of source %s has no CID, S3 location or URL to charge the privacy budget to",
+		e.Input.Name, e.Input.StorageSource)
+}
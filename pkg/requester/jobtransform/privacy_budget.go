@@ -0,0 +1,119 @@
+package jobtransform
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// NewPrivacyBudgetEnforcer returns a job transformer that refuses differentially private jobs that would make the
+// cumulative epsilon spent by their client on any of their input datasets exceed the budget. The spent budget is
+// computed from the jobs in the store, so that it is not reset when the requester restarts with a persistent store.
+// A negative budget disables the check.
+func NewPrivacyBudgetEnforcer(store jobstore.Store, budget float64) Transformer {
+	ledger := &privacyLedger{
+		store:    store,
+		budget:   budget,
+		reserved: make(map[string]map[string]*model.Job),
+	}
+	return ledger.enforce
+}
+
+type privacyLedger struct {
+	mu     sync.Mutex
+	store  jobstore.Store
+	budget float64
+	// jobs accepted by this ledger that may not be in the store yet, by client and job ID, so that concurrent
+	// submissions can't overspend the budget
+	reserved map[string]map[string]*model.Job
+}
+
+func (l *privacyLedger) enforce(ctx context.Context, j *model.Job) (modified bool, err error) {
+	privacySpec := j.Spec.DifferentialPrivacy
+	if privacySpec == nil || l.budget < 0 {
+		return false, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ids, err := datasets(j)
+	if err != nil {
+		return false, err
+	}
+	clientID := j.Metadata.ClientID
+	spent, err := l.spent(ctx, clientID)
+	if err != nil {
+		return false, err
+	}
+	epsilon := epsilonOf(j)
+	for _, dataset := range ids {
+		if spent[dataset]+epsilon > l.budget {
+			return false, NewErrPrivacyBudgetExceeded(clientID, dataset, spent[dataset], epsilon, l.budget)
+		}
+	}
+
+	if l.reserved[clientID] == nil {
+		l.reserved[clientID] = make(map[string]*model.Job)
+	}
+	l.reserved[clientID][j.ID()] = j
+	return false, nil
+}
+
+// spent returns the epsilon spent by the client on each dataset
+func (l *privacyLedger) spent(ctx context.Context, clientID string) (map[string]float64, error) {
+	jobs, err := l.store.GetJobs(ctx, jobstore.JobQuery{ClientID: clientID})
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make(map[string]float64)
+	add := func(j *model.Job) {
+		if j.Spec.DifferentialPrivacy == nil {
+			return
+		}
+		// jobs with inputs that can't be identified are rejected, so only those already in the store before they were
+		// would fail here, and their identified inputs are still charged
+		ids, _ := datasets(j)
+		for _, dataset := range ids {
+			spent[dataset] += epsilonOf(j)
+		}
+	}
+	for i := range jobs {
+		add(&jobs[i])
+		// the job is now accounted for by the store
+		delete(l.reserved[clientID], jobs[i].ID())
+	}
+	for _, j := range l.reserved[clientID] {
+		add(j)
+	}
+	return spent, nil
+}
+
+// epsilonOf returns the epsilon spent by the job. Each of its executions publishes its own independently noised
+// release of the same inputs, so the privacy loss adds up over the executions.
+func epsilonOf(j *model.Job) float64 {
+	return j.Spec.DifferentialPrivacy.Epsilon * float64(j.Spec.Deal.GetConcurrency())
+}
+
+// datasets returns the identifiers of the input datasets of the job, or an error if one of them can't be identified
+// and so can't be charged
+func datasets(j *model.Job) ([]string, error) {
+	ids := make([]string, 0, len(j.Spec.Inputs))
+	for _, input := range j.Spec.Inputs {
+		switch {
+		case input.CID != "":
+			ids = append(ids, input.CID)
+		case input.S3 != nil:
+			ids = append(ids, fmt.Sprintf("s3://%s/%s", input.S3.Bucket, input.S3.Key))
+		case input.URL != "":
+			ids = append(ids, input.URL)
+		default:
+			return nil, NewErrUnidentifiedDataset(input)
+		}
+	}
+	return ids, nil
+}
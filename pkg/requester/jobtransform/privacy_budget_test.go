@@ -0,0 +1,128 @@
+//go:build unit || !integration
+
+package jobtransform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type PrivacyBudgetSuite struct {
+	suite.Suite
+	ctx       context.Context
+	store     jobstore.Store
+	transform Transformer
+}
+
+func TestPrivacyBudgetSuite(t *testing.T) {
+	suite.Run(t, new(PrivacyBudgetSuite))
+}
+
+func (s *PrivacyBudgetSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = inmemory.NewJobStore()
+	s.transform = NewPrivacyBudgetEnforcer(s.store, 1)
+}
+
+func (s *PrivacyBudgetSuite) job(id, clientID string, epsilon float64, cids ...string) *model.Job {
+	j := &model.Job{Metadata: model.Metadata{ID: id, ClientID: clientID}}
+	for _, cid := range cids {
+		j.Spec.Inputs = append(j.Spec.Inputs, model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: cid})
+	}
+	if epsilon > 0 {
+		j.Spec.DifferentialPrivacy = &model.DifferentialPrivacySpec{
+			Mechanism:   model.PrivacyMechanismLaplace,
+			Epsilon:     epsilon,
+			Sensitivity: 1,
+			Outputs:     []string{"outputs/stats.csv"},
+		}
+	}
+	return j
+}
+
+func (s *PrivacyBudgetSuite) submit(j *model.Job) error {
+	_, err := s.transform(s.ctx, j)
+	if err == nil {
+		s.Require().NoError(s.store.CreateJob(s.ctx, *j))
+	}
+	return err
+}
+
+func (s *PrivacyBudgetSuite) TestEnforceBudget() {
+	s.NoError(s.submit(s.job("job-1", "client", 0.6, "QmA")))
+	s.ErrorAs(s.submit(s.job("job-2", "client", 0.6, "QmA", "QmB")), &ErrPrivacyBudgetExceeded{})
+
+	// the budget is per dataset and per client
+	s.NoError(s.submit(s.job("job-3", "client", 0.6, "QmB")))
+	s.NoError(s.submit(s.job("job-4", "other-client", 0.6, "QmA")))
+	s.NoError(s.submit(s.job("job-5", "client", 0.4, "QmA")))
+
+	// jobs that are not differentially private are not limited
+	s.NoError(s.submit(s.job("job-6", "client", 0, "QmA")))
+}
+
+func (s *PrivacyBudgetSuite) TestNegativeBudgetDisablesCheck() {
+	s.transform = NewPrivacyBudgetEnforcer(s.store, -1)
+	s.NoError(s.submit(s.job("job-1", "client", 5, "QmA")))
+	s.NoError(s.submit(s.job("job-2", "client", 5, "QmA")))
+
+	// a budget of 0 allows no spending at all
+	s.transform = NewPrivacyBudgetEnforcer(s.store, 0)
+	s.Error(s.submit(s.job("job-3", "client", 0.1, "QmB")))
+}
+
+func (s *PrivacyBudgetSuite) TestReservedBeforeStored() {
+	// jobs accepted by the transformer count against the budget even before being stored
+	_, err := s.transform(s.ctx, s.job("job-1", "client", 0.6, "QmA"))
+	s.Require().NoError(err)
+	_, err = s.transform(s.ctx, s.job("job-2", "client", 0.6, "QmA"))
+	s.Error(err)
+}
+
+func (s *PrivacyBudgetSuite) TestBudgetFromStore() {
+	// a new transformer, such as after a restart, accounts for the jobs already in the store
+	s.Require().NoError(s.submit(s.job("job-1", "client", 0.6, "QmA")))
+	s.transform = NewPrivacyBudgetEnforcer(s.store, 1)
+	s.Error(s.submit(s.job("job-2", "client", 0.6, "QmA")))
+}
+
+func (s *PrivacyBudgetSuite) TestChargeEveryExecution() {
+	// each execution publishes its own release, so a job with a concurrency of 2 spends twice its epsilon
+	j := s.job("job-1", "client", 0.4, "QmA")
+	j.Spec.Deal.Concurrency = 2
+	s.NoError(s.submit(j))
+	s.ErrorAs(s.submit(s.job("job-2", "client", 0.4, "QmA")), &ErrPrivacyBudgetExceeded{})
+
+	j = s.job("job-3", "client", 0.6, "QmB")
+	j.Spec.Deal.Concurrency = 2
+	s.ErrorAs(s.submit(j), &ErrPrivacyBudgetExceeded{})
+}
+
+func (s *PrivacyBudgetSuite) TestChargeS3Inputs() {
+	s3Job := func(id string) *model.Job {
+		j := s.job(id, "client", 0.6)
+		j.Spec.Inputs = []model.StorageSpec{{
+			StorageSource: model.StorageSourceS3,
+			S3:            &model.S3StorageSpec{Bucket: "bucket", Key: "data.csv"},
+		}}
+		return j
+	}
+	s.NoError(s.submit(s3Job("job-1")))
+	s.ErrorAs(s.submit(s3Job("job-2")), &ErrPrivacyBudgetExceeded{})
+}
+
+func (s *PrivacyBudgetSuite) TestRejectUnidentifiedInputs() {
+	j := s.job("job-1", "client", 0.1)
+	j.Spec.Inputs = []model.StorageSpec{{StorageSource: model.StorageSourceInline, Name: "data"}}
+	s.ErrorAs(s.submit(j), &ErrUnidentifiedDataset{})
+
+	// jobs that are not differentially private don't need identified inputs
+	j = s.job("job-2", "client", 0)
+	j.Spec.Inputs = []model.StorageSpec{{StorageSource: model.StorageSourceInline, Name: "data"}}
+	s.NoError(s.submit(j))
+}
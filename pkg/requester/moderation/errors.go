@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"fmt"
+)
+
+// ErrUnknownApprover is returned when a vote is cast by a client that is not an approver
+type ErrUnknownApprover struct {
+	ClientID string
+}
+
+func NewErrUnknownApprover(clientID string) ErrUnknownApprover {
+	return ErrUnknownApprover{ClientID: clientID}
+}
+
+func (e ErrUnknownApprover) Error() string {
+	return fmt.Sprintf("approval submitted by unknown client %s", e.ClientID)
+}
+
+// ErrNotEligible is returned when an approver votes on a job that needs approvals from roles the approver doesn't have
+type ErrNotEligible struct {
+	ClientID string
+	JobID    string
+}
+
+func NewErrNotEligible(clientID, jobID string) ErrNotEligible {
+	return ErrNotEligible{ClientID: clientID, JobID: jobID}
+}
+
+func (e ErrNotEligible) Error() string {
+	return fmt.Sprintf("approver %s has none of the roles needed to moderate job %s", e.ClientID, e.JobID)
+}
+
+// ErrAlreadyModerated is returned when an approver votes twice on the same job
+type ErrAlreadyModerated struct {
+	ClientID string
+	JobID    string
+}
+
+func NewErrAlreadyModerated(clientID, jobID string) ErrAlreadyModerated {
+	return ErrAlreadyModerated{ClientID: clientID, JobID: jobID}
+}
+
+func (e ErrAlreadyModerated) Error() string {
+	return fmt.Sprintf("approver %s already moderated job %s", e.ClientID, e.JobID)
+}
+
+// ErrJobNotPending is returned when voting on a job that is not waiting for moderation
+type ErrJobNotPending struct {
+	JobID  string
+	Reason string
+}
+
+func NewErrJobNotPending(jobID, reason string) ErrJobNotPending {
+	return ErrJobNotPending{JobID: jobID, Reason: reason}
+}
+
+func (e ErrJobNotPending) Error() string {
+	return fmt.Sprintf("job %s is not waiting for moderation: %s", e.JobID, e.Reason)
+}
@@ -0,0 +1,174 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+)
+
+type ModeratorParams struct {
+	JobStore jobstore.Store
+	// Policy decides who can moderate jobs and how many approvals they need. Defaults to DefaultPolicy, which is
+	// read again for every vote so that the approver can be changed without restarting the requester.
+	Policy *Policy
+}
+
+// Moderator keeps the moderation audit trail of the jobs held for moderation, and decides whether they should run
+// by tallying the votes of the approvers against its policy.
+type Moderator struct {
+	jobStore jobstore.Store
+	policy   *Policy
+	// mu serializes votes so that concurrent votes on a job are tallied against each other
+	mu sync.Mutex
+}
+
+func NewModerator(params ModeratorParams) *Moderator {
+	m := &Moderator{
+		jobStore: params.JobStore,
+		policy:   params.Policy,
+	}
+	m.mu.EnableTracerWithOpts(sync.Opts{
+		Threshold: 10 * time.Millisecond,
+		Id:        "Moderator.mu",
+	})
+	return m
+}
+
+// Hold records that the job is waiting for moderation
+func (m *Moderator) Hold(ctx context.Context, jobID string, reason string) error {
+	return m.jobStore.AddJobModeration(ctx, model.JobModeration{
+		JobID:  jobID,
+		Action: model.JobModerationRequested,
+		Reason: reason,
+		Time:   time.Now(),
+	})
+}
+
+// Moderate records the vote of an approver on a job waiting for moderation, and returns the decision once the vote is
+// tallied. Responses asking the job to keep waiting are not votes, and only return the current decision.
+func (m *Moderator) Moderate(ctx context.Context, request bidstrategy.ModerateJobRequest) (model.Job, Decision, error) {
+	policy := m.getPolicy()
+	approver, ok := policy.Approver(request.ClientID)
+	if !ok {
+		return model.Job{}, Decision{}, NewErrUnknownApprover(request.ClientID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info, err := m.get(ctx, policy, request.JobID)
+	if err != nil {
+		return model.Job{}, Decision{}, err
+	}
+	job := info.Job
+	state, err := m.jobStore.GetJobState(ctx, job.ID())
+	if err != nil {
+		return job, Decision{}, err
+	}
+	if state.State != model.JobStateQueued {
+		return job, Decision{}, NewErrJobNotPending(job.ID(), fmt.Sprintf("job is %s", state.State))
+	}
+	if info.Decision.Status != StatusPending {
+		return job, Decision{}, NewErrJobNotPending(job.ID(), info.Decision.Reason)
+	}
+	if !policy.CanModerate(approver, job) {
+		return job, Decision{}, NewErrNotEligible(approver.ClientID, job.ID())
+	}
+	if request.Response.ShouldWait {
+		return job, info.Decision, nil
+	}
+	for _, moderation := range info.Moderations {
+		if isVote(moderation) && moderation.ClientID == approver.ClientID {
+			return job, Decision{}, NewErrAlreadyModerated(approver.ClientID, job.ID())
+		}
+	}
+
+	moderation := model.JobModeration{
+		JobID:    job.ID(),
+		ClientID: approver.ClientID,
+		Action:   model.JobModerationRejected,
+		Reason:   request.Response.Reason,
+		Time:     time.Now(),
+	}
+	for _, role := range approver.Roles {
+		moderation.Roles = append(moderation.Roles, string(role))
+	}
+	if request.Response.ShouldBid {
+		moderation.Action = model.JobModerationApproved
+	}
+	if err = m.jobStore.AddJobModeration(ctx, moderation); err != nil {
+		return job, Decision{}, err
+	}
+	return job, policy.Decide(job, append(info.Moderations, moderation)), nil
+}
+
+// Get returns the moderation audit trail of a job and the current decision
+func (m *Moderator) Get(ctx context.Context, jobID string) (JobModerationInfo, error) {
+	return m.get(ctx, m.getPolicy(), jobID)
+}
+
+// Pending returns the queued jobs that were held for moderation and are still waiting for votes, oldest first
+func (m *Moderator) Pending(ctx context.Context) ([]JobModerationInfo, error) {
+	policy := m.getPolicy()
+	jobs, err := m.jobStore.GetInProgressJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]JobModerationInfo, 0)
+	for _, jobWithInfo := range jobs {
+		if jobWithInfo.State.State != model.JobStateQueued {
+			continue
+		}
+		info, err := m.get(ctx, policy, jobWithInfo.Job.ID())
+		if err != nil {
+			return nil, err
+		}
+		if info.Decision.Status == StatusPending && wasHeld(info.Moderations) {
+			pending = append(pending, info)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Job.Metadata.CreatedAt.Before(pending[j].Job.Metadata.CreatedAt)
+	})
+	return pending, nil
+}
+
+func (m *Moderator) get(ctx context.Context, policy Policy, jobID string) (JobModerationInfo, error) {
+	job, err := m.jobStore.GetJob(ctx, jobID)
+	if err != nil {
+		return JobModerationInfo{}, err
+	}
+	moderations, err := m.jobStore.GetJobModerations(ctx, job.ID())
+	if err != nil {
+		return JobModerationInfo{}, err
+	}
+	return JobModerationInfo{
+		Job:         job,
+		Decision:    policy.Decide(job, moderations),
+		Moderations: moderations,
+	}, nil
+}
+
+func (m *Moderator) getPolicy() Policy {
+	if m.policy == nil {
+		return DefaultPolicy()
+	}
+	return *m.policy
+}
+
+// wasHeld returns true if the requester held the job for moderation
+func wasHeld(moderations []model.JobModeration) bool {
+	for _, moderation := range moderations {
+		if moderation.Action == model.JobModerationRequested {
+			return true
+		}
+	}
+	return false
+}
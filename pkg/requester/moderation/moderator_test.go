@@ -0,0 +1,143 @@
+//go:build unit || !integration
+
+package moderation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type ModeratorTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	store     jobstore.Store
+	moderator *Moderator
+}
+
+func TestModeratorTestSuite(t *testing.T) {
+	suite.Run(t, new(ModeratorTestSuite))
+}
+
+func (s *ModeratorTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = inmemory.NewJobStore()
+	s.moderator = NewModerator(ModeratorParams{
+		JobStore: s.store,
+		Policy: &Policy{
+			Approvers: []Approver{
+				{ClientID: "alice", Roles: []Role{"security"}},
+				{ClientID: "bob", Roles: []Role{"security"}},
+				{ClientID: "carol", Roles: []Role{"security"}},
+				{ClientID: "dave"},
+			},
+			Rules: []Rule{{Networked: true, Role: "security", Quorum: 2}},
+		},
+	})
+}
+
+// holdJob creates a queued job that is held for moderation
+func (s *ModeratorTestSuite) holdJob(network model.Network) model.Job {
+	job := model.Job{
+		Metadata: model.Metadata{ID: uuid.NewString()},
+		Spec:     model.Spec{Network: model.NetworkConfig{Type: network}},
+	}
+	s.Require().NoError(s.store.CreateJob(s.ctx, job))
+	s.Require().NoError(s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateQueued,
+	}))
+	s.Require().NoError(s.moderator.Hold(s.ctx, job.ID(), "needs approval"))
+	return job
+}
+
+func (s *ModeratorTestSuite) moderate(clientID string, jobID string, approve bool) (Decision, error) {
+	_, decision, err := s.moderator.Moderate(s.ctx, bidstrategy.ModerateJobRequest{
+		ClientID: clientID,
+		JobID:    jobID,
+		Response: bidstrategy.BidStrategyResponse{ShouldBid: approve, Reason: "because"},
+	})
+	return decision, err
+}
+
+func (s *ModeratorTestSuite) TestApprovesOnQuorum() {
+	job := s.holdJob(model.NetworkFull)
+
+	decision, err := s.moderate("alice", job.ID(), true)
+	s.Require().NoError(err)
+	s.Equal(StatusPending, decision.Status)
+
+	_, err = s.moderate("alice", job.ID(), true)
+	s.ErrorAs(err, &ErrAlreadyModerated{})
+	_, err = s.moderate("dave", job.ID(), true)
+	s.ErrorAs(err, &ErrNotEligible{})
+	_, err = s.moderate("eve", job.ID(), true)
+	s.ErrorAs(err, &ErrUnknownApprover{})
+
+	decision, err = s.moderate("bob", job.ID(), true)
+	s.Require().NoError(err)
+	s.Equal(StatusApproved, decision.Status)
+
+	// the job was decided, even though it is still queued
+	_, err = s.moderate("carol", job.ID(), false)
+	s.ErrorAs(err, &ErrJobNotPending{})
+
+	info, err := s.moderator.Get(s.ctx, job.ID())
+	s.Require().NoError(err)
+	s.Equal(StatusApproved, info.Decision.Status)
+	s.Require().Len(info.Moderations, 3)
+	s.Equal(model.JobModerationRequested, info.Moderations[0].Action)
+	s.Equal("alice", info.Moderations[1].ClientID)
+	s.Equal([]string{"security"}, info.Moderations[1].Roles)
+	s.Equal("because", info.Moderations[1].Reason)
+}
+
+func (s *ModeratorTestSuite) TestRejects() {
+	job := s.holdJob(model.NetworkNone)
+	decision, err := s.moderate("dave", job.ID(), false)
+	s.Require().NoError(err)
+	s.Equal(StatusRejected, decision.Status)
+}
+
+func (s *ModeratorTestSuite) TestOnlyModeratesQueuedJobs() {
+	job := s.holdJob(model.NetworkNone)
+	s.Require().NoError(s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateCancelled,
+	}))
+	_, err := s.moderate("dave", job.ID(), true)
+	s.ErrorAs(err, &ErrJobNotPending{})
+}
+
+func (s *ModeratorTestSuite) TestListsPendingJobs() {
+	first := s.holdJob(model.NetworkFull)
+	second := s.holdJob(model.NetworkNone)
+	decided := s.holdJob(model.NetworkNone)
+
+	// queued jobs that were not held for moderation are not pending
+	notHeld := model.Job{Metadata: model.Metadata{ID: uuid.NewString()}}
+	s.Require().NoError(s.store.CreateJob(s.ctx, notHeld))
+	s.Require().NoError(s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    notHeld.ID(),
+		NewState: model.JobStateQueued,
+	}))
+
+	_, err := s.moderate("alice", first.ID(), true)
+	s.Require().NoError(err)
+	_, err = s.moderate("alice", decided.ID(), true)
+	s.Require().NoError(err)
+
+	pending, err := s.moderator.Pending(s.ctx)
+	s.Require().NoError(err)
+	ids := make([]string, len(pending))
+	for i, info := range pending {
+		ids[i] = info.Job.ID()
+	}
+	s.ElementsMatch([]string{first.ID(), second.ID()}, ids)
+}
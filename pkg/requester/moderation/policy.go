@@ -0,0 +1,212 @@
+package moderation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"golang.org/x/exp/slices"
+	"sigs.k8s.io/yaml"
+)
+
+// ApproverEnvVar names the only approver of the default policy
+const ApproverEnvVar = "BACALHAU_JOB_APPROVER"
+
+// Role is granted to approvers so that their votes count towards the rules requiring it
+type Role string
+
+// Approver is a client that can approve or reject the jobs held for moderation
+type Approver struct {
+	ClientID string `json:"ClientID"`
+	Roles    []Role `json:"Roles,omitempty"`
+}
+
+// HasRole returns true if the approver has the role. Every approver has the empty role.
+func (a Approver) HasRole(role Role) bool {
+	return role == "" || slices.Contains(a.Roles, role)
+}
+
+// Rule requires a quorum of approvals for the jobs it applies to
+type Rule struct {
+	Name string `json:"Name,omitempty"`
+	// Networked limits the rule to the jobs that require network access. The rule applies to every job otherwise.
+	Networked bool `json:"Networked,omitempty"`
+	// Role only counts the votes of approvers with the role. The votes of every approver count if empty.
+	Role Role `json:"Role,omitempty"`
+	// Quorum is the number of approvals the job needs
+	Quorum int `json:"Quorum"`
+}
+
+// DefaultRule applies to the jobs that none of the rules of a policy apply to
+var DefaultRule = Rule{Name: "default", Quorum: 1}
+
+// AppliesTo returns true if the job needs the approvals required by the rule
+func (r Rule) AppliesTo(job model.Job) bool {
+	return !r.Networked || !job.Spec.Network.Disabled()
+}
+
+func (r Rule) String() string {
+	description := fmt.Sprintf("%d approval(s)", r.Quorum)
+	if r.Role != "" {
+		description += fmt.Sprintf(" from %s", r.Role)
+	}
+	if r.Name != "" {
+		description += fmt.Sprintf(" (%s)", r.Name)
+	}
+	return description
+}
+
+// Policy decides who can moderate jobs and how many approvals they need. A job is approved once every rule that
+// applies to it has reached its quorum, and rejected as soon as one of the approvers counting towards those rules
+// rejects it.
+type Policy struct {
+	Approvers []Approver `json:"Approvers"`
+	Rules     []Rule     `json:"Rules,omitempty"`
+}
+
+// DefaultPolicy has the client named by ApproverEnvVar as its only approver, whose approval is enough for any job.
+// We deliberately expect the client to be the empty string if unset. This is so that if this env variable is
+// (accidentally) left unset, no jobs can be approved because an empty ClientID is invalid.
+func DefaultPolicy() Policy {
+	return Policy{Approvers: []Approver{{ClientID: os.Getenv(ApproverEnvVar)}}}
+}
+
+// LoadPolicy reads and validates a policy from a YAML or JSON file
+func LoadPolicy(path string) (Policy, error) {
+	var policy Policy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read moderation policy %s: %w", path, err)
+	}
+	if err = yaml.UnmarshalStrict(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse moderation policy %s: %w", path, err)
+	}
+	if err = policy.Validate(); err != nil {
+		return policy, fmt.Errorf("invalid moderation policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// Validate checks that approvers are unique and that every rule can be met by the approvers
+func (p Policy) Validate() error {
+	if len(p.Approvers) == 0 {
+		return fmt.Errorf("no approvers")
+	}
+	seen := make(map[string]struct{}, len(p.Approvers))
+	for _, approver := range p.Approvers {
+		if strings.TrimSpace(approver.ClientID) == "" {
+			return fmt.Errorf("approver with an empty client ID")
+		}
+		if _, ok := seen[approver.ClientID]; ok {
+			return fmt.Errorf("duplicate approver %s", approver.ClientID)
+		}
+		seen[approver.ClientID] = struct{}{}
+	}
+	for _, rule := range p.Rules {
+		if rule.Quorum < 1 {
+			return fmt.Errorf("rule %s must require at least one approval", rule)
+		}
+		if eligible := p.eligibleApprovers(rule); eligible < rule.Quorum {
+			return fmt.Errorf("rule %s can never be met, as only %d approver(s) count towards it", rule, eligible)
+		}
+	}
+	return nil
+}
+
+// Approver returns the approver with the given client ID, if any
+func (p Policy) Approver(clientID string) (Approver, bool) {
+	for _, approver := range p.Approvers {
+		if approver.ClientID == clientID {
+			return approver, true
+		}
+	}
+	return Approver{}, false
+}
+
+// RulesFor returns the rules that apply to the job, or the default rule if none do
+func (p Policy) RulesFor(job model.Job) []Rule {
+	var rules []Rule
+	for _, rule := range p.Rules {
+		if rule.AppliesTo(job) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		rules = []Rule{DefaultRule}
+	}
+	return rules
+}
+
+// CanModerate returns true if the votes of the approver count towards any of the rules that apply to the job
+func (p Policy) CanModerate(approver Approver, job model.Job) bool {
+	for _, rule := range p.RulesFor(job) {
+		if approver.HasRole(rule.Role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decide tallies the votes in the moderation audit trail of the job against the rules that apply to it. Votes cast by
+// clients that are no longer approvers, or that don't count towards any of the rules, are ignored.
+func (p Policy) Decide(job model.Job, moderations []model.JobModeration) Decision {
+	var approvers []Approver
+	for _, moderation := range moderations {
+		if !isVote(moderation) {
+			continue
+		}
+		approver, ok := p.Approver(moderation.ClientID)
+		if !ok || !p.CanModerate(approver, job) {
+			continue
+		}
+		switch moderation.Action {
+		case model.JobModerationRejected:
+			reason := fmt.Sprintf("rejected by %s", moderation.ClientID)
+			if moderation.Reason != "" {
+				reason += ": " + moderation.Reason
+			}
+			return Decision{Status: StatusRejected, Reason: reason}
+		case model.JobModerationApproved:
+			approvers = append(approvers, approver)
+		}
+	}
+
+	var outstanding []string
+	for _, rule := range p.RulesFor(job) {
+		approvals := 0
+		for _, approver := range approvers {
+			if approver.HasRole(rule.Role) {
+				approvals++
+			}
+		}
+		if approvals < rule.Quorum {
+			outstanding = append(outstanding, fmt.Sprintf("%d of %s", approvals, rule))
+		}
+	}
+	if len(outstanding) > 0 {
+		return Decision{Status: StatusPending, Reason: "waiting for " + strings.Join(outstanding, ", ")}
+	}
+
+	clientIDs := make([]string, len(approvers))
+	for i, approver := range approvers {
+		clientIDs[i] = approver.ClientID
+	}
+	return Decision{Status: StatusApproved, Reason: "approved by " + strings.Join(clientIDs, ", ")}
+}
+
+// eligibleApprovers counts the approvers whose votes count towards the rule
+func (p Policy) eligibleApprovers(rule Rule) int {
+	count := 0
+	for _, approver := range p.Approvers {
+		if approver.HasRole(rule.Role) {
+			count++
+		}
+	}
+	return count
+}
+
+// isVote returns true if the moderation was cast by an approver, rather than recorded by the requester
+func isVote(moderation model.JobModeration) bool {
+	return moderation.Action == model.JobModerationApproved || moderation.Action == model.JobModerationRejected
+}
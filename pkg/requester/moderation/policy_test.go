@@ -0,0 +1,141 @@
+//go:build unit || !integration
+
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/stretchr/testify/suite"
+)
+
+type PolicyTestSuite struct {
+	suite.Suite
+	policy Policy
+}
+
+func TestPolicyTestSuite(t *testing.T) {
+	suite.Run(t, new(PolicyTestSuite))
+}
+
+func (s *PolicyTestSuite) SetupTest() {
+	s.policy = Policy{
+		Approvers: []Approver{
+			{ClientID: "alice", Roles: []Role{"security"}},
+			{ClientID: "bob", Roles: []Role{"security"}},
+			{ClientID: "carol", Roles: []Role{"security", "ops"}},
+			{ClientID: "dave", Roles: []Role{"ops"}},
+		},
+		Rules: []Rule{
+			{Name: "networked", Networked: true, Role: "security", Quorum: 2},
+		},
+	}
+}
+
+func vote(clientID string, action model.JobModerationAction) model.JobModeration {
+	return model.JobModeration{ClientID: clientID, Action: action}
+}
+
+func networkedJob() model.Job {
+	return model.Job{Spec: model.Spec{Network: model.NetworkConfig{Type: model.NetworkFull}}}
+}
+
+func (s *PolicyTestSuite) TestDefaultRuleForJobsNoRuleAppliesTo() {
+	job := model.Job{}
+	s.Equal([]Rule{DefaultRule}, s.policy.RulesFor(job))
+	s.True(s.policy.CanModerate(Approver{ClientID: "dave", Roles: []Role{"ops"}}, job))
+
+	s.Equal(StatusPending, s.policy.Decide(job, nil).Status)
+	s.Equal(StatusApproved, s.policy.Decide(job, []model.JobModeration{vote("dave", model.JobModerationApproved)}).Status)
+}
+
+func (s *PolicyTestSuite) TestQuorumForNetworkedJobs() {
+	job := networkedJob()
+	s.False(s.policy.CanModerate(Approver{ClientID: "dave", Roles: []Role{"ops"}}, job))
+
+	moderations := []model.JobModeration{
+		{Action: model.JobModerationRequested},
+		vote("alice", model.JobModerationApproved),
+		// dave doesn't count towards the rule, and eve is not an approver
+		vote("dave", model.JobModerationApproved),
+		vote("eve", model.JobModerationApproved),
+	}
+	decision := s.policy.Decide(job, moderations)
+	s.Equal(StatusPending, decision.Status)
+	s.Contains(decision.Reason, "1 of 2 approval(s) from security")
+
+	decision = s.policy.Decide(job, append(moderations, vote("carol", model.JobModerationApproved)))
+	s.Equal(StatusApproved, decision.Status)
+	s.Equal("approved by alice, carol", decision.Reason)
+}
+
+func (s *PolicyTestSuite) TestRejectedByAnyEligibleApprover() {
+	job := networkedJob()
+	decision := s.policy.Decide(job, []model.JobModeration{
+		vote("alice", model.JobModerationApproved),
+		vote("dave", model.JobModerationRejected),
+	})
+	s.Equal(StatusPending, decision.Status)
+
+	decision = s.policy.Decide(job, []model.JobModeration{
+		vote("alice", model.JobModerationApproved),
+		{ClientID: "bob", Action: model.JobModerationRejected, Reason: "mining crypto"},
+	})
+	s.Equal(StatusRejected, decision.Status)
+	s.Equal("rejected by bob: mining crypto", decision.Reason)
+}
+
+func (s *PolicyTestSuite) TestValidate() {
+	s.NoError(s.policy.Validate())
+
+	for name, policy := range map[string]Policy{
+		"no approvers":       {},
+		"empty client ID":    {Approvers: []Approver{{ClientID: " "}}},
+		"duplicate approver": {Approvers: []Approver{{ClientID: "alice"}, {ClientID: "alice"}}},
+		"zero quorum":        {Approvers: []Approver{{ClientID: "alice"}}, Rules: []Rule{{Quorum: 0}}},
+		"unreachable quorum": {Approvers: []Approver{{ClientID: "alice"}, {ClientID: "bob"}}, Rules: []Rule{{Quorum: 3}}},
+		"role nobody has":    {Approvers: []Approver{{ClientID: "alice"}}, Rules: []Rule{{Role: "ops", Quorum: 1}}},
+	} {
+		s.Error(policy.Validate(), name)
+	}
+}
+
+func (s *PolicyTestSuite) TestLoadPolicy() {
+	path := filepath.Join(s.T().TempDir(), "policy.yaml")
+	s.Require().NoError(os.WriteFile(path, []byte(`
+Approvers:
+  - ClientID: alice
+    Roles: [security]
+  - ClientID: bob
+    Roles: [security]
+Rules:
+  - Name: networked
+    Networked: true
+    Role: security
+    Quorum: 2
+`), 0600))
+
+	policy, err := LoadPolicy(path)
+	s.Require().NoError(err)
+	s.Len(policy.Approvers, 2)
+	s.Equal([]Rule{{Name: "networked", Networked: true, Role: "security", Quorum: 2}}, policy.Rules)
+
+	s.Require().NoError(os.WriteFile(path, []byte(`{"Approvers": [{"ClientID": "alice"}], "Rules": [{"Quorum": 2}]}`), 0600))
+	_, err = LoadPolicy(path)
+	s.Error(err)
+
+	s.Require().NoError(os.WriteFile(path, []byte(`{"Approvers": [{"ClientID": "alice"}], "Quorum": 1}`), 0600))
+	_, err = LoadPolicy(path)
+	s.Error(err, "unknown fields are rejected")
+}
+
+func (s *PolicyTestSuite) TestDefaultPolicy() {
+	s.T().Setenv(ApproverEnvVar, "alice")
+	policy := DefaultPolicy()
+	_, ok := policy.Approver("alice")
+	s.True(ok)
+	_, ok = policy.Approver("bob")
+	s.False(ok)
+}
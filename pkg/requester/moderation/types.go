@@ -0,0 +1,33 @@
+package moderation
+
+import (
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+// Status is the outcome of the moderation of a job
+type Status string
+
+const (
+	// StatusPending is the status of jobs that haven't reached their quorum of approvals yet
+	StatusPending Status = "pending"
+	// StatusApproved is the status of jobs that reached their quorum of approvals
+	StatusApproved Status = "approved"
+	// StatusRejected is the status of jobs that were rejected by an approver
+	StatusRejected Status = "rejected"
+)
+
+// Decision is the outcome of tallying the votes on a job, with a human readable reason
+type Decision struct {
+	Status Status `json:"Status"`
+	Reason string `json:"Reason"`
+}
+
+// JobModerationInfo is a job held for moderation, with its moderation audit trail and the current decision
+type JobModerationInfo struct {
+	Job         model.Job             `json:"Job"`
+	Decision    Decision              `json:"Decision"`
+	Moderations []model.JobModeration `json:"Moderations"`
+	// QueuePosition is the position of the job in the requester's queue once it is approved and waits to start,
+	// or 0 otherwise. Only set by the requester API.
+	QueuePosition int `json:"QueuePosition,omitempty"`
+}
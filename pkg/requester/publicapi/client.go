@@ -2,6 +2,7 @@ package publicapi
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/url"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/job"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog/log"
@@ -247,14 +249,20 @@ func (apiClient *RequesterAPIClient) Submit(
 	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Submit")
 	defer span.End()
 
+	clientPublicKey, err := base64.StdEncoding.DecodeString(system.GetClientPublicKey())
+	if err != nil {
+		return &model.Job{}, err
+	}
+
 	data := model.JobCreatePayload{
-		ClientID:   system.GetClientID(),
-		APIVersion: j.APIVersion,
-		Spec:       &j.Spec,
+		ClientID:        system.GetClientID(),
+		ClientPublicKey: clientPublicKey,
+		APIVersion:      j.APIVersion,
+		Spec:            &j.Spec,
 	}
 
 	var res submitResponse
-	err := apiClient.PostSigned(ctx, APIPrefix+"submit", data, &res)
+	err = apiClient.PostSigned(ctx, APIPrefix+"submit", data, &res)
 	if err != nil {
 		return &model.Job{}, err
 	}
@@ -279,6 +287,39 @@ func (apiClient *RequesterAPIClient) Approve(
 	return apiClient.PostSigned(ctx, APIPrefix+ApprovalRoute, data, nil)
 }
 
+// GetModeration returns the moderation audit trail of a job, and whether it was approved or rejected
+func (apiClient *RequesterAPIClient) GetModeration(ctx context.Context, jobID string) (moderation.JobModerationInfo, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.GetModeration")
+	defer span.End()
+
+	if jobID == "" {
+		return moderation.JobModerationInfo{}, fmt.Errorf("jobID must be non-empty in a GetModeration call")
+	}
+
+	req := moderationRequest{
+		JobID: jobID,
+	}
+
+	var res moderationResponse
+	if err := apiClient.Post(ctx, APIPrefix+ModerationRoute, req, &res); err != nil {
+		return moderation.JobModerationInfo{}, err
+	}
+	return res.Moderation, nil
+}
+
+// PendingModeration returns the jobs waiting for approval, oldest first
+func (apiClient *RequesterAPIClient) PendingModeration(ctx context.Context) ([]moderation.JobModerationInfo, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.PendingModeration")
+	defer span.End()
+
+	req := struct{}{}
+	var res pendingModerationResponse
+	if err := apiClient.Post(ctx, APIPrefix+ModerationRoute+"/pending", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Jobs, nil
+}
+
 // Logs will retrieve the address of an endpoint where a client connection can be
 // made to stream the results of an execution back to a TTY
 func (apiClient *RequesterAPIClient) Logs(
@@ -357,3 +398,17 @@ func (apiClient *RequesterAPIClient) Debug(ctx context.Context) (map[string]mode
 
 	return res, nil
 }
+
+// Nodes returns the compute nodes known to the requester node.
+func (apiClient *RequesterAPIClient) Nodes(ctx context.Context) ([]model.NodeInfo, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/requester/publicapi.RequesterAPIClient.Nodes")
+	defer span.End()
+
+	req := struct{}{}
+	var res nodesResponse
+	if err := apiClient.Post(ctx, APIPrefix+"nodes", req, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Nodes, nil
+}
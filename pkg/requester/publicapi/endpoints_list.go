@@ -74,8 +74,8 @@ func (s *RequesterAPIServer) list(res http.ResponseWriter, req *http.Request) {
 			return
 		}
 		jobWithInfos[i] = &model.JobWithInfo{
-			Job:   job,
-			State: jobState,
+			Job:   job.Redacted(),
+			State: s.withQueuePosition(jobState),
 		}
 	}
 	res.WriteHeader(http.StatusOK)
@@ -0,0 +1,93 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/publicapi/handlerwrapper"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+)
+
+type moderationRequest struct {
+	JobID string `json:"job_id" example:"9304c616-291f-41ad-b862-54e133c0149e"`
+}
+
+type moderationResponse struct {
+	Moderation moderation.JobModerationInfo `json:"moderation"`
+}
+
+type pendingModerationResponse struct {
+	Jobs []moderation.JobModerationInfo `json:"jobs"`
+}
+
+// moderation godoc
+//
+//	@ID			pkg/requester/publicapi/moderation
+//	@Summary	Returns the moderation audit trail of the job-id specified in the body payload.
+//	@Tags		Job
+//	@Accept		json
+//	@Produce	json
+//	@Param		moderationRequest	body		moderationRequest	true	" "
+//	@Success	200					{object}	moderationResponse
+//	@Failure	400					{object}	string
+//	@Failure	500					{object}	string
+//	@Router		/requester/moderation [post]
+func (s *RequesterAPIServer) moderation(res http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	var moderationReq moderationRequest
+	if err := json.NewDecoder(req.Body).Decode(&moderationReq); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res.Header().Set(handlerwrapper.HTTPHeaderJobID, moderationReq.JobID)
+	ctx = system.AddJobIDToBaggage(ctx, moderationReq.JobID)
+
+	info, err := s.moderator.Get(ctx, moderationReq.JobID)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(moderationResponse{Moderation: s.forAPI(info)})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// pendingModeration godoc
+//
+//	@ID			pkg/requester/publicapi/moderation/pending
+//	@Summary	Returns the jobs waiting for approval, oldest first.
+//	@Tags		Job
+//	@Produce	json
+//	@Success	200	{object}	pendingModerationResponse
+//	@Failure	500	{object}	string
+//	@Router		/requester/moderation/pending [post]
+func (s *RequesterAPIServer) pendingModeration(res http.ResponseWriter, req *http.Request) {
+	pending, err := s.moderator.Pending(req.Context())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range pending {
+		pending[i] = s.forAPI(pending[i])
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(pendingModerationResponse{Jobs: pending})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// forAPI redacts the job of the moderation info and sets its position in the queue, as for the other job endpoints
+func (s *RequesterAPIServer) forAPI(info moderation.JobModerationInfo) moderation.JobModerationInfo {
+	info.Job = info.Job.Redacted()
+	if s.queue != nil {
+		info.QueuePosition = s.queue.QueuePosition(info.Job.ID())
+	}
+	return info
+}
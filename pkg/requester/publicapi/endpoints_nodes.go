@@ -0,0 +1,37 @@
+package publicapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+)
+
+type nodesResponse struct {
+	Nodes []model.NodeInfo `json:"nodes"`
+}
+
+type NodesResponse = nodesResponse
+
+// nodes godoc
+//
+//	@ID			pkg/requester/publicapi/nodes
+//	@Summary	Returns the compute nodes known to the requester.
+//	@Tags		Utils
+//	@Produce	json
+//	@Success	200	{object}	nodesResponse
+//	@Failure	500	{object}	string
+//	@Router		/requester/nodes [post]
+func (s *RequesterAPIServer) nodes(res http.ResponseWriter, req *http.Request) {
+	nodes, err := s.nodeDiscoverer.ListNodes(req.Context())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(res).Encode(nodesResponse{Nodes: nodes})
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -60,5 +60,17 @@ func (s *RequesterAPIServer) states(res http.ResponseWriter, req *http.Request)
 }
 
 func getJobStateFromRequest(ctx context.Context, apiServer *RequesterAPIServer, stateReq stateRequest) (model.JobState, error) {
-	return apiServer.jobStore.GetJobState(ctx, stateReq.JobID)
+	jobState, err := apiServer.jobStore.GetJobState(ctx, stateReq.JobID)
+	if err != nil {
+		return jobState, err
+	}
+	return apiServer.withQueuePosition(jobState), nil
+}
+
+// withQueuePosition sets the position of the job in the requester's queue, if it is waiting to start
+func (s *RequesterAPIServer) withQueuePosition(jobState model.JobState) model.JobState {
+	if s.queue != nil && jobState.State == model.JobStateQueued {
+		jobState.QueuePosition = s.queue.QueuePosition(jobState.JobID)
+	}
+	return jobState
 }
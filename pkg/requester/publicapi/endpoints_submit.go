@@ -61,8 +61,9 @@ func (s *RequesterAPIServer) submit(res http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	redacted := j.Redacted()
 	res.WriteHeader(http.StatusOK)
-	err = json.NewEncoder(res).Encode(submitResponse{Job: j})
+	err = json.NewEncoder(res).Encode(submitResponse{Job: &redacted})
 	if err != nil {
 		publicapi.HTTPError(ctx, res, err, http.StatusInternalServerError)
 		return
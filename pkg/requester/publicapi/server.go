@@ -7,6 +7,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/publicapi"
 	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	sync "github.com/bacalhau-project/golang-mutex-tracer"
 	"github.com/gorilla/websocket"
@@ -14,6 +15,7 @@ import (
 
 const APIPrefix = "requester/"
 const ApprovalRoute = "approve"
+const ModerationRoute = "moderation"
 
 type RequesterAPIServerParams struct {
 	APIServer          *publicapi.APIServer
@@ -21,6 +23,9 @@ type RequesterAPIServerParams struct {
 	DebugInfoProviders []model.DebugInfoProvider
 	JobStore           jobstore.Store
 	StorageProviders   storage.StorageProvider
+	NodeDiscoverer     requester.NodeDiscoverer
+	Queue              requester.QueueInfoProvider
+	Moderator          *moderation.Moderator
 }
 
 type RequesterAPIServer struct {
@@ -29,6 +34,9 @@ type RequesterAPIServer struct {
 	debugInfoProviders []model.DebugInfoProvider
 	jobStore           jobstore.Store
 	storageProviders   storage.StorageProvider
+	nodeDiscoverer     requester.NodeDiscoverer
+	queue              requester.QueueInfoProvider
+	moderator          *moderation.Moderator
 	// jobId or "" (for all events) -> connections for that subscription
 	websockets      map[string][]*websocket.Conn
 	websocketsMutex sync.RWMutex
@@ -41,6 +49,9 @@ func NewRequesterAPIServer(params RequesterAPIServerParams) *RequesterAPIServer
 		debugInfoProviders: params.DebugInfoProviders,
 		jobStore:           params.JobStore,
 		storageProviders:   params.StorageProviders,
+		nodeDiscoverer:     params.NodeDiscoverer,
+		queue:              params.Queue,
+		moderator:          params.Moderator,
 		websockets:         make(map[string][]*websocket.Conn),
 	}
 }
@@ -53,10 +64,13 @@ func (s *RequesterAPIServer) RegisterAllHandlers() error {
 		{URI: "/" + APIPrefix + "events", Handler: http.HandlerFunc(s.events)},
 		{URI: "/" + APIPrefix + "submit", Handler: http.HandlerFunc(s.submit)},
 		{URI: "/" + APIPrefix + ApprovalRoute, Handler: http.HandlerFunc(s.approve)},
+		{URI: "/" + APIPrefix + ModerationRoute, Handler: http.HandlerFunc(s.moderation)},
+		{URI: "/" + APIPrefix + ModerationRoute + "/pending", Handler: http.HandlerFunc(s.pendingModeration)},
 		{URI: "/" + APIPrefix + "cancel", Handler: http.HandlerFunc(s.cancel)},
 		{URI: "/" + APIPrefix + "websocket/events", Handler: http.HandlerFunc(s.websocketJobEvents), Raw: true},
 		{URI: "/" + APIPrefix + "logs", Handler: http.HandlerFunc(s.logs), Raw: true},
 		{URI: "/" + APIPrefix + "debug", Handler: http.HandlerFunc(s.debug)},
+		{URI: "/" + APIPrefix + "nodes", Handler: http.HandlerFunc(s.nodes)},
 	}
 	return s.apiServer.RegisterHandlers(handlerConfigs...)
 }
@@ -2,24 +2,80 @@ package requester
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
+type QueueParams struct {
+	JobStore     jobstore.Store
+	Scheduler    Scheduler
+	EventEmitter EventEmitter
+	NodeSelector *NodeSelector
+	// SchedulingPolicy orders the jobs waiting in the queue and admits them to start. If nil, jobs are started in
+	// the order they were queued as soon as there are enough nodes to run them.
+	SchedulingPolicy SchedulingPolicy
+	// MaxQueueTime is how long a job can wait in the queue to be admitted and for enough nodes to run it before it
+	// fails. If zero, jobs are not queued and fail straight away when they can't start.
+	MaxQueueTime time.Duration
+}
+
+// queuedJob is a job waiting in the queue to be admitted or for enough nodes to run it
+type queuedJob struct {
+	job        model.Job
+	enqueuedAt time.Time
+	reason     error
+	expiry     *time.Timer
+}
+
+// QueuedJobInfo describes a job waiting in the queue, as reported by the /debug endpoint
+type QueuedJobInfo struct {
+	JobID      string    `json:"JobID"`
+	Position   int       `json:"Position"`
+	EnqueuedAt time.Time `json:"EnqueuedAt"`
+	Reason     string    `json:"Reason"`
+}
+
+// queue holds jobs in the Queued state until they are approved, admitted by the scheduling policy, and until there are
+// enough nodes to run them. Waiting jobs are re-evaluated in the order of the scheduling policy whenever the info of a
+// compute node is added or updated, which compute nodes also do periodically, and fail once they have waited for
+// longer than the max queue time.
 type queue struct {
-	scheduler Scheduler
-	emitter   EventEmitter
-	store     jobstore.Store
+	scheduler    Scheduler
+	emitter      EventEmitter
+	store        jobstore.Store
+	nodeSelector *NodeSelector
+	policy       SchedulingPolicy
+	maxQueueTime time.Duration
+
+	pending []*queuedJob
+	// reevaluating is set while pending jobs are being re-evaluated, and reevaluateAgain if nodes were updated since
+	reevaluating    bool
+	reevaluateAgain bool
+	mu              sync.Mutex
 }
 
-func NewQueue(store jobstore.Store, scheduler Scheduler, emitter EventEmitter) Queue {
-	return &queue{
-		scheduler: scheduler,
-		emitter:   emitter,
-		store:     store,
+func NewQueue(params QueueParams) Queue {
+	q := &queue{
+		scheduler:    params.Scheduler,
+		emitter:      params.EventEmitter,
+		store:        params.JobStore,
+		nodeSelector: params.NodeSelector,
+		policy:       params.SchedulingPolicy,
+		maxQueueTime: params.MaxQueueTime,
 	}
+	q.mu.EnableTracerWithOpts(sync.Opts{
+		Threshold: 10 * time.Millisecond,
+		Id:        "Queue.mu",
+	})
+	return q
 }
 
 func (q *queue) EnqueueJob(ctx context.Context, job model.Job) error {
@@ -33,7 +89,25 @@ func (q *queue) EnqueueJob(ctx context.Context, job model.Job) error {
 	})
 }
 
+// StartJob starts a queued job if it is admitted by the scheduling policy and there are enough nodes to run it,
+// or keeps it in the queue until then.
 func (q *queue) StartJob(ctx context.Context, req StartJobRequest) error {
+	reason, err := q.checkStart(ctx, req.Job)
+	if err != nil {
+		return err
+	}
+	if reason != nil && q.maxQueueTime > 0 {
+		return q.wait(ctx, req, reason)
+	}
+	var quotaExceeded ErrClientQuotaExceeded
+	if errors.As(reason, &quotaExceeded) {
+		q.fail(ctx, req.Job, reason.Error())
+		return reason
+	}
+	return q.startJob(ctx, req)
+}
+
+func (q *queue) startJob(ctx context.Context, req StartJobRequest) error {
 	err := q.store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
 		JobID: req.Job.Metadata.ID,
 		Condition: jobstore.UpdateJobCondition{
@@ -61,6 +135,272 @@ func (q *queue) CancelJob(ctx context.Context, req CancelJobRequest) (CancelJobR
 	if err != nil && errors.As(err, &invalidJobErr) {
 		return q.scheduler.CancelJob(ctx, req)
 	}
+	if err == nil {
+		q.dequeue(req.JobID)
+	}
 	defer q.emitter.EmitJobCanceled(ctx, req)
 	return CancelJobResult{}, err
 }
+
+// NodeInfoUpdated re-evaluates the jobs waiting in the queue when the info of a compute node is added or updated.
+// The jobs are re-evaluated in the background, and updates received in the meantime trigger a single new evaluation.
+func (q *queue) NodeInfoUpdated(ctx context.Context, nodeInfo model.NodeInfo) error {
+	if !nodeInfo.IsComputeNode() {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return nil
+	}
+	if q.reevaluating {
+		q.reevaluateAgain = true
+		return nil
+	}
+	q.reevaluating = true
+	go q.reevaluate(util.NewDetachedContext(ctx))
+	return nil
+}
+
+// QueuePosition returns the position of the job in the order of the scheduling policy, starting at 1,
+// or 0 if the job is not waiting in the queue.
+func (q *queue) QueuePosition(jobID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.indexOf(jobID) + 1
+}
+
+// GetDebugInfo implements model.DebugInfoProvider
+func (q *queue) GetDebugInfo(context.Context) (model.DebugInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]QueuedJobInfo, len(q.pending))
+	for i, queued := range q.pending {
+		jobs[i] = QueuedJobInfo{
+			JobID:      queued.job.ID(),
+			Position:   i + 1,
+			EnqueuedAt: queued.enqueuedAt,
+			Reason:     queued.reason.Error(),
+		}
+	}
+	return model.DebugInfo{
+		Component: "JobQueue",
+		Info:      jobs,
+	}, nil
+}
+
+// checkStart returns why the job can't start yet, or a nil reason if it can. Placement is only checked when jobs can
+// wait in the queue, as the scheduler fails jobs without enough nodes otherwise.
+func (q *queue) checkStart(ctx context.Context, job model.Job) (reason error, err error) {
+	if q.policy != nil {
+		err = q.policy.Admit(ctx, job)
+		var quotaExceeded ErrClientQuotaExceeded
+		if errors.As(err, &quotaExceeded) {
+			return err, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if q.maxQueueTime > 0 {
+		return q.checkPlacement(ctx, job), nil
+	}
+	return nil, nil
+}
+
+// checkPlacement returns why the job can't be placed yet, or nil if there are enough nodes to run it. Other errors
+// selecting nodes are left to the scheduler, which fails the job.
+func (q *queue) checkPlacement(ctx context.Context, job model.Job) error {
+	minBids := system.Max(job.Spec.Deal.MinBids, job.Spec.Deal.Concurrency)
+	_, err := q.nodeSelector.SelectNodes(ctx, job, minBids, minBids)
+	var notEnoughNodes ErrNotEnoughNodes
+	if errors.As(err, &notEnoughNodes) {
+		return err
+	}
+	return nil
+}
+
+// wait keeps the job in the queue until it can start, or it expires
+func (q *queue) wait(ctx context.Context, req StartJobRequest, reason error) error {
+	job := req.Job
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.indexOf(job.ID()) >= 0 {
+		return nil
+	}
+
+	// jobs put back in the queue after a restart keep the state they were queued with, as updating it would reset the
+	// time they were queued at for the next restart
+	enqueuedAt := req.EnqueuedAt
+	if enqueuedAt.IsZero() {
+		enqueuedAt = time.Now()
+		err := q.store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+			JobID: job.ID(),
+			Condition: jobstore.UpdateJobCondition{
+				ExpectedState: model.JobStateQueued,
+			},
+			NewState: model.JobStateQueued,
+			Comment:  fmt.Sprintf("waiting in the queue: %s", reason),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Ctx(ctx).Info().Err(reason).Msgf("job %s queued until it can start", job.ID())
+	expiryCtx := util.NewDetachedContext(ctx)
+	q.pending = append(q.pending, &queuedJob{
+		job:        job,
+		enqueuedAt: enqueuedAt,
+		reason:     reason,
+		expiry:     time.AfterFunc(q.maxQueueTime-time.Since(enqueuedAt), func() { q.expire(expiryCtx, job) }),
+	})
+	q.order(ctx)
+	return nil
+}
+
+// reevaluate starts the jobs waiting in the queue that can now start, in the order of the scheduling policy. The queue
+// is re-ordered after each job is started, as starting a job changes the share of its client.
+func (q *queue) reevaluate(ctx context.Context) {
+	for {
+		if q.startNext(ctx) {
+			continue
+		}
+
+		q.mu.Lock()
+		if !q.reevaluateAgain {
+			q.reevaluating = false
+			q.mu.Unlock()
+			return
+		}
+		q.reevaluateAgain = false
+		q.mu.Unlock()
+	}
+}
+
+// startNext starts the first job in the queue that can start, and returns whether one was started
+func (q *queue) startNext(ctx context.Context) bool {
+	q.mu.Lock()
+	q.order(ctx)
+	q.mu.Unlock()
+
+	for _, queued := range q.snapshot() {
+		reason, err := q.checkStart(ctx, queued.job)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("failed to check if queued job %s can start", queued.job.ID())
+			continue
+		}
+		if reason != nil {
+			q.mu.Lock()
+			queued.reason = reason
+			q.mu.Unlock()
+			continue
+		}
+		// the job may have been cancelled or expired since the snapshot
+		if !q.dequeue(queued.job.ID()) {
+			continue
+		}
+		log.Ctx(ctx).Info().Msgf("starting job %s after %s in the queue", queued.job.ID(), time.Since(queued.enqueuedAt))
+		if err := q.startJob(ctx, StartJobRequest{Job: queued.job}); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("failed to start queued job %s", queued.job.ID())
+		}
+		return true
+	}
+	return false
+}
+
+// expire fails a job that waited in the queue for longer than the max queue time
+func (q *queue) expire(ctx context.Context, job model.Job) {
+	q.mu.Lock()
+	index := q.indexOf(job.ID())
+	if index < 0 {
+		q.mu.Unlock()
+		return
+	}
+	reason := fmt.Sprintf("job waited in the queue for longer than %s: %s", q.maxQueueTime, q.pending[index].reason)
+	q.remove(index)
+	q.mu.Unlock()
+
+	log.Ctx(ctx).Info().Msgf("job %s expired", job.ID())
+	q.fail(ctx, job, reason)
+}
+
+// fail moves a queued job to the error state
+func (q *queue) fail(ctx context.Context, job model.Job, reason string) {
+	err := q.store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID: job.ID(),
+		Condition: jobstore.UpdateJobCondition{
+			ExpectedState: model.JobStateQueued,
+		},
+		NewState: model.JobStateError,
+		Comment:  reason,
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to fail queued job %s", job.ID())
+		return
+	}
+	q.emitter.EmitEventSilently(ctx, model.JobEvent{
+		SourceNodeID: job.Metadata.Requester.RequesterNodeID,
+		JobID:        job.ID(),
+		Status:       reason,
+		EventName:    model.JobEventError,
+		EventTime:    time.Now(),
+	})
+}
+
+// order sorts the waiting jobs in the order of the scheduling policy. It must be called with the lock held.
+func (q *queue) order(ctx context.Context) {
+	if q.policy == nil || len(q.pending) < 2 {
+		return
+	}
+	jobs := make([]model.Job, len(q.pending))
+	byID := make(map[string]*queuedJob, len(q.pending))
+	for i, queued := range q.pending {
+		jobs[i] = queued.job
+		byID[queued.job.ID()] = queued
+	}
+	ordered, err := q.policy.Order(ctx, jobs)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to order the queue, keeping the previous order")
+		return
+	}
+	for i, job := range ordered {
+		q.pending[i] = byID[job.ID()]
+	}
+}
+
+func (q *queue) snapshot() []*queuedJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]*queuedJob(nil), q.pending...)
+}
+
+// dequeue removes the job from the jobs waiting in the queue, and returns whether it was waiting
+func (q *queue) dequeue(jobID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	index := q.indexOf(jobID)
+	if index < 0 {
+		return false
+	}
+	q.remove(index)
+	return true
+}
+
+func (q *queue) remove(index int) {
+	q.pending[index].expiry.Stop()
+	q.pending = append(q.pending[:index], q.pending[index+1:]...)
+}
+
+func (q *queue) indexOf(jobID string) int {
+	for i, queued := range q.pending {
+		if queued.job.ID() == jobID {
+			return i
+		}
+	}
+	return -1
+}
+
+// compile-time check that queue implements the expected interface
+var _ Queue = (*queue)(nil)
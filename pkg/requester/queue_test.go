@@ -0,0 +1,281 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/eventhandler"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
+	sync "github.com/bacalhau-project/golang-mutex-tracer"
+	"github.com/google/uuid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+// fixedNodeDiscoverer discovers the compute nodes it was given, and ranks all of them as suitable
+type fixedNodeDiscoverer struct {
+	nodes []model.NodeInfo
+	mu    sync.Mutex
+}
+
+func (d *fixedNodeDiscoverer) ListNodes(ctx context.Context) ([]model.NodeInfo, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]model.NodeInfo(nil), d.nodes...), nil
+}
+
+func (d *fixedNodeDiscoverer) FindNodes(ctx context.Context, job model.Job) ([]model.NodeInfo, error) {
+	return d.ListNodes(ctx)
+}
+
+func (d *fixedNodeDiscoverer) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]NodeRank, error) {
+	ranks := make([]NodeRank, len(nodes))
+	for i, node := range nodes {
+		ranks[i] = NodeRank{NodeInfo: node, Rank: 1}
+	}
+	return ranks, nil
+}
+
+func (d *fixedNodeDiscoverer) addNode() model.NodeInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	node := model.NodeInfo{
+		PeerInfo:        peer.AddrInfo{ID: peer.ID(uuid.NewString())},
+		NodeType:        model.NodeTypeCompute,
+		ComputeNodeInfo: &model.ComputeNodeInfo{},
+	}
+	d.nodes = append(d.nodes, node)
+	return node
+}
+
+type QueueTestSuite struct {
+	suite.Suite
+	ctx        context.Context
+	store      jobstore.Store
+	discoverer *fixedNodeDiscoverer
+	started    chan string
+	policy     SchedulingPolicy
+	queue      Queue
+}
+
+func TestQueueTestSuite(t *testing.T) {
+	suite.Run(t, new(QueueTestSuite))
+}
+
+func (s *QueueTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.store = inmemory.NewJobStore()
+	s.discoverer = &fixedNodeDiscoverer{}
+	s.started = make(chan string, 10)
+	s.policy = nil
+	s.queue = s.newQueue(time.Minute)
+}
+
+func (s *QueueTestSuite) newQueue(maxQueueTime time.Duration) Queue {
+	// jobs may still be started in the background once the test completes, so the scheduler doesn't read the suite
+	store, started := s.store, s.started
+	return NewQueue(QueueParams{
+		JobStore: store,
+		Scheduler: &mockScheduler{
+			handleStartJob: func(ctx context.Context, req StartJobRequest) error {
+				started <- req.Job.ID()
+				return store.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+					JobID:    req.Job.ID(),
+					NewState: model.JobStateInProgress,
+				})
+			},
+		},
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(ctx context.Context, event model.JobEvent) error {
+				return nil
+			}),
+		}),
+		NodeSelector: NewNodeSelector(NodeSelectorParams{
+			NodeDiscoverer: s.discoverer,
+			NodeRanker:     s.discoverer,
+		}),
+		SchedulingPolicy: s.policy,
+		MaxQueueTime:     maxQueueTime,
+	})
+}
+
+func (s *QueueTestSuite) enqueueJob(concurrency int) model.Job {
+	return s.enqueue(model.Job{
+		Metadata: model.Metadata{ID: uuid.NewString()},
+		Spec:     model.Spec{Deal: model.Deal{Concurrency: concurrency}},
+	})
+}
+
+func (s *QueueTestSuite) enqueue(job model.Job) model.Job {
+	s.Require().NoError(s.store.CreateJob(s.ctx, job))
+	s.Require().NoError(s.queue.EnqueueJob(s.ctx, job))
+	s.Require().NoError(s.queue.StartJob(s.ctx, StartJobRequest{Job: job}))
+	return job
+}
+
+func (s *QueueTestSuite) requireState(jobID string, expected model.JobStateType) {
+	state, err := s.store.GetJobState(s.ctx, jobID)
+	s.Require().NoError(err)
+	s.Require().Equal(expected, state.State)
+}
+
+func (s *QueueTestSuite) requireStarted(jobID string) {
+	select {
+	case started := <-s.started:
+		s.Require().Equal(jobID, started)
+	case <-time.After(5 * time.Second):
+		s.FailNow("job was not started", jobID)
+	}
+}
+
+func (s *QueueTestSuite) TestStartsJobWithEnoughNodes() {
+	s.discoverer.addNode()
+	job := s.enqueueJob(1)
+	s.requireStarted(job.ID())
+	s.Zero(s.queue.QueuePosition(job.ID()))
+}
+
+func (s *QueueTestSuite) TestQueuesJobUntilEnoughNodes() {
+	first := s.enqueueJob(2)
+	second := s.enqueueJob(1)
+	s.requireState(first.ID(), model.JobStateQueued)
+	s.requireState(second.ID(), model.JobStateQueued)
+	s.Equal(1, s.queue.QueuePosition(first.ID()))
+	s.Equal(2, s.queue.QueuePosition(second.ID()))
+
+	// a single node is enough for the second job only
+	s.Require().NoError(s.queue.NodeInfoUpdated(s.ctx, s.discoverer.addNode()))
+	s.requireStarted(second.ID())
+	s.requireState(second.ID(), model.JobStateInProgress)
+	s.Equal(1, s.queue.QueuePosition(first.ID()))
+
+	s.Require().NoError(s.queue.NodeInfoUpdated(s.ctx, s.discoverer.addNode()))
+	s.requireStarted(first.ID())
+	s.Zero(s.queue.QueuePosition(first.ID()))
+}
+
+func (s *QueueTestSuite) TestIgnoresNonComputeNodes() {
+	job := s.enqueueJob(1)
+	s.discoverer.addNode()
+	s.Require().NoError(s.queue.NodeInfoUpdated(s.ctx, model.NodeInfo{}))
+	started := s.started
+	s.Never(func() bool { return len(started) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+	s.requireState(job.ID(), model.JobStateQueued)
+}
+
+func (s *QueueTestSuite) TestCancelsQueuedJob() {
+	job := s.enqueueJob(1)
+	_, err := s.queue.CancelJob(s.ctx, CancelJobRequest{JobID: job.ID(), Reason: "cancelled"})
+	s.Require().NoError(err)
+	s.requireState(job.ID(), model.JobStateCancelled)
+	s.Zero(s.queue.QueuePosition(job.ID()))
+}
+
+func (s *QueueTestSuite) TestExpiresQueuedJob() {
+	s.queue = s.newQueue(50 * time.Millisecond)
+	job := s.enqueueJob(1)
+	store := s.store
+	s.Eventually(func() bool {
+		state, err := store.GetJobState(context.Background(), job.ID())
+		return err == nil && state.State == model.JobStateError
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Zero(s.queue.QueuePosition(job.ID()))
+}
+
+func (s *QueueTestSuite) TestFailsStraightAwayWithoutMaxQueueTime() {
+	s.queue = s.newQueue(0)
+	job := s.enqueueJob(1)
+	// the scheduler is left to fail the job
+	s.requireStarted(job.ID())
+}
+
+func (s *QueueTestSuite) TestOrdersQueuedJobsByPolicy() {
+	s.policy = NewFairSharePolicy(FairSharePolicyParams{JobStore: s.store})
+	s.queue = s.newQueue(time.Minute)
+	low := s.enqueue(newFairShareJob("a", 0, 1, ""))
+	high := s.enqueue(newFairShareJob("a", 10, 1, ""))
+	s.Equal(1, s.queue.QueuePosition(high.ID()))
+	s.Equal(2, s.queue.QueuePosition(low.ID()))
+
+	s.Require().NoError(s.queue.NodeInfoUpdated(s.ctx, s.discoverer.addNode()))
+	s.requireStarted(high.ID())
+	s.requireStarted(low.ID())
+}
+
+func (s *QueueTestSuite) TestHoldsJobsOverClientQuota() {
+	s.policy = NewFairSharePolicy(FairSharePolicyParams{
+		JobStore:    s.store,
+		ClientQuota: ClientQuota{MaxConcurrentExecutions: 1},
+	})
+	s.queue = s.newQueue(time.Minute)
+	s.discoverer.addNode()
+
+	first := s.enqueue(newFairShareJob("a", 0, 1, ""))
+	s.requireStarted(first.ID())
+	second := s.enqueue(newFairShareJob("a", 0, 1, ""))
+	s.requireState(second.ID(), model.JobStateQueued)
+	s.Equal(1, s.queue.QueuePosition(second.ID()))
+	other := s.enqueue(newFairShareJob("b", 0, 1, ""))
+	s.requireStarted(other.ID())
+
+	s.Require().NoError(s.store.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    first.ID(),
+		NewState: model.JobStateCompleted,
+	}))
+	s.Require().NoError(s.queue.NodeInfoUpdated(s.ctx, s.discoverer.addNode()))
+	s.requireStarted(second.ID())
+}
+
+func (s *QueueTestSuite) TestFailsJobOverClientQuotaWithoutMaxQueueTime() {
+	s.policy = NewFairSharePolicy(FairSharePolicyParams{
+		JobStore:    s.store,
+		ClientQuota: ClientQuota{MaxConcurrentExecutions: 1},
+	})
+	s.queue = s.newQueue(0)
+
+	job := newFairShareJob("a", 0, 2, "")
+	s.Require().NoError(s.store.CreateJob(s.ctx, job))
+	s.Require().NoError(s.queue.EnqueueJob(s.ctx, job))
+	s.ErrorAs(s.queue.StartJob(s.ctx, StartJobRequest{Job: job}), &ErrClientQuotaExceeded{})
+	s.requireState(job.ID(), model.JobStateError)
+}
+
+func (s *QueueTestSuite) TestRecoversQueuedJobsAfterRestart() {
+	newJob := func(concurrency int) model.Job {
+		return model.Job{
+			Metadata: model.Metadata{ID: uuid.NewString(), Requester: model.JobRequester{RequesterNodeID: "requester"}},
+			Spec:     model.Spec{Deal: model.Deal{Concurrency: concurrency}},
+		}
+	}
+	// the expired job has no queue time left after the restart
+	expired := s.enqueue(newJob(2))
+	time.Sleep(time.Second)
+	waiting := s.enqueue(newJob(1))
+	held := newJob(1)
+	s.Require().NoError(s.store.CreateJob(s.ctx, held))
+	s.Require().NoError(s.queue.EnqueueJob(s.ctx, held))
+	moderator := moderation.NewModerator(moderation.ModeratorParams{JobStore: s.store})
+	s.Require().NoError(moderator.Hold(s.ctx, held.ID(), "needs approval"))
+
+	// the queue only keeps the waiting jobs in memory
+	s.queue = s.newQueue(time.Second)
+	scheduler := NewBaseScheduler(BaseSchedulerParams{ID: "requester", JobStore: s.store})
+	s.Require().NoError(scheduler.RecoverJobs(s.ctx, RecoverJobsRequest{Queue: s.queue, Moderator: moderator}))
+
+	s.Eventually(func() bool {
+		state, err := s.store.GetJobState(s.ctx, expired.ID())
+		return err == nil && state.State == model.JobStateError
+	}, 5*time.Second, 10*time.Millisecond)
+	s.Equal(1, s.queue.QueuePosition(waiting.ID()))
+	s.Zero(s.queue.QueuePosition(held.ID()))
+	s.requireState(held.ID(), model.JobStateQueued)
+
+	s.Require().NoError(s.queue.NodeInfoUpdated(s.ctx, s.discoverer.addNode()))
+	s.requireStarted(waiting.ID())
+}
@@ -0,0 +1,50 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+// EncryptionNodeRanker filters out nodes that can't decrypt the encrypted inputs or secrets of a job, as the client
+// did not wrap the data key for them.
+type EncryptionNodeRanker struct {
+}
+
+func NewEncryptionNodeRanker() *EncryptionNodeRanker {
+	return &EncryptionNodeRanker{}
+}
+
+// RankNodes ranks nodes based on whether they can decrypt the job's encrypted storage specs and secrets:
+// - Rank 0: The job has nothing encrypted, or the node can decrypt all of it.
+// - Rank -1: The node can't decrypt one of the encrypted storage specs, or the secrets.
+func (s *EncryptionNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		rank := 0
+		if what, ok := undecryptable(job, node.PeerInfo.ID.String()); ok {
+			log.Ctx(ctx).Trace().Msgf("filtering node %s as it can't decrypt %s", node.PeerInfo.ID, what)
+			rank = -1
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
+
+// undecryptable returns what part of the job the node can't decrypt, if any.
+func undecryptable(job model.Job, nodeID string) (string, bool) {
+	if job.Spec.Secrets != nil && !job.Spec.Secrets.Encryption.CanDecrypt(nodeID) {
+		return "the secrets", true
+	}
+	for _, spec := range job.Spec.AllStorageSpecs() {
+		if !spec.Encryption.CanDecrypt(nodeID) {
+			return "storage " + spec.Name, true
+		}
+	}
+	return "", false
+}
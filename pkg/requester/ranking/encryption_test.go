@@ -0,0 +1,81 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type EncryptionNodeRankerSuite struct {
+	suite.Suite
+	EncryptionNodeRanker *EncryptionNodeRanker
+	nodes                []model.NodeInfo
+}
+
+func (s *EncryptionNodeRankerSuite) SetupTest() {
+	s.EncryptionNodeRanker = NewEncryptionNodeRanker()
+	s.nodes = []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("both")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("first")}},
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("none")}},
+	}
+}
+
+func TestEncryptionNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(EncryptionNodeRankerSuite))
+}
+
+func (s *EncryptionNodeRankerSuite) encryptedFor(nodeIDs ...string) *model.EncryptionSpec {
+	spec := &model.EncryptionSpec{
+		Algorithm:   model.EncryptionAlgorithmAES256GCM,
+		WrappedKeys: map[string][]byte{},
+	}
+	for _, nodeID := range nodeIDs {
+		spec.WrappedKeys[peer.ID(nodeID).String()] = []byte("key")
+	}
+	return spec
+}
+
+func (s *EncryptionNodeRankerSuite) TestRankNodes_NoEncryption() {
+	job := model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{{StorageSource: model.StorageSourceInline}}}}
+	ranks, err := s.EncryptionNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "both", 0)
+	assertEquals(s.T(), ranks, "first", 0)
+	assertEquals(s.T(), ranks, "none", 0)
+}
+
+func (s *EncryptionNodeRankerSuite) TestRankNodes_Encrypted() {
+	job := model.Job{Spec: model.Spec{Inputs: []model.StorageSpec{
+		{StorageSource: model.StorageSourceInline, Encryption: s.encryptedFor("both", "first")},
+		{StorageSource: model.StorageSourceInline, Encryption: s.encryptedFor("both")},
+	}}}
+	ranks, err := s.EncryptionNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "both", 0)
+	assertEquals(s.T(), ranks, "first", -1)
+	assertEquals(s.T(), ranks, "none", -1)
+}
+
+func (s *EncryptionNodeRankerSuite) TestRankNodes_Secrets() {
+	job := model.Job{Spec: model.Spec{
+		Inputs: []model.StorageSpec{{StorageSource: model.StorageSourceInline, Encryption: s.encryptedFor("both", "first")}},
+		Secrets: &model.SecretsSpec{
+			Values:     map[string][]byte{"TOKEN": []byte("encrypted")},
+			Encryption: s.encryptedFor("both"),
+		},
+	}}
+	ranks, err := s.EncryptionNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "both", 0)
+	assertEquals(s.T(), ranks, "first", -1)
+	assertEquals(s.T(), ranks, "none", -1)
+}
@@ -0,0 +1,43 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute/capacity"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+)
+
+type GPUsNodeRanker struct {
+}
+
+func NewGPUsNodeRanker() *GPUsNodeRanker {
+	return &GPUsNodeRanker{}
+}
+
+// RankNodes ranks nodes based on the GPU devices the compute nodes report:
+// - Rank 10: Node has enough devices not in use by other executions to run the job right away.
+// - Rank -1: Node reports fewer devices than the job requires.
+// - Rank 0: Job doesn't require GPUs, node doesn't report its devices, or its devices are in use by other executions.
+func (s *GPUsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	gpus := capacity.ParseResourceUsageConfig(job.Spec.Resources).GPU
+	for i, node := range nodes {
+		rank := 0
+		if gpus > 0 && node.ComputeNodeInfo != nil && len(node.ComputeNodeInfo.GPUs) > 0 {
+			if uint64(len(node.ComputeNodeInfo.AvailableGPUs)) >= gpus {
+				rank = 10
+			} else if uint64(len(node.ComputeNodeInfo.GPUs)) < gpus {
+				log.Ctx(ctx).Trace().Msgf("filtering node %s with %d GPUs, job requires %d",
+					node.PeerInfo.ID, len(node.ComputeNodeInfo.GPUs), gpus)
+				rank = -1
+			}
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
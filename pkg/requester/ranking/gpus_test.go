@@ -0,0 +1,81 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type GPUsNodeRankerSuite struct {
+	suite.Suite
+	GPUsNodeRanker *GPUsNodeRanker
+	nodes          []model.NodeInfo
+}
+
+func (s *GPUsNodeRankerSuite) SetupSuite() {
+	gpus := []model.GPU{{Index: 0, Name: "Tesla T4"}, {Index: 1, Name: "Tesla T4"}}
+	s.nodes = []model.NodeInfo{
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("unknown")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{},
+		},
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("single")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{GPUs: gpus[:1], AvailableGPUs: gpus[:1]},
+		},
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("busy")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{GPUs: gpus, AvailableGPUs: gpus[1:]},
+		},
+		{
+			PeerInfo:        peer.AddrInfo{ID: peer.ID("free")},
+			ComputeNodeInfo: &model.ComputeNodeInfo{GPUs: gpus, AvailableGPUs: gpus},
+		},
+	}
+}
+
+func (s *GPUsNodeRankerSuite) SetupTest() {
+	s.GPUsNodeRanker = NewGPUsNodeRanker()
+}
+
+func TestGPUsNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(GPUsNodeRankerSuite))
+}
+
+func (s *GPUsNodeRankerSuite) TestRankNodes_NoGPUs() {
+	job := model.Job{Spec: model.Spec{Resources: model.ResourceUsageConfig{CPU: "1"}}}
+	ranks, err := s.GPUsNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "unknown", 0)
+	assertEquals(s.T(), ranks, "single", 0)
+	assertEquals(s.T(), ranks, "busy", 0)
+	assertEquals(s.T(), ranks, "free", 0)
+}
+
+func (s *GPUsNodeRankerSuite) TestRankNodes_SingleGPU() {
+	job := model.Job{Spec: model.Spec{Resources: model.ResourceUsageConfig{GPU: "1"}}}
+	ranks, err := s.GPUsNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "unknown", 0)
+	assertEquals(s.T(), ranks, "single", 10)
+	assertEquals(s.T(), ranks, "busy", 10)
+	assertEquals(s.T(), ranks, "free", 10)
+}
+
+func (s *GPUsNodeRankerSuite) TestRankNodes_MultipleGPUs() {
+	job := model.Job{Spec: model.Spec{Resources: model.ResourceUsageConfig{GPU: "2"}}}
+	ranks, err := s.GPUsNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.NoError(err)
+	s.Equal(len(s.nodes), len(ranks))
+	assertEquals(s.T(), ranks, "unknown", 0)
+	assertEquals(s.T(), ranks, "single", -1)
+	assertEquals(s.T(), ranks, "busy", 0)
+	assertEquals(s.T(), ranks, "free", 10)
+}
@@ -21,7 +21,7 @@ func NewLabelsNodeRanker() *LabelsNodeRanker {
 	return &LabelsNodeRanker{}
 }
 
-// RankNodes ranks nodes based on the node labels and job selectors:
+// RankNodes ranks nodes based on the node labels, including those derived from its sandbox profile, and job selectors:
 // - Rank 20: Selectors with `favour_` prefix and matching node labels
 // - Rank 10: Selectors match node labels.
 // - Rank -1: Selectors don't match node labels.
@@ -53,11 +53,11 @@ func (s *LabelsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes [
 	for i, node := range nodes {
 		rank := 0
 		if !mustSelector.Empty() {
-			if mustSelector.Matches(labels.Set(node.Labels)) {
+			if mustSelector.Matches(labels.Set(node.SelectorLabels())) {
 				rank = 10
 			} else {
 				log.Ctx(ctx).Trace().Msgf("filtering node %s with labels %s doesn't match selectors %+v",
-					node.PeerInfo.ID, node.Labels, job.Spec.NodeSelectors)
+					node.PeerInfo.ID, node.SelectorLabels(), job.Spec.NodeSelectors)
 				rank = -1
 			}
 		}
@@ -69,7 +69,7 @@ func (s *LabelsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes [
 
 	if !favourSelector.Empty() {
 		for i, rank := range ranks {
-			if rank.Rank != -1 && favourSelector.Matches(labels.Set(rank.NodeInfo.Labels)) {
+			if rank.Rank != -1 && favourSelector.Matches(labels.Set(rank.NodeInfo.SelectorLabels())) {
 				ranks[i].Rank += 10
 			}
 		}
@@ -0,0 +1,79 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+type LabelsNodeRankerSuite struct {
+	suite.Suite
+	LabelsNodeRanker *LabelsNodeRanker
+	nodes            []model.NodeInfo
+}
+
+func (s *LabelsNodeRankerSuite) SetupTest() {
+	s.LabelsNodeRanker = NewLabelsNodeRanker()
+	s.nodes = []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("plain")}, Labels: map[string]string{"region": "eu"}},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("gvisor")},
+			Labels:   map[string]string{"region": "eu"},
+			ComputeNodeInfo: &model.ComputeNodeInfo{
+				Sandbox: &model.SandboxProfile{ReadOnlyRootfs: true, Runtime: "runsc"},
+			},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("read-only")},
+			Labels:   map[string]string{"region": "us"},
+			ComputeNodeInfo: &model.ComputeNodeInfo{
+				Sandbox: &model.SandboxProfile{ReadOnlyRootfs: true},
+			},
+		},
+	}
+}
+
+func TestLabelsNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(LabelsNodeRankerSuite))
+}
+
+func (s *LabelsNodeRankerSuite) rank(selectors ...model.LabelSelectorRequirement) []requester.NodeRank {
+	job := model.Job{Spec: model.Spec{NodeSelectors: selectors}}
+	ranks, err := s.LabelsNodeRanker.RankNodes(context.Background(), job, s.nodes)
+	s.Require().NoError(err)
+	s.Require().Equal(len(s.nodes), len(ranks))
+	return ranks
+}
+
+func (s *LabelsNodeRankerSuite) TestRankNodes_NoSelectors() {
+	ranks := s.rank()
+	assertEquals(s.T(), ranks, "plain", 0)
+	assertEquals(s.T(), ranks, "gvisor", 0)
+	assertEquals(s.T(), ranks, "read-only", 0)
+}
+
+func (s *LabelsNodeRankerSuite) TestRankNodes_SandboxSelectors() {
+	ranks := s.rank(model.LabelSelectorRequirement{
+		Key:      model.SandboxLabelPrefix + "runtime",
+		Operator: selection.Equals,
+		Values:   []string{"runsc"},
+	})
+	assertEquals(s.T(), ranks, "plain", -1)
+	assertEquals(s.T(), ranks, "gvisor", 10)
+	assertEquals(s.T(), ranks, "read-only", -1)
+
+	ranks = s.rank(
+		model.LabelSelectorRequirement{Key: model.SandboxLabelPrefix + "read-only-rootfs", Operator: selection.Exists},
+		model.LabelSelectorRequirement{Key: "region", Operator: selection.Equals, Values: []string{"us"}},
+	)
+	assertEquals(s.T(), ranks, "plain", -1)
+	assertEquals(s.T(), ranks, "gvisor", -1)
+	assertEquals(s.T(), ranks, "read-only", 10)
+}
@@ -0,0 +1,69 @@
+package ranking
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+)
+
+type ProtocolsNodeRankerParams struct {
+	// Protocols spoken by the requester node, keyed by protocol name, as advertised in its NodeInfo.
+	Protocols map[string][]string
+}
+
+type ProtocolsNodeRanker struct {
+	protocols map[string][]string
+}
+
+func NewProtocolsNodeRanker(params ProtocolsNodeRankerParams) *ProtocolsNodeRanker {
+	return &ProtocolsNodeRanker{
+		protocols: params.Protocols,
+	}
+}
+
+// RankNodes ranks nodes based on the protocol versions they advertise:
+// - Rank -1: Node advertises a protocol the requester also speaks, but shares no version of it with the requester.
+// - Rank 0: Node shares a version of every common protocol, or doesn't advertise its protocols.
+func (s *ProtocolsNodeRanker) RankNodes(ctx context.Context, job model.Job, nodes []model.NodeInfo) ([]requester.NodeRank, error) {
+	ranks := make([]requester.NodeRank, len(nodes))
+	for i, node := range nodes {
+		rank := 0
+		// nodes that predate protocol negotiation don't advertise their protocols, and can only be reached
+		// if the requester still speaks the oldest version, which is left to stream negotiation to find out.
+		if name, ok := s.incompatibleProtocol(node.Protocols); ok {
+			log.Ctx(ctx).Debug().Msgf("filtering node %s with incompatible %s protocol versions %v",
+				node.PeerInfo.ID, name, node.Protocols[name])
+			rank = -1
+		}
+		ranks[i] = requester.NodeRank{
+			NodeInfo: node,
+			Rank:     rank,
+		}
+	}
+	return ranks, nil
+}
+
+// incompatibleProtocol returns the name of a protocol spoken by both the requester and the node
+// for which they share no version.
+func (s *ProtocolsNodeRanker) incompatibleProtocol(nodeProtocols map[string][]string) (string, bool) {
+	for name, nodeVersions := range nodeProtocols {
+		versions, ok := s.protocols[name]
+		if !ok {
+			continue
+		}
+		compatible := false
+		for _, version := range nodeVersions {
+			if slices.Contains(versions, version) {
+				compatible = true
+				break
+			}
+		}
+		if !compatible {
+			return name, true
+		}
+	}
+	return "", false
+}
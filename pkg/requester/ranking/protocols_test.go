@@ -0,0 +1,72 @@
+//go:build unit || !integration
+
+package ranking
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+type ProtocolsNodeRankerSuite struct {
+	suite.Suite
+	ProtocolsNodeRanker *ProtocolsNodeRanker
+}
+
+func (s *ProtocolsNodeRankerSuite) SetupTest() {
+	s.ProtocolsNodeRanker = NewProtocolsNodeRanker(ProtocolsNodeRankerParams{
+		Protocols: map[string][]string{
+			"/bacalhau/compute/ask_for_bid":      {"1.1.0", "1.0.0"},
+			"/bacalhau/callback/on_bid_complete": {"1.1.0", "1.0.0"},
+		},
+	})
+}
+
+func TestProtocolsNodeRankerSuite(t *testing.T) {
+	suite.Run(t, new(ProtocolsNodeRankerSuite))
+}
+
+func (s *ProtocolsNodeRankerSuite) TestRankNodes() {
+	nodes := []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("legacy")}},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("same")},
+			Protocols: map[string][]string{
+				"/bacalhau/compute/ask_for_bid":      {"1.1.0", "1.0.0"},
+				"/bacalhau/callback/on_bid_complete": {"1.1.0", "1.0.0"},
+			},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("older")},
+			Protocols: map[string][]string{
+				"/bacalhau/compute/ask_for_bid":      {"1.0.0"},
+				"/bacalhau/callback/on_bid_complete": {"1.0.0"},
+			},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("newer")},
+			Protocols: map[string][]string{
+				"/bacalhau/compute/ask_for_bid":      {"2.0.0"},
+				"/bacalhau/callback/on_bid_complete": {"1.1.0"},
+			},
+		},
+		{
+			PeerInfo: peer.AddrInfo{ID: peer.ID("unknown-protocol")},
+			Protocols: map[string][]string{
+				"/bacalhau/compute/ask_for_bid": {"1.0.0"},
+				"/bacalhau/compute/new":         {"2.0.0"},
+			},
+		},
+	}
+	ranks, err := s.ProtocolsNodeRanker.RankNodes(context.Background(), model.Job{}, nodes)
+	s.NoError(err)
+	s.Equal(len(nodes), len(ranks))
+	assertEquals(s.T(), ranks, "legacy", 0)
+	assertEquals(s.T(), ranks, "same", 0)
+	assertEquals(s.T(), ranks, "older", 0)
+	assertEquals(s.T(), ranks, "newer", -1)
+	assertEquals(s.T(), ranks, "unknown-protocol", 0)
+}
@@ -193,24 +193,30 @@ func (s *BaseScheduler) updateAndNotifyBidAccepted(ctx context.Context, executio
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msgf("failed to update execution state to BidAccepted. %s", execution)
 	} else {
-		go func(ctx context.Context) {
-			request := compute.BidAcceptedRequest{
-				ExecutionID: execution.ComputeReference,
-				RoutingMetadata: compute.RoutingMetadata{
-					SourcePeerID: s.id,
-					TargetPeerID: execution.NodeID,
-				},
-			}
-			response, notifyErr := s.computeService.BidAccepted(ctx, request)
-			if notifyErr != nil {
-				s.handleExecutionFailure(ctx, execution.ID(), notifyErr)
-			} else {
-				s.eventEmitter.EmitBidAccepted(ctx, request, response)
-			}
-		}(util.NewDetachedContext(ctx))
+		s.notifyBidAccepted(ctx, execution)
 	}
 }
 
+// notifyBidAccepted only notifies the compute node that its bid was accepted, and expects the execution state to
+// have already been updated in the job store.
+func (s *BaseScheduler) notifyBidAccepted(ctx context.Context, execution model.ExecutionState) {
+	go func(ctx context.Context) {
+		request := compute.BidAcceptedRequest{
+			ExecutionID: execution.ComputeReference,
+			RoutingMetadata: compute.RoutingMetadata{
+				SourcePeerID: s.id,
+				TargetPeerID: execution.NodeID,
+			},
+		}
+		response, notifyErr := s.computeService.BidAccepted(ctx, request)
+		if notifyErr != nil {
+			s.handleExecutionFailure(ctx, execution.ID(), notifyErr)
+		} else {
+			s.eventEmitter.EmitBidAccepted(ctx, request, response)
+		}
+	}(util.NewDetachedContext(ctx))
+}
+
 func (s *BaseScheduler) updateAndNotifyBidRejected(ctx context.Context, execution model.ExecutionState) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s responding with BidRejected for bid: %s", s.id, execution.ComputeReference)
 	err := s.jobStore.UpdateExecution(ctx, jobstore.UpdateExecutionRequest{
@@ -261,24 +267,30 @@ func (s *BaseScheduler) updateAndNotifyResultAccepted(ctx context.Context, resul
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msgf("failed to update execution state to ResultAccepted. %s", result.Execution.ID())
 	} else {
-		go func(ctx context.Context) {
-			request := compute.ResultAcceptedRequest{
-				ExecutionID: result.Execution.ComputeReference,
-				RoutingMetadata: compute.RoutingMetadata{
-					SourcePeerID: s.id,
-					TargetPeerID: result.Execution.NodeID,
-				},
-			}
-			response, notifyErr := s.computeService.ResultAccepted(ctx, request)
-			if notifyErr != nil {
-				s.handleExecutionFailure(ctx, result.Execution.ID(), notifyErr)
-			} else {
-				s.eventEmitter.EmitResultAccepted(ctx, request, response)
-			}
-		}(util.NewDetachedContext(ctx))
+		s.notifyResultAccepted(ctx, result.Execution)
 	}
 }
 
+// notifyResultAccepted only notifies the compute node that its result was accepted, and expects the execution state to
+// have already been updated in the job store.
+func (s *BaseScheduler) notifyResultAccepted(ctx context.Context, execution model.ExecutionState) {
+	go func(ctx context.Context) {
+		request := compute.ResultAcceptedRequest{
+			ExecutionID: execution.ComputeReference,
+			RoutingMetadata: compute.RoutingMetadata{
+				SourcePeerID: s.id,
+				TargetPeerID: execution.NodeID,
+			},
+		}
+		response, notifyErr := s.computeService.ResultAccepted(ctx, request)
+		if notifyErr != nil {
+			s.handleExecutionFailure(ctx, execution.ID(), notifyErr)
+		} else {
+			s.eventEmitter.EmitResultAccepted(ctx, request, response)
+		}
+	}(util.NewDetachedContext(ctx))
+}
+
 func (s *BaseScheduler) updateAndNotifyResultRejected(ctx context.Context, result verifier.VerifierResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s responding with ResultRejected for bid: %s", s.id, result.Execution.ID())
 	err := s.jobStore.UpdateExecution(ctx, jobstore.UpdateExecutionRequest{
@@ -376,6 +388,10 @@ func (s *BaseScheduler) verifyResult(
 // OnBidComplete implements compute.Callback
 func (s *BaseScheduler) OnBidComplete(ctx context.Context, response compute.BidResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node received bid response %+v", response)
+	if err := s.checkCallbackSource(ctx, response.RoutingMetadata, response.ExecutionMetadata); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("[OnBidComplete] dropping callback")
+		return
+	}
 
 	executionID := model.ExecutionID{
 		JobID:       response.JobID,
@@ -414,6 +430,10 @@ func (s *BaseScheduler) OnBidComplete(ctx context.Context, response compute.BidR
 func (s *BaseScheduler) OnRunComplete(ctx context.Context, result compute.RunResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s received RunComplete for execution: %s from %s",
 		s.id, result.ExecutionID, result.SourcePeerID)
+	if err := s.checkCallbackSource(ctx, result.RoutingMetadata, result.ExecutionMetadata); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("[OnRunComplete] dropping callback")
+		return
+	}
 	s.eventEmitter.EmitRunComplete(ctx, result)
 
 	// update execution state
@@ -443,6 +463,10 @@ func (s *BaseScheduler) OnRunComplete(ctx context.Context, result compute.RunRes
 func (s *BaseScheduler) OnPublishComplete(ctx context.Context, result compute.PublishResult) {
 	log.Ctx(ctx).Debug().Msgf("Requester node %s received PublishComplete for execution: %s from %s",
 		s.id, result.ExecutionID, result.SourcePeerID)
+	if err := s.checkCallbackSource(ctx, result.RoutingMetadata, result.ExecutionMetadata); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("[OnPublishComplete] dropping callback")
+		return
+	}
 	s.eventEmitter.EmitPublishComplete(ctx, result)
 	// TODO: #831 verify that the published results are the same as the ones we expect, or let the verifier
 	//  publish the result and not all the compute nodes.
@@ -478,6 +502,10 @@ func (s *BaseScheduler) OnCancelComplete(ctx context.Context, result compute.Can
 func (s *BaseScheduler) OnComputeFailure(ctx context.Context, result compute.ComputeError) {
 	log.Ctx(ctx).Debug().Err(result).Msgf("Requester node %s received ComputeFailure for execution: %s from %s",
 		s.id, result.ExecutionID, result.SourcePeerID)
+	if err := s.checkCallbackSource(ctx, result.RoutingMetadata, result.ExecutionMetadata); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msgf("[OnComputeFailure] dropping callback")
+		return
+	}
 	s.handleExecutionFailure(ctx, model.ExecutionID{
 		JobID:       result.JobID,
 		NodeID:      result.SourcePeerID,
@@ -485,6 +513,30 @@ func (s *BaseScheduler) OnComputeFailure(ctx context.Context, result compute.Com
 	}, result)
 }
 
+// checkCallbackSource returns an error if the execution of a callback is not assigned to the node the callback
+// comes from, so that compute nodes can't update the executions of other nodes.
+func (s *BaseScheduler) checkCallbackSource(
+	ctx context.Context, routing compute.RoutingMetadata, metadata compute.ExecutionMetadata) error {
+	jobState, err := s.jobStore.GetJobState(ctx, metadata.JobID)
+	if err != nil {
+		return err
+	}
+	for _, execution := range jobState.Executions {
+		if execution.ComputeReference != metadata.ExecutionID {
+			continue
+		}
+		if execution.NodeID != routing.SourcePeerID {
+			return NewErrExecutionNodeMismatch(execution.ID(), routing.SourcePeerID)
+		}
+		return nil
+	}
+	return jobstore.NewErrExecutionNotFound(model.ExecutionID{
+		JobID:       metadata.JobID,
+		NodeID:      routing.SourcePeerID,
+		ExecutionID: metadata.ExecutionID,
+	})
+}
+
 func (s *BaseScheduler) handleExecutionFailure(ctx context.Context, executionID model.ExecutionID, failure error) {
 	// update execution state
 	err := s.jobStore.UpdateExecution(ctx, jobstore.UpdateExecutionRequest{
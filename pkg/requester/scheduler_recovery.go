@@ -0,0 +1,193 @@
+package requester
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
+	"github.com/bacalhau-project/bacalhau/pkg/util"
+	"github.com/rs/zerolog/log"
+)
+
+// RecoverJobsRequest holds the components the jobs that were waiting in the queue are recovered with.
+type RecoverJobsRequest struct {
+	// Queue the jobs that were waiting to start are put back in. Queued jobs are not recovered if nil.
+	Queue Queue
+	// Moderator tells which queued jobs are still held for moderation, which stay queued until they are moderated.
+	Moderator *moderation.Moderator
+}
+
+// RecoverJobs resumes the jobs owned by this requester that were still in progress when the node was stopped.
+// Executions that were waiting on the compute nodes are reconciled with the compute nodes' view of the execution,
+// and the job state is then transitioned as if a callback was received, so that bidding, verification and publishing
+// can continue instead of waiting for the job to time out. Jobs that were waiting in the queue to start are put back
+// in the queue for the rest of their max queue time.
+func (s *BaseScheduler) RecoverJobs(ctx context.Context, request RecoverJobsRequest) error {
+	jobs, err := s.jobStore.GetInProgressJobs(ctx)
+	if err != nil {
+		return err
+	}
+	held := make(map[string]bool)
+	if request.Moderator != nil {
+		pending, err := request.Moderator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, info := range pending {
+			held[info.Job.ID()] = true
+		}
+	}
+	for _, jobWithInfo := range jobs {
+		// in case the job store is shared between multiple nodes, we only want to recover jobs that are owned by this node
+		if jobWithInfo.Job.Metadata.Requester.RequesterNodeID != s.id {
+			continue
+		}
+		if jobWithInfo.State.State == model.JobStateQueued {
+			s.recoverQueuedJob(ctx, request.Queue, jobWithInfo, held[jobWithInfo.Job.ID()])
+			continue
+		}
+		s.recoverJob(ctx, jobWithInfo)
+	}
+	return nil
+}
+
+// recoverQueuedJob puts a job that was waiting to start back in the queue, which only kept it in memory. Jobs held
+// for moderation are left as is, and are started once they are approved.
+func (s *BaseScheduler) recoverQueuedJob(ctx context.Context, queue Queue, jobWithInfo model.JobWithInfo, held bool) {
+	job := jobWithInfo.Job
+	if held || queue == nil {
+		return
+	}
+	log.Ctx(ctx).Info().Msgf("putting job %s back in the queue", job.ID())
+	// the state of jobs waiting in the queue is last updated when they start waiting
+	err := queue.StartJob(ctx, StartJobRequest{Job: job, EnqueuedAt: jobWithInfo.State.UpdateTime})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("[recoverQueuedJob] failed to queue job %s", job.ID())
+	}
+}
+
+func (s *BaseScheduler) recoverJob(ctx context.Context, jobWithInfo model.JobWithInfo) {
+	job := jobWithInfo.Job
+	log.Ctx(ctx).Info().Msgf("recovering job %s in state %s", job.ID(), jobWithInfo.State.State)
+
+	switch jobWithInfo.State.State {
+	case model.JobStateNew:
+		// the job was accepted, but the node stopped before it was scheduled
+		if err := s.StartJob(ctx, StartJobRequest{Job: job}); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msgf("[recoverJob] failed to start job %s", job.ID())
+		}
+		return
+	}
+
+	for _, execution := range jobWithInfo.State.Executions {
+		if !execution.State.IsTerminal() {
+			s.recoverExecution(ctx, job, execution)
+		}
+	}
+	s.transitionJobState(ctx, job.ID())
+}
+
+// recoverExecution reconciles an execution that is waiting on a compute node with the compute node's state of the
+// execution. Executions that are waiting on the requester itself, such as ExecutionStateAskForBidAccepted or
+// ExecutionStateResultProposed, are left as is and are handled by transitionJobState.
+func (s *BaseScheduler) recoverExecution(ctx context.Context, job model.Job, execution model.ExecutionState) {
+	if execution.State != model.ExecutionStateAskForBid &&
+		execution.State != model.ExecutionStateBidAccepted &&
+		execution.State != model.ExecutionStateResultAccepted {
+		return
+	}
+
+	response, err := s.computeService.ExecutionStatus(ctx, compute.ExecutionStatusRequest{
+		ExecutionID: execution.ComputeReference,
+		RoutingMetadata: compute.RoutingMetadata{
+			SourcePeerID: s.id,
+			TargetPeerID: execution.NodeID,
+		},
+	})
+	if err != nil {
+		s.handleExecutionFailure(ctx, execution.ID(), NewErrExecutionNotRecoverable(execution, err.Error()))
+		return
+	}
+
+	computeState := response.State
+	log.Ctx(ctx).Debug().Msgf("recovering execution %s in state %s with compute state %s",
+		execution, execution.State, computeState)
+
+	executionMetadata := compute.ExecutionMetadata{
+		ExecutionID: execution.ComputeReference,
+		JobID:       execution.JobID,
+	}
+	routingMetadata := compute.RoutingMetadata{
+		SourcePeerID: execution.NodeID,
+		TargetPeerID: s.id,
+	}
+	switch execution.State {
+	case model.ExecutionStateAskForBid:
+		switch {
+		case computeState == store.ExecutionStateUndefined:
+			// the compute node never received the ask for bid
+			go s.doNotifyAskForBid(util.NewDetachedContext(ctx), job, execution.ID())
+			return
+		case response.Bid != nil:
+			// the compute node bid on the execution, or rejected it, but we missed its callback
+			s.OnBidComplete(ctx, compute.BidResult{
+				ExecutionMetadata: executionMetadata,
+				RoutingMetadata:   routingMetadata,
+				Accepted:          response.Bid.Accepted,
+				Reason:            response.Bid.Reason,
+			})
+			return
+		case computeState == store.ExecutionStateCreated:
+			// the compute node is still deciding whether to bid and will call back once done
+			return
+		}
+	case model.ExecutionStateBidAccepted:
+		switch computeState {
+		case store.ExecutionStateCreated:
+			// the compute node never received the bid acceptance
+			s.notifyBidAccepted(ctx, execution)
+			return
+		case store.ExecutionStateBidAccepted, store.ExecutionStateRunning:
+			// the compute node is still running the execution and will call back once done
+			return
+		case store.ExecutionStateWaitingVerification:
+			// the compute node ran the execution, but we missed its callback
+			s.OnRunComplete(ctx, compute.RunResult{
+				ExecutionMetadata: executionMetadata,
+				RoutingMetadata:   routingMetadata,
+				ResultProposal:    response.ResultProposal,
+				RunCommandResult:  response.RunCommandResult,
+			})
+			return
+		}
+	case model.ExecutionStateResultAccepted:
+		switch computeState {
+		case store.ExecutionStateWaitingVerification:
+			// the compute node never received the result acceptance
+			s.notifyResultAccepted(ctx, execution)
+			return
+		case store.ExecutionStateResultAccepted, store.ExecutionStatePublishing:
+			// the compute node is still publishing the result and will call back once done
+			return
+		case store.ExecutionStateCompleted:
+			// the compute node published the result, but we missed its callback
+			s.OnPublishComplete(ctx, compute.PublishResult{
+				ExecutionMetadata: executionMetadata,
+				RoutingMetadata:   routingMetadata,
+				PublishResult:     response.PublishResult,
+			})
+			return
+		}
+	}
+
+	// The compute node moved past the state we are waiting for, which means we missed its callback while
+	// the requester was down. We fail the execution so that it can be retried, and make sure the compute
+	// node stops working on it.
+	recoveryErr := NewErrExecutionNotRecoverable(execution, "compute node reported state "+computeState.String())
+	if computeState != store.ExecutionStateUndefined && !computeState.IsTerminal() {
+		s.notifyCancel(ctx, recoveryErr.Error(), execution)
+	}
+	s.handleExecutionFailure(ctx, execution.ID(), recoveryErr)
+}
@@ -0,0 +1,312 @@
+//go:build unit || !integration
+
+package requester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/compute/store"
+	"github.com/bacalhau-project/bacalhau/pkg/eventhandler"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore/inmemory"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	noop_verifier "github.com/bacalhau-project/bacalhau/pkg/verifier/noop"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+// recoveryComputeEndpoint is a compute.Endpoint that reports a fixed execution state and records the requests it
+// received from the scheduler.
+type recoveryComputeEndpoint struct {
+	state         store.ExecutionState
+	bid           *store.Bid
+	runResult     *model.RunCommandResult
+	publishResult model.StorageSpec
+	bidAccepted   chan compute.BidAcceptedRequest
+	cancelled     chan compute.CancelExecutionRequest
+	statusChecks  chan compute.ExecutionStatusRequest
+}
+
+func newRecoveryComputeEndpoint(state store.ExecutionState) *recoveryComputeEndpoint {
+	return &recoveryComputeEndpoint{
+		state:        state,
+		bidAccepted:  make(chan compute.BidAcceptedRequest, 1),
+		cancelled:    make(chan compute.CancelExecutionRequest, 1),
+		statusChecks: make(chan compute.ExecutionStatusRequest, 1),
+	}
+}
+
+func (e *recoveryComputeEndpoint) AskForBid(context.Context, compute.AskForBidRequest) (compute.AskForBidResponse, error) {
+	return compute.AskForBidResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) BidAccepted(
+	_ context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
+	e.bidAccepted <- request
+	return compute.BidAcceptedResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) BidRejected(context.Context, compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
+	return compute.BidRejectedResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) ResultAccepted(
+	context.Context, compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
+	return compute.ResultAcceptedResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) ResultRejected(
+	context.Context, compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
+	return compute.ResultRejectedResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) CancelExecution(
+	_ context.Context, request compute.CancelExecutionRequest) (compute.CancelExecutionResponse, error) {
+	e.cancelled <- request
+	return compute.CancelExecutionResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) ExecutionLogs(context.Context, compute.ExecutionLogsRequest) (compute.ExecutionLogsResponse, error) {
+	return compute.ExecutionLogsResponse{}, nil
+}
+
+func (e *recoveryComputeEndpoint) ExecutionStatus(
+	_ context.Context, request compute.ExecutionStatusRequest) (compute.ExecutionStatusResponse, error) {
+	e.statusChecks <- request
+	return compute.ExecutionStatusResponse{
+		State:            e.state,
+		Bid:              e.bid,
+		RunCommandResult: e.runResult,
+		PublishResult:    e.publishResult,
+	}, nil
+}
+
+var _ compute.Endpoint = (*recoveryComputeEndpoint)(nil)
+
+type noRetryStrategy struct{}
+
+func (noRetryStrategy) ShouldRetry(context.Context, RetryRequest) bool {
+	return false
+}
+
+type SchedulerRecoverySuite struct {
+	suite.Suite
+	ctx      context.Context
+	nodeID   string
+	jobStore jobstore.Store
+}
+
+func TestSchedulerRecoverySuite(t *testing.T) {
+	suite.Run(t, new(SchedulerRecoverySuite))
+}
+
+func (s *SchedulerRecoverySuite) SetupTest() {
+	s.ctx = context.Background()
+	s.nodeID = "requester-" + uuid.NewString()
+	s.jobStore = inmemory.NewJobStore()
+}
+
+func (s *SchedulerRecoverySuite) newScheduler(endpoint compute.Endpoint) *BaseScheduler {
+	cm := system.NewCleanupManager()
+	s.T().Cleanup(func() { cm.Cleanup(context.Background()) })
+	noopVerifier, err := noop_verifier.NewNoopVerifier(s.ctx, cm)
+	s.Require().NoError(err)
+
+	return NewBaseScheduler(BaseSchedulerParams{
+		ID:              s.nodeID,
+		JobStore:        s.jobStore,
+		RetryStrategy:   noRetryStrategy{},
+		ComputeEndpoint: endpoint,
+		Verifiers:       model.NewMappedProvider(map[model.Verifier]verifier.Verifier{model.VerifierNoop: noopVerifier}),
+		EventEmitter: NewEventEmitter(EventEmitterParams{
+			EventConsumer: eventhandler.JobEventHandlerFunc(func(context.Context, model.JobEvent) error {
+				return nil
+			}),
+		}),
+	})
+}
+
+// createInProgressJob creates a job owned by requesterNodeID with a single execution in the given state
+func (s *SchedulerRecoverySuite) createInProgressJob(
+	requesterNodeID string, state model.ExecutionStateType) model.ExecutionState {
+	job := model.Job{
+		APIVersion: model.APIVersionLatest().String(),
+		Metadata: model.Metadata{
+			ID:        uuid.NewString(),
+			CreatedAt: time.Now(),
+			Requester: model.JobRequester{RequesterNodeID: requesterNodeID},
+		},
+		Spec: model.Spec{
+			Engine:   model.EngineNoop,
+			Verifier: model.VerifierNoop,
+			Deal:     model.Deal{Concurrency: 1},
+		},
+	}
+	s.Require().NoError(s.jobStore.CreateJob(s.ctx, job))
+	s.Require().NoError(s.jobStore.UpdateJobState(s.ctx, jobstore.UpdateJobStateRequest{
+		JobID:    job.ID(),
+		NewState: model.JobStateInProgress,
+	}))
+
+	execution := model.ExecutionState{
+		JobID:             job.ID(),
+		NodeID:            "compute-" + uuid.NewString(),
+		ComputeReference:  "e-" + uuid.NewString(),
+		State:             state,
+		AcceptedAskForBid: true,
+	}
+	s.Require().NoError(s.jobStore.CreateExecution(s.ctx, execution))
+	return execution
+}
+
+func (s *SchedulerRecoverySuite) getExecution(id model.ExecutionID) model.ExecutionState {
+	jobState, err := s.jobStore.GetJobState(s.ctx, id.JobID)
+	s.Require().NoError(err)
+	for _, execution := range jobState.Executions {
+		if execution.ID() == id {
+			return execution
+		}
+	}
+	s.FailNow("execution not found", id.String())
+	return model.ExecutionState{}
+}
+
+func (s *SchedulerRecoverySuite) TestResendsMissedBidAcceptance() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateCreated)
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateBidAccepted)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	select {
+	case request := <-endpoint.bidAccepted:
+		s.Equal(execution.ComputeReference, request.ExecutionID)
+		s.Equal(execution.NodeID, request.TargetPeerID)
+	case <-time.After(5 * time.Second):
+		s.FailNow("bid acceptance was not sent to the compute node")
+	}
+	s.Equal(model.ExecutionStateBidAccepted, s.getExecution(execution.ID()).State)
+}
+
+func (s *SchedulerRecoverySuite) TestWaitsForRunningExecution() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateRunning)
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateBidAccepted)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	s.Len(endpoint.statusChecks, 1)
+	s.Empty(endpoint.bidAccepted)
+	s.Empty(endpoint.cancelled)
+	s.Equal(model.ExecutionStateBidAccepted, s.getExecution(execution.ID()).State)
+}
+
+func (s *SchedulerRecoverySuite) TestReplaysMissedBidCallback() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateCreated)
+	endpoint.bid = &store.Bid{Accepted: true}
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateAskForBid)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	// the bid is accepted once it is received, as the job only needs one execution
+	select {
+	case request := <-endpoint.bidAccepted:
+		s.Equal(execution.ComputeReference, request.ExecutionID)
+	case <-time.After(5 * time.Second):
+		s.FailNow("bid acceptance was not sent to the compute node")
+	}
+	recovered := s.getExecution(execution.ID())
+	s.Equal(model.ExecutionStateBidAccepted, recovered.State)
+	s.True(recovered.AcceptedAskForBid)
+}
+
+func (s *SchedulerRecoverySuite) TestWaitsForPendingBid() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateCreated)
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateAskForBid)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	s.Len(endpoint.statusChecks, 1)
+	s.Empty(endpoint.cancelled)
+	s.Equal(model.ExecutionStateAskForBid, s.getExecution(execution.ID()).State)
+}
+
+func (s *SchedulerRecoverySuite) TestReplaysMissedRunCallback() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateWaitingVerification)
+	endpoint.runResult = &model.RunCommandResult{STDOUT: "hello"}
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateBidAccepted)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	s.Empty(endpoint.cancelled)
+	recovered := s.getExecution(execution.ID())
+	// the results are verified and accepted once received, as the job only needs one execution
+	s.Equal(model.ExecutionStateResultAccepted, recovered.State)
+	s.Require().NotNil(recovered.RunOutput)
+	s.Equal("hello", recovered.RunOutput.STDOUT)
+}
+
+func (s *SchedulerRecoverySuite) TestFailsExecutionInUnexpectedState() {
+	// the compute node is running an execution the requester already accepted the results of
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateRunning)
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateResultAccepted)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	select {
+	case request := <-endpoint.cancelled:
+		s.Equal(execution.ComputeReference, request.ExecutionID)
+	case <-time.After(5 * time.Second):
+		s.FailNow("execution was not cancelled on the compute node")
+	}
+	s.Equal(model.ExecutionStateFailed, s.getExecution(execution.ID()).State)
+
+	jobState, err := s.jobStore.GetJobState(s.ctx, execution.JobID)
+	s.Require().NoError(err)
+	s.Equal(model.JobStateError, jobState.State)
+}
+
+func (s *SchedulerRecoverySuite) TestCompletesExecutionWithMissedPublishCallback() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateCompleted)
+	endpoint.publishResult = model.StorageSpec{StorageSource: model.StorageSourceIPFS, CID: "QmPublished"}
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateResultAccepted)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	s.Empty(endpoint.cancelled)
+	recovered := s.getExecution(execution.ID())
+	s.Equal(model.ExecutionStateCompleted, recovered.State)
+	s.Equal("QmPublished", recovered.PublishedResult.CID)
+
+	jobState, err := s.jobStore.GetJobState(s.ctx, execution.JobID)
+	s.Require().NoError(err)
+	s.Equal(model.JobStateCompleted, jobState.State)
+}
+
+func (s *SchedulerRecoverySuite) TestSkipsJobsOwnedByOtherRequesters() {
+	endpoint := newRecoveryComputeEndpoint(store.ExecutionStateCreated)
+	execution := s.createInProgressJob("another-requester", model.ExecutionStateBidAccepted)
+
+	s.Require().NoError(s.newScheduler(endpoint).RecoverJobs(s.ctx, RecoverJobsRequest{}))
+
+	s.Empty(endpoint.statusChecks)
+	s.Equal(model.ExecutionStateBidAccepted, s.getExecution(execution.ID()).State)
+}
+
+func (s *SchedulerRecoverySuite) TestDropsCallbacksFromOtherNodes() {
+	scheduler := s.newScheduler(newRecoveryComputeEndpoint(store.ExecutionStateRunning))
+	execution := s.createInProgressJob(s.nodeID, model.ExecutionStateBidAccepted)
+	metadata := compute.ExecutionMetadata{JobID: execution.JobID, ExecutionID: execution.ComputeReference}
+
+	spoofed := compute.RoutingMetadata{SourcePeerID: "compute-" + uuid.NewString(), TargetPeerID: s.nodeID}
+	scheduler.OnRunComplete(s.ctx, compute.RunResult{RoutingMetadata: spoofed, ExecutionMetadata: metadata})
+	scheduler.OnComputeFailure(s.ctx, compute.ComputeError{RoutingMetadata: spoofed, ExecutionMetadata: metadata, Err: "spoofed"})
+	s.Equal(model.ExecutionStateBidAccepted, s.getExecution(execution.ID()).State)
+
+	owner := compute.RoutingMetadata{SourcePeerID: execution.NodeID, TargetPeerID: s.nodeID}
+	scheduler.OnComputeFailure(s.ctx, compute.ComputeError{RoutingMetadata: owner, ExecutionMetadata: metadata, Err: "failed"})
+	s.Equal(model.ExecutionStateFailed, s.getExecution(execution.ID()).State)
+}
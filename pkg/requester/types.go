@@ -2,6 +2,7 @@ package requester
 
 import (
 	"context"
+	"time"
 
 	"github.com/bacalhau-project/bacalhau/pkg/bidstrategy"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
@@ -27,8 +28,27 @@ type Scheduler interface {
 
 type Queue interface {
 	Scheduler
+	QueueInfoProvider
+	model.DebugInfoProvider
 
 	EnqueueJob(context.Context, model.Job) error
+	// NodeInfoUpdated notifies the queue that the info of a node was added or updated, so that jobs waiting for
+	// enough nodes to run them can be re-evaluated.
+	NodeInfoUpdated(context.Context, model.NodeInfo) error
+}
+
+// QueueInfoProvider reports the position of jobs waiting in the queue to start.
+type QueueInfoProvider interface {
+	// QueuePosition returns the position of the job in the queue starting at 1, or 0 if the job is not waiting.
+	QueuePosition(jobID string) int
+}
+
+// SchedulingPolicy decides the order in which jobs waiting in the queue are started, and whether they can start yet.
+type SchedulingPolicy interface {
+	// Order returns the jobs in the order they should be started.
+	Order(ctx context.Context, jobs []model.Job) ([]model.Job, error)
+	// Admit returns an error if the job should not start yet, such as when its client has reached its quota.
+	Admit(ctx context.Context, job model.Job) error
 }
 
 // NodeDiscoverer discovers nodes in the network that are suitable to execute a job.
@@ -52,6 +72,9 @@ type NodeRank struct {
 // StartJobRequest triggers the scheduling of a job.
 type StartJobRequest struct {
 	Job model.Job
+	// EnqueuedAt is when the job started waiting in the queue, for jobs put back in the queue after the requester
+	// restarted. They can only wait for the rest of the max queue time. Defaults to now.
+	EnqueuedAt time.Time
 }
 
 type CancelJobRequest struct {
@@ -4,9 +4,11 @@ import (
 	"context"
 
 	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	"github.com/rs/zerolog/log"
 )
 
 type NodeInfoProviderParams struct {
@@ -15,6 +17,7 @@ type NodeInfoProviderParams struct {
 	Labels              map[string]string
 	ComputeInfoProvider model.ComputeNodeInfoProvider
 	BacalhauVersion     model.BuildVersionInfo
+	Protocols           map[string][]string
 }
 
 type NodeInfoProvider struct {
@@ -23,6 +26,7 @@ type NodeInfoProvider struct {
 	labels              map[string]string
 	computeInfoProvider model.ComputeNodeInfoProvider
 	bacalhauVersion     model.BuildVersionInfo
+	protocols           map[string][]string
 }
 
 func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
@@ -32,6 +36,7 @@ func NewNodeInfoProvider(params NodeInfoProviderParams) *NodeInfoProvider {
 		labels:              params.Labels,
 		computeInfoProvider: params.ComputeInfoProvider,
 		bacalhauVersion:     params.BacalhauVersion,
+		protocols:           params.Protocols,
 	}
 }
 
@@ -47,7 +52,16 @@ func (n *NodeInfoProvider) GetNodeInfo(ctx context.Context) model.NodeInfo {
 			ID:    n.h.ID(),
 			Addrs: n.identityService.OwnObservedAddrs(),
 		},
-		Labels: n.labels,
+		Labels:    n.labels,
+		Protocols: n.protocols,
+	}
+	if publicKey := n.h.Peerstore().PubKey(n.h.ID()); publicKey != nil {
+		marshaledPublicKey, err := crypto.MarshalPublicKey(publicKey)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to marshal node public key")
+		} else {
+			res.PublicKey = marshaledPublicKey
+		}
 	}
 	if n.computeInfoProvider != nil {
 		info := n.computeInfoProvider.GetComputeInfo(ctx)
@@ -107,6 +107,11 @@ func (e *RequestHandler) ExecutionLogs(
 	return e.computeProxy.ExecutionLogs(ctx, request)
 }
 
+func (e *RequestHandler) ExecutionStatus(
+	ctx context.Context, request compute.ExecutionStatusRequest) (compute.ExecutionStatusResponse, error) {
+	return e.computeProxy.ExecutionStatus(ctx, request)
+}
+
 func (e *RequestHandler) OnBidComplete(ctx context.Context, result compute.BidResult) {
 	e.executionStore[result.ExecutionMetadata.ExecutionID] = result.ExecutionMetadata
 	if result.Accepted {
@@ -0,0 +1,143 @@
+package encrypted
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// SealedArchiveFilename is the name of the file holding a sealed directory, as published in place of the directory.
+const SealedArchiveFilename = "sealed.tar.gz.enc"
+
+// SealDirectory archives the directory at src, and writes the archive encrypted with key to dst. Unlike EncryptPath,
+// the names and layout of the files are not visible without the key.
+func SealDirectory(dst io.Writer, src string, key []byte) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(archiveDirectory(writer, src))
+	}()
+	err := Encrypt(dst, reader, key)
+	// unblock the archiver if encryption failed before consuming the whole archive
+	return multierr.Append(err, reader.Close())
+}
+
+// OpenDirectory decrypts an archive sealed with SealDirectory from src, and extracts it into the directory dst.
+func OpenDirectory(src io.Reader, dst string, key []byte) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(Decrypt(writer, src, key))
+	}()
+	err := extractArchive(reader, dst)
+	if err == nil {
+		// consume the rest of the data to make sure it is authentic up to the last chunk
+		_, err = io.Copy(io.Discard, reader)
+	}
+	return multierr.Append(err, reader.Close())
+}
+
+func archiveDirectory(dst io.Writer, src string) error {
+	gzipWriter := gzip.NewWriter(dst)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(src, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			// symlinks and special files are not archived, as they could point outside the directory
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relativePath)
+		if err = tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tarWriter, file)
+		return multierr.Append(err, file.Close())
+	})
+	if err != nil {
+		return err
+	}
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func extractArchive(src io.Reader, dst string) error {
+	gzipReader, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	if err = os.MkdirAll(dst, os.ModePerm); err != nil {
+		return err
+	}
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path %q in sealed archive", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+			if err = extractFile(tarReader, target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %q of type %c in sealed archive", header.Name, header.Typeflag)
+		}
+	}
+}
+
+func extractFile(src io.Reader, dst string, perm fs.FileMode) error {
+	file, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, src)
+	return multierr.Append(err, file.Close())
+}
@@ -0,0 +1,167 @@
+// Package encrypted implements client-side encryption of job inputs.
+//
+// Clients encrypt input data with a random data key, and wrap that key with
+// the public key of each compute node that is allowed to read the data. The
+// wrapped keys travel with the job in the StorageSpec, so the data is never
+// visible in clear to the storage it is published on or to the requester node.
+//
+// Compute nodes wrap their storage providers with Wrap, which transparently
+// unwraps the data key with the node's private key and decrypts the data when
+// it is prepared for the execution.
+//
+// Job secrets are encrypted the same way, and are only decrypted by executors
+// through a SecretsDecrypter right before they are injected into the execution.
+//
+// Files are encrypted with AES-256-GCM in fixed size chunks so that large
+// inputs can be streamed without holding them in memory. Each chunk is sealed
+// with a nonce derived from a random per-file prefix and the chunk index, and
+// the last chunk is authenticated as such so that truncation is detected.
+package encrypted
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// DataKeySize is the size in bytes of the data keys used to encrypt inputs
+	DataKeySize = 32
+
+	chunkSize       = 64 * 1024
+	noncePrefixSize = 8
+	formatVersion   = 1
+)
+
+// magic identifies files encrypted by this package
+var magic = []byte("BENC")
+
+var ErrNotEncrypted = errors.New("data was not encrypted with a bacalhau data key")
+
+// NewDataKey returns a new random data key
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, DataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != DataKeySize {
+		return nil, fmt.Errorf("invalid data key size %d, expected %d", len(key), DataKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(aead cipher.AEAD, prefix []byte, index uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], uint32(index))
+	return nonce
+}
+
+func chunkAdditionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// Encrypt reads plaintext from src and writes it encrypted with key to dst
+func Encrypt(dst io.Writer, src io.Reader, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	prefix := make([]byte, noncePrefixSize)
+	if _, err = io.ReadFull(rand.Reader, prefix); err != nil {
+		return err
+	}
+	header := append(append([]byte{}, magic...), formatVersion)
+	if _, err = dst.Write(append(header, prefix...)); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(src, chunkSize)
+	plaintext := make([]byte, chunkSize)
+	for index := uint64(0); ; index++ {
+		if index > math.MaxUint32 {
+			return errors.New("data is too large to encrypt")
+		}
+		n, readErr := io.ReadFull(reader, plaintext)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return readErr
+		}
+		final := readErr != nil
+		if !final {
+			// a full chunk was read, check if it was the last one
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				final = true
+			}
+		}
+		sealed := aead.Seal(nil, chunkNonce(aead, prefix, index), plaintext[:n], chunkAdditionalData(final))
+		if _, err = dst.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// Decrypt reads data encrypted with key from src and writes the plaintext to dst
+func Decrypt(dst io.Writer, src io.Reader, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, len(magic)+1+noncePrefixSize)
+	if _, err = io.ReadFull(src, header); err != nil {
+		return ErrNotEncrypted
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return ErrNotEncrypted
+	}
+	if version := header[len(magic)]; version != formatVersion {
+		return fmt.Errorf("unsupported encryption format version %d", version)
+	}
+	prefix := header[len(magic)+1:]
+
+	reader := bufio.NewReaderSize(src, chunkSize+aead.Overhead())
+	sealed := make([]byte, chunkSize+aead.Overhead())
+	for index := uint64(0); ; index++ {
+		n, readErr := io.ReadFull(reader, sealed)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return readErr
+		}
+		final := readErr != nil
+		if !final {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				final = true
+			}
+		}
+		plaintext, openErr := aead.Open(nil, chunkNonce(aead, prefix, index), sealed[:n], chunkAdditionalData(final))
+		if openErr != nil {
+			return fmt.Errorf("failed to decrypt chunk %d: %w", index, openErr)
+		}
+		if _, err = dst.Write(plaintext); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
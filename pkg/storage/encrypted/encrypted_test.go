@@ -0,0 +1,216 @@
+//go:build unit || !integration
+
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage/inline"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type EncryptedSuite struct {
+	suite.Suite
+	ctx context.Context
+	key []byte
+}
+
+func TestEncryptedSuite(t *testing.T) {
+	suite.Run(t, new(EncryptedSuite))
+}
+
+func (s *EncryptedSuite) SetupTest() {
+	s.ctx = context.Background()
+	key, err := NewDataKey()
+	s.Require().NoError(err)
+	s.key = key
+}
+
+func (s *EncryptedSuite) encrypt(plaintext []byte) []byte {
+	var buf bytes.Buffer
+	s.Require().NoError(Encrypt(&buf, bytes.NewReader(plaintext), s.key))
+	return buf.Bytes()
+}
+
+func (s *EncryptedSuite) TestRoundTrip() {
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 7} {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		s.Require().NoError(err)
+
+		ciphertext := s.encrypt(plaintext)
+		if size > 0 {
+			s.False(bytes.Contains(ciphertext, plaintext), "size %d", size)
+		}
+
+		var decrypted bytes.Buffer
+		s.Require().NoError(Decrypt(&decrypted, bytes.NewReader(ciphertext), s.key), "size %d", size)
+		s.True(bytes.Equal(plaintext, decrypted.Bytes()), "size %d", size)
+	}
+}
+
+func (s *EncryptedSuite) TestDecryptWrongKey() {
+	ciphertext := s.encrypt([]byte("hello"))
+	otherKey, err := NewDataKey()
+	s.Require().NoError(err)
+	s.Error(Decrypt(&bytes.Buffer{}, bytes.NewReader(ciphertext), otherKey))
+}
+
+func (s *EncryptedSuite) TestDecryptTampered() {
+	ciphertext := s.encrypt([]byte("hello"))
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	s.Error(Decrypt(&bytes.Buffer{}, bytes.NewReader(ciphertext), s.key))
+}
+
+func (s *EncryptedSuite) TestDecryptTruncated() {
+	plaintext := make([]byte, 2*chunkSize+10)
+	ciphertext := s.encrypt(plaintext)
+
+	// drop the last chunk, so that the remaining data ends on a chunk boundary
+	headerSize := len(magic) + 1 + noncePrefixSize
+	truncated := ciphertext[:headerSize+2*(chunkSize+16)]
+	s.Error(Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated), s.key))
+}
+
+func (s *EncryptedSuite) TestDecryptNotEncrypted() {
+	err := Decrypt(&bytes.Buffer{}, bytes.NewReader([]byte("plain text data")), s.key)
+	s.ErrorIs(err, ErrNotEncrypted)
+}
+
+func (s *EncryptedSuite) TestPathRoundTrip() {
+	src := s.T().TempDir()
+	s.Require().NoError(os.MkdirAll(filepath.Join(src, "nested"), 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(src, "a.txt"), []byte("file a"), 0644))
+	s.Require().NoError(os.WriteFile(filepath.Join(src, "nested", "b.txt"), []byte("file b"), 0644))
+
+	encryptedDir := filepath.Join(s.T().TempDir(), "encrypted")
+	s.Require().NoError(EncryptPath(src, encryptedDir, s.key))
+	content, err := os.ReadFile(filepath.Join(encryptedDir, "nested", "b.txt"))
+	s.Require().NoError(err)
+	s.NotContains(string(content), "file b")
+
+	decryptedDir := filepath.Join(s.T().TempDir(), "decrypted")
+	s.Require().NoError(DecryptPath(encryptedDir, decryptedDir, s.key))
+	s.requireFileContent(filepath.Join(decryptedDir, "a.txt"), "file a")
+	s.requireFileContent(filepath.Join(decryptedDir, "nested", "b.txt"), "file b")
+}
+
+func (s *EncryptedSuite) requireFileContent(path, expected string) {
+	content, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Equal(expected, string(content))
+}
+
+func (s *EncryptedSuite) TestStorageDecryptsInputs() {
+	privateKey, publicKey, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	s.Require().NoError(err)
+	nodeID, err := peer.IDFromPublicKey(publicKey)
+	s.Require().NoError(err)
+	marshaledPublicKey, err := crypto.MarshalPublicKey(publicKey)
+	s.Require().NoError(err)
+	encrypter := verifier.NewEncrypter(privateKey)
+
+	// encrypt a file on the client, and send it inline
+	plaintextPath := filepath.Join(s.T().TempDir(), "secret.txt")
+	s.Require().NoError(os.WriteFile(plaintextPath, []byte("top secret"), 0644))
+	encryptedPath := filepath.Join(s.T().TempDir(), "secret.txt")
+	s.Require().NoError(EncryptPath(plaintextPath, encryptedPath, s.key))
+
+	inlineStorage := inline.NewStorage()
+	spec, err := inlineStorage.Upload(s.ctx, encryptedPath)
+	s.Require().NoError(err)
+	spec.Path = "/inputs/secret.txt"
+	spec.Encryption, err = WrapDataKey(s.ctx, encrypter.Encrypt, s.key, []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: nodeID}, PublicKey: marshaledPublicKey},
+	})
+	s.Require().NoError(err)
+
+	// prepare it on the compute node
+	decryptingStorage := Wrap(inlineStorage, StorageParams{NodeID: nodeID.String(), Decrypter: encrypter.Decrypt})
+	volume, err := decryptingStorage.PrepareStorage(s.ctx, spec)
+	s.Require().NoError(err)
+	s.Equal(spec.Path, volume.Target)
+	s.requireFileContent(volume.Source, "top secret")
+
+	s.Require().NoError(decryptingStorage.CleanupStorage(s.ctx, spec, volume))
+	s.NoFileExists(volume.Source)
+
+	// another node can't decrypt it
+	otherStorage := Wrap(inlineStorage, StorageParams{NodeID: "other-node", Decrypter: encrypter.Decrypt})
+	_, err = otherStorage.PrepareStorage(s.ctx, spec)
+	s.Error(err)
+}
+
+func TestWrapDataKeyRequiresPublicKey(t *testing.T) {
+	_, err := WrapDataKey(context.Background(), verifier.NewEncrypter(nil).Encrypt, []byte("key"), []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: peer.ID("node")}},
+	})
+	require.Error(t, err)
+}
+
+func (s *EncryptedSuite) TestSealDirectoryRoundTrip() {
+	src := s.T().TempDir()
+	s.Require().NoError(os.MkdirAll(filepath.Join(src, "outputs", "empty"), 0755))
+	s.Require().NoError(os.WriteFile(filepath.Join(src, "stdout"), []byte("hello"), 0644))
+	s.Require().NoError(os.WriteFile(filepath.Join(src, "outputs", "result.txt"), []byte("result"), 0644))
+
+	var sealed bytes.Buffer
+	s.Require().NoError(SealDirectory(&sealed, src, s.key))
+	s.False(bytes.Contains(sealed.Bytes(), []byte("result.txt")))
+
+	dst := filepath.Join(s.T().TempDir(), "opened")
+	s.Require().NoError(OpenDirectory(bytes.NewReader(sealed.Bytes()), dst, s.key))
+	s.requireFileContent(filepath.Join(dst, "stdout"), "hello")
+	s.requireFileContent(filepath.Join(dst, "outputs", "result.txt"), "result")
+	s.DirExists(filepath.Join(dst, "outputs", "empty"))
+
+	otherKey, err := NewDataKey()
+	s.Require().NoError(err)
+	s.Error(OpenDirectory(bytes.NewReader(sealed.Bytes()), filepath.Join(s.T().TempDir(), "other"), otherKey))
+}
+
+func (s *EncryptedSuite) TestSecretsRoundTrip() {
+	privateKey, publicKey, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	s.Require().NoError(err)
+	nodeID, err := peer.IDFromPublicKey(publicKey)
+	s.Require().NoError(err)
+	marshaledPublicKey, err := crypto.MarshalPublicKey(publicKey)
+	s.Require().NoError(err)
+	encrypter := verifier.NewEncrypter(privateKey)
+
+	encryption, err := WrapDataKey(s.ctx, encrypter.Encrypt, s.key, []model.NodeInfo{
+		{PeerInfo: peer.AddrInfo{ID: nodeID}, PublicKey: marshaledPublicKey},
+	})
+	s.Require().NoError(err)
+	secrets, err := EncryptSecrets(map[string]string{"TOKEN": "top secret", "EMPTY": ""}, s.key, encryption)
+	s.Require().NoError(err)
+	s.NotContains(string(secrets.Values["TOKEN"]), "top secret")
+
+	decrypter := NewSecretsDecrypter(StorageParams{NodeID: nodeID.String(), Decrypter: encrypter.Decrypt})
+	values, err := decrypter.Decrypt(s.ctx, secrets)
+	s.Require().NoError(err)
+	s.Equal(map[string]string{"TOKEN": "top secret", "EMPTY": ""}, values)
+
+	// another node can't decrypt them
+	other := NewSecretsDecrypter(StorageParams{NodeID: "other-node", Decrypter: encrypter.Decrypt})
+	_, err = other.Decrypt(s.ctx, secrets)
+	s.Error(err)
+
+	// nodes without a decrypter can only run jobs without secrets
+	var unsupported *SecretsDecrypter
+	_, err = unsupported.Decrypt(s.ctx, secrets)
+	s.Error(err)
+	values, err = unsupported.Decrypt(s.ctx, nil)
+	s.NoError(err)
+	s.Empty(values)
+}
@@ -0,0 +1,55 @@
+package encrypted
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+)
+
+// WrapDataKey wraps the data key for each of the given compute nodes using their published public key, and returns
+// the encryption spec to attach to the encrypted storage specs.
+func WrapDataKey(
+	ctx context.Context,
+	encrypter verifier.EncrypterFunction,
+	key []byte,
+	nodes []model.NodeInfo,
+) (*model.EncryptionSpec, error) {
+	spec := &model.EncryptionSpec{
+		Algorithm:   model.EncryptionAlgorithmAES256GCM,
+		WrappedKeys: make(map[string][]byte, len(nodes)),
+	}
+	for _, node := range nodes {
+		if len(node.PublicKey) == 0 {
+			return nil, fmt.Errorf("node %s did not publish a public key", node.PeerInfo.ID)
+		}
+		wrappedKey, err := encrypter(ctx, key, node.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wrap data key for node %s: %w", node.PeerInfo.ID, err)
+		}
+		spec.WrappedKeys[node.PeerInfo.ID.String()] = wrappedKey
+	}
+	return spec, nil
+}
+
+// UnwrapDataKey returns the data key wrapped for nodeID, decrypted with the node's private key.
+func UnwrapDataKey(
+	ctx context.Context,
+	decrypter verifier.DecrypterFunction,
+	spec *model.EncryptionSpec,
+	nodeID string,
+) ([]byte, error) {
+	if spec.Algorithm != model.EncryptionAlgorithmAES256GCM {
+		return nil, fmt.Errorf("unsupported encryption algorithm %q", spec.Algorithm)
+	}
+	wrappedKey, ok := spec.WrappedKeys[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("data key was not wrapped for node %s", nodeID)
+	}
+	key, err := decrypter(ctx, wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return key, nil
+}
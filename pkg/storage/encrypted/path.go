@@ -0,0 +1,66 @@
+package encrypted
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/multierr"
+)
+
+type transformFunc func(dst io.Writer, src io.Reader, key []byte) error
+
+// EncryptPath encrypts the file or directory at src into dst with the data key. Directories are encrypted file by
+// file, keeping their structure so that they can be mounted as is once decrypted.
+func EncryptPath(src, dst string, key []byte) error {
+	return transformPath(src, dst, key, Encrypt)
+}
+
+// DecryptPath decrypts the file or directory at src that was encrypted with EncryptPath into dst.
+func DecryptPath(src, dst string, key []byte) error {
+	return transformPath(src, dst, key, Decrypt)
+}
+
+func transformPath(src, dst string, key []byte, transform transformFunc) error {
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relativePath)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode().IsRegular():
+			return transformFile(path, target, info.Mode().Perm(), key, transform)
+		default:
+			// symlinks and special files are not carried over, as they would point outside the encrypted data
+			return nil
+		}
+	})
+}
+
+func transformFile(src, dst string, perm fs.FileMode, key []byte, transform transformFunc) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, in.Close()) }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() { err = multierr.Append(err, out.Close()) }()
+
+	return transform(out, in, key)
+}
@@ -0,0 +1,69 @@
+package encrypted
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+)
+
+// EncryptSecrets encrypts the value of each secret with the data key, and returns the secrets spec to attach to the
+// job. The encryption spec holds the data key wrapped for the compute nodes allowed to run the job.
+func EncryptSecrets(secrets map[string]string, key []byte, encryption *model.EncryptionSpec) (*model.SecretsSpec, error) {
+	spec := &model.SecretsSpec{
+		Values:     make(map[string][]byte, len(secrets)),
+		Encryption: encryption,
+	}
+	for name, value := range secrets {
+		var buf bytes.Buffer
+		if err := Encrypt(&buf, strings.NewReader(value), key); err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret %s: %w", name, err)
+		}
+		spec.Values[name] = buf.Bytes()
+	}
+	return spec, nil
+}
+
+// SecretsDecrypter decrypts the secrets of jobs encrypted for a compute node, so that executors can inject them into
+// the execution. A nil SecretsDecrypter can't decrypt secrets.
+type SecretsDecrypter struct {
+	nodeID    string
+	decrypter verifier.DecrypterFunction
+}
+
+func NewSecretsDecrypter(params StorageParams) *SecretsDecrypter {
+	return &SecretsDecrypter{
+		nodeID:    params.NodeID,
+		decrypter: params.Decrypter,
+	}
+}
+
+// Decrypt returns the values of the secrets in clear, keyed by name. The values must not be logged or stored.
+func (d *SecretsDecrypter) Decrypt(ctx context.Context, secrets *model.SecretsSpec) (map[string]string, error) {
+	if secrets == nil || len(secrets.Values) == 0 {
+		return nil, nil
+	}
+	if d == nil || d.decrypter == nil {
+		return nil, fmt.Errorf("secrets are not supported by this node")
+	}
+	if secrets.Encryption == nil {
+		return nil, fmt.Errorf("secrets were not encrypted")
+	}
+
+	key, err := UnwrapDataKey(ctx, d.decrypter, secrets.Encryption, d.nodeID)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(secrets.Values))
+	for name, ciphertext := range secrets.Values {
+		var buf bytes.Buffer
+		if err = Decrypt(&buf, bytes.NewReader(ciphertext), key); err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", name, err)
+		}
+		values[name] = buf.String()
+	}
+	return values, nil
+}
@@ -0,0 +1,112 @@
+package encrypted
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/storage"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/rs/zerolog/log"
+)
+
+type StorageParams struct {
+	// NodeID of the compute node, used to find the data key wrapped for this node
+	NodeID string
+	// Decrypter unwraps data keys using the private key of the compute node
+	Decrypter verifier.DecrypterFunction
+}
+
+// decryptingStorage decrypts the data of encrypted storage specs after it was prepared by the delegate, and exposes
+// the decrypted copy to the execution instead. Storage specs that are not encrypted are passed through as is.
+type decryptingStorage struct {
+	delegate  storage.Storage
+	nodeID    string
+	decrypter verifier.DecrypterFunction
+}
+
+func Wrap(delegate storage.Storage, params StorageParams) storage.Storage {
+	return &decryptingStorage{
+		delegate:  delegate,
+		nodeID:    params.NodeID,
+		decrypter: params.Decrypter,
+	}
+}
+
+// WrapProvider wraps all the storages of the provider with Wrap
+func WrapProvider(
+	storages map[model.StorageSourceType]storage.Storage,
+	params StorageParams,
+) map[model.StorageSourceType]storage.Storage {
+	wrapped := make(map[model.StorageSourceType]storage.Storage, len(storages))
+	for sourceType, delegate := range storages {
+		wrapped[sourceType] = Wrap(delegate, params)
+	}
+	return wrapped
+}
+
+func (d *decryptingStorage) IsInstalled(ctx context.Context) (bool, error) {
+	return d.delegate.IsInstalled(ctx)
+}
+
+func (d *decryptingStorage) HasStorageLocally(ctx context.Context, spec model.StorageSpec) (bool, error) {
+	return d.delegate.HasStorageLocally(ctx, spec)
+}
+
+func (d *decryptingStorage) GetVolumeSize(ctx context.Context, spec model.StorageSpec) (uint64, error) {
+	return d.delegate.GetVolumeSize(ctx, spec)
+}
+
+func (d *decryptingStorage) PrepareStorage(ctx context.Context, spec model.StorageSpec) (storage.StorageVolume, error) {
+	if spec.Encryption == nil {
+		return d.delegate.PrepareStorage(ctx, spec)
+	}
+
+	// unwrap the key first to avoid fetching data we can't decrypt
+	key, err := UnwrapDataKey(ctx, d.decrypter, spec.Encryption, d.nodeID)
+	if err != nil {
+		return storage.StorageVolume{}, err
+	}
+
+	encryptedVolume, err := d.delegate.PrepareStorage(ctx, spec)
+	if err != nil {
+		return storage.StorageVolume{}, err
+	}
+	// the encrypted copy is no longer needed once decrypted
+	defer func() {
+		if cleanupErr := d.delegate.CleanupStorage(ctx, spec, encryptedVolume); cleanupErr != nil {
+			log.Ctx(ctx).Warn().Err(cleanupErr).Msgf("failed to cleanup encrypted storage %s", encryptedVolume.Source)
+		}
+	}()
+
+	dir, err := os.MkdirTemp(os.TempDir(), "encrypted-storage")
+	if err != nil {
+		return storage.StorageVolume{}, err
+	}
+	target := filepath.Join(dir, filepath.Base(encryptedVolume.Source))
+	if err = DecryptPath(encryptedVolume.Source, target, key); err != nil {
+		_ = os.RemoveAll(dir)
+		return storage.StorageVolume{}, fmt.Errorf("failed to decrypt storage %s: %w", spec.Name, err)
+	}
+
+	return storage.StorageVolume{
+		Type:   encryptedVolume.Type,
+		Source: target,
+		Target: encryptedVolume.Target,
+	}, nil
+}
+
+func (d *decryptingStorage) CleanupStorage(ctx context.Context, spec model.StorageSpec, volume storage.StorageVolume) error {
+	if spec.Encryption == nil {
+		return d.delegate.CleanupStorage(ctx, spec, volume)
+	}
+	return os.RemoveAll(filepath.Dir(volume.Source))
+}
+
+func (d *decryptingStorage) Upload(ctx context.Context, path string) (model.StorageSpec, error) {
+	return d.delegate.Upload(ctx, path)
+}
+
+var _ storage.Storage = (*decryptingStorage)(nil)
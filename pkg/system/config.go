@@ -159,6 +159,28 @@ func Verify(msg []byte, sig, publicKey string) error {
 	return rsa.VerifyPKCS1v15(key, sigHash, hashBytes, sigBytes)
 }
 
+// EncryptForClient encrypts a small message, such as a data key, so that only
+// the client owning the given base64-encoded public key can decrypt it.
+func EncryptForClient(msg []byte, publicKey string) ([]byte, error) {
+	key, err := decodePublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	return rsa.EncryptOAEP(sigHash.New(), rand.Reader, key, msg, nil)
+}
+
+// DecryptForClient decrypts a message encrypted by EncryptForClient with the
+// user's private ID key.
+// NOTE: must be called after InitConfig() or system will panic.
+func DecryptForClient(msg []byte) ([]byte, error) {
+	if globalUserIDKey == nil {
+		panic("must call InitConfig() before calling DecryptForClient()")
+	}
+
+	return rsa.DecryptOAEP(sigHash.New(), rand.Reader, globalUserIDKey, msg, nil)
+}
+
 // GetClientID returns a hash identifying a user based on their ID key.
 // NOTE: must be called after InitConfig() or system will panic.
 func GetClientID() string {
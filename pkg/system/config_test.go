@@ -48,6 +48,19 @@ func (s *SystemConfigSuite) TestMessageSigning() {
 	s.NoError(err)
 }
 
+func (s *SystemConfigSuite) TestClientEncryption() {
+	InitConfigForTesting(s.T())
+
+	msg := []byte("data key")
+	ciphertext, err := EncryptForClient(msg, GetClientPublicKey())
+	s.Require().NoError(err)
+	s.NotEqual(msg, ciphertext)
+
+	plaintext, err := DecryptForClient(ciphertext)
+	s.Require().NoError(err)
+	s.Equal(msg, plaintext)
+}
+
 func (s *SystemConfigSuite) TestGetClientID() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -0,0 +1,49 @@
+package telemetry
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Metrics for the resources actually consumed by executions:
+var (
+	usageMeter         = global.MeterProvider().Meter("execution_usage")
+	usageCPUSeconds, _ = usageMeter.Float64Counter(
+		"execution_cpu_seconds",
+		instrument.WithDescription("CPU time in seconds consumed by executions"),
+	)
+
+	usagePeakMemory, _ = usageMeter.Int64Histogram(
+		"execution_peak_memory_bytes",
+		instrument.WithDescription("Peak memory in bytes used by executions"),
+	)
+
+	usageDiskWritten, _ = usageMeter.Int64Counter(
+		"execution_disk_written_bytes",
+		instrument.WithDescription("Bytes written by executions to their output volumes"),
+	)
+
+	usageNetworkRx, _ = usageMeter.Int64Counter(
+		"execution_network_received_bytes",
+		instrument.WithDescription("Bytes received over the network by executions"),
+	)
+
+	usageNetworkTx, _ = usageMeter.Int64Counter(
+		"execution_network_sent_bytes",
+		instrument.WithDescription("Bytes sent over the network by executions"),
+	)
+)
+
+// RecordResourceUsage emits the resources consumed by an execution as metrics, labelled with the engine that ran it.
+func RecordResourceUsage(ctx context.Context, engine model.Engine, usage model.ResourceUsageMetrics) {
+	attrs := []attribute.KeyValue{attribute.String("engine", engine.String())}
+	usageCPUSeconds.Add(ctx, usage.CPUSeconds, attrs...)
+	usagePeakMemory.Record(ctx, int64(usage.PeakMemory), attrs...)
+	usageDiskWritten.Add(ctx, int64(usage.DiskWritten), attrs...)
+	usageNetworkRx.Add(ctx, int64(usage.NetworkRxBytes), attrs...)
+	usageNetworkTx.Add(ctx, int64(usage.NetworkTxBytes), attrs...)
+}
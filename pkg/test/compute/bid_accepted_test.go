@@ -25,15 +25,32 @@ func (s *BidAcceptedSuite) TestBidAccepted() {
 	ctx := context.Background()
 	executionID := s.prepareAndAskForBid(ctx, generateJob())
 
-	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: executionID})
+	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{RoutingMetadata: s.routingMetadata(), ExecutionID: executionID})
 	s.NoError(err)
 	err = s.stateResolver.Wait(ctx, executionID, resolver.CheckForState(store.ExecutionStateWaitingVerification))
 	s.NoError(err)
 }
 
+func (s *BidAcceptedSuite) TestOtherRequester() {
+	ctx := context.Background()
+	executionID := s.prepareAndAskForBid(ctx, generateJob())
+
+	routingMetadata := s.routingMetadata()
+	routingMetadata.SourcePeerID = "other-requester"
+	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{RoutingMetadata: routingMetadata, ExecutionID: executionID})
+	s.ErrorIs(err, compute.NewErrExecutionRequesterMismatch(executionID, s.node.ID, "other-requester"))
+
+	execution, err := s.node.ExecutionStore.GetExecution(ctx, executionID)
+	s.NoError(err)
+	s.Equal(store.ExecutionStateCreated, execution.State)
+}
+
 func (s *BidAcceptedSuite) TestDoesntExist() {
 	ctx := context.Background()
-	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: uuid.NewString()})
+	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{
+		RoutingMetadata: s.routingMetadata(),
+		ExecutionID:     uuid.NewString(),
+	})
 	s.Error(err)
 }
 
@@ -54,7 +71,7 @@ func (s *BidAcceptedSuite) TestWrongState() {
 		})
 		s.NoError(err)
 
-		_, err = s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: executionID})
+		_, err = s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{RoutingMetadata: s.routingMetadata(), ExecutionID: executionID})
 		s.Error(err)
 	}
 }
@@ -25,7 +25,7 @@ func (s *BidRejectedSuite) TestBidRejected() {
 	ctx := context.Background()
 	executionID := s.prepareAndAskForBid(ctx, generateJob())
 
-	_, err := s.node.LocalEndpoint.BidRejected(ctx, compute.BidRejectedRequest{ExecutionID: executionID})
+	_, err := s.node.LocalEndpoint.BidRejected(ctx, compute.BidRejectedRequest{RoutingMetadata: s.routingMetadata(), ExecutionID: executionID})
 	s.NoError(err)
 	err = s.stateResolver.Wait(ctx, executionID, resolver.CheckForState(store.ExecutionStateCancelled))
 	s.NoError(err)
@@ -33,7 +33,10 @@ func (s *BidRejectedSuite) TestBidRejected() {
 
 func (s *BidRejectedSuite) TestDoesntExist() {
 	ctx := context.Background()
-	_, err := s.node.LocalEndpoint.BidRejected(ctx, compute.BidRejectedRequest{ExecutionID: uuid.NewString()})
+	_, err := s.node.LocalEndpoint.BidRejected(ctx, compute.BidRejectedRequest{
+		RoutingMetadata: s.routingMetadata(),
+		ExecutionID:     uuid.NewString(),
+	})
 	s.Error(err)
 }
 
@@ -54,7 +57,7 @@ func (s *BidRejectedSuite) TestWrongState() {
 		})
 		s.NoError(err)
 
-		_, err = s.node.LocalEndpoint.BidRejected(ctx, compute.BidRejectedRequest{ExecutionID: executionID})
+		_, err = s.node.LocalEndpoint.BidRejected(ctx, compute.BidRejectedRequest{RoutingMetadata: s.routingMetadata(), ExecutionID: executionID})
 		s.Error(err)
 	}
 }
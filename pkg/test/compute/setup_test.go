@@ -20,6 +20,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/storage"
 	noop_storage "github.com/bacalhau-project/bacalhau/pkg/storage/noop"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/transport/bprotocol"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
 	noop_verifier "github.com/bacalhau-project/bacalhau/pkg/verifier/noop"
 	"github.com/google/uuid"
@@ -90,6 +91,7 @@ func (s *ComputeSuite) setupNode() {
 		model.NewNoopProvider[model.Engine, executor.Executor](s.executor),
 		model.NewNoopProvider[model.Verifier, verifier.Verifier](s.verifier),
 		model.NewNoopProvider[model.Publisher, publisher.Publisher](s.publisher),
+		bprotocol.SupportedVersions,
 	)
 	s.NoError(err)
 	s.stateResolver = *resolver.NewStateResolver(resolver.StateResolverParams{
@@ -104,17 +106,22 @@ func (s *ComputeSuite) setupNode() {
 	s.T().Cleanup(func() { close(s.bidChannel) })
 }
 
+// routingMetadata returns the routing metadata of requests sent by the requester node of the test executions.
+func (s *ComputeSuite) routingMetadata() compute.RoutingMetadata {
+	return compute.RoutingMetadata{
+		TargetPeerID: s.node.ID,
+		SourcePeerID: s.node.ID,
+	}
+}
+
 func (s *ComputeSuite) askForBid(ctx context.Context, job model.Job) compute.BidResult {
 	_, err := s.node.LocalEndpoint.AskForBid(ctx, compute.AskForBidRequest{
 		ExecutionMetadata: compute.ExecutionMetadata{
 			JobID:       job.Metadata.ID,
 			ExecutionID: uuid.NewString(),
 		},
-		RoutingMetadata: compute.RoutingMetadata{
-			TargetPeerID: s.node.ID,
-			SourcePeerID: s.node.ID,
-		},
-		Job: job,
+		RoutingMetadata: s.routingMetadata(),
+		Job:             job,
 	})
 	s.NoError(err)
 
@@ -137,7 +144,7 @@ func (s *ComputeSuite) prepareAndRun(ctx context.Context, job model.Job) string
 	executionID := s.prepareAndAskForBid(ctx, job)
 
 	// run the job
-	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{ExecutionID: executionID})
+	_, err := s.node.LocalEndpoint.BidAccepted(ctx, compute.BidAcceptedRequest{RoutingMetadata: s.routingMetadata(), ExecutionID: executionID})
 	s.NoError(err)
 	err = s.stateResolver.Wait(ctx, executionID, resolver.CheckForState(store.ExecutionStateWaitingVerification))
 	s.NoError(err)
@@ -0,0 +1,97 @@
+//go:build integration || !unit
+
+package devstack
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bacalhau-project/bacalhau/pkg/devstack"
+	noop_executor "github.com/bacalhau-project/bacalhau/pkg/executor/noop"
+	"github.com/bacalhau-project/bacalhau/pkg/job"
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/node"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
+	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
+	"github.com/bacalhau-project/bacalhau/pkg/transport/bprotocol"
+	"github.com/stretchr/testify/suite"
+)
+
+// ProtocolVersionsSuite runs jobs between requester and compute nodes speaking different sets of
+// protocol versions, as happens in a fleet being upgraded one node at a time.
+type ProtocolVersionsSuite struct {
+	suite.Suite
+}
+
+func TestProtocolVersionsSuite(t *testing.T) {
+	suite.Run(t, new(ProtocolVersionsSuite))
+}
+
+type protocolVersionsTestCase struct {
+	requester  []string
+	compute    []string
+	compatible bool
+}
+
+func (s *ProtocolVersionsSuite) TestCompatibilityMatrix() {
+	legacy := []string{bprotocol.Version1_0_0}
+	latest := []string{bprotocol.Version1_2_0}
+	testCases := []protocolVersionsTestCase{
+		{requester: bprotocol.SupportedVersions, compute: bprotocol.SupportedVersions, compatible: true},
+		{requester: bprotocol.SupportedVersions, compute: legacy, compatible: true},
+		{requester: legacy, compute: bprotocol.SupportedVersions, compatible: true},
+		{requester: bprotocol.SupportedVersions, compute: latest, compatible: true},
+		{requester: latest, compute: bprotocol.SupportedVersions, compatible: true},
+		{requester: legacy, compute: legacy, compatible: true},
+		{requester: latest, compute: legacy, compatible: false},
+		{requester: legacy, compute: latest, compatible: false},
+	}
+	for _, tc := range testCases {
+		s.Run(fmt.Sprintf("requester%v/compute%v", tc.requester, tc.compute), func() {
+			s.runJob(tc)
+		})
+	}
+}
+
+func (s *ProtocolVersionsSuite) runJob(tc protocolVersionsTestCase) {
+	ctx := context.Background()
+	nodeOverrides := []node.NodeConfig{
+		{ProtocolVersions: tc.requester},
+		{ProtocolVersions: tc.compute},
+	}
+	for i := range nodeOverrides {
+		nodeOverrides[i].NodeInfoPublisherInterval = 100 * time.Millisecond
+	}
+	stack := testutils.SetupTestWithNoopExecutor(ctx, s.T(),
+		devstack.DevStackOptions{NumberOfRequesterOnlyNodes: 1, NumberOfComputeOnlyNodes: 1},
+		node.NewComputeConfigWithDefaults(),
+		node.NewRequesterConfigWithDefaults(),
+		noop_executor.ExecutorConfig{},
+		nodeOverrides...,
+	)
+	requester := stack.Nodes[0]
+	testutils.WaitForNodeDiscovery(s.T(), requester, 2)
+
+	client := publicapi.NewRequesterAPIClient(requester.APIServer.Address, requester.APIServer.Port)
+	submittedJob, err := client.Submit(ctx, testutils.MakeNoopJob())
+	if !tc.compatible {
+		s.Error(err)
+		return
+	}
+	s.Require().NoError(err)
+
+	stateResolver := job.NewStateResolver(
+		func(ctx context.Context, id string) (model.Job, error) {
+			return requester.RequesterNode.JobStore.GetJob(ctx, id)
+		},
+		func(ctx context.Context, id string) (model.JobState, error) {
+			return requester.RequesterNode.JobStore.GetJobState(ctx, id)
+		},
+	)
+	s.Require().NoError(stateResolver.WaitUntilComplete(ctx, submittedJob.Metadata.ID))
+	jobState, err := stateResolver.GetJobState(ctx, submittedJob.Metadata.ID)
+	s.Require().NoError(err)
+	s.Len(job.GetCompletedExecutionStates(jobState), 1)
+}
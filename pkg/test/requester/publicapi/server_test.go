@@ -6,9 +6,11 @@ import (
 	"context"
 	"testing"
 
+	"github.com/bacalhau-project/bacalhau/pkg/jobstore"
 	"github.com/bacalhau-project/bacalhau/pkg/logger"
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/node"
+	"github.com/bacalhau-project/bacalhau/pkg/requester/moderation"
 	requester_publicapi "github.com/bacalhau-project/bacalhau/pkg/requester/publicapi"
 	testutils "github.com/bacalhau-project/bacalhau/pkg/test/utils"
 	"github.com/google/uuid"
@@ -64,6 +66,34 @@ func (s *ServerSuite) TestList() {
 	require.Len(s.T(), jobs, 1)
 }
 
+func (s *ServerSuite) TestModerationRedactsSecrets() {
+	ctx := context.Background()
+	jobStore := s.node.RequesterNode.JobStore
+	j := testutils.MakeNoopJob()
+	j.Metadata.ID = uuid.NewString()
+	j.Spec.Secrets = &model.SecretsSpec{
+		Values:     map[string][]byte{"TOKEN": []byte("ciphertext")},
+		Encryption: &model.EncryptionSpec{WrappedKeys: map[string][]byte{"node": []byte("wrapped key")}},
+	}
+	require.NoError(s.T(), jobStore.CreateJob(ctx, *j))
+	require.NoError(s.T(), jobStore.UpdateJobState(ctx, jobstore.UpdateJobStateRequest{
+		JobID:    j.ID(),
+		NewState: model.JobStateQueued,
+	}))
+	moderator := moderation.NewModerator(moderation.ModeratorParams{JobStore: jobStore})
+	require.NoError(s.T(), moderator.Hold(ctx, j.ID(), "needs approval"))
+
+	redacted := &model.SecretsSpec{Values: map[string][]byte{"TOKEN": nil}}
+	info, err := s.client.GetModeration(ctx, j.ID())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), redacted, info.Job.Spec.Secrets)
+
+	pending, err := s.client.PendingModeration(ctx)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), pending, 1)
+	require.Equal(s.T(), redacted, pending[0].Job.Spec.Secrets)
+}
+
 func (s *ServerSuite) TestSubmitRejectsJobWithSigilHeader() {
 	j := testutils.MakeNoopJob()
 	jobID, err := uuid.NewRandom()
@@ -0,0 +1,61 @@
+package bprotocol
+
+import (
+	"context"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/exp/slices"
+)
+
+// routable is implemented by requests and results that embed compute.RoutingMetadata.
+type routable interface {
+	Routing() compute.RoutingMetadata
+}
+
+// authenticator signs the messages sent by the host, and checks that messages received by the host are signed
+// by, and claim to come from, the peer that sent them.
+type authenticator struct {
+	host host.Host
+	// trustedPeers can relay messages on behalf of other peers, such as the simulator node
+	trustedPeers []string
+}
+
+func newAuthenticator(host host.Host, trustedPeers []string) authenticator {
+	return authenticator{host: host, trustedPeers: trustedPeers}
+}
+
+// privateKey returns the key messages sent by the host are signed with.
+func (a authenticator) privateKey() crypto.PrivKey {
+	return a.host.Peerstore().PrivKey(a.host.ID())
+}
+
+// encode serializes a message sent by the host over the stream.
+func (a authenticator) encode(stream network.Stream, message interface{}) ([]byte, error) {
+	return encodeMessage(protocolVersion(stream.Protocol()), a.privateKey(), message)
+}
+
+// decode reads a message received by the host over the stream, and checks it was sent by the remote peer.
+func (a authenticator) decode(ctx context.Context, stream network.Stream, message interface{}) error {
+	remotePeer := stream.Conn().RemotePeer()
+	err := decodeMessage(protocolVersion(stream.Protocol()), remotePeer, stream.Conn().RemotePublicKey(), stream, message)
+	if err == nil {
+		if r, ok := message.(routable); ok {
+			sourcePeerID := r.Routing().SourcePeerID
+			if sourcePeerID != remotePeer.String() && !slices.Contains(a.trustedPeers, remotePeer.String()) {
+				err = NewErrSourcePeerMismatch(sourcePeerID, remotePeer.String())
+			}
+		}
+	}
+
+	switch err.(type) {
+	case ErrInvalidSignature, ErrSourcePeerMismatch:
+		messagesRejected.Add(ctx, 1, attribute.String("protocol", string(stream.Protocol())))
+		log.Ctx(ctx).Warn().Err(err).Msgf("rejecting message received over %s", stream.Protocol())
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+//go:build unit || !integration
+
+package bprotocol
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/libp2p"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSpoofedSourcePeer checks that peers can't send requests on behalf of other peers, unless they are trusted.
+func TestSpoofedSourcePeer(t *testing.T) {
+	for _, version := range SupportedVersions {
+		t.Run(version, func(t *testing.T) {
+			ctx := context.Background()
+			computeNode, err := libp2p.NewHostForTest(ctx)
+			require.NoError(t, err)
+			defer computeNode.Close()
+			requesterNode, err := libp2p.NewHostForTest(ctx, computeNode)
+			require.NoError(t, err)
+			defer requesterNode.Close()
+			spoofingNode, err := libp2p.NewHostForTest(ctx, computeNode)
+			require.NoError(t, err)
+			defer spoofingNode.Close()
+
+			NewComputeHandler(ComputeHandlerParams{
+				Host:            computeNode,
+				ComputeEndpoint: &TestEndpoint{},
+				Versions:        []string{version},
+			})
+			request := compute.BidAcceptedRequest{
+				RoutingMetadata: compute.RoutingMetadata{
+					SourcePeerID: requesterNode.ID().String(),
+					TargetPeerID: computeNode.ID().String(),
+				},
+			}
+
+			_, err = NewComputeProxy(ComputeProxyParams{Host: requesterNode, Versions: []string{version}}).BidAccepted(ctx, request)
+			require.NoError(t, err)
+
+			_, err = NewComputeProxy(ComputeProxyParams{Host: spoofingNode, Versions: []string{version}}).BidAccepted(ctx, request)
+			require.EqualError(t, err, NewErrSourcePeerMismatch(requesterNode.ID().String(), spoofingNode.ID().String()).Error())
+
+			// trusted peers can relay requests of other peers
+			NewComputeHandler(ComputeHandlerParams{
+				Host:            computeNode,
+				ComputeEndpoint: &TestEndpoint{},
+				Versions:        []string{version},
+				TrustedPeers:    []string{spoofingNode.ID().String()},
+			})
+			_, err = NewComputeProxy(ComputeProxyParams{Host: spoofingNode, Versions: []string{version}}).BidAccepted(ctx, request)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package bprotocol
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
@@ -10,12 +9,17 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/rs/zerolog/log"
 )
 
 type CallbackHandlerParams struct {
 	Host     host.Host
 	Callback compute.Callback
+	// Versions of the protocol to serve. Defaults to SupportedVersions.
+	Versions []string
+	// TrustedPeers can send callbacks on behalf of other compute nodes, such as the simulator node.
+	TrustedPeers []string
 }
 
 // CallbackHandler is a handler for callback events that registers for incoming libp2p requests to Bacalhau callback
@@ -23,6 +27,8 @@ type CallbackHandlerParams struct {
 type CallbackHandler struct {
 	host     host.Host
 	callback compute.Callback
+	versions []string
+	auth     authenticator
 }
 
 type callbackHandler[Request any] func(context.Context, Request)
@@ -31,26 +37,36 @@ func NewCallbackHandler(params CallbackHandlerParams) *CallbackHandler {
 	handler := &CallbackHandler{
 		host:     params.Host,
 		callback: params.Callback,
+		versions: orDefaultVersions(params.Versions),
+		auth:     newAuthenticator(params.Host, params.TrustedPeers),
 	}
 
-	host := handler.host
-	host.SetStreamHandler(OnBidComplete, handleCallback(host, handler.callback.OnBidComplete))
-	host.SetStreamHandler(OnRunComplete, handleCallback(host, handler.callback.OnRunComplete))
-	host.SetStreamHandler(OnPublishComplete, handleCallback(host, handler.callback.OnPublishComplete))
-	host.SetStreamHandler(OnCancelComplete, handleCallback(host, handler.callback.OnCancelComplete))
-	host.SetStreamHandler(OnComputeFailure, handleCallback(host, handler.callback.OnComputeFailure))
+	// register a stream handler for each version of the protocols, so that older peers can still reach this node
+	auth := handler.auth
+	for _, version := range handler.versions {
+		registerCallback(auth, OnBidComplete, version, handler.callback.OnBidComplete)
+		registerCallback(auth, OnRunComplete, version, handler.callback.OnRunComplete)
+		registerCallback(auth, OnPublishComplete, version, handler.callback.OnPublishComplete)
+		registerCallback(auth, OnCancelComplete, version, handler.callback.OnCancelComplete)
+		registerCallback(auth, OnComputeFailure, version, handler.callback.OnComputeFailure)
+	}
 	return handler
 }
 
-func handleCallback[Request any](host host.Host, f callbackHandler[Request]) func(network.Stream) {
+func registerCallback[Request any](auth authenticator, protocolID protocol.ID, version string, f callbackHandler[Request]) {
+	auth.host.SetStreamHandler(ProtocolIDs(protocolID, []string{version})[0], handleCallback(auth, f))
+}
+
+func handleCallback[Request any](auth authenticator, f callbackHandler[Request]) func(network.Stream) {
 	return func(stream network.Stream) {
-		ctx := logger.ContextWithNodeIDLogger(context.Background(), host.ID().String())
-		handleCallbackStream(ctx, stream, f)
+		ctx := logger.ContextWithNodeIDLogger(context.Background(), auth.host.ID().String())
+		handleCallbackStream(ctx, auth, stream, f)
 	}
 }
 
 func handleCallbackStream[Request any](
 	ctx context.Context,
+	auth authenticator,
 	stream network.Stream,
 	f func(ctx context.Context, r Request)) {
 	ctx = logger.ContextWithNodeIDLogger(ctx, stream.Conn().LocalPeer().String())
@@ -60,8 +76,10 @@ func handleCallbackStream[Request any](
 		return
 	}
 
+	// callbacks that were not sent by the compute node they claim to come from are dropped, as a peer could
+	// otherwise complete or fail executions it does not own.
 	request := new(Request)
-	err := json.NewDecoder(stream).Decode(request)
+	err := auth.decode(ctx, stream, request)
 	if err != nil {
 		log.Ctx(ctx).Error().Msgf("error decoding %s: %s", reflect.TypeOf(request), err)
 		_ = stream.Reset()
@@ -2,7 +2,6 @@ package bprotocol
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
@@ -18,6 +17,9 @@ import (
 type CallbackProxyParams struct {
 	Host          host.Host
 	LocalCallback compute.Callback
+	// Versions of the protocol to negotiate with requester nodes, from the most to the least preferred.
+	// Defaults to SupportedVersions.
+	Versions []string
 }
 
 // CallbackProxy is a proxy for a compute.Callback that can be used to send compute callbacks to the requester node,
@@ -27,12 +29,16 @@ type CallbackProxyParams struct {
 type CallbackProxy struct {
 	host          host.Host
 	localCallback compute.Callback
+	versions      []string
+	auth          authenticator
 }
 
 func NewCallbackProxy(params CallbackProxyParams) *CallbackProxy {
 	proxy := &CallbackProxy{
 		host:          params.Host,
 		localCallback: params.LocalCallback,
+		versions:      orDefaultVersions(params.Versions),
+		auth:          newAuthenticator(params.Host, nil),
 	}
 	return proxy
 }
@@ -95,15 +101,8 @@ func proxyCallbackRequest(
 			return
 		}
 
-		// deserialize the request object
-		data, err := json.Marshal(request)
-		if err != nil {
-			log.Ctx(ctx).Error().Err(errors.WithStack(err)).Msgf("%s: failed to marshal request", reflect.TypeOf(request))
-			return
-		}
-
-		// opening a stream to the destination peer
-		stream, err := p.host.NewStream(ctx, peerID, protocolID)
+		// opening a stream to the destination peer, which negotiates the most preferred version both peers support
+		stream, err := p.host.NewStream(ctx, peerID, ProtocolIDs(protocolID, p.versions)...)
 		if err != nil {
 			log.Ctx(ctx).Err(errors.WithStack(err)).Msgf("%s: failed to open stream to peer %s", reflect.TypeOf(request), targetPeerID)
 			return
@@ -115,6 +114,14 @@ func proxyCallbackRequest(
 			return
 		}
 
+		// serialize the request object
+		data, err := p.auth.encode(stream, request)
+		if err != nil {
+			_ = stream.Reset() //nolint:errcheck
+			log.Ctx(ctx).Error().Err(errors.WithStack(err)).Msgf("%s: failed to marshal request", reflect.TypeOf(request))
+			return
+		}
+
 		// write the request to the stream
 		_, err = stream.Write(data)
 		if err != nil {
@@ -2,7 +2,6 @@ package bprotocol
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 
 	"github.com/bacalhau-project/bacalhau/pkg/compute"
@@ -10,12 +9,17 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/util/closer"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/rs/zerolog/log"
 )
 
 type ComputeHandlerParams struct {
 	Host            host.Host
 	ComputeEndpoint compute.Endpoint
+	// Versions of the protocol to serve. Defaults to SupportedVersions.
+	Versions []string
+	// TrustedPeers can send requests on behalf of other requester nodes, such as the simulator node.
+	TrustedPeers []string
 }
 
 // ComputeHandler is a handler for compute requests that registers for incoming libp2p requests to Bacalhau compute
@@ -23,6 +27,8 @@ type ComputeHandlerParams struct {
 type ComputeHandler struct {
 	host            host.Host
 	computeEndpoint compute.Endpoint
+	versions        []string
+	auth            authenticator
 }
 
 type handlerWithResponse[Request, Response any] func(context.Context, Request) (Response, error)
@@ -31,51 +37,64 @@ func NewComputeHandler(params ComputeHandlerParams) *ComputeHandler {
 	handler := &ComputeHandler{
 		host:            params.Host,
 		computeEndpoint: params.ComputeEndpoint,
+		versions:        orDefaultVersions(params.Versions),
+		auth:            newAuthenticator(params.Host, params.TrustedPeers),
 	}
 
-	host := handler.host
-	host.SetStreamHandler(AskForBidProtocolID, handleWith(host, handler.computeEndpoint.AskForBid))
-	host.SetStreamHandler(BidAcceptedProtocolID, handleWith(host, handler.computeEndpoint.BidAccepted))
-	host.SetStreamHandler(BidRejectedProtocolID, handleWith(host, handler.computeEndpoint.BidRejected))
-	host.SetStreamHandler(ResultAcceptedProtocolID, handleWith(host, handler.computeEndpoint.ResultAccepted))
-	host.SetStreamHandler(ResultRejectedProtocolID, handleWith(host, handler.computeEndpoint.ResultRejected))
-	host.SetStreamHandler(CancelProtocolID, handleWith(host, handler.computeEndpoint.CancelExecution))
-	host.SetStreamHandler(ExecutionLogsID, handleWith(host, handler.computeEndpoint.ExecutionLogs))
-	log.Debug().Msgf("ComputeHandler started on host %s", handler.host.ID().String())
+	// register a stream handler for each version of the protocols, so that older peers can still reach this node
+	auth := handler.auth
+	for _, version := range handler.versions {
+		register(auth, AskForBidProtocolID, version, handler.computeEndpoint.AskForBid)
+		register(auth, BidAcceptedProtocolID, version, handler.computeEndpoint.BidAccepted)
+		register(auth, BidRejectedProtocolID, version, handler.computeEndpoint.BidRejected)
+		register(auth, ResultAcceptedProtocolID, version, handler.computeEndpoint.ResultAccepted)
+		register(auth, ResultRejectedProtocolID, version, handler.computeEndpoint.ResultRejected)
+		register(auth, CancelProtocolID, version, handler.computeEndpoint.CancelExecution)
+		register(auth, ExecutionLogsID, version, handler.computeEndpoint.ExecutionLogs)
+		register(auth, ExecutionStatusID, version, handler.computeEndpoint.ExecutionStatus)
+	}
+	log.Debug().Msgf("ComputeHandler started on host %s with protocol versions %v", handler.host.ID().String(), handler.versions)
 	return handler
 }
 
-func handleWith[Request, Response any](host host.Host, f handlerWithResponse[Request, Response]) func(network.Stream) {
+func register[Request, Response any](
+	auth authenticator, protocolID protocol.ID, version string, f handlerWithResponse[Request, Response]) {
+	auth.host.SetStreamHandler(ProtocolIDs(protocolID, []string{version})[0], handleWith(auth, f))
+}
+
+func handleWith[Request, Response any](auth authenticator, f handlerWithResponse[Request, Response]) func(network.Stream) {
 	return func(stream network.Stream) {
-		ctx := logger.ContextWithNodeIDLogger(context.Background(), host.ID().String())
-		handleStream(ctx, stream, f)
+		ctx := logger.ContextWithNodeIDLogger(context.Background(), auth.host.ID().String())
+		handleStream(ctx, auth, stream, f)
 	}
 }
 
-func handleStream[Request, Response any](ctx context.Context, stream network.Stream, f handlerWithResponse[Request, Response]) {
+func handleStream[Request, Response any](
+	ctx context.Context, auth authenticator, stream network.Stream, f handlerWithResponse[Request, Response]) {
 	if err := stream.Scope().SetService(ComputeServiceName); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("error attaching stream to compute service")
 		_ = stream.Reset()
 		return
 	}
 
+	// We will wrap up the response/error in a bprotocol Result type which
+	// can be decoded by the proxy itself.
+	result := Result[Response]{}
+
 	request := new(Request)
-	err := json.NewDecoder(stream).Decode(request)
-	if err != nil {
+	err := auth.decode(ctx, stream, request)
+	switch err.(type) {
+	case nil:
+		result.Response, err = f(ctx, *request)
+	case ErrInvalidSignature, ErrSourcePeerMismatch:
+		// let the caller know why its request was rejected
+	default:
 		log.Ctx(ctx).Error().Msgf("error decoding %s: %s", reflect.TypeOf(request), err)
 		_ = stream.Reset()
 		return
 	}
 	defer closer.CloseWithLogOnError("stream", stream)
 
-	response, err := f(ctx, *request)
-
-	// We will wrap up the response/error in a bprotocol Result type which
-	// can be decoded by the proxy itself.
-	result := Result[Response]{
-		Response: response,
-	}
-
 	// We can log the error here, but we should not bail as we want the error to be sent
 	// back to the caller.
 	if err != nil {
@@ -83,9 +102,16 @@ func handleStream[Request, Response any](ctx context.Context, stream network.Str
 		log.Ctx(ctx).Debug().Err(err).Msgf("error delegating %s", reflect.TypeOf(request))
 	}
 
-	err = json.NewEncoder(stream).Encode(result)
+	data, err := auth.encode(stream, result)
+	if err != nil {
+		log.Ctx(ctx).Error().Msgf("error encoding %s: %s", reflect.TypeOf(result.Response), err)
+		_ = stream.Reset()
+		return
+	}
+
+	_, err = stream.Write(data)
 	if err != nil {
-		log.Ctx(ctx).Error().Msgf("error encoding %s: %s", reflect.TypeOf(response), err)
+		log.Ctx(ctx).Error().Msgf("error writing %s: %s", reflect.TypeOf(result.Response), err)
 		_ = stream.Reset()
 		return
 	}
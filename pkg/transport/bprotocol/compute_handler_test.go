@@ -65,6 +65,9 @@ func (t *TestEndpoint) CancelExecution(context.Context, compute.CancelExecutionR
 func (t *TestEndpoint) ExecutionLogs(context.Context, compute.ExecutionLogsRequest) (compute.ExecutionLogsResponse, error) {
 	return compute.ExecutionLogsResponse{}, errors.New("No test implemenation")
 }
+func (t *TestEndpoint) ExecutionStatus(context.Context, compute.ExecutionStatusRequest) (compute.ExecutionStatusResponse, error) {
+	return compute.ExecutionStatusResponse{}, errors.New("No test implemenation")
+}
 
 func (s *ComputeProxyTestSuite) TeardownSuite() {
 	s.proxy.host.Close()
@@ -2,7 +2,6 @@ package bprotocol
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"reflect"
 
@@ -15,6 +14,9 @@ import (
 type ComputeProxyParams struct {
 	Host          host.Host
 	LocalEndpoint compute.Endpoint // optional in case this host is also a compute node and to allow local calls
+	// Versions of the protocol to negotiate with compute nodes, from the most to the least preferred.
+	// Defaults to SupportedVersions.
+	Versions []string
 }
 
 // ComputeProxy is a proxy to a compute node endpoint that will forward requests to remote compute nodes, or
@@ -23,12 +25,14 @@ type ComputeProxyParams struct {
 type ComputeProxy struct {
 	host          host.Host
 	localEndpoint compute.Endpoint
+	versions      []string
 }
 
 func NewComputeProxy(params ComputeProxyParams) *ComputeProxy {
 	proxy := &ComputeProxy{
 		host:          params.Host,
 		localEndpoint: params.LocalEndpoint,
+		versions:      orDefaultVersions(params.Versions),
 	}
 	return proxy
 }
@@ -45,7 +49,7 @@ func (p *ComputeProxy) AskForBid(ctx context.Context, request compute.AskForBidR
 		return p.localEndpoint.AskForBid(ctx, request)
 	}
 	return proxyRequest[compute.AskForBidRequest, compute.AskForBidResponse](
-		ctx, p.host, request.TargetPeerID, AskForBidProtocolID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(AskForBidProtocolID, p.versions), request)
 }
 
 func (p *ComputeProxy) BidAccepted(ctx context.Context, request compute.BidAcceptedRequest) (compute.BidAcceptedResponse, error) {
@@ -56,7 +60,7 @@ func (p *ComputeProxy) BidAccepted(ctx context.Context, request compute.BidAccep
 		return p.localEndpoint.BidAccepted(ctx, request)
 	}
 	return proxyRequest[compute.BidAcceptedRequest, compute.BidAcceptedResponse](
-		ctx, p.host, request.TargetPeerID, BidAcceptedProtocolID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(BidAcceptedProtocolID, p.versions), request)
 }
 
 func (p *ComputeProxy) BidRejected(ctx context.Context, request compute.BidRejectedRequest) (compute.BidRejectedResponse, error) {
@@ -67,7 +71,7 @@ func (p *ComputeProxy) BidRejected(ctx context.Context, request compute.BidRejec
 		return p.localEndpoint.BidRejected(ctx, request)
 	}
 	return proxyRequest[compute.BidRejectedRequest, compute.BidRejectedResponse](
-		ctx, p.host, request.TargetPeerID, BidRejectedProtocolID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(BidRejectedProtocolID, p.versions), request)
 }
 
 func (p *ComputeProxy) ResultAccepted(ctx context.Context, request compute.ResultAcceptedRequest) (compute.ResultAcceptedResponse, error) {
@@ -78,7 +82,7 @@ func (p *ComputeProxy) ResultAccepted(ctx context.Context, request compute.Resul
 		return p.localEndpoint.ResultAccepted(ctx, request)
 	}
 	return proxyRequest[compute.ResultAcceptedRequest, compute.ResultAcceptedResponse](
-		ctx, p.host, request.TargetPeerID, ResultAcceptedProtocolID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(ResultAcceptedProtocolID, p.versions), request)
 }
 
 func (p *ComputeProxy) ResultRejected(ctx context.Context, request compute.ResultRejectedRequest) (compute.ResultRejectedResponse, error) {
@@ -89,7 +93,7 @@ func (p *ComputeProxy) ResultRejected(ctx context.Context, request compute.Resul
 		return p.localEndpoint.ResultRejected(ctx, request)
 	}
 	return proxyRequest[compute.ResultRejectedRequest, compute.ResultRejectedResponse](
-		ctx, p.host, request.TargetPeerID, ResultRejectedProtocolID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(ResultRejectedProtocolID, p.versions), request)
 }
 
 func (p *ComputeProxy) CancelExecution(
@@ -101,7 +105,7 @@ func (p *ComputeProxy) CancelExecution(
 		return p.localEndpoint.CancelExecution(ctx, request)
 	}
 	return proxyRequest[compute.CancelExecutionRequest, compute.CancelExecutionResponse](
-		ctx, p.host, request.TargetPeerID, CancelProtocolID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(CancelProtocolID, p.versions), request)
 }
 
 func (p *ComputeProxy) ExecutionLogs(
@@ -113,14 +117,26 @@ func (p *ComputeProxy) ExecutionLogs(
 		return p.localEndpoint.ExecutionLogs(ctx, request)
 	}
 	return proxyRequest[compute.ExecutionLogsRequest, compute.ExecutionLogsResponse](
-		ctx, p.host, request.TargetPeerID, ExecutionLogsID, request)
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(ExecutionLogsID, p.versions), request)
+}
+
+func (p *ComputeProxy) ExecutionStatus(
+	ctx context.Context, request compute.ExecutionStatusRequest) (compute.ExecutionStatusResponse, error) {
+	if request.TargetPeerID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ExecutionStatusResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ExecutionStatus(ctx, request)
+	}
+	return proxyRequest[compute.ExecutionStatusRequest, compute.ExecutionStatusResponse](
+		ctx, p.host, request.TargetPeerID, ProtocolIDs(ExecutionStatusID, p.versions), request)
 }
 
 func proxyRequest[Request any, Response any](
 	ctx context.Context,
 	h host.Host,
 	destPeerID string,
-	protocolID protocol.ID,
+	protocols []protocol.ID,
 	request Request) (Response, error) {
 	// response object
 	response := new(Response)
@@ -131,14 +147,8 @@ func proxyRequest[Request any, Response any](
 		return *response, fmt.Errorf("%s: failed to decode peer ID %s: %w", reflect.TypeOf(request), destPeerID, err)
 	}
 
-	// deserialize the request object
-	data, err := json.Marshal(request)
-	if err != nil {
-		return *response, fmt.Errorf("%s: failed to marshal request: %w", reflect.TypeOf(request), err)
-	}
-
-	// opening a stream to the destination peer
-	stream, err := h.NewStream(ctx, peerID, protocolID)
+	// opening a stream to the destination peer, which negotiates the most preferred version both peers support
+	stream, err := h.NewStream(ctx, peerID, protocols...)
 	if err != nil {
 		return *response, fmt.Errorf("%s: failed to open stream to peer %s: %w", reflect.TypeOf(request), destPeerID, err)
 	}
@@ -147,6 +157,14 @@ func proxyRequest[Request any, Response any](
 		_ = stream.Reset()
 		return *response, fmt.Errorf("%s: failed to attach stream to compute service: %w", reflect.TypeOf(request), scopingErr)
 	}
+	auth := newAuthenticator(h, nil)
+
+	// serialize the request object
+	data, err := auth.encode(stream, request)
+	if err != nil {
+		_ = stream.Reset()
+		return *response, fmt.Errorf("%s: failed to marshal request: %w", reflect.TypeOf(request), err)
+	}
 
 	// write the request to the stream
 	_, err = stream.Write(data)
@@ -159,7 +177,7 @@ func proxyRequest[Request any, Response any](
 	// any error that occurred, so we will decode it and pass the
 	// inner response/error on to the caller.
 	result := &Result[Response]{}
-	err = json.NewDecoder(stream).Decode(result)
+	err = auth.decode(ctx, stream, result)
 	if err != nil {
 		_ = stream.Reset()
 		return *response, fmt.Errorf("%s: failed to decode response from peer %s: %w", reflect.TypeOf(request), destPeerID, err)
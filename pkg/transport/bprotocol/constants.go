@@ -9,6 +9,7 @@ const (
 	ResultRejectedProtocolID = "/bacalhau/compute/result_rejected/1.0.0"
 	CancelProtocolID         = "/bacalhau/compute/cancel/1.0.0"
 	ExecutionLogsID          = "/bacalhau/compute/executionlogs/1.0.0"
+	ExecutionStatusID        = "/bacalhau/compute/executionstatus/1.0.0"
 
 	CallbackServiceName = "bacalhau.callback"
 	OnBidComplete       = "/bacalhau/callback/on_bid_complete/1.0.0"
@@ -0,0 +1,31 @@
+package bprotocol
+
+import "fmt"
+
+// ErrInvalidSignature is returned when a message is not signed by the peer that sent it
+type ErrInvalidSignature struct {
+	PeerID string
+}
+
+func NewErrInvalidSignature(peerID string) ErrInvalidSignature {
+	return ErrInvalidSignature{PeerID: peerID}
+}
+
+func (e ErrInvalidSignature) Error() string {
+	return "message has an invalid signature for peer: " + e.PeerID
+}
+
+// ErrSourcePeerMismatch is returned when the source peer in the routing metadata of a message is not the peer
+// that sent it
+type ErrSourcePeerMismatch struct {
+	SourcePeerID string
+	RemotePeerID string
+}
+
+func NewErrSourcePeerMismatch(sourcePeerID, remotePeerID string) ErrSourcePeerMismatch {
+	return ErrSourcePeerMismatch{SourcePeerID: sourcePeerID, RemotePeerID: remotePeerID}
+}
+
+func (e ErrSourcePeerMismatch) Error() string {
+	return fmt.Sprintf("message claims to come from peer %s but was sent by peer %s", e.SourcePeerID, e.RemotePeerID)
+}
@@ -0,0 +1,15 @@
+package bprotocol
+
+import (
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+)
+
+// Metrics for monitoring the bacalhau protocol:
+var (
+	meter               = global.MeterProvider().Meter("bprotocol")
+	messagesRejected, _ = meter.Int64Counter(
+		"bprotocol_messages_rejected",
+		instrument.WithDescription("Number of messages rejected because they were not signed or sent by the peer they claim to come from"),
+	)
+)
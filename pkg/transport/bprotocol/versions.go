@@ -0,0 +1,175 @@
+package bprotocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// Version1_0_0 exchanges raw JSON encoded requests and responses.
+	Version1_0_0 = "1.0.0"
+	// Version1_1_0 wraps requests and responses in an Envelope so that
+	// metadata can be added without breaking the payload format.
+	Version1_1_0 = "1.1.0"
+	// Version1_2_0 signs the payload of each Envelope with the libp2p key of
+	// the sender.
+	Version1_2_0 = "1.2.0"
+)
+
+// SupportedVersions are the protocol versions this node can speak, from the
+// most to the least preferred.
+var SupportedVersions = []string{Version1_2_0, Version1_1_0, Version1_0_0}
+
+// SignedVersions are the supported protocol versions that sign messages. Nodes
+// speaking only these versions reject unsigned messages, instead of letting
+// peers downgrade to a version that doesn't sign them.
+var SignedVersions = []string{Version1_2_0}
+
+// computeProtocols and callbackProtocols are the protocols served by compute
+// and requester nodes respectively, at their oldest version.
+var (
+	computeProtocols = []protocol.ID{
+		AskForBidProtocolID,
+		BidAcceptedProtocolID,
+		BidRejectedProtocolID,
+		ResultAcceptedProtocolID,
+		ResultRejectedProtocolID,
+		CancelProtocolID,
+		ExecutionLogsID,
+		ExecutionStatusID,
+	}
+	callbackProtocols = []protocol.ID{
+		OnBidComplete,
+		OnRunComplete,
+		OnPublishComplete,
+		OnCancelComplete,
+		OnComputeFailure,
+	}
+)
+
+// Envelope is the wire format of messages from Version1_1_0 onwards.
+type Envelope struct {
+	Version string
+	Payload json.RawMessage
+	// Signature of the payload by the sender, from Version1_2_0 onwards.
+	Signature []byte `json:",omitempty"`
+}
+
+// ValidateVersions returns an error if any of the passed versions is not
+// supported by this node.
+func ValidateVersions(versions []string) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("at least one protocol version is required")
+	}
+	for _, version := range versions {
+		if !slices.Contains(SupportedVersions, version) {
+			return fmt.Errorf("unsupported protocol version %q. Supported versions are %s",
+				version, strings.Join(SupportedVersions, ", "))
+		}
+	}
+	return nil
+}
+
+// Protocols returns the versions of each compute and callback protocol that a
+// node speaking the passed versions supports, keyed by the protocol name
+// without its version. This is what nodes advertise in their NodeInfo.
+func Protocols(versions []string) map[string][]string {
+	protocols := make(map[string][]string, len(computeProtocols)+len(callbackProtocols))
+	for _, id := range append(append([]protocol.ID{}, computeProtocols...), callbackProtocols...) {
+		protocols[protocolName(id)] = append([]string{}, versions...)
+	}
+	return protocols
+}
+
+// ProtocolIDs returns the ID of the protocol at each of the passed versions,
+// in the same order.
+func ProtocolIDs(id protocol.ID, versions []string) []protocol.ID {
+	ids := make([]protocol.ID, len(versions))
+	for i, version := range versions {
+		ids[i] = protocol.ID(protocolName(id) + "/" + version)
+	}
+	return ids
+}
+
+// protocolName returns the ID of the protocol without its version.
+func protocolName(id protocol.ID) string {
+	return path.Dir(string(id))
+}
+
+// protocolVersion returns the version of the protocol ID.
+func protocolVersion(id protocol.ID) string {
+	return path.Base(string(id))
+}
+
+// orDefaultVersions returns SupportedVersions if no versions are passed.
+func orDefaultVersions(versions []string) []string {
+	if len(versions) == 0 {
+		return SupportedVersions
+	}
+	return versions
+}
+
+// encodeMessage serializes the message using the wire format of the version,
+// signing it with the key if the version requires it.
+func encodeMessage(version string, key crypto.PrivKey, message interface{}) ([]byte, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	switch version {
+	case Version1_0_0:
+		return data, nil
+	case Version1_1_0:
+		return json.Marshal(Envelope{Version: version, Payload: data})
+	case Version1_2_0:
+		if key == nil {
+			return nil, fmt.Errorf("a private key is required to sign messages of protocol version %q", version)
+		}
+		signature, err := key.Sign(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign message: %w", err)
+		}
+		return json.Marshal(Envelope{Version: version, Payload: data, Signature: signature})
+	default:
+		return nil, fmt.Errorf("unsupported protocol version %q", version)
+	}
+}
+
+// decodeMessage reads a message from the reader using the wire format of the
+// version, checking it was signed by the sender's key if the version requires
+// it.
+func decodeMessage(version string, sender peer.ID, key crypto.PubKey, reader io.Reader, message interface{}) error {
+	decoder := json.NewDecoder(reader)
+	switch version {
+	case Version1_0_0:
+		return decoder.Decode(message)
+	case Version1_1_0, Version1_2_0:
+		envelope := new(Envelope)
+		if err := decoder.Decode(envelope); err != nil {
+			return err
+		}
+		if envelope.Version != version {
+			return fmt.Errorf("envelope version %q does not match protocol version %q", envelope.Version, version)
+		}
+		if version == Version1_2_0 {
+			if key == nil {
+				return NewErrInvalidSignature(sender.String())
+			}
+			valid, err := key.Verify(envelope.Payload, envelope.Signature)
+			if err != nil || !valid {
+				return NewErrInvalidSignature(sender.String())
+			}
+		}
+		return json.Unmarshal(envelope.Payload, message)
+	default:
+		return fmt.Errorf("unsupported protocol version %q", version)
+	}
+}
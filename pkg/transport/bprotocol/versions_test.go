@@ -0,0 +1,155 @@
+//go:build unit || !integration
+
+package bprotocol
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/compute"
+	"github.com/bacalhau-project/bacalhau/pkg/libp2p"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtocolIDs(t *testing.T) {
+	require.Equal(t, []protocol.ID{
+		"/bacalhau/compute/ask_for_bid/1.2.0",
+		"/bacalhau/compute/ask_for_bid/1.1.0",
+		"/bacalhau/compute/ask_for_bid/1.0.0",
+	}, ProtocolIDs(AskForBidProtocolID, SupportedVersions))
+	require.Equal(t, Version1_0_0, protocolVersion(OnRunComplete))
+
+	protocols := Protocols([]string{Version1_0_0})
+	require.Len(t, protocols, len(computeProtocols)+len(callbackProtocols))
+	require.Equal(t, []string{Version1_0_0}, protocols["/bacalhau/callback/on_run_complete"])
+}
+
+func TestValidateVersions(t *testing.T) {
+	require.NoError(t, ValidateVersions(SupportedVersions))
+	require.NoError(t, ValidateVersions([]string{Version1_0_0}))
+	require.Error(t, ValidateVersions(nil))
+	require.Error(t, ValidateVersions([]string{"0.9.0"}))
+}
+
+func TestEncodeDecodeMessage(t *testing.T) {
+	privateKey, publicKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	sender, err := peer.IDFromPublicKey(publicKey)
+	require.NoError(t, err)
+
+	for _, version := range SupportedVersions {
+		t.Run(version, func(t *testing.T) {
+			request := compute.BidAcceptedRequest{ExecutionID: "test"}
+			data, err := encodeMessage(version, privateKey, request)
+			require.NoError(t, err)
+
+			decoded := compute.BidAcceptedRequest{}
+			require.NoError(t, decodeMessage(version, sender, publicKey, bytes.NewReader(data), &decoded))
+			require.Equal(t, request, decoded)
+		})
+	}
+
+	// envelopes must match the version negotiated for the stream
+	data, err := json.Marshal(Envelope{Version: Version1_0_0, Payload: []byte("{}")})
+	require.NoError(t, err)
+	require.Error(t, decodeMessage(Version1_1_0, sender, publicKey, bytes.NewReader(data), &Result[string]{}))
+}
+
+func TestDecodeMessageInvalidSignature(t *testing.T) {
+	privateKey, publicKey, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	otherKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	sender, err := peer.IDFromPublicKey(publicKey)
+	require.NoError(t, err)
+
+	signed, err := encodeMessage(Version1_2_0, privateKey, compute.BidAcceptedRequest{ExecutionID: "test"})
+	require.NoError(t, err)
+	envelope := Envelope{}
+	require.NoError(t, json.Unmarshal(signed, &envelope))
+
+	// signed by another key
+	signedByOther, err := encodeMessage(Version1_2_0, otherKey, compute.BidAcceptedRequest{ExecutionID: "test"})
+	require.NoError(t, err)
+
+	// payload tampered with after signing
+	tampered := envelope
+	tampered.Payload = []byte(`{"ExecutionID":"other"}`)
+	tamperedData, err := json.Marshal(tampered)
+	require.NoError(t, err)
+
+	// signature stripped
+	unsigned := envelope
+	unsigned.Signature = nil
+	unsignedData, err := json.Marshal(unsigned)
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{"other key": signedByOther, "tampered": tamperedData, "unsigned": unsignedData} {
+		t.Run(name, func(t *testing.T) {
+			err := decodeMessage(Version1_2_0, sender, publicKey, bytes.NewReader(data), &compute.BidAcceptedRequest{})
+			require.ErrorIs(t, err, NewErrInvalidSignature(sender.String()))
+		})
+	}
+}
+
+// TestVersionNegotiation checks that proxies and handlers speaking different sets of versions can
+// still talk to each other as long as they share one.
+func TestVersionNegotiation(t *testing.T) {
+	testCases := []struct {
+		proxy      []string
+		handler    []string
+		compatible bool
+	}{
+		{proxy: SupportedVersions, handler: SupportedVersions, compatible: true},
+		{proxy: SupportedVersions, handler: []string{Version1_0_0}, compatible: true},
+		{proxy: []string{Version1_0_0}, handler: SupportedVersions, compatible: true},
+		{proxy: []string{Version1_0_0, Version1_1_0}, handler: SupportedVersions, compatible: true},
+		{proxy: []string{Version1_2_0}, handler: []string{Version1_1_0}, compatible: false},
+		{proxy: []string{Version1_1_0}, handler: []string{Version1_0_0}, compatible: false},
+		// nodes requiring signed messages reject peers that can only send unsigned ones
+		{proxy: SupportedVersions, handler: SignedVersions, compatible: true},
+		{proxy: []string{Version1_1_0, Version1_0_0}, handler: SignedVersions, compatible: false},
+		{proxy: []string{Version1_0_0}, handler: SignedVersions, compatible: false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("proxy%v/handler%v", tc.proxy, tc.handler), func(t *testing.T) {
+			ctx := context.Background()
+			computeNode, err := libp2p.NewHostForTest(ctx)
+			require.NoError(t, err)
+			defer computeNode.Close()
+			proxyNode, err := libp2p.NewHostForTest(ctx, computeNode)
+			require.NoError(t, err)
+			defer proxyNode.Close()
+
+			NewComputeHandler(ComputeHandlerParams{
+				Host:            computeNode,
+				ComputeEndpoint: &TestEndpoint{},
+				Versions:        tc.handler,
+			})
+			proxy := NewComputeProxy(ComputeProxyParams{
+				Host:     proxyNode,
+				Versions: tc.proxy,
+			})
+
+			response, err := proxy.BidAccepted(ctx, compute.BidAcceptedRequest{
+				RoutingMetadata: compute.RoutingMetadata{
+					SourcePeerID: proxyNode.ID().String(),
+					TargetPeerID: computeNode.ID().String(),
+				},
+			})
+			if !tc.compatible {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "test", response.ExecutionID)
+		})
+	}
+}
@@ -123,6 +123,18 @@ func (p *ComputeProxy) ExecutionLogs(
 		ctx, p.host, p.simulatorNodeID, bprotocol.CancelProtocolID, request)
 }
 
+func (p *ComputeProxy) ExecutionStatus(
+	ctx context.Context, request compute.ExecutionStatusRequest) (compute.ExecutionStatusResponse, error) {
+	if p.simulatorNodeID == p.host.ID().String() {
+		if p.localEndpoint == nil {
+			return compute.ExecutionStatusResponse{}, fmt.Errorf("unable to dial to self, unless a local compute endpoint is provided")
+		}
+		return p.localEndpoint.ExecutionStatus(ctx, request)
+	}
+	return proxyRequest[compute.ExecutionStatusRequest, compute.ExecutionStatusResponse](
+		ctx, p.host, p.simulatorNodeID, bprotocol.ExecutionStatusID, request)
+}
+
 func proxyRequest[Request any, Response any](
 	ctx context.Context,
 	h host.Host,
@@ -29,6 +29,11 @@ func Decompress(src io.Reader, dst string) error {
 	return decompress(src, dst, MaximumContextSize)
 }
 
+// DecompressWithMaxBytes is like Decompress, but with a custom limit on the size of each file in the archive
+func DecompressWithMaxBytes(src io.Reader, dst string, max datasize.ByteSize) error {
+	return decompress(src, dst, max)
+}
+
 func UncompressedSize(src io.Reader) (datasize.ByteSize, error) {
 	var size datasize.ByteSize
 	zr, err := gzip.NewReader(src)
@@ -0,0 +1,94 @@
+package attestation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const SoftwareProviderName = "software"
+
+// SoftwareProvider attests executions without any trusted hardware, by signing documents with the libp2p key of the
+// compute node. The measurement is derived from the workload only, so it proves which node produced the results but
+// not that they ran in an isolated runtime. It is meant for testing and for environments without TEEs.
+type SoftwareProvider struct {
+	privateKey crypto.PrivKey
+}
+
+func NewSoftwareProvider(privateKey crypto.PrivKey) *SoftwareProvider {
+	return &SoftwareProvider{
+		privateKey: privateKey,
+	}
+}
+
+// SoftwareMeasurement returns the measurement reported by the software provider for a workload, to be added to the
+// trusted measurements of the requester.
+func SoftwareMeasurement(imageDigest string) string {
+	digest := sha256.Sum256([]byte(imageDigest))
+	return hex.EncodeToString(digest[:])
+}
+
+func (p *SoftwareProvider) Name() string {
+	return SoftwareProviderName
+}
+
+func (p *SoftwareProvider) Attest(_ context.Context, claims Claims) (Document, error) {
+	if p.privateKey == nil {
+		return Document{}, fmt.Errorf("software attestation requires the private key of the node")
+	}
+	publicKey, err := crypto.MarshalPublicKey(p.privateKey.GetPublic())
+	if err != nil {
+		return Document{}, err
+	}
+
+	document := Document{
+		Provider:    SoftwareProviderName,
+		Measurement: SoftwareMeasurement(claims.ImageDigest),
+		Claims:      claims,
+		PublicKey:   publicKey,
+	}
+	reportData, err := document.ReportData()
+	if err != nil {
+		return Document{}, err
+	}
+	document.Evidence, err = p.privateKey.Sign(reportData)
+	return document, err
+}
+
+func (p *SoftwareProvider) Verify(_ context.Context, document Document) error {
+	publicKey, err := crypto.UnmarshalPublicKey(document.PublicKey)
+	if err != nil {
+		return err
+	}
+	// the document must be signed by the node that claims to have run the execution
+	signer, err := peer.IDFromPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if signer.String() != document.Claims.NodeID {
+		return fmt.Errorf("document is signed by %s instead of node %s", signer, document.Claims.NodeID)
+	}
+	if document.Measurement != SoftwareMeasurement(document.Claims.ImageDigest) {
+		return fmt.Errorf("measurement does not match image digest %s", document.Claims.ImageDigest)
+	}
+
+	reportData, err := document.ReportData()
+	if err != nil {
+		return err
+	}
+	valid, err := publicKey.Verify(reportData, document.Evidence)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("invalid document signature")
+	}
+	return nil
+}
+
+// Compile-time check that SoftwareProvider implements the correct interface:
+var _ Provider = (*SoftwareProvider)(nil)
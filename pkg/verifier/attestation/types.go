@@ -0,0 +1,63 @@
+package attestation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+)
+
+// Claims are the facts about an execution that an attestation binds to the trusted runtime that produced them.
+type Claims struct {
+	JobID  string
+	NodeID string
+	// ImageDigest is the content digest of the workload that ran, such as the docker image pinned by digest as
+	// repo@sha256:..., or the CID of the WASM entry module
+	ImageDigest string
+	// InputCIDs identify the inputs of the job, in the order of the job spec
+	InputCIDs []string
+	// OutputHash is the hash of the results produced by the execution
+	OutputHash string
+}
+
+// Document is a signed attestation document, as proposed by compute nodes to the requester for verification.
+type Document struct {
+	// Provider is the name of the attestation provider that produced the document
+	Provider string
+	// Measurement of the trusted runtime that ran the workload, checked against the trusted measurements
+	Measurement string
+	Claims      Claims
+	// Evidence proves that the measurement and claims were produced by the trusted runtime, such as a signature or a
+	// hardware quote over ReportData
+	Evidence []byte
+	// PublicKey checks the evidence, marshaled as a libp2p public key, if the provider uses one
+	PublicKey []byte `json:",omitempty"`
+}
+
+// ReportData returns the digest of the provider, measurement and claims of the document, which the evidence must be
+// bound to.
+func (d Document) ReportData() ([]byte, error) {
+	payload, err := json.Marshal(struct {
+		Provider    string
+		Measurement string
+		Claims      Claims
+	}{
+		Provider:    d.Provider,
+		Measurement: d.Measurement,
+		Claims:      d.Claims,
+	})
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(payload)
+	return digest[:], nil
+}
+
+// Provider produces and checks attestation documents for a type of trusted runtime.
+type Provider interface {
+	// Name of the provider, recorded in the documents it produces
+	Name() string
+	// Attest measures the runtime, and returns a document binding the claims to it
+	Attest(ctx context.Context, claims Claims) (Document, error)
+	// Verify checks that the evidence of a document produced by this type of provider is authentic
+	Verify(ctx context.Context, document Document) error
+}
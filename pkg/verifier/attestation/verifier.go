@@ -0,0 +1,232 @@
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/results"
+	"github.com/docker/distribution/reference"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/exp/slices"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+type VerifierParams struct {
+	// NodeID of this node, claimed in the attestations it produces as a compute node
+	NodeID string
+	// Provider produces attestations on compute nodes, and checks them on requester nodes
+	Provider Provider
+	// TrustedMeasurements are the measurements of the runtimes the requester trusts to run jobs
+	TrustedMeasurements []string
+	// ResolveImage returns the content digest of the docker image a job ran, as repo@sha256:... Jobs whose image is
+	// not pinned by digest can't be attested without it.
+	ResolveImage ImageResolver
+}
+
+// ImageResolver returns the content digest of a docker image, as repo@sha256:...
+type ImageResolver func(ctx context.Context, image string) (string, error)
+
+// AttestationVerifier verifies that each execution ran inside a trusted runtime. Compute nodes propose a signed
+// attestation document binding the workload, inputs and results of the execution to the measurement of the runtime,
+// and the requester accepts executions whose attestation is authentic and whose measurement is trusted. Unlike the
+// deterministic verifier, executions are verified independently of each other.
+type AttestationVerifier struct {
+	results             *results.Results
+	nodeID              string
+	provider            Provider
+	trustedMeasurements []string
+	resolveImage        ImageResolver
+}
+
+func NewAttestationVerifier(
+	_ context.Context, _ *system.CleanupManager,
+	params VerifierParams,
+) (*AttestationVerifier, error) {
+	results, err := results.NewResults()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttestationVerifier{
+		results:             results,
+		nodeID:              params.NodeID,
+		provider:            params.Provider,
+		trustedMeasurements: params.TrustedMeasurements,
+		resolveImage:        params.ResolveImage,
+	}, nil
+}
+
+func (attestationVerifier *AttestationVerifier) IsInstalled(context.Context) (bool, error) {
+	return attestationVerifier.provider != nil, nil
+}
+
+func (attestationVerifier *AttestationVerifier) GetResultPath(
+	_ context.Context,
+	executionID string,
+	job model.Job,
+) (string, error) {
+	return attestationVerifier.results.EnsureResultsDir(executionID)
+}
+
+func (attestationVerifier *AttestationVerifier) GetProposal(
+	ctx context.Context,
+	job model.Job,
+	resultPath string,
+) ([]byte, error) {
+	outputHash, err := dirhash.HashDir(resultPath, "results", dirhash.Hash1)
+	if err != nil {
+		return nil, err
+	}
+	imageDigest, err := attestationVerifier.imageDigest(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the digest of the workload of job %s: %w", job.ID(), err)
+	}
+	document, err := attestationVerifier.provider.Attest(ctx, Claims{
+		JobID:       job.ID(),
+		NodeID:      attestationVerifier.nodeID,
+		ImageDigest: imageDigest,
+		InputCIDs:   InputCIDs(job),
+		OutputHash:  outputHash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attest execution of job %s: %w", job.ID(), err)
+	}
+	return json.Marshal(document)
+}
+
+func (attestationVerifier *AttestationVerifier) Verify(
+	ctx context.Context,
+	job model.Job,
+	executionStates []model.ExecutionState,
+) ([]verifier.VerifierResult, error) {
+	ctx, span := system.NewSpan(ctx, system.GetTracer(), "pkg/verifier.AttestationVerifier.Verify")
+	defer span.End()
+
+	err := verifier.ValidateExecutions(job, executionStates)
+	if err != nil {
+		return nil, err
+	}
+
+	allResults := make([]verifier.VerifierResult, 0, len(executionStates))
+	for _, executionState := range executionStates { //nolint:gocritic
+		err = attestationVerifier.verifyProposal(ctx, job, executionState)
+		if err != nil {
+			log.Ctx(ctx).Info().Err(err).Msgf("rejecting attestation of execution %s", executionState.ID())
+		}
+		allResults = append(allResults, verifier.VerifierResult{
+			Execution: executionState,
+			Verified:  err == nil,
+		})
+	}
+	return allResults, nil
+}
+
+func (attestationVerifier *AttestationVerifier) verifyProposal(
+	ctx context.Context,
+	job model.Job,
+	executionState model.ExecutionState,
+) error {
+	var document Document
+	if err := json.Unmarshal(executionState.VerificationProposal, &document); err != nil {
+		return fmt.Errorf("invalid attestation document: %w", err)
+	}
+	if document.Provider != attestationVerifier.provider.Name() {
+		return fmt.Errorf("unsupported attestation provider %q", document.Provider)
+	}
+	if err := attestationVerifier.provider.Verify(ctx, document); err != nil {
+		return fmt.Errorf("invalid attestation evidence: %w", err)
+	}
+
+	claims := document.Claims
+	switch {
+	case claims.JobID != job.ID():
+		return fmt.Errorf("attestation is for job %s", claims.JobID)
+	case claims.NodeID != executionState.NodeID:
+		return fmt.Errorf("attestation is for node %s", claims.NodeID)
+	case !isDigestOf(claims.ImageDigest, job):
+		return fmt.Errorf("attestation is for image %s", claims.ImageDigest)
+	case !slices.Equal(claims.InputCIDs, InputCIDs(job)):
+		return fmt.Errorf("attestation is for inputs %v", claims.InputCIDs)
+	case claims.OutputHash == "":
+		return fmt.Errorf("attestation has no output hash")
+	case !slices.Contains(attestationVerifier.trustedMeasurements, document.Measurement):
+		return fmt.Errorf("untrusted measurement %s", document.Measurement)
+	}
+	return nil
+}
+
+// imageDigest returns the content digest of the workload the job ran. Docker images pinned by digest are attested as
+// is, and the digest of the image a tag pointed to when the job ran is resolved otherwise.
+func (attestationVerifier *AttestationVerifier) imageDigest(ctx context.Context, job model.Job) (string, error) {
+	if job.Spec.Engine != model.EngineDocker {
+		return workloadID(job), nil
+	}
+	image := job.Spec.Docker.Image
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return image, nil
+	}
+	if attestationVerifier.resolveImage == nil {
+		return "", fmt.Errorf("image %s is not pinned by digest", image)
+	}
+	return attestationVerifier.resolveImage(ctx, image)
+}
+
+// isDigestOf returns whether the attested digest is the content digest of the workload of the job. The digest of a
+// docker image must be in the same repository as the image of the job, and match it if it is pinned by digest. The
+// image a tag points to is then bound by the measurement, which is derived from the digest.
+func isDigestOf(digest string, job model.Job) bool {
+	if job.Spec.Engine != model.EngineDocker {
+		return digest == workloadID(job)
+	}
+	attested, err := reference.ParseNormalizedNamed(digest)
+	if err != nil {
+		return false
+	}
+	attestedCanonical, ok := attested.(reference.Canonical)
+	if !ok {
+		return false
+	}
+	image, err := reference.ParseNormalizedNamed(job.Spec.Docker.Image)
+	if err != nil || image.Name() != attested.Name() {
+		return false
+	}
+	if imageCanonical, ok := image.(reference.Canonical); ok {
+		return imageCanonical.Digest() == attestedCanonical.Digest()
+	}
+	return true
+}
+
+// workloadID returns the identifier of the workload of jobs that don't run docker images
+func workloadID(job model.Job) string {
+	if job.Spec.Engine == model.EngineWasm {
+		return storageID(job.Spec.Wasm.EntryModule)
+	}
+	return job.Spec.Engine.String()
+}
+
+// InputCIDs returns the identifiers of the inputs of the job
+func InputCIDs(job model.Job) []string {
+	ids := make([]string, 0, len(job.Spec.Inputs))
+	for _, input := range job.Spec.Inputs {
+		ids = append(ids, storageID(input))
+	}
+	return ids
+}
+
+func storageID(spec model.StorageSpec) string {
+	if spec.CID != "" {
+		return spec.CID
+	}
+	return spec.URL
+}
+
+// Compile-time check that AttestationVerifier implements the correct interface:
+var _ verifier.Verifier = (*AttestationVerifier)(nil)
@@ -0,0 +1,159 @@
+//go:build unit || !integration
+
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bacalhau-project/bacalhau/pkg/model"
+	"github.com/bacalhau-project/bacalhau/pkg/system"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/suite"
+)
+
+const imageDigest = "sha256:a8ef7d1ec36c1a2d13f5ebd5a3c3e8c7c41e0c3c3b7ddeb8d1a58b4ecb4e1d35"
+
+type AttestationVerifierSuite struct {
+	suite.Suite
+	ctx        context.Context
+	job        model.Job
+	resultPath string
+	nodeID     string
+	compute    *AttestationVerifier
+	requester  *AttestationVerifier
+}
+
+func TestAttestationVerifierSuite(t *testing.T) {
+	suite.Run(t, new(AttestationVerifierSuite))
+}
+
+func (s *AttestationVerifierSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.job = model.Job{Metadata: model.Metadata{ID: "job-id"}}
+	s.job.Spec.Engine = model.EngineDocker
+	s.job.Spec.Docker.Image = "ubuntu@" + imageDigest
+	s.job.Spec.Inputs = []model.StorageSpec{{StorageSource: model.StorageSourceIPFS, CID: "QmInput"}}
+
+	s.resultPath = s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(s.resultPath, "stdout"), []byte("hello"), 0644))
+
+	s.compute, s.nodeID = s.newComputeVerifier()
+
+	var err error
+	s.requester, err = NewAttestationVerifier(s.ctx, system.NewCleanupManager(), VerifierParams{
+		Provider:            NewSoftwareProvider(nil),
+		TrustedMeasurements: []string{SoftwareMeasurement(s.job.Spec.Docker.Image)},
+	})
+	s.Require().NoError(err)
+}
+
+func (s *AttestationVerifierSuite) newComputeVerifier() (*AttestationVerifier, string) {
+	privateKey, _, err := crypto.GenerateKeyPair(crypto.Ed25519, 0)
+	s.Require().NoError(err)
+	nodeID, err := peer.IDFromPrivateKey(privateKey)
+	s.Require().NoError(err)
+	v, err := NewAttestationVerifier(s.ctx, system.NewCleanupManager(), VerifierParams{
+		NodeID:   nodeID.String(),
+		Provider: NewSoftwareProvider(privateKey),
+	})
+	s.Require().NoError(err)
+	return v, nodeID.String()
+}
+
+func (s *AttestationVerifierSuite) execution(nodeID string, proposal []byte) model.ExecutionState {
+	return model.ExecutionState{
+		JobID:                s.job.ID(),
+		NodeID:               nodeID,
+		ComputeReference:     "e-" + nodeID,
+		State:                model.ExecutionStateResultProposed,
+		VerificationProposal: proposal,
+	}
+}
+
+func (s *AttestationVerifierSuite) propose(v *AttestationVerifier, job model.Job) []byte {
+	proposal, err := v.GetProposal(s.ctx, job, s.resultPath)
+	s.Require().NoError(err)
+	return proposal
+}
+
+func (s *AttestationVerifierSuite) verify(executions ...model.ExecutionState) []bool {
+	results, err := s.requester.Verify(s.ctx, s.job, executions)
+	s.Require().NoError(err)
+	verified := make([]bool, 0, len(results))
+	for _, result := range results {
+		verified = append(verified, result.Verified)
+	}
+	return verified
+}
+
+func (s *AttestationVerifierSuite) TestVerifyTrusted() {
+	otherCompute, otherNodeID := s.newComputeVerifier()
+	s.Equal([]bool{true, true}, s.verify(
+		s.execution(s.nodeID, s.propose(s.compute, s.job)),
+		s.execution(otherNodeID, s.propose(otherCompute, s.job)),
+	))
+}
+
+func (s *AttestationVerifierSuite) TestRejectUntrustedMeasurement() {
+	s.requester.trustedMeasurements = []string{SoftwareMeasurement("other-image")}
+	s.Equal([]bool{false}, s.verify(s.execution(s.nodeID, s.propose(s.compute, s.job))))
+}
+
+func (s *AttestationVerifierSuite) TestRejectOtherNode() {
+	// a node can't reuse the attestation of another node
+	_, otherNodeID := s.newComputeVerifier()
+	s.Equal([]bool{false}, s.verify(s.execution(otherNodeID, s.propose(s.compute, s.job))))
+}
+
+func (s *AttestationVerifierSuite) TestRejectOtherInputs() {
+	job := s.job
+	job.Spec.Inputs = []model.StorageSpec{{StorageSource: model.StorageSourceIPFS, CID: "QmOther"}}
+	s.Equal([]bool{false}, s.verify(s.execution(s.nodeID, s.propose(s.compute, job))))
+}
+
+func (s *AttestationVerifierSuite) TestRejectTampered() {
+	var document Document
+	s.Require().NoError(json.Unmarshal(s.propose(s.compute, s.job), &document))
+	document.Claims.OutputHash = "h1:tampered"
+	tampered, err := json.Marshal(document)
+	s.Require().NoError(err)
+
+	s.Equal([]bool{false, false}, s.verify(
+		s.execution(s.nodeID, tampered),
+		s.execution(s.nodeID, []byte("not a document")),
+	))
+}
+
+func (s *AttestationVerifierSuite) TestResolveTaggedImage() {
+	// the digest of the image the tag pointed to is attested, and bound by the measurement
+	s.job.Spec.Docker.Image = "ubuntu:22.04"
+	s.compute.resolveImage = func(_ context.Context, image string) (string, error) {
+		return "ubuntu@" + imageDigest, nil
+	}
+	proposal := s.propose(s.compute, s.job)
+	var document Document
+	s.Require().NoError(json.Unmarshal(proposal, &document))
+	s.Equal("ubuntu@"+imageDigest, document.Claims.ImageDigest)
+	s.Equal([]bool{true}, s.verify(s.execution(s.nodeID, proposal)))
+
+	// a tag can't be attested without resolving it
+	s.compute.resolveImage = nil
+	_, err := s.compute.GetProposal(s.ctx, s.job, s.resultPath)
+	s.ErrorContains(err, "not pinned by digest")
+}
+
+func (s *AttestationVerifierSuite) TestRejectOtherImage() {
+	s.compute.resolveImage = func(_ context.Context, image string) (string, error) {
+		return "debian@" + imageDigest, nil
+	}
+	job := s.job
+	job.Spec.Docker.Image = "debian:12"
+	s.job.Spec.Docker.Image = "ubuntu:22.04"
+	s.requester.trustedMeasurements = []string{SoftwareMeasurement("debian@" + imageDigest)}
+	s.Equal([]bool{false}, s.verify(s.execution(s.nodeID, s.propose(s.compute, job))))
+}
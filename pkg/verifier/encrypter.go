@@ -2,7 +2,6 @@ package verifier
 
 import (
 	"context"
-	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha512"
 	"crypto/x509"
@@ -10,6 +9,7 @@ import (
 
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto/pb"
 )
 
 type Encrypter struct {
@@ -22,40 +22,44 @@ func NewEncrypter(privateKey crypto.PrivKey) Encrypter {
 	}
 }
 
+// Encrypt seals data in an envelope that can only be opened by the owner of the libp2p public key. Any type of libp2p
+// key is supported, and there is no limit on the size of the data.
 func (e Encrypter) Encrypt(ctx context.Context, data, libp2pKeyBytes []byte) ([]byte, error) {
 	_, span := system.NewSpan(ctx, system.GetTracer(), "pkg/verifier.Encrypter.Encrypt")
 	defer span.End()
 
-	unmarshalledPublicKey, err := crypto.UnmarshalPublicKey(libp2pKeyBytes)
+	publicKey, err := crypto.UnmarshalPublicKey(libp2pKeyBytes)
 	if err != nil {
 		return nil, err
 	}
-	publicKeyBytes, err := unmarshalledPublicKey.Raw()
-	if err != nil {
-		return nil, err
-	}
-	genericPublicKey, err := x509.ParsePKIXPublicKey(publicKeyBytes)
-	if err != nil {
-		return nil, err
-	}
-	rsaPublicKey, ok := genericPublicKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("could not cast public key to RSA")
-	}
-	return rsa.EncryptOAEP(
-		sha512.New(),
-		rand.Reader,
-		rsaPublicKey,
-		data,
-		nil,
-	)
+	return sealEnvelope(data, publicKey)
 }
 
+// Decrypt opens data sealed for the public key of this encrypter. Data encrypted with raw RSA-OAEP by older nodes is
+// still supported.
 func (e Encrypter) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
 	_, span := system.NewSpan(ctx, system.GetTracer(), "pkg/verifier.Encrypter.Decrypt")
 	defer span.End()
 
-	privateKeyBytes, err := e.privateKey.Raw()
+	if !isEnvelope(data) {
+		return decryptLegacy(e.privateKey, data)
+	}
+	plaintext, err := openEnvelope(data, e.privateKey)
+	if err != nil && e.privateKey.Type() == pb.KeyType_RSA {
+		// legacy ciphertexts are random bytes, which could happen to start like an envelope
+		if legacyPlaintext, legacyErr := decryptLegacy(e.privateKey, data); legacyErr == nil {
+			return legacyPlaintext, nil
+		}
+	}
+	return plaintext, err
+}
+
+// decryptLegacy decrypts data encrypted with raw RSA-OAEP-SHA512, as done before envelopes were introduced
+func decryptLegacy(privateKey crypto.PrivKey, data []byte) ([]byte, error) {
+	if privateKey.Type() != pb.KeyType_RSA {
+		return nil, fmt.Errorf("%w, and legacy encryption requires an RSA key", ErrNotEnvelope)
+	}
+	privateKeyBytes, err := privateKey.Raw()
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +69,7 @@ func (e Encrypter) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
 	}
 	return rsa.DecryptOAEP(
 		sha512.New(),
-		rand.Reader,
+		nil,
 		rsaPrivateKey,
 		data,
 		nil,
@@ -0,0 +1,116 @@
+//go:build unit || !integration
+
+package verifier
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/stretchr/testify/suite"
+)
+
+type EncrypterSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+func TestEncrypterSuite(t *testing.T) {
+	suite.Run(t, new(EncrypterSuite))
+}
+
+func (s *EncrypterSuite) SetupTest() {
+	s.ctx = context.Background()
+}
+
+var keyTypes = map[string]int{
+	"RSA":       crypto.RSA,
+	"Ed25519":   crypto.Ed25519,
+	"Secp256k1": crypto.Secp256k1,
+	"ECDSA":     crypto.ECDSA,
+}
+
+func (s *EncrypterSuite) generateKey(keyType int) (Encrypter, []byte) {
+	privateKey, publicKey, err := crypto.GenerateKeyPair(keyType, 2048)
+	s.Require().NoError(err)
+	publicKeyBytes, err := crypto.MarshalPublicKey(publicKey)
+	s.Require().NoError(err)
+	return NewEncrypter(privateKey), publicKeyBytes
+}
+
+func (s *EncrypterSuite) TestRoundTrip() {
+	// larger than what RSA-OAEP can encrypt directly
+	data := make([]byte, 64*1024)
+	_, err := rand.Read(data)
+	s.Require().NoError(err)
+
+	for name, keyType := range keyTypes {
+		s.Run(name, func() {
+			encrypter, publicKey := s.generateKey(keyType)
+			ciphertext, err := NewEncrypter(nil).Encrypt(s.ctx, data, publicKey)
+			s.Require().NoError(err)
+
+			plaintext, err := encrypter.Decrypt(s.ctx, ciphertext)
+			s.Require().NoError(err)
+			s.Equal(data, plaintext)
+
+			// another key of the same type can't decrypt it
+			otherEncrypter, _ := s.generateKey(keyType)
+			_, err = otherEncrypter.Decrypt(s.ctx, ciphertext)
+			s.Error(err)
+		})
+	}
+}
+
+func (s *EncrypterSuite) TestTampered() {
+	for name, keyType := range keyTypes {
+		s.Run(name, func() {
+			encrypter, publicKey := s.generateKey(keyType)
+			ciphertext, err := encrypter.Encrypt(s.ctx, []byte("hello"), publicKey)
+			s.Require().NoError(err)
+
+			for _, index := range []int{len(envelopeMagic), envelopeHeaderSize, len(ciphertext) - 1} {
+				tampered := append([]byte{}, ciphertext...)
+				tampered[index] ^= 0x01
+				_, err = encrypter.Decrypt(s.ctx, tampered)
+				s.Error(err, "byte %d", index)
+			}
+		})
+	}
+}
+
+func (s *EncrypterSuite) TestWrongKeyType() {
+	_, publicKey := s.generateKey(crypto.Ed25519)
+	ciphertext, err := NewEncrypter(nil).Encrypt(s.ctx, []byte("hello"), publicKey)
+	s.Require().NoError(err)
+
+	encrypter, _ := s.generateKey(crypto.Secp256k1)
+	_, err = encrypter.Decrypt(s.ctx, ciphertext)
+	s.Error(err)
+}
+
+func (s *EncrypterSuite) TestDecryptLegacy() {
+	privateKey, _, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	s.Require().NoError(err)
+	privateKeyBytes, err := privateKey.Raw()
+	s.Require().NoError(err)
+	rsaPrivateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBytes)
+	s.Require().NoError(err)
+
+	ciphertext, err := rsa.EncryptOAEP(sha512.New(), rand.Reader, &rsaPrivateKey.PublicKey, []byte("hello"), nil)
+	s.Require().NoError(err)
+
+	plaintext, err := NewEncrypter(privateKey).Decrypt(s.ctx, ciphertext)
+	s.Require().NoError(err)
+	s.Equal("hello", string(plaintext))
+}
+
+func (s *EncrypterSuite) TestDecryptLegacyRequiresRSA() {
+	encrypter, _ := s.generateKey(crypto.Ed25519)
+	_, err := encrypter.Decrypt(s.ctx, []byte("not an envelope"))
+	s.ErrorIs(err, ErrNotEnvelope)
+}
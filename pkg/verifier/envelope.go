@@ -0,0 +1,304 @@
+package verifier
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/crypto/pb"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Data encrypted by the Encrypter is wrapped in a versioned envelope, so that the format can evolve while nodes keep
+// being able to decrypt data from older nodes. Version 1 is a hybrid scheme: a secret is agreed with the public key of
+// the recipient, and used to derive a one-time AES-256-GCM key that encrypts the data. How the secret is agreed depends
+// on the type of the recipient key:
+//   - RSA: a random secret is encrypted with RSA-OAEP-SHA256
+//   - Ed25519: X25519 with an ephemeral key, using the Montgomery form of the recipient key
+//   - Secp256k1 and ECDSA: ECDH with an ephemeral key on the curve of the recipient key
+//
+// The envelope is laid out as:
+//
+//	magic | version | key type | encapsulated key length (uint16) | encapsulated key | nonce | ciphertext
+//
+// where everything before the nonce is authenticated along with the data.
+
+const (
+	envelopeVersion1 byte = 1
+	envelopeInfo          = "bacalhau verifier envelope v1"
+	envelopeKeySize       = 32
+	// size of the header without the encapsulated key
+	envelopeHeaderSize = len(envelopeMagic) + 4
+)
+
+var (
+	envelopeMagic = [3]byte{'B', 'V', 'E'}
+
+	// ErrNotEnvelope is returned when opening data that is not wrapped in an envelope
+	ErrNotEnvelope = errors.New("data is not an encrypted envelope")
+)
+
+// sealEnvelope encrypts data so that it can only be decrypted with the private key matching recipient
+func sealEnvelope(data []byte, recipient crypto.PubKey) ([]byte, error) {
+	encapsulatedKey, secret, err := encapsulate(recipient)
+	if err != nil {
+		return nil, err
+	}
+	if len(encapsulatedKey) > 0xffff {
+		return nil, fmt.Errorf("encapsulated key of %d bytes is too large", len(encapsulatedKey))
+	}
+
+	header := make([]byte, 0, envelopeHeaderSize+len(encapsulatedKey))
+	header = append(header, envelopeMagic[:]...)
+	header = append(header, envelopeVersion1, byte(recipient.Type()))
+	header = binary.BigEndian.AppendUint16(header, uint16(len(encapsulatedKey)))
+	header = append(header, encapsulatedKey...)
+
+	aead, err := envelopeAEAD(secret, encapsulatedKey, recipient)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	envelope := make([]byte, 0, len(header)+len(nonce)+len(data)+aead.Overhead())
+	envelope = append(envelope, header...)
+	envelope = append(envelope, nonce...)
+	return aead.Seal(envelope, nonce, data, header), nil
+}
+
+// openEnvelope decrypts an envelope sealed for the public key matching privateKey
+func openEnvelope(envelope []byte, privateKey crypto.PrivKey) ([]byte, error) {
+	if !isEnvelope(envelope) {
+		return nil, ErrNotEnvelope
+	}
+	version, keyType := envelope[len(envelopeMagic)], pb.KeyType(envelope[len(envelopeMagic)+1])
+	if version != envelopeVersion1 {
+		return nil, fmt.Errorf("unsupported envelope version %d", version)
+	}
+	if keyType != privateKey.Type() {
+		return nil, fmt.Errorf("envelope was sealed for a %s key, but the private key is %s", keyType, privateKey.Type())
+	}
+
+	encapsulatedKeySize := int(binary.BigEndian.Uint16(envelope[envelopeHeaderSize-2:]))
+	if len(envelope) < envelopeHeaderSize+encapsulatedKeySize {
+		return nil, fmt.Errorf("envelope is truncated")
+	}
+	header := envelope[:envelopeHeaderSize+encapsulatedKeySize]
+	encapsulatedKey := header[envelopeHeaderSize:]
+
+	secret, err := decapsulate(privateKey, encapsulatedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := envelopeAEAD(secret, encapsulatedKey, privateKey.GetPublic())
+	if err != nil {
+		return nil, err
+	}
+
+	body := envelope[len(header):]
+	if len(body) < aead.NonceSize() {
+		return nil, fmt.Errorf("envelope is truncated")
+	}
+	return aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], header)
+}
+
+func isEnvelope(data []byte) bool {
+	return len(data) >= envelopeHeaderSize && bytes.Equal(data[:len(envelopeMagic)], envelopeMagic[:])
+}
+
+// envelopeAEAD derives the key encrypting the data from the agreed secret, bound to the encapsulated key and to the
+// recipient public key.
+func envelopeAEAD(secret, encapsulatedKey []byte, recipient crypto.PubKey) (cipher.AEAD, error) {
+	recipientBytes, err := crypto.MarshalPublicKey(recipient)
+	if err != nil {
+		return nil, err
+	}
+	info := append([]byte(envelopeInfo), recipientBytes...)
+
+	key := make([]byte, envelopeKeySize)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, secret, encapsulatedKey, info), key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encapsulate agrees a new secret with the recipient, and returns it along with the encapsulated key that allows the
+// recipient to recover it.
+func encapsulate(recipient crypto.PubKey) (encapsulatedKey, secret []byte, err error) {
+	switch recipient.Type() {
+	case pb.KeyType_RSA:
+		publicKey, err := crypto.PubKeyToStdKey(recipient)
+		if err != nil {
+			return nil, nil, err
+		}
+		secret = make([]byte, envelopeKeySize)
+		if _, err = io.ReadFull(rand.Reader, secret); err != nil {
+			return nil, nil, err
+		}
+		encapsulatedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey.(*rsa.PublicKey), secret, []byte(envelopeInfo))
+		return encapsulatedKey, secret, err
+	case pb.KeyType_Ed25519:
+		raw, err := recipient.Raw()
+		if err != nil {
+			return nil, nil, err
+		}
+		montgomery, err := edwardsToMontgomery(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey, err := ecdh.X25519().NewPublicKey(montgomery)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ephemeralECDH(publicKey)
+	case pb.KeyType_ECDSA:
+		publicKey, err := crypto.PubKeyToStdKey(recipient)
+		if err != nil {
+			return nil, nil, err
+		}
+		ecdhPublicKey, err := publicKey.(*ecdsa.PublicKey).ECDH()
+		if err != nil {
+			return nil, nil, err
+		}
+		return ephemeralECDH(ecdhPublicKey)
+	case pb.KeyType_Secp256k1:
+		raw, err := recipient.Raw()
+		if err != nil {
+			return nil, nil, err
+		}
+		publicKey, err := secp256k1.ParsePubKey(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		ephemeralKey, err := secp256k1.GeneratePrivateKey()
+		if err != nil {
+			return nil, nil, err
+		}
+		return ephemeralKey.PubKey().SerializeCompressed(), secp256k1.GenerateSharedSecret(ephemeralKey, publicKey), nil
+	default:
+		return nil, nil, fmt.Errorf("encryption is not supported for %s keys", recipient.Type())
+	}
+}
+
+// decapsulate recovers the secret agreed by the sender from the encapsulated key
+func decapsulate(privateKey crypto.PrivKey, encapsulatedKey []byte) ([]byte, error) {
+	switch privateKey.Type() {
+	case pb.KeyType_RSA:
+		stdKey, err := crypto.PrivKeyToStdKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		return rsa.DecryptOAEP(sha256.New(), nil, stdKey.(*rsa.PrivateKey), encapsulatedKey, []byte(envelopeInfo))
+	case pb.KeyType_Ed25519:
+		raw, err := privateKey.Raw()
+		if err != nil {
+			return nil, err
+		}
+		// the X25519 scalar of an Ed25519 key is the first half of the hash of its seed
+		digest := sha512.Sum512(ed25519.PrivateKey(raw).Seed())
+		ecdhKey, err := ecdh.X25519().NewPrivateKey(digest[:32])
+		if err != nil {
+			return nil, err
+		}
+		return staticECDH(ecdhKey, encapsulatedKey)
+	case pb.KeyType_ECDSA:
+		stdKey, err := crypto.PrivKeyToStdKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		ecdhKey, err := stdKey.(*ecdsa.PrivateKey).ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return staticECDH(ecdhKey, encapsulatedKey)
+	case pb.KeyType_Secp256k1:
+		raw, err := privateKey.Raw()
+		if err != nil {
+			return nil, err
+		}
+		ephemeralKey, err := secp256k1.ParsePubKey(encapsulatedKey)
+		if err != nil {
+			return nil, err
+		}
+		return secp256k1.GenerateSharedSecret(secp256k1.PrivKeyFromBytes(raw), ephemeralKey), nil
+	default:
+		return nil, fmt.Errorf("decryption is not supported for %s keys", privateKey.Type())
+	}
+}
+
+func ephemeralECDH(recipient *ecdh.PublicKey) (encapsulatedKey, secret []byte, err error) {
+	ephemeralKey, err := recipient.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, err = ephemeralKey.ECDH(recipient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ephemeralKey.PublicKey().Bytes(), secret, nil
+}
+
+func staticECDH(privateKey *ecdh.PrivateKey, encapsulatedKey []byte) ([]byte, error) {
+	ephemeralKey, err := privateKey.Curve().NewPublicKey(encapsulatedKey)
+	if err != nil {
+		return nil, err
+	}
+	return privateKey.ECDH(ephemeralKey)
+}
+
+// curve25519P is the order of the field of curve25519, 2^255 - 19
+var curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// edwardsToMontgomery converts an Ed25519 public key to the X25519 public key of the same secret, using the birational
+// map u = (1 + y) / (1 - y). Public keys are not secret, so the arithmetic does not need to be constant time.
+func edwardsToMontgomery(publicKey []byte) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key size %d", len(publicKey))
+	}
+	// the key is the little-endian y coordinate, with the sign of x in the top bit
+	yBytes := make([]byte, len(publicKey))
+	for i, b := range publicKey {
+		yBytes[len(publicKey)-1-i] = b
+	}
+	yBytes[0] &= 0x7f
+	y := new(big.Int).SetBytes(yBytes)
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, fmt.Errorf("invalid Ed25519 public key")
+	}
+
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curve25519P)
+	if denominator.Sign() == 0 {
+		return nil, fmt.Errorf("invalid Ed25519 public key")
+	}
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, denominator.ModInverse(denominator, curve25519P))
+	u.Mod(u, curve25519P)
+
+	uBytes := u.FillBytes(make([]byte, 32))
+	for i, j := 0, len(uBytes)-1; i < j; i, j = i+1, j-1 {
+		uBytes[i], uBytes[j] = uBytes[j], uBytes[i]
+	}
+	return uBytes, nil
+}
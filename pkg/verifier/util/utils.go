@@ -6,6 +6,7 @@ import (
 	"github.com/bacalhau-project/bacalhau/pkg/model"
 	"github.com/bacalhau-project/bacalhau/pkg/system"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier"
+	"github.com/bacalhau-project/bacalhau/pkg/verifier/attestation"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier/deterministic"
 	"github.com/bacalhau-project/bacalhau/pkg/verifier/noop"
 )
@@ -15,6 +16,7 @@ func NewStandardVerifiers(
 	cm *system.CleanupManager,
 	encrypter verifier.EncrypterFunction,
 	decrypter verifier.DecrypterFunction,
+	attestationParams attestation.VerifierParams,
 ) (verifier.VerifierProvider, error) {
 	noopVerifier, err := noop.NewNoopVerifier(
 		ctx,
@@ -34,9 +36,19 @@ func NewStandardVerifiers(
 		return nil, err
 	}
 
+	attestationVerifier, err := attestation.NewAttestationVerifier(
+		ctx,
+		cm,
+		attestationParams,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return model.NewMappedProvider(map[model.Verifier]verifier.Verifier{
 		model.VerifierNoop:          noopVerifier,
 		model.VerifierDeterministic: deterministicVerifier,
+		model.VerifierAttestation:   attestationVerifier,
 	}), nil
 }
 